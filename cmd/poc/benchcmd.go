@@ -0,0 +1,181 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pocethereum/pochain/cmd/utils"
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/consensus/poc"
+	"github.com/pocethereum/pochain/consensus/poc/data"
+	plotparams "github.com/pocethereum/pochain/params/plot"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	benchRoundsFlag = cli.IntFlag{
+		Name:  "rounds",
+		Usage: "Number of synthetic rounds to mine",
+		Value: 10,
+	}
+	benchNumberFlag = cli.Uint64Flag{
+		Name:  "number",
+		Usage: "Block number of the first synthetic round",
+		Value: 1,
+	}
+	benchBaseTargetFlag = cli.Uint64Flag{
+		Name:  "basetarget",
+		Usage: "Network base target to derive deadlines with",
+		Value: plotparams.GenesisBaseTarget.Uint64(),
+	}
+	benchCommand = cli.Command{
+		Name:     "bench",
+		Usage:    "Benchmark proof-of-capacity mining",
+		Category: "PLOT COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Name:   "mine",
+				Usage:  "Mine synthetic rounds on the local plots",
+				Action: utils.MigrateFlags(benchMine),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.PlotdataDirFlag,
+					utils.PlotPathsFlag,
+					plotSeedFlag,
+					benchRoundsFlag,
+					benchNumberFlag,
+					benchBaseTargetFlag,
+				},
+				Description: `
+    poc bench mine --seed <address> [--rounds <count>] [--basetarget <target>]
+
+Searches the plots of the seed for a sequence of synthetic rounds, exactly as
+the miner does, and reports the scan time per round, the read throughput per
+plot drive, a histogram of the deadlines found and the number of blocks the
+plots are expected to win per day at the given network base target.
+
+A round is only counted as won if its plots were scanned before its deadline
+elapsed, as the rest of the network is assumed to forge a block every ` + fmt.Sprint(plotparams.DurationLimit) + `
+seconds on average.`,
+			},
+		},
+	}
+)
+
+// benchDeadlineBuckets are the upper bounds in seconds of the deadline
+// histogram buckets.
+var benchDeadlineBuckets = []uint64{10, 30, 60, 120, 180, 300, 600, 1800, 3600}
+
+// benchMine scans the local plots for a sequence of synthetic rounds.
+func benchMine(ctx *cli.Context) error {
+	seed := plotSeed(ctx)
+	if seed == "" {
+		utils.Fatalf("A valid --%s address is required", plotSeedFlag.Name)
+	}
+	baseTarget := new(big.Int).SetUint64(ctx.Uint64(benchBaseTargetFlag.Name))
+	if baseTarget.Sign() == 0 {
+		utils.Fatalf("A non-zero --%s is required", benchBaseTargetFlag.Name)
+	}
+	rounds := ctx.Int(benchRoundsFlag.Name)
+	if rounds <= 0 {
+		utils.Fatalf("A positive --%s count is required", benchRoundsFlag.Name)
+	}
+	dirs := utils.MakePlotPaths(ctx)
+	if len(dirs) == 0 {
+		dirs = []string{utils.MakePlotdataDir(ctx)}
+	}
+	plots := data.NewPlots(dirs, seed)
+	nonces := plots.GetSize() / plotparams.PlotSize
+	if nonces == 0 {
+		utils.Fatalf("No plots of %s found in %s", seed, strings.Join(dirs, ","))
+	}
+	fmt.Printf("Mining %d rounds on %d nonces (%s) of %s\n\n", rounds, nonces, common.StorageSize(plots.GetSize()), seed)
+
+	var (
+		coinbase  = common.HexToAddress(seed)
+		genSig    = common.BytesToHash(poc.CalcGenerationSignature(nil, coinbase.Bytes()))
+		number    = ctx.Uint64(benchNumberFlag.Name)
+		blockTime = float64(plotparams.DurationLimit)
+
+		drives    = make(map[string]*poc.DriveScan)
+		histogram = make([]int, len(benchDeadlineBuckets)+1)
+		elapsed   time.Duration
+		slowest   time.Duration
+		late      int
+		winRate   float64
+	)
+	for i := 0; i < rounds; i, number = i+1, number+1 {
+		scan, err := poc.ScanRound(plots, genSig, number)
+		if err != nil {
+			utils.Fatalf("Round %d failed: %v", number, err)
+		}
+		deadline := new(big.Int).Div(scan.Hit, baseTarget).Uint64()
+		fmt.Printf("Round %d: scoop %4d, nonce %d, deadline %ds, scanned in %v\n", number, scan.Scoop, scan.Nonce, deadline, scan.Elapsed)
+
+		for _, drive := range scan.Drives {
+			if total := drives[drive.Directory]; total != nil {
+				total.Bytes += drive.Bytes
+				total.Elapsed += drive.Elapsed
+			} else {
+				drives[drive.Directory] = &poc.DriveScan{Directory: drive.Directory, Bytes: drive.Bytes, Elapsed: drive.Elapsed}
+			}
+		}
+		histogram[sort.Search(len(benchDeadlineBuckets), func(i int) bool { return deadline < benchDeadlineBuckets[i] })]++
+
+		elapsed += scan.Elapsed
+		if scan.Elapsed > slowest {
+			slowest = scan.Elapsed
+		}
+		// A deadline only counts once the plots were fully scanned
+		effective := math.Max(float64(deadline), scan.Elapsed.Seconds())
+		if scan.Elapsed.Seconds() > float64(deadline) {
+			late++
+		}
+		winRate += math.Exp(-effective / blockTime)
+
+		// Chain the next round onto this one as if we forged the block
+		genSig = common.BytesToHash(poc.CalcGenerationSignature(genSig.Bytes(), coinbase.Bytes()))
+	}
+	fmt.Printf("\nScan time: average %v, slowest %v, %d rounds scanned after their deadline\n", elapsed/time.Duration(rounds), slowest, late)
+
+	fmt.Println("\nDrive throughput:")
+	dirs = dirs[:0]
+	for dir := range drives {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		drive := drives[dir]
+		fmt.Printf("  %s: %s in %v, %s/s\n", drive.Directory, common.StorageSize(drive.Bytes), drive.Elapsed, common.StorageSize(drive.Throughput()))
+	}
+	fmt.Println("\nDeadlines:")
+	for i, count := range histogram {
+		label := fmt.Sprintf(">= %ds", benchDeadlineBuckets[len(benchDeadlineBuckets)-1])
+		if i < len(benchDeadlineBuckets) {
+			label = fmt.Sprintf("< %ds", benchDeadlineBuckets[i])
+		}
+		fmt.Printf("  %8s %4d %s\n", label, count, strings.Repeat("#", count*40/rounds))
+	}
+	fmt.Printf("\nExpected blocks per day at base target %v: %.2f\n", baseTarget, winRate/float64(rounds)*86400/blockTime)
+	return nil
+}
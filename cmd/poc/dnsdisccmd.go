@@ -0,0 +1,260 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pocethereum/pochain/cmd/utils"
+	"github.com/pocethereum/pochain/crypto"
+	"github.com/pocethereum/pochain/p2p/dnsdisc"
+	"github.com/pocethereum/pochain/p2p/enr"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	dnsDomainFlag = cli.StringFlag{
+		Name:  "domain",
+		Usage: "Domain name the tree is published at (default = domain of the last signature)",
+	}
+	dnsSeqFlag = cli.UintFlag{
+		Name:  "seq",
+		Usage: "New sequence number of the tree (default = previous sequence number + 1)",
+	}
+	dnsdiscCommand = cli.Command{
+		Name:     "dnsdisc",
+		Usage:    "Manage DNS discovery node lists (EIP-1459)",
+		Category: "MISCELLANEOUS COMMANDS",
+		Description: `
+DNS discovery node lists are signed merkle trees of node records published in
+DNS TXT records, which nodes resolve via --discovery.dns to find peers to dial.
+
+A tree is kept in a directory holding two files:
+
+  nodes.json          the node records in the list, a JSON array of "enr:..." strings
+  enrtree-info.json   the signature, sequence number, links to other lists and URL
+
+The records of the nodes to list are signed by the nodes themselves and must
+be collected from their operators.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "sync",
+				Usage:     "Download a DNS discovery tree",
+				ArgsUsage: "<url> [<tree-dir>]",
+				Action:    utils.MigrateFlags(dnsSync),
+				Description: `
+    poc dnsdisc sync enrtree://<key>@<domain> [<tree-dir>]
+
+Resolves and verifies the tree published at the given URL, printing a summary
+of it. If a directory is given, the tree is written into it.`,
+			},
+			{
+				Name:      "sign",
+				Usage:     "Sign a DNS discovery tree",
+				ArgsUsage: "<tree-dir> <key-file>",
+				Action:    utils.MigrateFlags(dnsSign),
+				Flags: []cli.Flag{
+					dnsDomainFlag,
+					dnsSeqFlag,
+				},
+				Description: `
+    poc dnsdisc sign <tree-dir> <key-file>
+
+Builds the tree of the node records and links in the directory, signs it with
+the hex encoded private key in the key file and stores the signature and the
+enrtree:// URL of the tree in enrtree-info.json.`,
+			},
+			{
+				Name:      "to-txt",
+				Usage:     "Create the DNS TXT records of a signed tree",
+				ArgsUsage: "<tree-dir> [<output-file>]",
+				Action:    utils.MigrateFlags(dnsToTXT),
+				Description: `
+    poc dnsdisc to-txt <tree-dir> [<output-file>]
+
+Verifies the signature of the tree in the directory and writes the TXT records
+to publish it as a JSON object mapping the DNS names to the record contents.
+The records are printed if no output file is given.`,
+			},
+		},
+	}
+)
+
+const (
+	treeNodesFile = "nodes.json"
+	treeInfoFile  = "enrtree-info.json"
+)
+
+// dnsTreeInfo is the content of the tree info file.
+type dnsTreeInfo struct {
+	URL       string   `json:"url,omitempty"`
+	Seq       uint     `json:"seq"`
+	Signature string   `json:"signature,omitempty"`
+	Links     []string `json:"links"`
+}
+
+// dnsSync downloads a tree, optionally writing it into a directory.
+func dnsSync(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 || len(ctx.Args()) > 2 {
+		utils.Fatalf("This command requires a tree URL and an optional directory.")
+	}
+	url := ctx.Args().Get(0)
+	client := dnsdisc.NewClient(dnsdisc.Config{})
+	t, err := client.SyncTree(url)
+	if err != nil {
+		utils.Fatalf("Failed to sync tree: %v", err)
+	}
+	fmt.Printf("Tree %s: seq %d, %d nodes, %d links\n", url, t.Seq(), len(t.Nodes()), len(t.Links()))
+
+	if dir := ctx.Args().Get(1); dir != "" {
+		info := &dnsTreeInfo{URL: url, Seq: t.Seq(), Signature: t.Signature(), Links: t.Links()}
+		writeTreeDir(dir, info, t.Nodes())
+		fmt.Println("Tree written to", dir)
+	}
+	return nil
+}
+
+// dnsSign builds the tree of a directory and signs it.
+func dnsSign(ctx *cli.Context) error {
+	if len(ctx.Args()) != 2 {
+		utils.Fatalf("This command requires a tree directory and a key file.")
+	}
+	dir, keyfile := ctx.Args().Get(0), ctx.Args().Get(1)
+	info, nodes := loadTreeDir(dir)
+
+	key, err := crypto.LoadECDSA(keyfile)
+	if err != nil {
+		utils.Fatalf("Failed to load signing key: %v", err)
+	}
+	domain := ctx.String(dnsDomainFlag.Name)
+	if domain == "" {
+		if info.URL == "" {
+			utils.Fatalf("The --%s flag is required for unsigned trees", dnsDomainFlag.Name)
+		}
+		if domain, _, err = dnsdisc.ParseURL(info.URL); err != nil {
+			utils.Fatalf("Invalid tree URL: %v", err)
+		}
+	}
+	info.Seq++
+	if ctx.IsSet(dnsSeqFlag.Name) {
+		info.Seq = ctx.Uint(dnsSeqFlag.Name)
+	}
+	t, err := dnsdisc.MakeTree(info.Seq, nodes, info.Links)
+	if err != nil {
+		utils.Fatalf("Failed to build tree: %v", err)
+	}
+	if info.URL, err = t.Sign(key, domain); err != nil {
+		utils.Fatalf("Failed to sign tree: %v", err)
+	}
+	info.Signature = t.Signature()
+	writeTreeDir(dir, info, nil)
+
+	fmt.Printf("Signed tree with %d nodes and %d links, seq %d\n", len(nodes), len(info.Links), info.Seq)
+	fmt.Println(info.URL)
+	return nil
+}
+
+// dnsToTXT writes the TXT records of a signed tree.
+func dnsToTXT(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 || len(ctx.Args()) > 2 {
+		utils.Fatalf("This command requires a tree directory and an optional output file.")
+	}
+	info, nodes := loadTreeDir(ctx.Args().Get(0))
+	if info.URL == "" || info.Signature == "" {
+		utils.Fatalf("The tree is not signed, run 'poc dnsdisc sign' first")
+	}
+	domain, pubkey, err := dnsdisc.ParseURL(info.URL)
+	if err != nil {
+		utils.Fatalf("Invalid tree URL: %v", err)
+	}
+	t, err := dnsdisc.MakeTree(info.Seq, nodes, info.Links)
+	if err != nil {
+		utils.Fatalf("Failed to build tree: %v", err)
+	}
+	if err := t.SetSignature(pubkey, info.Signature); err != nil {
+		utils.Fatalf("Tree signature doesn't match its content, sign it again: %v", err)
+	}
+	out, err := json.MarshalIndent(t.ToTXT(domain), "", "  ")
+	if err != nil {
+		return err
+	}
+	if file := ctx.Args().Get(1); file != "" {
+		return ioutil.WriteFile(file, out, 0644)
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// loadTreeDir reads the info and node records of a tree directory. A missing
+// info file yields an empty, unsigned info.
+func loadTreeDir(dir string) (*dnsTreeInfo, []*enr.Record) {
+	info := new(dnsTreeInfo)
+	if blob, err := ioutil.ReadFile(filepath.Join(dir, treeInfoFile)); err == nil {
+		if err := json.Unmarshal(blob, info); err != nil {
+			utils.Fatalf("Invalid %s: %v", treeInfoFile, err)
+		}
+	} else if !os.IsNotExist(err) {
+		utils.Fatalf("Failed to read %s: %v", treeInfoFile, err)
+	}
+	blob, err := ioutil.ReadFile(filepath.Join(dir, treeNodesFile))
+	if err != nil {
+		utils.Fatalf("Failed to read node records: %v", err)
+	}
+	var texts []string
+	if err := json.Unmarshal(blob, &texts); err != nil {
+		utils.Fatalf("Invalid %s: %v", treeNodesFile, err)
+	}
+	nodes := make([]*enr.Record, len(texts))
+	for i, text := range texts {
+		if nodes[i], err = dnsdisc.ParseRecord(text); err != nil {
+			utils.Fatalf("Invalid node record %d: %v", i, err)
+		}
+	}
+	return info, nodes
+}
+
+// writeTreeDir stores the info and, if given, the node records of a tree.
+func writeTreeDir(dir string, info *dnsTreeInfo, nodes []*enr.Record) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		utils.Fatalf("Failed to create tree directory: %v", err)
+	}
+	if info.Links == nil {
+		info.Links = []string{}
+	}
+	writeJSON(filepath.Join(dir, treeInfoFile), info)
+	if nodes != nil {
+		texts := make([]string, len(nodes))
+		for i, n := range nodes {
+			texts[i] = dnsdisc.RecordString(n)
+		}
+		writeJSON(filepath.Join(dir, treeNodesFile), texts)
+	}
+}
+
+func writeJSON(file string, v interface{}) {
+	blob, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		utils.Fatalf("Failed to encode %s: %v", file, err)
+	}
+	if err := ioutil.WriteFile(file, append(blob, '\n'), 0644); err != nil {
+		utils.Fatalf("Failed to write %s: %v", file, err)
+	}
+}
@@ -0,0 +1,342 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pocethereum/pochain/cmd/utils"
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/consensus/poc/data"
+	"github.com/pocethereum/pochain/consensus/poc/plot"
+	"github.com/pocethereum/pochain/consensus/poc/plotter"
+	plotparams "github.com/pocethereum/pochain/params/plot"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	plotSeedFlag = cli.StringFlag{
+		Name:  "seed",
+		Usage: "Address to seed the plots with (the coinbase mining them)",
+	}
+	plotDirFlag = cli.StringFlag{
+		Name:  "dir",
+		Usage: "Directory to write the plot files into",
+	}
+	plotNoncesFlag = cli.Uint64Flag{
+		Name:  "nonces",
+		Usage: "Number of nonces to plot (256KB each)",
+	}
+	plotStartFlag = cli.Uint64Flag{
+		Name:  "start",
+		Usage: "First nonce to plot (default = after the plots of the seed already in the directory)",
+	}
+	plotFileNoncesFlag = cli.Uint64Flag{
+		Name:  "filenonces",
+		Usage: "Maximum number of nonces per plot file",
+		Value: 4096,
+	}
+	plotSamplesFlag = cli.IntFlag{
+		Name:  "samples",
+		Usage: "Number of randomly picked nonces to verify",
+		Value: 8,
+	}
+	plotThreadsFlag = cli.IntFlag{
+		Name:  "threads",
+		Usage: "Number of CPU threads to benchmark plotting with",
+		Value: runtime.NumCPU(),
+	}
+	plotCommand = cli.Command{
+		Name:     "plot",
+		Usage:    "Manage proof-of-capacity plot files",
+		Category: "PLOT COMMANDS",
+		Description: `
+Create, inspect and benchmark the plot files a proof-of-capacity miner scans
+for deadlines. None of the commands need a running node, so drives can be
+plotted on a separate machine and mounted into the miner afterwards.`,
+		Subcommands: []cli.Command{
+			{
+				Name:   "create",
+				Usage:  "Plot nonces of a seed into a directory",
+				Action: utils.MigrateFlags(plotCreate),
+				Flags: []cli.Flag{
+					plotSeedFlag,
+					plotDirFlag,
+					plotNoncesFlag,
+					plotStartFlag,
+					plotFileNoncesFlag,
+				},
+				Description: `
+    poc plot create --seed <address> --dir <directory> --nonces <count>
+
+Plots the requested number of nonces into files of at most --filenonces nonces
+each. Without --start, plotting continues after the last complete plot file of
+the seed in the directory, or at the first incomplete one, which is removed and
+plotted again along with any other incomplete file. Files already completed are
+skipped, so an interrupted run is resumed by restarting it with the same
+parameters.`,
+			},
+			{
+				Name:      "list",
+				Usage:     "Print summary of the plot files in the plot directories",
+				ArgsUsage: "[<directory> ...]",
+				Action:    utils.MigrateFlags(plotList),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.PlotdataDirFlag,
+					utils.PlotPathsFlag,
+					plotSeedFlag,
+				},
+				Description: `
+    poc plot list [<directory> ...]
+
+Lists the plot files of the given directories, or of the configured plot paths
+if none are given, optionally only those of --seed.`,
+			},
+			{
+				Name:      "info",
+				Usage:     "Print details of a plot file and verify its content",
+				ArgsUsage: "<file>",
+				Action:    utils.MigrateFlags(plotInfo),
+				Flags: []cli.Flag{
+					plotSamplesFlag,
+				},
+				Description: `
+    poc plot info <file>
+
+Prints the seed, nonce range and size of a plot file and regenerates --samples
+randomly picked nonces to check them against the file.`,
+			},
+			{
+				Name:   "bench",
+				Usage:  "Benchmark the plotting speed of this machine",
+				Action: utils.MigrateFlags(plotBench),
+				Flags: []cli.Flag{
+					plotNoncesFlag,
+					plotThreadsFlag,
+				},
+				Description: `
+    poc plot bench [--nonces <count>] [--threads <count>]
+
+Generates nonces in memory and reports the plotting rate, excluding any disk
+writes.`,
+			},
+		},
+	}
+)
+
+// plotCreate plots a range of nonces into a directory.
+func plotCreate(ctx *cli.Context) error {
+	seed := plotSeed(ctx)
+	if seed == "" {
+		utils.Fatalf("A valid --%s address is required", plotSeedFlag.Name)
+	}
+	dir := ctx.String(plotDirFlag.Name)
+	if dir == "" {
+		utils.Fatalf("A --%s is required", plotDirFlag.Name)
+	}
+	nonces := ctx.Uint64(plotNoncesFlag.Name)
+	if nonces == 0 {
+		utils.Fatalf("A non-zero --%s count is required", plotNoncesFlag.Name)
+	}
+	fileNonces := ctx.Uint64(plotFileNoncesFlag.Name)
+	if fileNonces == 0 {
+		utils.Fatalf("A non-zero --%s count is required", plotFileNoncesFlag.Name)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		utils.Fatalf("Failed to create plot directory: %v", err)
+	}
+	// Continue after the complete plots of the seed unless told otherwise. The
+	// incomplete files of an interrupted run are dropped and plotted again from
+	// the first one on, so the miner never scans a truncated file.
+	start := ctx.Uint64(plotStartFlag.Name)
+	if !ctx.IsSet(plotStartFlag.Name) {
+		var incomplete []*data.PlotFile
+		for _, drive := range data.NewPlots([]string{dir}, seed).GetPlotDrives() {
+			for _, pf := range drive.GetPlotFiles() {
+				if !plotComplete(pf) {
+					incomplete = append(incomplete, pf)
+				} else if end := pf.GetStartNonce() + pf.GetNonces(); end > start {
+					start = end
+				}
+			}
+		}
+		for _, pf := range incomplete {
+			if pf.GetStartNonce() < start {
+				start = pf.GetStartNonce()
+			}
+		}
+		for _, pf := range incomplete {
+			fmt.Printf("Removing incomplete plot file %s\n", pf.GetFilePath())
+			if err := os.Remove(pf.GetFilePath()); err != nil {
+				utils.Fatalf("Failed to remove incomplete plot file: %v", err)
+			}
+		}
+	}
+	work := plotter.NewWork(seed, dir, start, nonces, fileNonces)
+	worker := plotter.NewWorker(work)
+
+	fmt.Printf("Plotting nonces %d-%d of %s into %s (%s)\n", start, start+nonces-1, seed, dir, common.StorageSize(nonces*plotparams.PlotSize))
+	fmt.Printf("Resume an interrupted run with --%s %d\n", plotStartFlag.Name, start)
+
+	done := make(chan error, 1)
+	go func() { done <- worker.Run() }()
+
+	started := time.Now()
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				utils.Fatalf("Plotting failed: %v", err)
+			}
+			fmt.Printf("Plotted %d nonces in %v\n", nonces, time.Since(started).Round(time.Second))
+			for _, path := range work.PlotFiles() {
+				fmt.Println(path)
+			}
+			return nil
+		case <-time.After(10 * time.Second):
+			progress, _ := worker.Progress()
+			fmt.Printf("Plotting %.2f%% done, elapsed %v\n", float64(progress)*100/plotter.PROGRESS_MAX, time.Since(started).Round(time.Second))
+		}
+	}
+}
+
+// plotList prints the plot files found in the plot directories.
+func plotList(ctx *cli.Context) error {
+	dirs := ctx.Args()
+	if len(dirs) == 0 {
+		if dirs = utils.MakePlotPaths(ctx); len(dirs) == 0 {
+			dirs = []string{utils.MakePlotdataDir(ctx)}
+		}
+	}
+	seed := plotSeed(ctx)
+	if seed == "" && ctx.String(plotSeedFlag.Name) != "" {
+		utils.Fatalf("Invalid --%s address", plotSeedFlag.Name)
+	}
+	var (
+		files int
+		size  uint64
+	)
+	for _, drive := range data.NewPlots(dirs, seed).GetPlotDrives() {
+		fmt.Printf("%s:\n", drive.GetDirectory())
+		for _, pf := range drive.GetPlotFiles() {
+			status := "ok"
+			if !plotComplete(pf) {
+				status = "incomplete"
+			}
+			fmt.Printf("  %s  nonces %d-%d  %s  %s\n", pf.GetFileName(), pf.GetStartNonce(), pf.GetStartNonce()+pf.GetNonces()-1, common.StorageSize(pf.GetSize()), status)
+			files++
+		}
+		size += drive.GetSize()
+	}
+	fmt.Printf("%d plot files, %s total\n", files, common.StorageSize(size))
+	return nil
+}
+
+// plotComplete reports whether a plot file has been written completely.
+func plotComplete(pf *data.PlotFile) bool {
+	stat, err := os.Stat(pf.GetFilePath())
+	return err == nil && uint64(stat.Size()) == pf.GetSize()
+}
+
+// plotInfo prints the details of a plot file and spot checks its content.
+func plotInfo(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires exactly one plot file argument.")
+	}
+	pf, err := data.ParsePlotFile(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Invalid plot file: %v", err)
+	}
+	stat, err := os.Stat(pf.GetFilePath())
+	if err != nil {
+		utils.Fatalf("Failed to stat plot file: %v", err)
+	}
+	fmt.Printf("File:        %s\n", pf.GetFilePath())
+	fmt.Printf("Seed:        %s\n", pf.GetAddress().Hex())
+	fmt.Printf("Nonces:      %d-%d (%d)\n", pf.GetStartNonce(), pf.GetStartNonce()+pf.GetNonces()-1, pf.GetNonces())
+	fmt.Printf("Size:        %s (expected %s)\n", common.StorageSize(stat.Size()), common.StorageSize(pf.GetSize()))
+
+	if uint64(stat.Size()) != pf.GetSize() {
+		utils.Fatalf("Plot file is incomplete")
+	}
+	samples := ctx.Int(plotSamplesFlag.Name)
+	if uint64(samples) > pf.GetNonces() {
+		samples = int(pf.GetNonces())
+	}
+	for i := 0; i < samples; i++ {
+		nonce := pf.GetStartNonce() + uint64(rand.Int63n(int64(pf.GetNonces())))
+		if err := pf.VerifyNonce(nonce); err != nil {
+			utils.Fatalf("Plot file is corrupt: %v", err)
+		}
+	}
+	fmt.Printf("Verified:    %d nonces ok\n", samples)
+	return nil
+}
+
+// plotBench measures how fast nonces can be plotted on this machine.
+func plotBench(ctx *cli.Context) error {
+	var (
+		nonces  = ctx.Uint64(plotNoncesFlag.Name)
+		threads = ctx.Int(plotThreadsFlag.Name)
+		seed    = hex.EncodeToString(common.Address{}.Bytes())
+		next    = uint64(0)
+		pend    sync.WaitGroup
+	)
+	if nonces == 0 {
+		nonces = 256
+	}
+	if threads <= 0 {
+		threads = 1
+	}
+	fmt.Printf("Plotting %d nonces with %d threads\n", nonces, threads)
+
+	started := time.Now()
+	for i := 0; i < threads; i++ {
+		pend.Add(1)
+		go func() {
+			defer pend.Done()
+			for nonce := atomic.AddUint64(&next, 1) - 1; nonce < nonces; nonce = atomic.AddUint64(&next, 1) - 1 {
+				plot.NewMiningPlot(seed, nonce)
+			}
+		}()
+	}
+	pend.Wait()
+	elapsed := time.Since(started)
+
+	rate := float64(nonces) / elapsed.Seconds() * 60
+	fmt.Printf("Plotted %d nonces in %v\n", nonces, elapsed)
+	fmt.Printf("Rate: %.0f nonces/minute, %s/hour\n", rate, common.StorageSize(rate*60*float64(plotparams.PlotSize)))
+	return nil
+}
+
+// plotSeed returns the seed requested on the command line in the lowercase hex
+// form plot files are named after, or the empty string if none was given.
+func plotSeed(ctx *cli.Context) string {
+	seed := ctx.String(plotSeedFlag.Name)
+	if seed == "" || !common.IsHexAddress(seed) {
+		return ""
+	}
+	return hex.EncodeToString(common.HexToAddress(seed).Bytes())
+}
@@ -0,0 +1,101 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"github.com/pocethereum/pochain/cmd/utils"
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/core/state/pruner"
+	"github.com/pocethereum/pochain/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	bloomFilterSizeFlag = cli.Uint64Flag{
+		Name:  "bloomfilter.size",
+		Usage: "Megabytes of memory allocated to the bloom filter recording the reachable state",
+		Value: 512,
+	}
+	snapshotCommand = cli.Command{
+		Name:     "snapshot",
+		Usage:    "A set of commands based on the chain state",
+		Category: "BLOCKCHAIN COMMANDS",
+		Subcommands: []cli.Command{
+			{
+				Name:      "prune-state",
+				Usage:     "Prune stale state data not reachable from a recent state",
+				ArgsUsage: "[<root>]",
+				Action:    utils.MigrateFlags(pruneState),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.AncientFlag,
+					utils.CacheFlag,
+					bloomFilterSizeFlag,
+				},
+				Description: `
+    poc snapshot prune-state [<root>]
+
+Deletes all the state trie nodes and contract codes which are not reachable
+from the target state root. If no root is given, the state of the most recent
+block flushed to disk is kept, which is the head block after a clean shutdown.
+
+The reachable state is recorded in a bloom filter of the configured size, a
+larger filter keeps fewer stale entries around. The filter is saved into the
+data directory before deleting anything, so an interrupted pruning is resumed
+the next time this command or the node is started.
+
+The node must be stopped while pruning.`,
+			},
+		},
+	}
+)
+
+// pruneState deletes the stale state data from the chain database.
+func pruneState(ctx *cli.Context) error {
+	if len(ctx.Args()) > 1 {
+		utils.Fatalf("This command requires at most one argument.")
+	}
+	stack, _ := makeConfigNode(ctx)
+	datadir := stack.ResolvePath("")
+	if datadir == "" {
+		utils.Fatalf("State pruning requires a data directory")
+	}
+	chaindb := utils.MakeChainDatabase(ctx, stack)
+	defer chaindb.Close()
+
+	// Finish any interrupted run first, its bloom filter must not be lost
+	if err := pruner.RecoverPruning(datadir, chaindb); err != nil {
+		utils.Fatalf("Failed to resume state pruning: %v", err)
+	}
+	var root common.Hash
+	if ctx.NArg() == 1 {
+		if !hashish(ctx.Args().First()) || len(common.FromHex(ctx.Args().First())) != common.HashLength {
+			utils.Fatalf("Invalid state root: %s", ctx.Args().First())
+		}
+		root = common.HexToHash(ctx.Args().First())
+	}
+	p, err := pruner.NewPruner(chaindb, datadir, ctx.Uint64(bloomFilterSizeFlag.Name))
+	if err != nil {
+		log.Error("Failed to open state pruner", "err", err)
+		return err
+	}
+	if err := p.Prune(root); err != nil {
+		log.Error("Failed to prune state", "err", err)
+		return err
+	}
+	return nil
+}
@@ -19,6 +19,7 @@ package utils
 
 import (
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -45,13 +46,16 @@ import (
 	"github.com/pocethereum/pochain/eth/gasprice"
 	"github.com/pocethereum/pochain/ethdb"
 	"github.com/pocethereum/pochain/ethstats"
+	"github.com/pocethereum/pochain/graphql"
 	"github.com/pocethereum/pochain/les"
 	"github.com/pocethereum/pochain/log"
 	"github.com/pocethereum/pochain/metrics"
+	"github.com/pocethereum/pochain/miner/pool"
 	"github.com/pocethereum/pochain/node"
 	"github.com/pocethereum/pochain/p2p"
 	"github.com/pocethereum/pochain/p2p/discover"
 	"github.com/pocethereum/pochain/p2p/discv5"
+	"github.com/pocethereum/pochain/p2p/dnsdisc"
 	"github.com/pocethereum/pochain/p2p/nat"
 	"github.com/pocethereum/pochain/p2p/netutil"
 	"github.com/pocethereum/pochain/params"
@@ -118,6 +122,15 @@ var (
 		Usage: "Data directory for the databases and keystore",
 		Value: DirectoryString{node.DefaultDataDir()},
 	}
+	AncientFlag = DirectoryFlag{
+		Name:  "datadir.ancient",
+		Usage: "Data directory for ancient chain segments (default = inside chaindata)",
+	}
+	AncientThresholdFlag = cli.Uint64Flag{
+		Name:  "ancient.threshold",
+		Usage: "Number of recent blocks to keep in the chain database, older ones are moved to the ancient store",
+		Value: params.ImmutabilityThreshold,
+	}
 	KeyStoreDirFlag = DirectoryFlag{
 		Name:  "keystore",
 		Usage: "Directory for the keystore (default = inside the datadir)",
@@ -171,7 +184,7 @@ var (
 	defaultSyncMode = eth.DefaultConfig.SyncMode
 	SyncModeFlag    = TextMarshalerFlag{
 		Name:  "syncmode",
-		Usage: `Blockchain sync mode ("fast", "full", or "light")`,
+		Usage: `Blockchain sync mode ("fast", "snap", "full", or "light")`,
 		Value: &defaultSyncMode,
 	}
 	GCModeFlag = cli.StringFlag{
@@ -179,6 +192,11 @@ var (
 		Usage: `Blockchain garbage collection mode ("full", "archive")`,
 		Value: "full",
 	}
+	SealCheckFrequencyFlag = cli.IntFlag{
+		Name:  "sealcheck",
+		Usage: "Verify the PoC seal of one in this many historical headers during fast and light sync (1 = all)",
+		Value: eth.DefaultConfig.SealCheckFrequency,
+	}
 	LightServFlag = cli.IntFlag{
 		Name:  "lightserv",
 		Usage: "Maximum percentage of time allowed for serving LES requests (0-90)",
@@ -293,6 +311,21 @@ var (
 		Usage: "Maximum amount of time non-executable transaction are queued",
 		Value: eth.DefaultConfig.TxPool.Lifetime,
 	}
+	TxPoolPriorityFlag = cli.StringFlag{
+		Name:  "txpool.priority",
+		Usage: "Comma separated accounts whose transactions get priority slots exempt from eviction (the mortgage contract is always included)",
+		Value: "",
+	}
+	TxPoolRemoteJournalFlag = cli.StringFlag{
+		Name:  "txpool.remotejournal",
+		Usage: "Disk journal for remote transactions to survive node restarts (disabled if empty)",
+		Value: core.DefaultTxPoolConfig.RemoteJournal,
+	}
+	TxPoolRemoteLifetimeFlag = cli.DurationFlag{
+		Name:  "txpool.remotelifetime",
+		Usage: "Maximum age of journaled remote transactions to restore after a restart",
+		Value: eth.DefaultConfig.TxPool.RemoteLifetime,
+	}
 	// Performance tuning settings
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
@@ -343,6 +376,36 @@ var (
 		Name:  "extradata",
 		Usage: "Block extra data set by the miner (default = client version)",
 	}
+	// Mining pool settings
+	PoolEnabledFlag = cli.BoolFlag{
+		Name:  "pool",
+		Usage: "Run a mining pool accepting nonces for plots seeded with the etherbase",
+	}
+	PoolFeeFlag = cli.Uint64Flag{
+		Name:  "pool.fee",
+		Usage: "Pool fee kept from each won block, in basis points",
+		Value: eth.DefaultConfig.Pool.Fee,
+	}
+	PoolMinPayoutFlag = BigFlag{
+		Name:  "pool.minpayout",
+		Usage: "Minimum pending balance (in wei) before a pool member is paid out",
+		Value: eth.DefaultConfig.Pool.MinPayout,
+	}
+	PoolPayoutIntervalFlag = cli.DurationFlag{
+		Name:  "pool.payoutinterval",
+		Usage: "Time interval between two pool payout rounds",
+		Value: eth.DefaultConfig.Pool.PayoutInterval,
+	}
+	PoolShareWindowFlag = cli.Uint64Flag{
+		Name:  "pool.window",
+		Usage: "Number of most recent rounds whose shares split a won block",
+		Value: eth.DefaultConfig.Pool.ShareWindow,
+	}
+	PoolMaxDeadlineFlag = cli.Uint64Flag{
+		Name:  "pool.maxdeadline",
+		Usage: "Largest deadline (in seconds) accepted as a pool share",
+		Value: eth.DefaultConfig.Pool.MaxDeadline,
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -438,6 +501,58 @@ var (
 		Usage: "Origins from which to accept websockets requests",
 		Value: "",
 	}
+	AuthRPCEnabledFlag = cli.BoolFlag{
+		Name:  "authrpc",
+		Usage: "Enable the JWT authenticated HTTP/WS-RPC server",
+	}
+	AuthRPCListenAddrFlag = cli.StringFlag{
+		Name:  "authrpc.addr",
+		Usage: "Authenticated RPC server listening interface",
+		Value: node.DefaultAuthHost,
+	}
+	AuthRPCPortFlag = cli.IntFlag{
+		Name:  "authrpc.port",
+		Usage: "Authenticated RPC server listening port",
+		Value: node.DefaultAuthPort,
+	}
+	AuthRPCVirtualHostsFlag = cli.StringFlag{
+		Name:  "authrpc.vhosts",
+		Usage: "Comma separated list of virtual hostnames from which to accept authenticated requests (server enforced). Accepts '*' wildcard.",
+		Value: strings.Join(node.DefaultConfig.AuthVirtualHosts, ","),
+	}
+	AuthRPCApiFlag = cli.StringFlag{
+		Name:  "authrpc.api",
+		Usage: "API's offered over the authenticated RPC interface (default = all)",
+		Value: "",
+	}
+	AuthRPCJWTSecretFlag = cli.StringFlag{
+		Name:  "authrpc.jwtsecret",
+		Usage: "Path to a hex encoded JWT secret for the authenticated RPC server (default = generated in the datadir)",
+	}
+	GraphQLEnabledFlag = cli.BoolFlag{
+		Name:  "graphql",
+		Usage: "Enable the GraphQL server",
+	}
+	GraphQLListenAddrFlag = cli.StringFlag{
+		Name:  "graphql.addr",
+		Usage: "GraphQL server listening interface",
+		Value: node.DefaultGraphQLHost,
+	}
+	GraphQLPortFlag = cli.IntFlag{
+		Name:  "graphql.port",
+		Usage: "GraphQL server listening port",
+		Value: node.DefaultGraphQLPort,
+	}
+	GraphQLCORSDomainFlag = cli.StringFlag{
+		Name:  "graphql.corsdomain",
+		Usage: "Comma separated list of domains from which to accept cross origin requests (browser enforced)",
+		Value: "",
+	}
+	GraphQLVirtualHostsFlag = cli.StringFlag{
+		Name:  "graphql.vhosts",
+		Usage: "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' wildcard.",
+		Value: strings.Join(node.DefaultConfig.GraphQLVirtualHosts, ","),
+	}
 	ExecFlag = cli.StringFlag{
 		Name:  "exec",
 		Usage: "Execute JavaScript statement",
@@ -478,6 +593,10 @@ var (
 		Usage: "Comma separated enode URLs for P2P v5 discovery bootstrap (light server, light nodes)",
 		Value: "",
 	}
+	DNSDiscoveryFlag = cli.StringFlag{
+		Name:  "discovery.dns",
+		Usage: "Comma separated enrtree:// URLs of DNS discovery node lists to find peers in",
+	}
 	NodeKeyFileFlag = cli.StringFlag{
 		Name:  "nodekey",
 		Usage: "P2P node key file",
@@ -540,6 +659,10 @@ var (
 		Name:  "plotdata",
 		Usage: "Directory for the plotdata (default = inside the datadir)",
 	}
+	PlotPathsFlag = cli.StringFlag{
+		Name:  "plotpaths",
+		Usage: "Comma separated list of directories to mine plot files from (default = plotdata directory)",
+	}
 )
 
 // MakeDataDir retrieves the currently requested data directory, terminating
@@ -660,6 +783,24 @@ func setBootstrapNodesV5(ctx *cli.Context, cfg *p2p.Config) {
 	}
 }
 
+// setDNSDiscovery configures the DNS discovery node lists from the command line
+// flags, validating their URLs.
+func setDNSDiscovery(ctx *cli.Context, cfg *p2p.Config) {
+	if !ctx.GlobalIsSet(DNSDiscoveryFlag.Name) {
+		return
+	}
+	cfg.DNSDiscovery = nil
+	for _, url := range splitAndTrim(ctx.GlobalString(DNSDiscoveryFlag.Name)) {
+		if url == "" {
+			continue
+		}
+		if _, _, err := dnsdisc.ParseURL(url); err != nil {
+			Fatalf("Option %s: invalid URL %q: %v", DNSDiscoveryFlag.Name, url, err)
+		}
+		cfg.DNSDiscovery = append(cfg.DNSDiscovery, url)
+	}
+}
+
 // setListenAddress creates a TCP listening address string from set command
 // line flags.
 func setListenAddress(ctx *cli.Context, cfg *p2p.Config) {
@@ -734,6 +875,51 @@ func setWS(ctx *cli.Context, cfg *node.Config) {
 	}
 }
 
+// setAuthRPC creates the authenticated RPC listener interface string from the
+// set command line flags, returning empty if the endpoint is disabled.
+func setAuthRPC(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalBool(AuthRPCEnabledFlag.Name) && cfg.AuthHost == "" {
+		cfg.AuthHost = node.DefaultAuthHost
+		if ctx.GlobalIsSet(AuthRPCListenAddrFlag.Name) {
+			cfg.AuthHost = ctx.GlobalString(AuthRPCListenAddrFlag.Name)
+		}
+	}
+
+	if ctx.GlobalIsSet(AuthRPCPortFlag.Name) {
+		cfg.AuthPort = ctx.GlobalInt(AuthRPCPortFlag.Name)
+	}
+	if ctx.GlobalIsSet(AuthRPCVirtualHostsFlag.Name) {
+		cfg.AuthVirtualHosts = splitAndTrim(ctx.GlobalString(AuthRPCVirtualHostsFlag.Name))
+	}
+	if ctx.GlobalIsSet(AuthRPCApiFlag.Name) {
+		cfg.AuthModules = splitAndTrim(ctx.GlobalString(AuthRPCApiFlag.Name))
+	}
+	if ctx.GlobalIsSet(AuthRPCJWTSecretFlag.Name) {
+		cfg.JWTSecret = ctx.GlobalString(AuthRPCJWTSecretFlag.Name)
+	}
+}
+
+// setGraphQL creates the GraphQL listener interface string from the set
+// command line flags, returning empty if the GraphQL endpoint is disabled.
+func setGraphQL(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalBool(GraphQLEnabledFlag.Name) && cfg.GraphQLHost == "" {
+		cfg.GraphQLHost = "127.0.0.1"
+		if ctx.GlobalIsSet(GraphQLListenAddrFlag.Name) {
+			cfg.GraphQLHost = ctx.GlobalString(GraphQLListenAddrFlag.Name)
+		}
+	}
+
+	if ctx.GlobalIsSet(GraphQLPortFlag.Name) {
+		cfg.GraphQLPort = ctx.GlobalInt(GraphQLPortFlag.Name)
+	}
+	if ctx.GlobalIsSet(GraphQLCORSDomainFlag.Name) {
+		cfg.GraphQLCors = splitAndTrim(ctx.GlobalString(GraphQLCORSDomainFlag.Name))
+	}
+	if ctx.GlobalIsSet(GraphQLVirtualHostsFlag.Name) {
+		cfg.GraphQLVirtualHosts = splitAndTrim(ctx.GlobalString(GraphQLVirtualHostsFlag.Name))
+	}
+}
+
 // setIPC creates an IPC path configuration from the set command line flags,
 // returning an empty string if IPC was explicitly disabled, or the set path.
 func setIPC(ctx *cli.Context, cfg *node.Config) {
@@ -825,6 +1011,7 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 	setListenAddress(ctx, cfg)
 	setBootstrapNodes(ctx, cfg)
 	setBootstrapNodesV5(ctx, cfg)
+	setDNSDiscovery(ctx, cfg)
 
 	lightClient := ctx.GlobalBool(LightModeFlag.Name) || ctx.GlobalString(SyncModeFlag.Name) == "light"
 	lightServer := ctx.GlobalInt(LightServFlag.Name) != 0
@@ -892,6 +1079,8 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	setIPC(ctx, cfg)
 	setHTTP(ctx, cfg)
 	setWS(ctx, cfg)
+	setAuthRPC(ctx, cfg)
+	setGraphQL(ctx, cfg)
 	setNodeUserIdent(ctx, cfg)
 
 	switch {
@@ -958,6 +1147,42 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolPriorityFlag.Name) {
+		for _, account := range strings.Split(ctx.GlobalString(TxPoolPriorityFlag.Name), ",") {
+			if trimmed := strings.TrimSpace(account); !common.IsHexAddress(trimmed) {
+				Fatalf("Invalid account in --%s: %s", TxPoolPriorityFlag.Name, trimmed)
+			} else {
+				cfg.Priority = append(cfg.Priority, common.HexToAddress(trimmed))
+			}
+		}
+	}
+	if ctx.GlobalIsSet(TxPoolRemoteJournalFlag.Name) {
+		cfg.RemoteJournal = ctx.GlobalString(TxPoolRemoteJournalFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolRemoteLifetimeFlag.Name) {
+		cfg.RemoteLifetime = ctx.GlobalDuration(TxPoolRemoteLifetimeFlag.Name)
+	}
+}
+
+func setPool(ctx *cli.Context, cfg *pool.Config) {
+	if ctx.GlobalIsSet(PoolEnabledFlag.Name) {
+		cfg.Enabled = ctx.GlobalBool(PoolEnabledFlag.Name)
+	}
+	if ctx.GlobalIsSet(PoolFeeFlag.Name) {
+		cfg.Fee = ctx.GlobalUint64(PoolFeeFlag.Name)
+	}
+	if ctx.GlobalIsSet(PoolMinPayoutFlag.Name) {
+		cfg.MinPayout = GlobalBig(ctx, PoolMinPayoutFlag.Name)
+	}
+	if ctx.GlobalIsSet(PoolPayoutIntervalFlag.Name) {
+		cfg.PayoutInterval = ctx.GlobalDuration(PoolPayoutIntervalFlag.Name)
+	}
+	if ctx.GlobalIsSet(PoolShareWindowFlag.Name) {
+		cfg.ShareWindow = ctx.GlobalUint64(PoolShareWindowFlag.Name)
+	}
+	if ctx.GlobalIsSet(PoolMaxDeadlineFlag.Name) {
+		cfg.MaxDeadline = ctx.GlobalUint64(PoolMaxDeadlineFlag.Name)
+	}
 }
 
 func setEthash(ctx *cli.Context, cfg *eth.Config) {
@@ -981,6 +1206,9 @@ func setEthash(ctx *cli.Context, cfg *eth.Config) {
 	}
 
 	cfg.Ethash.PlotdataDir = MakePlotdataDir(ctx)
+	if ctx.GlobalIsSet(PlotPathsFlag.Name) {
+		cfg.Ethash.PlotPaths = MakePlotPaths(ctx)
+	}
 }
 
 // checkExclusive verifies that only a single isntance of the provided flags was
@@ -1043,6 +1271,7 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	setEtherbase(ctx, ks, cfg)
 	setGPO(ctx, &cfg.GPO)
 	setTxPool(ctx, &cfg.TxPool)
+	setPool(ctx, &cfg.Pool)
 	setEthash(ctx, cfg)
 
 	switch {
@@ -1053,6 +1282,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	case ctx.GlobalBool(LightModeFlag.Name):
 		cfg.SyncMode = downloader.LightSync
 	}
+	if ctx.GlobalIsSet(SealCheckFrequencyFlag.Name) {
+		cfg.SealCheckFrequency = ctx.GlobalInt(SealCheckFrequencyFlag.Name)
+	}
 	if ctx.GlobalIsSet(LightServFlag.Name) {
 		cfg.LightServ = ctx.GlobalInt(LightServFlag.Name)
 	}
@@ -1067,6 +1299,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 		cfg.DatabaseCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheDatabaseFlag.Name) / 100
 	}
 	cfg.DatabaseHandles = makeDatabaseHandles()
+	if ctx.GlobalIsSet(AncientFlag.Name) {
+		cfg.DatabaseFreezer = ctx.GlobalString(AncientFlag.Name)
+	}
+	if ctx.GlobalIsSet(AncientThresholdFlag.Name) {
+		cfg.DatabaseFreezerThreshold = ctx.GlobalUint64(AncientThresholdFlag.Name)
+	}
 
 	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
 		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
@@ -1203,6 +1441,27 @@ func RegisterEthStatsService(stack *node.Node, url string) {
 	}
 }
 
+// RegisterGraphQLService is a utility function to construct a new service and
+// register it against a node.
+func RegisterGraphQLService(stack *node.Node, endpoint string, cors, vhosts []string) {
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		// Try to construct the GraphQL service backed by a full node
+		var ethServ *eth.Ethereum
+		if err := ctx.Service(&ethServ); err == nil {
+			return graphql.New(ethServ.APIBackend, endpoint, cors, vhosts)
+		}
+		// Try to construct the GraphQL service backed by a light node
+		var lesServ *les.LightEthereum
+		if err := ctx.Service(&lesServ); err == nil {
+			return graphql.New(lesServ.ApiBackend, endpoint, cors, vhosts)
+		}
+		// Well, this should not have happened, bail out
+		return nil, errors.New("no Ethereum service")
+	}); err != nil {
+		Fatalf("Failed to register the GraphQL service: %v", err)
+	}
+}
+
 // SetupNetwork configures the system for either the main net or some test network.
 func SetupNetwork(ctx *cli.Context) {
 	// TODO(fjl): move target gas limit into config
@@ -1215,11 +1474,15 @@ func MakeChainDatabase(ctx *cli.Context, stack *node.Node) ethdb.Database {
 		cache   = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheDatabaseFlag.Name) / 100
 		handles = makeDatabaseHandles()
 	)
-	name := "chaindata"
+	var (
+		chainDb ethdb.Database
+		err     error
+	)
 	if ctx.GlobalBool(LightModeFlag.Name) {
-		name = "lightchaindata"
+		chainDb, err = stack.OpenDatabase("lightchaindata", cache, handles)
+	} else {
+		chainDb, err = stack.OpenDatabaseWithFreezer("chaindata", cache, handles, ctx.GlobalString(AncientFlag.Name), ctx.GlobalUint64(AncientThresholdFlag.Name))
 	}
-	chainDb, err := stack.OpenDatabase(name, cache, handles)
 	if err != nil {
 		Fatalf("Could not open database: %v", err)
 	}
@@ -1252,6 +1515,18 @@ func MakePlotdataDir(ctx *cli.Context) string {
 	}
 }
 
+// MakePlotPaths returns the list of plot directories requested on the command
+// line, dropping empty entries.
+func MakePlotPaths(ctx *cli.Context) []string {
+	var paths []string
+	for _, path := range strings.Split(ctx.GlobalString(PlotPathsFlag.Name), ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
 // MakeChain creates a chain manager from set command line flags.
 func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chainDb ethdb.Database) {
 	var err error
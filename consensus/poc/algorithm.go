@@ -48,15 +48,24 @@ func CalcDeadline(scoopDataBytes []byte, genSigBytes []byte, baseTarget *big.Int
 	return hit.Div(hit, baseTarget)
 }
 
-func CalcBlockPoc(header *types.Header) *types.BlockPoc {
-	nonce := header.Nonce.Uint64()
-	seed := strings.ToLower(header.Coinbase.Hex()[2:])
+// CalcNonceHit rebuilds the plot of the given seed address and nonce and
+// returns the raw (unscaled) hit of its scoop for the given round.
+func CalcNonceHit(coinbase common.Address, nonce uint64, genSigBytes []byte, number uint64) (uint64, *big.Int) {
+	scoopNumber := CalcScoop(genSigBytes, number)
+	return scoopNumber, CalcHit(CalcScoopData(coinbase, nonce, scoopNumber), genSigBytes)
+}
+
+// CalcScoopData rebuilds the plot of the given seed address and nonce and
+// returns the data of the requested scoop.
+func CalcScoopData(coinbase common.Address, nonce uint64, scoopNumber uint64) []byte {
+	seed := strings.ToLower(coinbase.Hex()[2:])
 	mp := plotpoc.NewMiningPlot(seed, nonce)
+	return mp.GetScoop(scoopNumber)
+}
 
+func CalcBlockPoc(header *types.Header) *types.BlockPoc {
 	genSigBytes := header.GetGenerationSignature().Bytes()
-	scoopNumber := CalcScoop(genSigBytes, header.Number.Uint64())
-	scoopDataBytes := mp.GetScoop(scoopNumber)
-	deadline := CalcHit(scoopDataBytes, genSigBytes)
+	scoopNumber, deadline := CalcNonceHit(header.Coinbase, header.Nonce.Uint64(), genSigBytes, header.Number.Uint64())
 	baseTarget := plotparams.DifficultyToBaseTarget(header.Difficulty)
 	deadline.Div(deadline, baseTarget)
 
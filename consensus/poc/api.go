@@ -0,0 +1,26 @@
+package poc
+
+// API exposes the proof-of-capacity engine settings over RPC.
+type API struct {
+	poc *Poc
+}
+
+// GetPlotPaths returns the directories currently scanned for plot files.
+func (api *API) GetPlotPaths() []string {
+	return api.poc.PlotPaths()
+}
+
+// SetPlotPaths replaces the directories scanned for plot files.
+func (api *API) SetPlotPaths(paths []string) error {
+	return api.poc.SetPlotPaths(paths)
+}
+
+// AddPlotPath adds a directory to the set scanned for plot files.
+func (api *API) AddPlotPath(path string) error {
+	return api.poc.AddPlotPath(path)
+}
+
+// RemovePlotPath removes a directory from the set scanned for plot files.
+func (api *API) RemovePlotPath(path string) error {
+	return api.poc.RemovePlotPath(path)
+}
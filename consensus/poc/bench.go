@@ -0,0 +1,67 @@
+package poc
+
+import (
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/consensus/poc/data"
+)
+
+// DriveScan is the amount of plot data read from a single plot drive while
+// searching for a deadline and the time it took.
+type DriveScan struct {
+	Directory string
+	Bytes     uint64
+	Elapsed   time.Duration
+}
+
+// Throughput returns the read rate of the drive in bytes per second.
+func (d *DriveScan) Throughput() float64 {
+	if d.Elapsed <= 0 {
+		return 0
+	}
+	return float64(d.Bytes) / d.Elapsed.Seconds()
+}
+
+// RoundScan is the outcome of searching a set of plots for the best nonce of a
+// single round.
+type RoundScan struct {
+	Number  uint64
+	Scoop   uint64
+	Nonce   uint64
+	Hit     *big.Int // Unscaled hit of the best nonce, divide by the base target for the deadline
+	Elapsed time.Duration
+	Drives  []*DriveScan
+}
+
+// ScanRound searches the plots for the best nonce of the round given by the
+// generation signature and block number, going through the same code path the
+// sealer uses when mining.
+func ScanRound(plots *data.Plots, genSig common.Hash, number uint64) (*RoundScan, error) {
+	var (
+		scoop  = CalcScoop(genSig.Bytes(), number)
+		drives = make(map[string]*DriveScan)
+		start  = time.Now()
+	)
+	if len(plots.GetStartNonceMap()) == 0 {
+		return nil, errPlotdataNotFound
+	}
+	result := searchPlots(plots, genSig.Bytes(), scoop, nil, drives)
+	if result.err != nil {
+		return nil, result.err
+	}
+	scan := &RoundScan{
+		Number:  number,
+		Scoop:   scoop,
+		Nonce:   result.nonce,
+		Hit:     result.deadline,
+		Elapsed: time.Since(start),
+	}
+	for _, drive := range drives {
+		scan.Drives = append(scan.Drives, drive)
+	}
+	sort.Slice(scan.Drives, func(i, j int) bool { return scan.Drives[i].Directory < scan.Drives[j].Directory })
+	return scan, nil
+}
@@ -0,0 +1,86 @@
+package poc
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/consensus/poc/data"
+	"github.com/pocethereum/pochain/consensus/poc/plotter"
+)
+
+var (
+	benchCoinbase = common.HexToAddress("0x77b45e75cf93e428ae2ac6151666bac9fdbb1aa2")
+	benchSeed     = "77b45e75cf93e428ae2ac6151666bac9fdbb1aa2"
+	benchGenSig   = common.HexToHash("0x49e40c9d1cb86d3ea5c0b8d0d4b2c1c2d7a4fb2fa7e7f5b2a6cf18a4a42cc6d9")
+)
+
+// makeTestPlots plots the given number of nonces of the bench seed into a
+// temporary directory, returning the loaded plots and the directory.
+func makeTestPlots(tb testing.TB, nonces uint64) (*data.Plots, string) {
+	dir, err := ioutil.TempDir("", "poc-bench")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := plotter.NewWorker(plotter.NewWork(benchSeed, dir, 0, nonces, 4)).Run(); err != nil {
+		os.RemoveAll(dir)
+		tb.Fatalf("failed to plot: %v", err)
+	}
+	return data.NewPlots([]string{dir}, benchSeed), dir
+}
+
+func TestScanRound(t *testing.T) {
+	plots, dir := makeTestPlots(t, 8)
+	defer os.RemoveAll(dir)
+
+	for number := uint64(1); number <= 4; number++ {
+		scan, err := ScanRound(plots, benchGenSig, number)
+		if err != nil {
+			t.Fatalf("round %d: failed to scan: %v", number, err)
+		}
+		// The plot search must find the same best nonce as rebuilding every plot
+		var (
+			bestNonce uint64
+			bestHit   *big.Int
+		)
+		for nonce := uint64(0); nonce < 8; nonce++ {
+			if _, hit := CalcNonceHit(benchCoinbase, nonce, benchGenSig.Bytes(), number); bestHit == nil || hit.Cmp(bestHit) < 0 {
+				bestNonce, bestHit = nonce, hit
+			}
+		}
+		if scan.Nonce != bestNonce || scan.Hit.Cmp(bestHit) != 0 {
+			t.Errorf("round %d: best nonce mismatch: have %d/%v, want %d/%v", number, scan.Nonce, scan.Hit, bestNonce, bestHit)
+		}
+		if len(scan.Drives) != 1 || scan.Drives[0].Bytes != 8*64 {
+			t.Errorf("round %d: drive stats mismatch: have %+v", number, scan.Drives)
+		}
+	}
+	if _, err := ScanRound(data.NewPlots([]string{dir}, "00"), benchGenSig, 1); err != errPlotdataNotFound {
+		t.Errorf("scan without plots error mismatch: have %v, want %v", err, errPlotdataNotFound)
+	}
+}
+
+func BenchmarkScanRound(b *testing.B) {
+	plots, dir := makeTestPlots(b, 64)
+	defer os.RemoveAll(dir)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ScanRound(plots, benchGenSig, uint64(i))
+	}
+}
+
+func BenchmarkCalcHit(b *testing.B) {
+	scoop := make([]byte, 64)
+	for i := 0; i < b.N; i++ {
+		CalcHit(scoop, benchGenSig.Bytes())
+	}
+}
+
+func BenchmarkCalcNonceHit(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		CalcNonceHit(benchCoinbase, uint64(i), benchGenSig.Bytes(), 1)
+	}
+}
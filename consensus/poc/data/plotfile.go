@@ -1,12 +1,16 @@
 package data
 
 import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/consensus/poc/plot"
 	"github.com/pocethereum/pochain/log"
 	plotparams "github.com/pocethereum/pochain/params/plot"
 )
@@ -21,43 +25,49 @@ type PlotFile struct {
 }
 
 func NewPlotFile(path string) *PlotFile {
+	pf, err := ParsePlotFile(path)
+	if err != nil {
+		log.Warn("Invalid plotfile", "plotfile", path, "error", err)
+		return nil
+	}
+	if stat, _ := os.Stat(path); int64(pf.size) != stat.Size() {
+		log.Warn("File size mismatch", "expected", pf.size, "actual", stat.Size())
+	}
+	return pf
+}
+
+// ParsePlotFile parses the seed, start nonce and nonce count of a plot file
+// from its name, returning an error if the name is malformed or the file is
+// missing.
+func ParsePlotFile(path string) (*PlotFile, error) {
 	pf := new(PlotFile)
 	pf.filePath = path
 	pf.fileName = filepath.Base(path)
 
 	parts := strings.Split(pf.fileName, "_")
 	if len(parts) != 3 {
-		log.Warn("Invalid fileName format", "plotfile", pf.filePath)
-		return nil
+		return nil, fmt.Errorf("invalid file name format, want <seed>_<startNonce>_<nonces>")
+	}
+	if seed, err := hex.DecodeString(parts[0]); err != nil || len(seed) != common.AddressLength {
+		return nil, fmt.Errorf("invalid seed %q", parts[0])
 	}
-
 	pf.address = common.HexToAddress(parts[0])
 
 	var err error
 	pf.startNonce, err = strconv.ParseUint(parts[1], 10, 64)
 	if err != nil {
-		log.Warn("Parse startNonce failed", "plotfile", pf.filePath)
-		return nil
+		return nil, fmt.Errorf("invalid start nonce: %v", err)
 	}
-
 	pf.plots, err = strconv.ParseUint(parts[2], 10, 64)
 	if err != nil {
-		log.Warn("Parse plots failed", "plotfile", pf.filePath)
-		return nil
+		return nil, fmt.Errorf("invalid nonce count: %v", err)
 	}
 	pf.size = pf.plots * plotparams.PlotSize
 
-	stat, err := os.Stat(pf.filePath)
-	if err != nil {
-		log.Warn("Stat failed", "plotfile", pf.filePath, "error", err)
-		return nil
-	}
-
-	if int64(pf.size) != stat.Size() {
-		log.Warn("File size mismatch", "expected", pf.size, "actual", stat.Size())
+	if _, err := os.Stat(pf.filePath); err != nil {
+		return nil, err
 	}
-
-	return pf
+	return pf, nil
 }
 
 func (pf *PlotFile) GetFilePath() string {
@@ -68,10 +78,67 @@ func (pf *PlotFile) GetFileName() string {
 	return pf.fileName
 }
 
+// GetAddress returns the address the plot file is seeded with.
+func (pf *PlotFile) GetAddress() common.Address {
+	return pf.address
+}
+
 func (pf *PlotFile) GetStartNonce() uint64 {
 	return pf.startNonce
 }
 
+// GetNonces returns the number of nonces stored in the plot file.
+func (pf *PlotFile) GetNonces() uint64 {
+	return pf.plots
+}
+
 func (pf *PlotFile) GetSize() uint64 {
 	return pf.size
 }
+
+// ReadScoop reads a single scoop of the given nonce from the plot file.
+func (pf *PlotFile) ReadScoop(nonce, scoop uint64) ([]byte, error) {
+	fd, err := os.Open(pf.filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	return pf.readScoop(fd, nonce, scoop)
+}
+
+// VerifyNonce regenerates the plot of the given nonce and checks every scoop of
+// it against the content of the plot file.
+func (pf *PlotFile) VerifyNonce(nonce uint64) error {
+	fd, err := os.Open(pf.filePath)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	mp := plot.NewMiningPlot(hex.EncodeToString(pf.address[:]), nonce)
+	for scoop := uint64(0); scoop < plotparams.ScoopsPerPlot; scoop++ {
+		data, err := pf.readScoop(fd, nonce, scoop)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(data, mp.GetScoop(scoop)) {
+			return fmt.Errorf("nonce %d: scoop %d mismatch", nonce, scoop)
+		}
+	}
+	return nil
+}
+
+// readScoop reads a scoop from an opened plot file. Plot files are optimized,
+// storing the same scoop of all their nonces next to each other.
+func (pf *PlotFile) readScoop(fd *os.File, nonce, scoop uint64) ([]byte, error) {
+	if nonce < pf.startNonce || nonce-pf.startNonce >= pf.plots {
+		return nil, fmt.Errorf("nonce %d not in plot file", nonce)
+	}
+	data := make([]byte, plotparams.ScoopSize)
+	offset := (scoop%plotparams.ScoopsPerPlot)*pf.plots*plotparams.ScoopSize + (nonce-pf.startNonce)*plotparams.ScoopSize
+	if _, err := fd.ReadAt(data, int64(offset)); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,52 @@
+package data
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pocethereum/pochain/consensus/poc/plotter"
+	plotparams "github.com/pocethereum/pochain/params/plot"
+)
+
+func TestPlotFileVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "poc-plotfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	seed := "77b45e75cf93e428ae2ac6151666bac9fdbb1aa2"
+	if err := plotter.NewWorker(plotter.NewWork(seed, dir, 10, 3, 2)).Run(); err != nil {
+		t.Fatalf("failed to plot: %v", err)
+	}
+	ps := NewPlots([]string{dir}, seed)
+	if size := ps.GetSize(); size != 3*plotparams.PlotSize {
+		t.Fatalf("plot size mismatch: have %d, want %d", size, 3*plotparams.PlotSize)
+	}
+	pf, err := ParsePlotFile(filepath.Join(dir, seed+"_12_1"))
+	if err != nil {
+		t.Fatalf("failed to parse plot file: %v", err)
+	}
+	if err := pf.VerifyNonce(12); err != nil {
+		t.Fatalf("failed to verify plot file: %v", err)
+	}
+	if err := pf.VerifyNonce(11); err == nil {
+		t.Fatalf("verified nonce outside of plot file")
+	}
+	// Corrupt a single byte of the plot and ensure it's detected
+	fd, err := os.OpenFile(pf.GetFilePath(), os.O_RDWR, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd.WriteAt([]byte{0xff}, int64(plotparams.PlotSize/2))
+	fd.Close()
+
+	if err := pf.VerifyNonce(12); err == nil {
+		t.Fatalf("corrupted plot file verified")
+	}
+	if _, err := ParsePlotFile(filepath.Join(dir, "notaplot")); err == nil {
+		t.Fatalf("invalid plot file name parsed")
+	}
+}
@@ -62,8 +62,8 @@ func AddTotalRewarded(state *state.StateDB, r *big.Int) {
 	state.SetState(MortgageContractAddr, key, y)
 }
 
-// Get mortgage amount
-func mortgageOf(addr common.Address, state *state.StateDB) *big.Int {
+// MortgageOf returns the amount pledged by addr in the mortgage contract.
+func MortgageOf(addr common.Address, state *state.StateDB) *big.Int {
 	//positions of solidity storage var _mortgages
 	pos_var_mortgages := MortgageMappingPos
 	//key of mapping
@@ -131,7 +131,7 @@ var (
 // Calculate miner reward
 func CalcReward(coinbase common.Address, nonce uint64, state *state.StateDB) *big.Int {
 	// Step 1. get fullBlockReward
-	x := mortgageOf(coinbase, state)
+	x := MortgageOf(coinbase, state)
 	fullBlockReward := F(M(x, nonce))
 	if fullBlockReward.Cmp(big.NewInt(0)) <= 0 {
 		return big.NewInt(0)
@@ -0,0 +1,95 @@
+package mortgage
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/common/hexutil"
+	"github.com/pocethereum/pochain/core/state"
+	"github.com/pocethereum/pochain/crypto"
+	"github.com/pocethereum/pochain/params"
+)
+
+// DefaultMortgageLockup is the number of blocks a redeemed pledge stays locked
+// if the chain config doesn't specify otherwise, about a week of blocks.
+const DefaultMortgageLockup = 3360
+
+var (
+	// MortgageUpgradeCode is the runtime code installed at the mortgage system
+	// address on the mortgage upgrade fork block, assembled from
+	// contracts/mortgage/contract/mortgage.easm which implements the interface of
+	// mortgage.sol next to it. Redeeming a pledge no longer pays out instantly,
+	// but locks the value up for the configured number of blocks before it can be
+	// withdrawn.
+	MortgageUpgradeCode = hexutil.MustDecode("0x" + "60043610630000007c5760003560e060020a90048063d8a830c6146300000081578063396ffa1b146300000094578063a5f1e2821463000000cb578063046380e6146300000102578063ee947a7c14630000013957806343794dda14630000014c578063db006a751463000001cc5780633ccfd60b146300000261575b600080fd5b34630000007c5760015460005260206000f35b34630000007c5760043573ffffffffffffffffffffffffffffffffffffffff16600052600060205260406000205460005260206000f35b34630000007c5760043573ffffffffffffffffffffffffffffffffffffffff16600052600360205260406000205460005260206000f35b34630000007c5760043573ffffffffffffffffffffffffffffffffffffffff16600052600460205260406000205460005260206000f35b34630000007c5760055460005260206000f35b60043573ffffffffffffffffffffffffffffffffffffffff168015630000007c5760243580341415630000007c578160005260006020526040600020805482019055600154810160015580600052817fbddecaad150f4a9f75fb6864ff351a7f06b19c5b9cf533c22b5bc05ecebc079060206000a2600160005260206000f35b34630000007c576004358015630000007c5733600052600060205260406000208054828110630000007c578290039055806001540360015533600052600360205260406000208054820190556005544301336000526004602052604060002055600052337f222838db2794d11532d940e8dec38ae307ed0b63cd97c233322e221f998767a660206000a2600160005260206000f35b34630000007c57336000526003602052604060002080548015630000007c573360005260046020526040600020544310630000007c5760008255600080808084336000f115630000007c57600052337f884edad9ce6fa2440d8a54cc123490eb96d2768479d49ff9c7366125a942436460206000a2600160005260206000f3")
+
+	MortgageLockedMappingPos      = hexutil.MustDecode("0x0000000000000000000000000000000000000000000000000000000000000003")
+	MortgageUnlockBlockMappingPos = hexutil.MustDecode("0x0000000000000000000000000000000000000000000000000000000000000004")
+	MortgageLockupPos             = hexutil.MustDecode("0x0000000000000000000000000000000000000000000000000000000000000005")
+)
+
+// ApplyUpgrade replaces the mortgage system contract with the lock-up enabled
+// one, keeping all pledges and totals as the storage layout is compatible. It
+// is meant to be called on the state of the mortgage upgrade fork block.
+func ApplyUpgrade(state *state.StateDB, config *params.ChainConfig) {
+	lockup := uint64(DefaultMortgageLockup)
+	if config.Poc != nil && config.Poc.MortgageLockup != 0 {
+		lockup = config.Poc.MortgageLockup
+	}
+	state.SetCode(MortgageContractAddr, MortgageUpgradeCode)
+	state.SetState(MortgageContractAddr, common.BytesToHash(MortgageLockupPos), common.BigToHash(new(big.Int).SetUint64(lockup)))
+}
+
+// LockedOf returns the redeemed amount of owner still held by the contract.
+func LockedOf(owner common.Address, state *state.StateDB) *big.Int {
+	return state.GetState(MortgageContractAddr, mappingKey(owner, MortgageLockedMappingPos)).Big()
+}
+
+// UnlockBlockOf returns the block from which the locked amount of owner can be
+// withdrawn.
+func UnlockBlockOf(owner common.Address, state *state.StateDB) *big.Int {
+	return state.GetState(MortgageContractAddr, mappingKey(owner, MortgageUnlockBlockMappingPos)).Big()
+}
+
+// Slash confiscates up to amount of the funds owner has in the mortgage system,
+// taking them from the locked redemptions first and the active pledge after.
+// The confiscated value is burnt from the contract balance and returned.
+func Slash(state *state.StateDB, owner common.Address, amount *big.Int) *big.Int {
+	slashed := new(big.Int)
+
+	lockedKey := mappingKey(owner, MortgageLockedMappingPos)
+	locked := state.GetState(MortgageContractAddr, lockedKey).Big()
+	if cut := minBig(locked, amount); cut.Sign() > 0 {
+		state.SetState(MortgageContractAddr, lockedKey, common.BigToHash(locked.Sub(locked, cut)))
+		slashed.Add(slashed, cut)
+	}
+	pledgeKey := mappingKey(owner, MortgageMappingPos)
+	pledge := state.GetState(MortgageContractAddr, pledgeKey).Big()
+	if cut := minBig(pledge, new(big.Int).Sub(amount, slashed)); cut.Sign() > 0 {
+		state.SetState(MortgageContractAddr, pledgeKey, common.BigToHash(pledge.Sub(pledge, cut)))
+
+		totalKey := common.BytesToHash(MortgageTotalMortgagePos)
+		total := state.GetState(MortgageContractAddr, totalKey).Big()
+		state.SetState(MortgageContractAddr, totalKey, common.BigToHash(total.Sub(total, minBig(total, cut))))
+		slashed.Add(slashed, cut)
+	}
+	if balance := state.GetBalance(MortgageContractAddr); balance.Cmp(slashed) < 0 {
+		state.SubBalance(MortgageContractAddr, balance)
+	} else {
+		state.SubBalance(MortgageContractAddr, slashed)
+	}
+	return slashed
+}
+
+// mappingKey returns the storage key of addr in the solidity mapping at pos.
+func mappingKey(addr common.Address, pos []byte) common.Hash {
+	return common.BytesToHash(crypto.Keccak256(bytes.Join([][]byte{addr.Hash().Bytes(), pos}, []byte{})))
+}
+
+func minBig(x, y *big.Int) *big.Int {
+	if x.Cmp(y) < 0 {
+		return new(big.Int).Set(x)
+	}
+	return new(big.Int).Set(y)
+}
@@ -0,0 +1,59 @@
+package mortgage
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/core/state"
+	"github.com/pocethereum/pochain/ethdb"
+	"github.com/pocethereum/pochain/params"
+)
+
+func TestApplyUpgrade(t *testing.T) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	owner := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	// Pledge through the original contract layout
+	statedb.SetCode(MortgageContractAddr, MortgageSystemCode)
+	statedb.AddBalance(MortgageContractAddr, big.NewInt(1000))
+	statedb.SetState(MortgageContractAddr, mappingKey(owner, MortgageMappingPos), common.BigToHash(big.NewInt(600)))
+	statedb.SetState(MortgageContractAddr, common.BytesToHash(MortgageTotalMortgagePos), common.BigToHash(big.NewInt(1000)))
+	statedb.SetState(MortgageContractAddr, mappingKey(owner, MortgageLockedMappingPos), common.BigToHash(big.NewInt(300)))
+
+	ApplyUpgrade(statedb, &params.ChainConfig{Poc: &params.PocConfig{MortgageLockup: 10}})
+
+	if !bytes.Equal(statedb.GetCode(MortgageContractAddr), MortgageUpgradeCode) {
+		t.Fatalf("mortgage contract not upgraded")
+	}
+	if lockup := statedb.GetState(MortgageContractAddr, common.BytesToHash(MortgageLockupPos)).Big(); lockup.Uint64() != 10 {
+		t.Fatalf("lockup period mismatch: have %v, want 10", lockup)
+	}
+	if pledge := MortgageOf(owner, statedb); pledge.Cmp(big.NewInt(600)) != 0 {
+		t.Fatalf("pledge lost by upgrade: have %v, want 600", pledge)
+	}
+	if locked := LockedOf(owner, statedb); locked.Cmp(big.NewInt(300)) != 0 {
+		t.Fatalf("locked value lost by upgrade: have %v, want 300", locked)
+	}
+	// Slashing takes the locked value first and the pledge after
+	if slashed := Slash(statedb, owner, big.NewInt(500)); slashed.Cmp(big.NewInt(500)) != 0 {
+		t.Fatalf("slashed amount mismatch: have %v, want 500", slashed)
+	}
+	if locked := LockedOf(owner, statedb); locked.Sign() != 0 {
+		t.Fatalf("locked value mismatch: have %v, want 0", locked)
+	}
+	if pledge := MortgageOf(owner, statedb); pledge.Cmp(big.NewInt(400)) != 0 {
+		t.Fatalf("pledge mismatch: have %v, want 400", pledge)
+	}
+	if total := GetTotalMortgage(statedb); total.Cmp(big.NewInt(800)) != 0 {
+		t.Fatalf("total mortgage mismatch: have %v, want 800", total)
+	}
+	if balance := statedb.GetBalance(MortgageContractAddr); balance.Cmp(big.NewInt(500)) != 0 {
+		t.Fatalf("contract balance mismatch: have %v, want 500", balance)
+	}
+	// Slashing can't take more than the owner has
+	if slashed := Slash(statedb, owner, big.NewInt(1000)); slashed.Cmp(big.NewInt(400)) != 0 {
+		t.Fatalf("slashed amount mismatch: have %v, want 400", slashed)
+	}
+}
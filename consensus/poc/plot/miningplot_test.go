@@ -0,0 +1,11 @@
+package plot
+
+import (
+	"testing"
+)
+
+func BenchmarkNewMiningPlot(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		NewMiningPlot("77b45e75cf93e428ae2ac6151666bac9fdbb1aa2", uint64(i))
+	}
+}
@@ -0,0 +1,112 @@
+package poc
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pocethereum/pochain/consensus/poc/data"
+)
+
+var (
+	errEmptyPlotPath    = errors.New("empty plot path")
+	errPlotPathExists   = errors.New("plot path already mined")
+	errPlotPathNotFound = errors.New("plot path not mined")
+)
+
+// PlotPaths returns the directories currently scanned for plot files.
+func (poc *Poc) PlotPaths() []string {
+	poc.lock.RLock()
+	defer poc.lock.RUnlock()
+
+	return append([]string{}, poc.plotPaths...)
+}
+
+// SetPlotPaths replaces the directories scanned for plot files. The new set
+// takes effect from the next sealing round on.
+func (poc *Poc) SetPlotPaths(paths []string) error {
+	cleaned := make([]string, 0, len(paths))
+	for _, path := range paths {
+		path, err := cleanPlotPath(path)
+		if err != nil {
+			return err
+		}
+		for _, known := range cleaned {
+			if known == path {
+				return fmt.Errorf("%v: %s", errPlotPathExists, path)
+			}
+		}
+		cleaned = append(cleaned, path)
+	}
+	poc.lock.Lock()
+	defer poc.lock.Unlock()
+
+	poc.plotPaths = cleaned
+	return nil
+}
+
+// AddPlotPath adds a directory to the set scanned for plot files.
+func (poc *Poc) AddPlotPath(path string) error {
+	path, err := cleanPlotPath(path)
+	if err != nil {
+		return err
+	}
+	poc.lock.Lock()
+	defer poc.lock.Unlock()
+
+	for _, known := range poc.plotPaths {
+		if known == path {
+			return fmt.Errorf("%v: %s", errPlotPathExists, path)
+		}
+	}
+	poc.plotPaths = append(append([]string{}, poc.plotPaths...), path)
+	return nil
+}
+
+// RemovePlotPath removes a directory from the set scanned for plot files.
+func (poc *Poc) RemovePlotPath(path string) error {
+	path, err := cleanPlotPath(path)
+	if err != nil {
+		return err
+	}
+	poc.lock.Lock()
+	defer poc.lock.Unlock()
+
+	for i, known := range poc.plotPaths {
+		if known == path {
+			paths := append([]string{}, poc.plotPaths[:i]...)
+			poc.plotPaths = append(paths, poc.plotPaths[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("%v: %s", errPlotPathNotFound, path)
+}
+
+// cleanPlotPath normalises a plot directory, rejecting empty paths and paths
+// pointing to something other than a directory. Paths that do not exist yet
+// are accepted, as plot drives may be mounted later on.
+func cleanPlotPath(path string) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", errEmptyPlotPath
+	}
+	path = filepath.Clean(path)
+	if stat, err := os.Stat(path); err == nil && !stat.IsDir() {
+		return "", fmt.Errorf("plot path is not a directory: %s", path)
+	}
+	return path, nil
+}
+
+// loadPlots returns the plot files of the given seed found in the current plot
+// paths, rescanning the directories if either changed since the last call.
+func (poc *Poc) loadPlots(seed string) *data.Plots {
+	poc.lock.Lock()
+	defer poc.lock.Unlock()
+
+	if poc.plots == nil || poc.plots.Seed != seed ||
+		strings.Join(poc.plots.PlotPaths, ",") != strings.Join(poc.plotPaths, ",") {
+		poc.plots = data.NewPlots(poc.plotPaths, seed)
+	}
+	return poc.plots
+}
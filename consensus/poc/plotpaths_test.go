@@ -0,0 +1,59 @@
+package poc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pocethereum/pochain/params"
+)
+
+func TestPlotPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "poc-plotpaths")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		drive1 = filepath.Join(dir, "drive1")
+		drive2 = filepath.Join(dir, "drive2")
+		file   = filepath.Join(dir, "file")
+	)
+	ioutil.WriteFile(file, nil, 0600)
+
+	poc := New(new(params.PocConfig), []string{drive1})
+	if err := poc.AddPlotPath(drive2 + "/"); err != nil {
+		t.Fatalf("failed to add plot path: %v", err)
+	}
+	if err := poc.AddPlotPath(drive1); err == nil {
+		t.Fatalf("duplicate plot path added")
+	}
+	if err := poc.AddPlotPath(file); err == nil {
+		t.Fatalf("file added as plot path")
+	}
+	if err := poc.AddPlotPath(" "); err != errEmptyPlotPath {
+		t.Fatalf("empty plot path error mismatch: have %v, want %v", err, errEmptyPlotPath)
+	}
+	if have, want := poc.PlotPaths(), []string{drive1, drive2}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("plot paths mismatch: have %v, want %v", have, want)
+	}
+	if err := poc.RemovePlotPath(drive1); err != nil {
+		t.Fatalf("failed to remove plot path: %v", err)
+	}
+	if err := poc.RemovePlotPath(drive1); err == nil {
+		t.Fatalf("unknown plot path removed")
+	}
+	if have, want := poc.PlotPaths(), []string{drive2}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("plot paths mismatch: have %v, want %v", have, want)
+	}
+	// Replacing the set is all or nothing
+	if err := poc.SetPlotPaths([]string{drive1, drive1}); err == nil {
+		t.Fatalf("duplicate plot paths set")
+	}
+	if have, want := poc.PlotPaths(), []string{drive2}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("plot paths changed by failed update: have %v, want %v", have, want)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 )
 
 type Task struct {
@@ -12,7 +13,7 @@ type Task struct {
 	nonceQuantity uint64
 	plotfilePath  string
 	plotfileSize  uint64
-	progress      uint
+	progress      uint32 // Plotting progress of the task, accessed atomically
 }
 
 type TASK_STATUS int
@@ -51,6 +52,16 @@ func NewTask(work *Work, startNonce uint64, nonceQuantity uint64) (task *Task) {
 	return task
 }
 
+// setProgress updates the plotting progress of the task.
+func (t *Task) setProgress(progress uint) {
+	atomic.StoreUint32(&t.progress, uint32(progress))
+}
+
+// Progress returns the plotting progress of the task, out of PROGRESS_MAX.
+func (t *Task) Progress() uint {
+	return uint(atomic.LoadUint32(&t.progress))
+}
+
 func (t *Task) Check() {
 
 }
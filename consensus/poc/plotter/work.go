@@ -1,6 +1,8 @@
 package plotter
 
-import ()
+import (
+	"path/filepath"
+)
 
 /*
  *一个Work对应着某一个需要P的目录;
@@ -20,6 +22,41 @@ type Work struct {
 	doneTasks     []*Task
 }
 
+// NewWork creates a work plotting nonces nonces of seed into dir, starting at
+// startNonce and split into plot files of at most fileNonces nonces each.
+func NewWork(seed, dir string, startNonce, nonces, fileNonces uint64) *Work {
+	work := &Work{
+		Id:            filepath.Join(dir, seed),
+		PlotSeed:      seed,
+		PlotDir:       dir,
+		PlotSize:      nonces << 18,
+		startNonce:    startNonce,
+		nonceQuantity: nonces,
+		todoTasks:     []*Task{},
+		doingTasks:    []*Task{},
+		doneTasks:     []*Task{},
+	}
+	for i := uint64(0); i < nonces; i += fileNonces {
+		n := fileNonces
+		if nonces-i < n {
+			n = nonces - i
+		}
+		work.todoTasks = append(work.todoTasks, NewTask(work, startNonce+i, n))
+	}
+	return work
+}
+
+// PlotFiles returns the paths of the plot files the work produces.
+func (work *Work) PlotFiles() []string {
+	var paths []string
+	for _, tasks := range [][]*Task{work.doneTasks, work.doingTasks, work.todoTasks} {
+		for _, task := range tasks {
+			paths = append(paths, task.plotfilePath)
+		}
+	}
+	return paths
+}
+
 func (work *Work) Init() {
 	const ONE_TASK_NONCE_NUM = 4096
 	work.todoTasks = []*Task{}
@@ -60,7 +97,7 @@ func (work *Work) Progress() uint {
 	total := (len(work.todoTasks) + len(work.doingTasks) + len(work.doneTasks))
 	done := len(work.doneTasks) * PROGRESS_MAX
 	for _, doing := range work.doingTasks {
-		done += int(doing.progress)
+		done += int(doing.Progress())
 	}
 	return uint(done / total)
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/pocethereum/pochain/log"
 	plotparams "github.com/pocethereum/pochain/params/plot"
 	"errors"
+	"fmt"
 	"os"
 	"strings"
 	"sync"
@@ -12,7 +13,7 @@ import (
 )
 
 type Worker struct {
-	lock      sync.Mutex
+	lock      sync.Mutex // Protects the task lists of the work, read while plotting
 	isworking uint64
 	work      *Work
 }
@@ -42,11 +43,28 @@ func (w *Worker) Stop() {
 	atomic.StoreUint64(&w.isworking, 0)
 }
 
+// Run plots all tasks of the work on the calling goroutine, returning an error
+// if any of them could not be completed.
+func (w *Worker) Run() error {
+	w.working()
+	w.lock.Lock()
+	left := len(w.work.todoTasks) + len(w.work.doingTasks)
+	w.lock.Unlock()
+
+	if left > 0 {
+		return fmt.Errorf("%d plot files not completed", left)
+	}
+	return nil
+}
+
 func (w *Worker) IsWorking() bool {
 	return atomic.LoadUint64(&w.isworking) == 1
 }
 
 func (w *Worker) Progress() (progress uint, plotsize uint64) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
 	return w.work.Progress(), w.work.PlotSize
 }
 
@@ -58,7 +76,9 @@ func (w *Worker) working() {
 
 	for atomic.LoadUint64(&w.isworking) == 1 {
 		// Step 1. Get Task
+		w.lock.Lock()
 		task := w.work.GetTask()
+		w.lock.Unlock()
 		if task == nil {
 			log.Info("all works done, plotter working exit")
 			atomic.StoreUint64(&w.isworking, 0)
@@ -71,7 +91,7 @@ func (w *Worker) working() {
 			log.Info("task is new, ready to doPlot", "task", task)
 		case TASK_STATUS_DONE:
 			log.Info("task have been done, commit & continue next", "task", task)
-			w.work.CommitTask(task)
+			w.commitTask(task)
 			continue
 		case TASK_STATUS_ERROR, TASK_STATUS_PLOTTING:
 			log.Info("something error", "status", status)
@@ -83,7 +103,7 @@ func (w *Worker) working() {
 		switch err := w.doPlot(task); err {
 		case errOk:
 			log.Info("doPlot done, commit & continue next", "task", task)
-			w.work.CommitTask(task)
+			w.commitTask(task)
 			continue
 		default:
 			log.Info("something error", "error", err.Error())
@@ -93,6 +113,14 @@ func (w *Worker) working() {
 	}
 }
 
+// commitTask marks a task of the work as completed.
+func (w *Worker) commitTask(task *Task) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.work.CommitTask(task)
+}
+
 func (w *Worker) taskCheck(task *Task) (status TASK_STATUS) {
 	if stat, err := os.Stat(task.plotfilePath); os.IsNotExist(err) {
 		log.Info("file is not exist", "plotfilePath", task.plotfilePath)
@@ -150,7 +178,7 @@ func (w *Worker) doPlot(task *Task) (err error) {
 			return errFileError
 		}
 		nonceIndex++
-		task.progress = progress + uint(nonceIndex*progress_rate/task.nonceQuantity)
+		task.setProgress(progress + uint(nonceIndex*progress_rate/task.nonceQuantity))
 	}
 
 	// Step 2. Plot of OPTIMIZE
@@ -175,7 +203,7 @@ func (w *Worker) doPlot(task *Task) (err error) {
 			return errFileError
 		}
 
-		task.progress = progress + uint(nonceIndex*progress_rate/task.nonceQuantity)
+		task.setProgress(progress + uint(scoopIndex*progress_rate/scoopLimit))
 		scoopIndex++
 	}
 
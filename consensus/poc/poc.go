@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"math/big"
 	"runtime"
+	"sync"
 	"time"
 
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/pocethereum/pochain/common"
 	"github.com/pocethereum/pochain/common/math"
 	"github.com/pocethereum/pochain/consensus"
@@ -16,6 +18,7 @@ import (
 	"github.com/pocethereum/pochain/consensus/poc/mortgage"
 	"github.com/pocethereum/pochain/core/state"
 	"github.com/pocethereum/pochain/core/types"
+	"github.com/pocethereum/pochain/event"
 	"github.com/pocethereum/pochain/params"
 	plotparams "github.com/pocethereum/pochain/params/plot"
 	"github.com/pocethereum/pochain/rpc"
@@ -37,29 +40,56 @@ var (
 	allowedFutureBlockTime = 15 * time.Second
 )
 
+// scoopCacheLimit is the number of recently verified scoops kept around, so
+// that re-verifying a header (e.g. after a reorg) doesn't rebuild its plot.
+const scoopCacheLimit = 8192
+
+// scoopKey identifies the scoop of a plot nonce a seal was verified against.
+type scoopKey struct {
+	coinbase common.Address
+	nonce    uint64
+	scoop    uint64
+}
+
 type Poc struct {
 	config *params.PocConfig
-	plots  *data.Plots
-}
 
-func New(config *params.PocConfig) *Poc {
-	conf := *config
-	go func(){
-		for{
-			log.Info("PlotpathsUpdater waiting...")
-			if plotpath, ok := <- conf.PlotpathsUpdater; ok &&  plotpath != ""{
-				log.Info("PlotpathsUpdater success", "current", plotpath, "pre", conf.PlotPaths)
-				conf.PlotPaths = plotpath
-			} else {
-				log.Info("PlotpathsUpdater error", "current plotpasth", conf.PlotPaths, "plotpath", plotpath)
-				time.Sleep(5*time.Second)
-			}
-		}
-	}()
+	plotPaths []string    // Directories scanned for plot files when sealing
+	plots     *data.Plots // Plot files found in plotPaths for the current seed
+
+	remote chan *NonceSubmission // Externally found nonces, nil unless remote nonces are enabled
+	lock   sync.RWMutex
+
+	roundFeed event.Feed // Rounds started and deadlines found by the sealer
+	slashers  []Slasher  // Hooks confiscating pledges on finalization
+
+	scoops *lru.Cache // Scoop data of recently verified seals
+
+	// The fields below are hooks for testing
+	fixedDifficulty bool // Keep the parent's difficulty instead of retargeting
+}
 
-	return &Poc{
-		config: &conf,
+// New creates a proof-of-capacity consensus engine, mining the plot files
+// found in the given directories.
+func New(config *params.PocConfig, plotPaths []string) *Poc {
+	scoops, _ := lru.New(scoopCacheLimit)
+	poc := &Poc{
+		config: config,
+		scoops: scoops,
 	}
+	if err := poc.SetPlotPaths(plotPaths); err != nil {
+		log.Warn("Invalid plot paths", "paths", plotPaths, "err", err)
+	}
+	return poc
+}
+
+// NewTester creates a proof-of-capacity engine for tests and simulations, which
+// never retargets: every block keeps the difficulty of the genesis block, so
+// the block time only depends on the number of nonces plotted.
+func NewTester(config *params.PocConfig, plotPaths []string) *Poc {
+	poc := New(config, plotPaths)
+	poc.fixedDifficulty = true
+	return poc
 }
 
 func (poc *Poc) Config() *params.PocConfig {
@@ -104,10 +134,14 @@ func (poc *Poc) getAncestorHeaders(chain consensus.ChainReader, header *types.He
 // concurrently. The method returns a quit channel to abort the operations and
 // a results channel to retrieve the async verifications.
 func (poc *Poc) VerifyHeaders(chain consensus.ChainReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
+	if len(headers) == 0 {
+		return make(chan struct{}), make(chan error)
+	}
 	workers := runtime.GOMAXPROCS(0)
 	if len(headers) < workers {
 		workers = len(headers)
 	}
+	batch := poc.newHeaderBatch(chain, headers, seals)
 
 	// Create a task channel and spawn the verifiers
 	var (
@@ -119,7 +153,7 @@ func (poc *Poc) VerifyHeaders(chain consensus.ChainReader, headers []*types.Head
 	for i := 0; i < workers; i++ {
 		go func() {
 			for index := range inputs {
-				errs[index] = poc.verifyHeaderWorker(chain, headers, seals, index)
+				errs[index] = poc.verifyHeaderWorker(chain, batch, index)
 				done <- index
 			}
 		}()
@@ -154,31 +188,67 @@ func (poc *Poc) VerifyHeaders(chain consensus.ChainReader, headers []*types.Head
 	return abort, errorsOut
 }
 
-func (poc *Poc) verifyHeaderWorker(chain consensus.ChainReader, headers []*types.Header, seals []bool, index int) error {
-	currentIndex := index
-	ancestorHeaders := []*types.Header{}
+// headerBatch is a batch of headers to verify, linked up with the canonical
+// headers preceding it, so that the ancestors of every header are only looked
+// up once.
+type headerBatch struct {
+	headers []*types.Header
+	hashes  []common.Hash
+	seals   []bool
+
+	chained  []*types.Header // Ancestors of the batch followed by the batch, oldest first
+	offset   int             // Index of the first batch header in chained
+	err      error           // Error looking up the ancestors of the batch
+	unlinked int             // Index of the first header not linked to its predecessor
+}
 
-	header := headers[index]
-	for i := uint64(0); i < plotparams.CalcDiffBlockLimit && index > 0; i++ {
-		if headers[index-1].Hash() != header.ParentHash {
-			return consensus.ErrUnknownAncestor
+// newHeaderBatch hashes and links up the headers of a batch, and looks up the
+// ancestors its first headers need for verification.
+func (poc *Poc) newHeaderBatch(chain consensus.ChainReader, headers []*types.Header, seals []bool) *headerBatch {
+	batch := &headerBatch{
+		headers:  headers,
+		hashes:   make([]common.Hash, len(headers)),
+		seals:    seals,
+		unlinked: len(headers),
+	}
+	for i, header := range headers {
+		batch.hashes[i] = header.Hash()
+		if i > 0 && batch.unlinked == len(headers) && header.ParentHash != batch.hashes[i-1] {
+			batch.unlinked = i
 		}
-		header = headers[index-1]
-		ancestorHeaders = append(ancestorHeaders, header)
-		index--
 	}
-
-	count := plotparams.CalcDiffBlockLimit - uint64(len(ancestorHeaders))
-	newHeaders, err := poc.getAncestorHeaders(chain, header, count)
-	if err != nil {
-		return err
+	ancestors, err := poc.getAncestorHeaders(chain, headers[0], plotparams.CalcDiffBlockLimit)
+	batch.chained = make([]*types.Header, 0, len(ancestors)+len(headers))
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		batch.chained = append(batch.chained, ancestors[i])
 	}
-	ancestorHeaders = append(ancestorHeaders, newHeaders...)
+	batch.chained = append(batch.chained, headers...)
+	batch.offset, batch.err = len(ancestors), err
+	return batch
+}
 
-	if chain.GetHeader(headers[currentIndex].Hash(), headers[currentIndex].Number.Uint64()) != nil {
+func (poc *Poc) verifyHeaderWorker(chain consensus.ChainReader, batch *headerBatch, index int) error {
+	if index >= batch.unlinked {
+		return consensus.ErrUnknownAncestor
+	}
+	// Headers close to the start of the batch need the looked up ancestors
+	pos := batch.offset + index
+	if batch.err != nil && pos < int(plotparams.CalcDiffBlockLimit) {
+		return batch.err
+	}
+	header := batch.headers[index]
+	if chain.GetHeader(batch.hashes[index], header.Number.Uint64()) != nil {
 		return nil
 	}
-	return poc.verifyHeader(chain, headers[currentIndex], ancestorHeaders, false, seals[index])
+	start := pos - int(plotparams.CalcDiffBlockLimit)
+	if start < 0 {
+		start = 0
+	}
+	ancestorHeaders := make([]*types.Header, 0, pos-start)
+	for i := pos - 1; i >= start; i-- {
+		ancestorHeaders = append(ancestorHeaders, batch.chained[i])
+	}
+	return poc.verifyHeader(chain, header, ancestorHeaders, false, batch.seals[index])
 }
 
 // VerifyUncles verifies that the given block's uncles conform to the consensus rules of the poc engine.
@@ -213,7 +283,7 @@ func (poc *Poc) verifyHeader(chain consensus.ChainReader, header *types.Header,
 		return errInvalidDifficulty
 	}
 
-	expected := CalcDifficulty(header, ancestorHeaders)
+	expected := poc.calcDifficulty(header, ancestorHeaders)
 	if expected.Cmp(header.Difficulty) != 0 {
 		return fmt.Errorf("invalid difficulty: have %v, want %v", header.Difficulty, expected)
 	}
@@ -238,25 +308,19 @@ func (poc *Poc) verifyHeader(chain consensus.ChainReader, header *types.Header,
 		return fmt.Errorf("invalid gas limit: have %d, want %d += %d", header.GasLimit, parentHeader.GasLimit, limit)
 	}
 
-	// Verify the block number and the generation signature
-	currentHeader := header
-	childHeader := currentHeader
-	for _, header := range ancestorHeaders {
-		if diff := new(big.Int).Sub(childHeader.Number, header.Number); diff.Cmp(big.NewInt(1)) != 0 {
-			return consensus.ErrInvalidNumber
-		}
-
-		genSigHash := header.GetGenerationSignature()
-		calcGenSigBytes := CalcGenerationSignature(genSigHash[:], header.Coinbase[:])
-		childGenSigHash := childHeader.GetGenerationSignature()
-		if !bytes.Equal(calcGenSigBytes, childGenSigHash[:]) {
-			return errInvalidGenSig
-		}
-		childHeader = header
+	// Verify the block number and the generation signature. The older ancestors
+	// were checked against their own parents when they were verified.
+	if diff := new(big.Int).Sub(header.Number, parentHeader.Number); diff.Cmp(big.NewInt(1)) != 0 {
+		return consensus.ErrInvalidNumber
+	}
+	parentGenSig := parentHeader.GetGenerationSignature()
+	genSig := header.GetGenerationSignature()
+	if !bytes.Equal(CalcGenerationSignature(parentGenSig[:], parentHeader.Coinbase[:]), genSig[:]) {
+		return errInvalidGenSig
 	}
 
 	if seal {
-		if err := poc.verifySeal(chain, currentHeader, parentHeader); err != nil {
+		if err := poc.verifySeal(chain, header, parentHeader); err != nil {
 			return err
 		}
 	}
@@ -271,7 +335,7 @@ func (poc *Poc) VerifySeal(chain consensus.ChainReader, header *types.Header) er
 
 func (poc *Poc) verifySeal(chain consensus.ChainReader, header *types.Header, parentHeader *types.Header) error {
 	if parentHeader != nil {
-		blockPoc := CalcBlockPoc(header)
+		blockPoc := poc.BlockPoc(header)
 		intervalTime := new(big.Int).Sub(header.Time, parentHeader.Time)
 		if intervalTime.Cmp(blockPoc.Deadline) < 0 {
 			return errInvalidDeadline
@@ -280,6 +344,34 @@ func (poc *Poc) verifySeal(chain consensus.ChainReader, header *types.Header, pa
 	return nil
 }
 
+// BlockPoc is the cached counterpart of CalcBlockPoc, only rebuilding the plot
+// of the header's nonce if its scoop wasn't verified recently.
+func (poc *Poc) BlockPoc(header *types.Header) *types.BlockPoc {
+	if poc.scoops == nil {
+		return CalcBlockPoc(header)
+	}
+	genSigBytes := header.GetGenerationSignature().Bytes()
+	key := scoopKey{
+		coinbase: header.Coinbase,
+		nonce:    header.Nonce.Uint64(),
+		scoop:    CalcScoop(genSigBytes, header.Number.Uint64()),
+	}
+	var scoopData []byte
+	if cached, ok := poc.scoops.Get(key); ok {
+		scoopData = cached.([]byte)
+	} else {
+		scoopData = common.CopyBytes(CalcScoopData(key.coinbase, key.nonce, key.scoop))
+		poc.scoops.Add(key, scoopData)
+	}
+	baseTarget := plotparams.DifficultyToBaseTarget(header.Difficulty)
+	return &types.BlockPoc{
+		Nonce:       header.Nonce,
+		ScoopNumber: key.scoop,
+		Deadline:    CalcDeadline(scoopData, genSigBytes, baseTarget),
+		BaseTarget:  baseTarget,
+	}
+}
+
 // Prepare implements consensus.Engine, initializing the difficulty field of a
 // header to conform to the poc protocol. The changes are done inline.
 func (poc *Poc) Prepare(chain consensus.ChainReader, header *types.Header) error {
@@ -292,14 +384,16 @@ func (poc *Poc) Prepare(chain consensus.ChainReader, header *types.Header) error
 	parentGenSig := parentHeader.GetGenerationSignature()
 	genSigBytes := CalcGenerationSignature(parentGenSig.Bytes(), parentHeader.Coinbase.Bytes())
 	header.SetGenerationSignature(common.BytesToHash(genSigBytes))
-	difficulty := CalcDifficulty(header, ancestorHeaders)
+	difficulty := poc.calcDifficulty(header, ancestorHeaders)
 	header.Difficulty = new(big.Int).Set(difficulty)
 	return nil
 }
 
-// Finalize implements consensus.Engine, accumulating the block and uncle rewards,
-// setting the final state and assembling the block.
+// Finalize implements consensus.Engine, running the slashing hooks, accumulating
+// the block reward, setting the final state and assembling the block.
 func (poc *Poc) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
+	poc.slash(chain, header, state)
+
 	reward := mortgage.CalcReward(header.Coinbase, header.Nonce.Uint64(), state)
 	state.AddBalance(header.Coinbase, reward)
 	mortgage.AddTotalRewarded(state, reward)
@@ -307,6 +401,15 @@ func (poc *Poc) Finalize(chain consensus.ChainReader, header *types.Header, stat
 	return types.NewBlock(header, txs, uncles, receipts), nil
 }
 
+// calcDifficulty returns the difficulty of the header following the given
+// ancestors, which the tester keeps at the parent's.
+func (poc *Poc) calcDifficulty(header *types.Header, ancestorHeaders []*types.Header) *big.Int {
+	if poc.fixedDifficulty {
+		return new(big.Int).Set(ancestorHeaders[0].Difficulty)
+	}
+	return CalcDifficulty(header, ancestorHeaders)
+}
+
 func (poc *Poc) CalcDifficulty(chain consensus.ChainReader, time uint64, parent *types.Header) *big.Int {
 	// dummy
 	return big.NewInt(1)
@@ -381,10 +484,31 @@ func CalcDifficulty(header *types.Header, ancestorHeaders []*types.Header) *big.
 		return plotparams.BaseTargetToDifficulty(newBaseTarget)
 	}
 }
+// APIs implements consensus.Engine, returning the user facing RPC APIs.
 func (poc *Poc) APIs(chain consensus.ChainReader) []rpc.API {
-	return []rpc.API{}
+	return []rpc.API{
+		{
+			Namespace: "poc",
+			Version:   "1.0",
+			Service:   &API{poc: poc},
+			Public:    false,
+		},
+		{
+			Namespace: "eth",
+			Version:   "1.0",
+			Service:   &PublicRoundAPI{poc: poc},
+			Public:    true,
+		},
+	}
 }
 
+// GetSize returns the total size of the plot files mined last.
 func (poc *Poc) GetSize() uint64 {
+	poc.lock.RLock()
+	defer poc.lock.RUnlock()
+
+	if poc.plots == nil {
+		return 0
+	}
 	return poc.plots.GetSize()
 }
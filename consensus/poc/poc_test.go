@@ -0,0 +1,191 @@
+package poc
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/consensus"
+	"github.com/pocethereum/pochain/consensus/poc/mortgage"
+	"github.com/pocethereum/pochain/core/state"
+	"github.com/pocethereum/pochain/core/types"
+	"github.com/pocethereum/pochain/crypto"
+	"github.com/pocethereum/pochain/ethdb"
+	"github.com/pocethereum/pochain/params"
+	plotparams "github.com/pocethereum/pochain/params/plot"
+)
+
+// Tests that the scoop cache of the engine yields the same seal results as
+// rebuilding the plot, and that re-verifying a header hits the cache.
+func TestBlockPocCache(t *testing.T) {
+	poc := New(&params.PocConfig{}, nil)
+
+	header := &types.Header{
+		Number:     big.NewInt(100),
+		Coinbase:   benchCoinbase,
+		Nonce:      types.EncodeNonce(7),
+		Difficulty: big.NewInt(1000000),
+	}
+	header.SetGenerationSignature(benchGenSig)
+
+	want := CalcBlockPoc(header)
+	for i := 0; i < 2; i++ {
+		if have := poc.BlockPoc(header); !reflect.DeepEqual(have, want) {
+			t.Fatalf("run %d: block poc mismatch: have %+v, want %+v", i, have, want)
+		}
+		if n := poc.scoops.Len(); n != 1 {
+			t.Fatalf("run %d: cached scoops mismatch: have %d, want 1", i, n)
+		}
+	}
+}
+
+// testChainReader is a consensus.ChainReader serving headers from memory.
+type testChainReader struct {
+	config  *params.ChainConfig // Chain config, params.TestChainConfig if nil
+	headers map[common.Hash]*types.Header
+}
+
+func (r *testChainReader) Config() *params.ChainConfig {
+	if r.config != nil {
+		return r.config
+	}
+	return params.TestChainConfig
+}
+func (r *testChainReader) CurrentHeader() *types.Header { return nil }
+func (r *testChainReader) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header := r.headers[hash]; header != nil && header.Number.Uint64() == number {
+		return header
+	}
+	return nil
+}
+func (r *testChainReader) GetHeaderByNumber(number uint64) *types.Header { return nil }
+func (r *testChainReader) GetHeaderByHash(hash common.Hash) *types.Header {
+	return r.headers[hash]
+}
+func (r *testChainReader) GetBlock(hash common.Hash, number uint64) *types.Block { return nil }
+
+// makeTestHeaders creates a chain of n headers on top of parent. The headers at
+// the bad indexes are sealed before their deadline has passed.
+func makeTestHeaders(parent *types.Header, n int, bad map[int]bool) []*types.Header {
+	headers := make([]*types.Header, n)
+	for i := range headers {
+		parentGenSig := parent.GetGenerationSignature()
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+			Coinbase:   benchCoinbase,
+			Difficulty: new(big.Int).Set(parent.Difficulty),
+			GasLimit:   parent.GasLimit,
+		}
+		header.SetGenerationSignature(common.BytesToHash(CalcGenerationSignature(parentGenSig[:], parent.Coinbase[:])))
+		for nonce := uint64(0); ; nonce++ {
+			header.Nonce = types.EncodeNonce(nonce)
+			if deadline := CalcBlockPoc(header).Deadline; deadline.Cmp(big.NewInt(1)) > 0 {
+				if bad[i] {
+					deadline.Sub(deadline, big.NewInt(1))
+				}
+				header.Time = new(big.Int).Add(parent.Time, deadline)
+				break
+			}
+		}
+		headers[i], parent = header, header
+	}
+	return headers
+}
+
+// Tests that batch verification checks the seal of exactly the headers it was
+// asked to, regardless of where they are in the batch.
+func TestVerifyHeadersSeals(t *testing.T) {
+	genesis := &types.Header{
+		Number:     new(big.Int),
+		Time:       new(big.Int),
+		Difficulty: new(big.Int).Set(plotparams.GenesisDifficulty),
+		GasLimit:   params.GenesisGasLimit,
+	}
+	genesis.SetGenerationSignature(benchGenSig)
+
+	chain := &testChainReader{headers: map[common.Hash]*types.Header{genesis.Hash(): genesis}}
+	for _, header := range makeTestHeaders(genesis, 4, nil) {
+		chain.headers[header.Hash()] = header
+		genesis = header
+	}
+	// Seal the headers with both verified and unverified seals invalidly, on
+	// both sides of the ancestor limit of the batch
+	limit := int(plotparams.CalcDiffBlockLimit)
+	bad := map[int]bool{3: true, 10: true, limit + 1: true, limit + 7: true}
+	headers := makeTestHeaders(genesis, limit+10, bad)
+
+	seals := make([]bool, len(headers))
+	for i := range seals {
+		seals[i] = i%2 == 1
+	}
+	engine := NewTester(&params.PocConfig{}, nil)
+	_, results := engine.VerifyHeaders(chain, headers, seals)
+	for i := range headers {
+		var err error
+		select {
+		case err = <-results:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("header %d: verification timed out", i)
+		}
+		if want := bad[i] && seals[i]; (err == errInvalidDeadline) != want {
+			t.Errorf("header %d (seal %v, bad %v): unexpected result %v", i, seals[i], bad[i], err)
+		}
+		if err != nil && err != errInvalidDeadline {
+			t.Errorf("header %d: verification failed: %v", i, err)
+		}
+	}
+	// Headers following a gap in the batch have no known ancestors
+	gapped := append(headers[:5:5], headers[6:]...)
+	_, results = engine.VerifyHeaders(chain, gapped, make([]bool, len(gapped)))
+	for i := range gapped {
+		if err := <-results; (err == consensus.ErrUnknownAncestor) != (i >= 5) {
+			t.Errorf("gapped header %d: unexpected result %v", i, err)
+		}
+	}
+}
+
+// testSlasher confiscates fixed amounts on every block.
+type testSlasher map[common.Address]*big.Int
+
+func (s testSlasher) Slashes(chain consensus.ChainReader, header *types.Header, state *state.StateDB) map[common.Address]*big.Int {
+	return s
+}
+
+// Tests that the slashing hooks burn pledges on finalization, but only from the
+// mortgage upgrade fork on.
+func TestFinalizeSlash(t *testing.T) {
+	owner := common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	config := *params.TestChainConfig
+	config.MortgageUpgradeBlock = big.NewInt(2)
+	chain := &testChainReader{config: &config}
+
+	engine := New(&params.PocConfig{}, nil)
+	engine.AddSlasher(testSlasher{owner: big.NewInt(300)})
+
+	for number, want := range []int64{1000, 1000, 700} {
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+		statedb.AddBalance(mortgage.MortgageContractAddr, big.NewInt(1000))
+		statedb.SetState(mortgage.MortgageContractAddr, mortgageKey(owner), common.BigToHash(big.NewInt(1000)))
+		statedb.SetState(mortgage.MortgageContractAddr, common.BytesToHash(mortgage.MortgageTotalMortgagePos), common.BigToHash(big.NewInt(1000)))
+
+		header := &types.Header{Number: big.NewInt(int64(number)), Coinbase: common.HexToAddress("0x02"), Difficulty: big.NewInt(1)}
+		if _, err := engine.Finalize(chain, header, statedb, nil, nil, nil); err != nil {
+			t.Fatalf("block %d: failed to finalize: %v", number, err)
+		}
+		if pledge := mortgage.MortgageOf(owner, statedb); pledge.Int64() != want {
+			t.Errorf("block %d: pledge mismatch: have %v, want %d", number, pledge, want)
+		}
+		if balance := statedb.GetBalance(mortgage.MortgageContractAddr); balance.Int64() != want {
+			t.Errorf("block %d: contract balance mismatch: have %v, want %d", number, balance, want)
+		}
+	}
+}
+
+// mortgageKey returns the storage key of the pledge of owner.
+func mortgageKey(owner common.Address) common.Hash {
+	return common.BytesToHash(crypto.Keccak256(owner.Hash().Bytes(), mortgage.MortgageMappingPos))
+}
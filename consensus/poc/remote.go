@@ -0,0 +1,76 @@
+package poc
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/core/types"
+	"github.com/pocethereum/pochain/log"
+)
+
+var (
+	errRemoteNoncesDisabled = errors.New("remote nonces disabled")
+	errRemoteNoncesBusy     = errors.New("remote nonce queue full")
+)
+
+// remoteNonceQueue is the number of externally found nonces that may be
+// buffered while the sealer is busy.
+const remoteNonceQueue = 256
+
+// NonceSubmission is a nonce found by a plot scanner outside of this node
+// (e.g. a pool member) for the block currently being sealed.
+type NonceSubmission struct {
+	Number              uint64
+	GenerationSignature common.Hash
+	Nonce               uint64
+}
+
+// EnableRemoteNonces allows nonces scanned outside of the local plots to be
+// fed into the sealer. Once enabled, a missing or unreadable local plot set
+// no longer aborts sealing, since remote scanners may still find a nonce.
+func (poc *Poc) EnableRemoteNonces() {
+	poc.lock.Lock()
+	defer poc.lock.Unlock()
+
+	if poc.remote == nil {
+		poc.remote = make(chan *NonceSubmission, remoteNonceQueue)
+	}
+}
+
+// SubmitNonce hands an externally found nonce to the sealer. Submissions
+// that do not belong to the block being sealed are silently dropped there.
+func (poc *Poc) SubmitNonce(sub *NonceSubmission) error {
+	remote := poc.remoteNonces()
+	if remote == nil {
+		return errRemoteNoncesDisabled
+	}
+	select {
+	case remote <- sub:
+		return nil
+	default:
+		return errRemoteNoncesBusy
+	}
+}
+
+func (poc *Poc) remoteNonces() chan *NonceSubmission {
+	poc.lock.RLock()
+	defer poc.lock.RUnlock()
+
+	return poc.remote
+}
+
+// verifyRemoteNonce recomputes the hit of a remote submission for the given
+// block, returning nil if the submission is for a different round.
+func (poc *Poc) verifyRemoteNonce(block *types.Block, sub *NonceSubmission) *MineResult {
+	genSig := block.GetGenerationSignature()
+	if sub.Number != block.NumberU64() || sub.GenerationSignature != genSig {
+		log.Debug("Dropping stale remote nonce", "number", sub.Number, "nonce", sub.Nonce)
+		return nil
+	}
+	_, hit := CalcNonceHit(block.Coinbase(), sub.Nonce, genSig.Bytes(), sub.Number)
+	return &MineResult{
+		nonce:    sub.Nonce,
+		deadline: new(big.Int).Set(hit),
+	}
+}
@@ -0,0 +1,118 @@
+package poc
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/common/hexutil"
+	"github.com/pocethereum/pochain/event"
+	"github.com/pocethereum/pochain/rpc"
+)
+
+// roundEventQueue is the number of round events buffered for each RPC
+// subscriber. Clients lagging further behind lose their oldest rounds, so a
+// slow client never stalls the sealer.
+const roundEventQueue = 16
+
+// RoundEvent is posted when the sealer starts a new mining round and each
+// time the best deadline found for the round improves. Deadline and Nonce
+// are only set once a deadline was found.
+type RoundEvent struct {
+	Number              uint64
+	GenerationSignature common.Hash
+	ScoopNumber         uint64
+	BaseTarget          *big.Int
+	Deadline            *big.Int
+	Nonce               uint64
+}
+
+// SubscribeRoundEvent registers a subscription of RoundEvent. The sealer waits
+// for the events to be delivered, so subscribers must keep receiving.
+func (poc *Poc) SubscribeRoundEvent(ch chan<- RoundEvent) event.Subscription {
+	return poc.roundFeed.Subscribe(ch)
+}
+
+// RPCRound is the RPC representation of a RoundEvent.
+type RPCRound struct {
+	Number              hexutil.Uint64  `json:"number"`
+	GenerationSignature common.Hash     `json:"generationSignature"`
+	ScoopNumber         hexutil.Uint64  `json:"scoopNumber"`
+	BaseTarget          *hexutil.Big    `json:"baseTarget"`
+	Deadline            *hexutil.Big    `json:"deadline"`
+	Nonce               *hexutil.Uint64 `json:"nonce"`
+}
+
+func newRPCRound(ev RoundEvent) *RPCRound {
+	round := &RPCRound{
+		Number:              hexutil.Uint64(ev.Number),
+		GenerationSignature: ev.GenerationSignature,
+		ScoopNumber:         hexutil.Uint64(ev.ScoopNumber),
+		BaseTarget:          (*hexutil.Big)(ev.BaseTarget),
+	}
+	if ev.Deadline != nil {
+		nonce := hexutil.Uint64(ev.Nonce)
+		round.Deadline = (*hexutil.Big)(ev.Deadline)
+		round.Nonce = &nonce
+	}
+	return round
+}
+
+// PublicRoundAPI streams the mining rounds of the proof-of-capacity engine.
+type PublicRoundAPI struct {
+	poc *Poc
+}
+
+// PocRounds creates a subscription that is triggered each time the sealer
+// starts a new round or finds a better deadline for the current one.
+func (api *PublicRoundAPI) PocRounds(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	var (
+		rounds = make(chan RoundEvent, roundEventQueue)
+		queue  = make(chan RoundEvent, roundEventQueue)
+		done   = make(chan struct{})
+	)
+	// Notify the client from the queue, which may block on a slow connection
+	go func() {
+		for {
+			select {
+			case ev := <-queue:
+				notifier.Notify(rpcSub.ID, newRPCRound(ev))
+			case <-done:
+				return
+			}
+		}
+	}()
+	// Relay the rounds into the queue without ever blocking the feed
+	go func() {
+		roundSub := api.poc.SubscribeRoundEvent(rounds)
+		defer roundSub.Unsubscribe()
+		defer close(done)
+
+		for {
+			select {
+			case ev := <-rounds:
+				select {
+				case queue <- ev:
+				default:
+					// The client lags behind, drop its oldest round for the new one
+					select {
+					case <-queue:
+					default:
+					}
+					queue <- ev
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
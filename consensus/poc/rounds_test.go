@@ -0,0 +1,126 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package poc
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/params"
+	"github.com/pocethereum/pochain/rpc"
+)
+
+// newRoundServer creates an RPC server streaming the rounds of a new engine.
+func newRoundServer(t *testing.T) (*Poc, *rpc.Server) {
+	poc := New(&params.PocConfig{}, nil)
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("poc", &PublicRoundAPI{poc: poc}); err != nil {
+		t.Fatalf("failed to register round API: %v", err)
+	}
+	return poc, server
+}
+
+// waitRoundSubscriber posts the given round until a subscriber received it.
+func waitRoundSubscriber(t *testing.T, poc *Poc, round RoundEvent) {
+	for i := 0; poc.roundFeed.Send(round) == 0; i++ {
+		if i == 100 {
+			t.Fatal("round subscription not installed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Tests that mining rounds are streamed to RPC subscribers.
+func TestPocRounds(t *testing.T) {
+	poc, server := newRoundServer(t)
+	defer server.Stop()
+
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	rounds := make(chan *RPCRound, 1)
+	sub, err := client.Subscribe(context.Background(), "poc", rounds, "pocRounds")
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	round := RoundEvent{
+		Number:              1,
+		GenerationSignature: common.HexToHash("0x01"),
+		ScoopNumber:         42,
+		BaseTarget:          big.NewInt(1000),
+		Deadline:            big.NewInt(7),
+		Nonce:               3,
+	}
+	waitRoundSubscriber(t, poc, round)
+
+	select {
+	case have := <-rounds:
+		if uint64(have.Number) != round.Number || uint64(have.ScoopNumber) != round.ScoopNumber || have.GenerationSignature != round.GenerationSignature {
+			t.Errorf("round mismatch: have %+v, want %+v", have, round)
+		}
+		if have.Deadline == nil || have.Deadline.ToInt().Cmp(round.Deadline) != 0 || have.Nonce == nil || uint64(*have.Nonce) != round.Nonce {
+			t.Errorf("deadline mismatch: have %v/%v, want %v/%v", have.Deadline, have.Nonce, round.Deadline, round.Nonce)
+		}
+	case err := <-sub.Err():
+		t.Fatalf("subscription failed: %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("round not delivered")
+	}
+}
+
+// Tests that a client not reading its notifications doesn't stall the posting
+// of new rounds.
+func TestPocRoundsSlowClient(t *testing.T) {
+	poc, server := newRoundServer(t)
+	defer server.Stop()
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	go server.ServeCodec(rpc.NewJSONCodec(serverConn), rpc.OptionMethodInvocation|rpc.OptionSubscriptions)
+
+	// Subscribe, then never read from the connection again
+	request := map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "poc_subscribe", "params": []string{"pocRounds"}}
+	if err := json.NewEncoder(clientConn).Encode(request); err != nil {
+		t.Fatalf("failed to send subscription request: %v", err)
+	}
+	var response map[string]interface{}
+	if err := json.NewDecoder(clientConn).Decode(&response); err != nil || response["result"] == nil {
+		t.Fatalf("failed to subscribe: %v %v", response, err)
+	}
+	waitRoundSubscriber(t, poc, RoundEvent{BaseTarget: big.NewInt(1)})
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10*roundEventQueue; i++ {
+			poc.roundFeed.Send(RoundEvent{Number: uint64(i), BaseTarget: big.NewInt(1)})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("slow client stalled the round feed")
+	}
+}
@@ -3,7 +3,7 @@ package poc
 import (
 	"github.com/pocethereum/pochain/common"
 	"github.com/pocethereum/pochain/consensus"
-	data "github.com/pocethereum/pochain/consensus/poc/data"
+	"github.com/pocethereum/pochain/consensus/poc/data"
 	"github.com/pocethereum/pochain/core/types"
 	"github.com/pocethereum/pochain/log"
 	plotparams "github.com/pocethereum/pochain/params/plot"
@@ -26,136 +26,167 @@ func (poc *Poc) Seal(chain consensus.ChainReader, block *types.Block, stop <-cha
 	}
 
 	abort := make(chan struct{})
-	found := make(chan *MineResult)
-	ready := make(chan struct{})
+	found := make(chan *MineResult, 1)
+	remote := poc.remoteNonces()
+
+	var (
+		result     *MineResult
+		ready      <-chan time.Time
+		genSig     = block.GetGenerationSignature()
+		baseTarget = plotparams.DifficultyToBaseTarget(block.Difficulty())
+	)
+	round := RoundEvent{
+		Number:              block.NumberU64(),
+		GenerationSignature: genSig,
+		ScoopNumber:         CalcScoop(genSig.Bytes(), block.NumberU64()),
+		BaseTarget:          baseTarget,
+	}
+	poc.roundFeed.Send(round)
 
-	var result *MineResult
 	go poc.mine(block, abort, found)
 
-L:
 	for {
+		var candidate *MineResult
 		select {
 		case <-stop:
 			close(abort)
 			return nil, errPocSearchAborted
-		case result = <-found:
-			if result.err != nil {
-				return nil, result.err
-			}
-
-			//JUST for debug
-			if common.ISBINGDEBUG {
-				log.Warn("=== JUST for debug ==", "deadline", result.deadline)
-			} else {
-				baseTarget := plotparams.DifficultyToBaseTarget(block.Difficulty())
-				result.deadline.Div(result.deadline, baseTarget)
+		case candidate = <-found:
+			if candidate.err != nil {
+				if remote == nil {
+					return nil, candidate.err
+				}
+				log.Warn("Local poc search failed, waiting for remote nonces", "error", candidate.err)
+				continue
 			}
-
-			waitSeconds := big.NewInt(time.Now().Unix())
-			waitSeconds.Sub(waitSeconds, parentHeader.Time)
-			waitSeconds.Sub(result.deadline, waitSeconds)
-
-			if waitSeconds.Sign() < 0 {
-				close(ready)
-			} else {
-				log.Info("Waiting time to elapse", "seconds", waitSeconds, "deadline", result.deadline)
-				go func() {
-					time.Sleep(time.Duration(waitSeconds.Int64()) * time.Second)
-					close(ready)
-				}()
+		case sub := <-remote:
+			if candidate = poc.verifyRemoteNonce(block, sub); candidate == nil {
+				continue
 			}
 		case <-ready:
+			close(abort)
 			copyHeader := block.Header()
 			copyHeader.Nonce = types.EncodeNonce(result.nonce)
 			newTime := new(big.Int).Add(parentHeader.Time, result.deadline)
 			if copyHeader.Time.Cmp(newTime) < 0 {
 				copyHeader.Time.Set(newTime)
 			}
-			block = block.WithSeal(copyHeader)
-			break L
+			return block.WithSeal(copyHeader), nil
 		}
+
+		//JUST for debug
+		if common.ISBINGDEBUG {
+			log.Warn("=== JUST for debug ==", "deadline", candidate.deadline)
+		} else {
+			candidate.deadline.Div(candidate.deadline, baseTarget)
+		}
+		if result != nil && candidate.deadline.Cmp(result.deadline) >= 0 {
+			continue
+		}
+		result = candidate
+
+		round.Deadline, round.Nonce = new(big.Int).Set(result.deadline), result.nonce
+		poc.roundFeed.Send(round)
+
+		waitSeconds := big.NewInt(time.Now().Unix())
+		waitSeconds.Sub(waitSeconds, parentHeader.Time)
+		waitSeconds.Sub(result.deadline, waitSeconds)
+
+		if waitSeconds.Sign() < 0 {
+			waitSeconds.SetInt64(0)
+		} else {
+			log.Info("Waiting time to elapse", "seconds", waitSeconds, "deadline", result.deadline)
+		}
+		ready = time.After(time.Duration(waitSeconds.Int64()) * time.Second)
 	}
-	return block, nil
 }
 
 func (poc *Poc) mine(block *types.Block, abort chan struct{}, found chan *MineResult) {
 	genSigBytes := block.GetGenerationSignature().Bytes()
 	scoopNumber := CalcScoop(genSigBytes, block.NumberU64())
 
-	conf := poc.Config()
-	plotPaths := strings.Split(conf.PlotPaths, ",")
-
 	seed := strings.ToLower(block.Coinbase().Hex()[2:])
-	if poc.plots == nil || poc.plots.Seed != seed ||
-		strings.Join(poc.plots.PlotPaths, ",") != strings.Join(plotPaths, ",") {
-		poc.plots = data.NewPlots(plotPaths, seed)
+	plots := poc.loadPlots(seed)
+
+	if len(plots.GetStartNonceMap()) == 0 {
+		log.Warn("Plotdata not found", "PlotPaths", plots.PlotPaths, "Seed", seed)
+		found <- &MineResult{
+			err:      errPlotdataNotFound,
+			deadline: plotparams.MaximumDeadline(),
+		}
+		return
 	}
-	startNonceMap := poc.plots.GetStartNonceMap()
 
+	log.Info("Start poc search for new nonces", "scoop", scoopNumber)
+	found <- searchPlots(plots, genSigBytes, scoopNumber, abort, nil)
+}
+
+// searchPlots reads the given scoop of every nonce in the plots and returns the
+// one with the lowest hit. If drives is non-nil, the bytes read and the time
+// spent are accumulated per plot drive.
+func searchPlots(plots *data.Plots, genSigBytes []byte, scoopNumber uint64, abort chan struct{}, drives map[string]*DriveScan) *MineResult {
 	result := &MineResult{
 		err:      errPlotdataReadFailed,
 		deadline: plotparams.MaximumDeadline(),
 	}
-	if len(startNonceMap) == 0 {
-		log.Warn("Plotdata not found", "PlotPaths", conf.PlotPaths, "Seed", seed)
-		result.err = errPlotdataNotFound
-		found <- result
-		return
-	}
-
-	log.Info("Start poc search for new nonces", "scoop", scoopNumber)
 
 search:
-	for startNonce, size := range startNonceMap {
-		pf := poc.plots.GetPlotFileByStartNonce(startNonce)
-		if pf == nil {
-			log.Warn("Plotfile not found")
-			continue
-		}
-
-		fd, err := os.Open(pf.GetFilePath())
-		if err != nil {
-			log.Warn("Plotfile open failed", "error", err)
-			continue
-		}
+	for _, plotDrive := range plots.GetPlotDrives() {
+		start := time.Now()
+		for _, pf := range plotDrive.GetPlotFiles() {
+			fd, err := os.Open(pf.GetFilePath())
+			if err != nil {
+				log.Warn("Plotfile open failed", "error", err)
+				continue
+			}
 
-		partSize := size / plotparams.ScoopsPerPlot
-		_, err2 := fd.Seek(int64(partSize*scoopNumber), os.SEEK_SET)
-		if err2 != nil {
-			log.Warn("Plotfile seek failed", "error", err2)
-			fd.Close()
-			continue
-		}
+			partSize := pf.GetSize() / plotparams.ScoopsPerPlot
+			_, err2 := fd.Seek(int64(partSize*scoopNumber), os.SEEK_SET)
+			if err2 != nil {
+				log.Warn("Plotfile seek failed", "error", err2)
+				fd.Close()
+				continue
+			}
 
-		scoopCount := partSize / plotparams.ScoopSize
-		scoopDataBytes := make([]byte, plotparams.ScoopSize)
-		nonce := startNonce
+			scoopCount := partSize / plotparams.ScoopSize
+			scoopDataBytes := make([]byte, plotparams.ScoopSize)
+			nonce := pf.GetStartNonce()
 
-	readLoop:
-		for i := uint64(0); i < scoopCount; i, nonce = i+1, nonce+1 {
-			select {
-			case <-abort:
-				log.Info("Poc search aborted")
-				fd.Close()
-				break search
-			default:
-				_, err3 := fd.Read(scoopDataBytes)
-				if err3 != nil {
-					log.Warn("Plotfile read failed", "error", err3)
+		readLoop:
+			for i := uint64(0); i < scoopCount; i, nonce = i+1, nonce+1 {
+				select {
+				case <-abort:
+					log.Info("Poc search aborted")
 					fd.Close()
-					break readLoop
-				}
-
-				deadline := CalcHit(scoopDataBytes, genSigBytes)
-				if deadline.Cmp(result.deadline) < 0 {
-					result.err = nil
-					result.nonce = nonce
-					result.deadline.Set(deadline)
+					break search
+				default:
+					_, err3 := fd.Read(scoopDataBytes)
+					if err3 != nil {
+						log.Warn("Plotfile read failed", "error", err3)
+						break readLoop
+					}
+					if drives != nil {
+						drive := drives[plotDrive.GetDirectory()]
+						if drive == nil {
+							drive = &DriveScan{Directory: plotDrive.GetDirectory()}
+							drives[drive.Directory] = drive
+						}
+						drive.Bytes += plotparams.ScoopSize
+					}
+
+					deadline := CalcHit(scoopDataBytes, genSigBytes)
+					if deadline.Cmp(result.deadline) < 0 {
+						result.err = nil
+						result.nonce = nonce
+						result.deadline.Set(deadline)
+					}
 				}
 			}
+			fd.Close()
+		}
+		if drive := drives[plotDrive.GetDirectory()]; drive != nil {
+			drive.Elapsed += time.Since(start)
 		}
-		fd.Close()
 	}
-
-	found <- result
+	return result
 }
@@ -0,0 +1,64 @@
+package poc
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/consensus"
+	"github.com/pocethereum/pochain/consensus/poc/mortgage"
+	"github.com/pocethereum/pochain/core/state"
+	"github.com/pocethereum/pochain/core/types"
+	"github.com/pocethereum/pochain/log"
+)
+
+// Slasher is a hook deciding which pledges to confiscate when a block past the
+// mortgage upgrade fork is finalized, e.g. to punish provable misbehaviour of
+// a miner. The confiscations are part of the state transition, so every node
+// of a network has to run the same slashers.
+type Slasher interface {
+	// Slashes returns the amounts to confiscate from the funds the owners hold
+	// in the mortgage system, given the state after the block's transactions.
+	Slashes(chain consensus.ChainReader, header *types.Header, state *state.StateDB) map[common.Address]*big.Int
+}
+
+// AddSlasher registers a slashing hook, run on every block finalized from the
+// mortgage upgrade fork on.
+func (poc *Poc) AddSlasher(slasher Slasher) {
+	poc.lock.Lock()
+	defer poc.lock.Unlock()
+
+	poc.slashers = append(poc.slashers, slasher)
+}
+
+// slash runs the registered slashing hooks on a block being finalized, burning
+// the confiscated pledges. Funds locked by the mortgage contract only exist
+// from the upgrade fork on, so earlier blocks are never slashed.
+func (poc *Poc) slash(chain consensus.ChainReader, header *types.Header, state *state.StateDB) {
+	if !chain.Config().IsMortgageUpgrade(header.Number) {
+		return
+	}
+	poc.lock.RLock()
+	slashers := poc.slashers
+	poc.lock.RUnlock()
+
+	for _, slasher := range slashers {
+		slashes := slasher.Slashes(chain, header, state)
+
+		// Apply in a fixed order, the burnt amounts depend on it
+		owners := make([]common.Address, 0, len(slashes))
+		for owner := range slashes {
+			owners = append(owners, owner)
+		}
+		sort.Slice(owners, func(i, j int) bool {
+			return bytes.Compare(owners[i][:], owners[j][:]) < 0
+		})
+		for _, owner := range owners {
+			if amount := slashes[owner]; amount != nil && amount.Sign() > 0 {
+				slashed := mortgage.Slash(state, owner, amount)
+				log.Debug("Slashed mortgage", "number", header.Number, "owner", owner, "amount", amount, "slashed", slashed)
+			}
+		}
+	}
+}
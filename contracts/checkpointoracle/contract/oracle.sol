@@ -0,0 +1,82 @@
+pragma solidity ^0.4.24;
+
+/**
+ * @title CheckpointOracle
+ * @author pochain developers
+ * @dev Implementation of the trusted checkpoint registrar for light clients.
+ *
+ * A fixed set of admins signs the checkpoint of every finished CHT section.
+ * Anybody can relay a signature, once the threshold of distinct admins voted
+ * for the same checkpoint it becomes the latest one. Light clients don't need
+ * to read the contract state, they verify the signed votes advertised by the
+ * servers (see the NewCheckpointVote event) against their configured admins.
+ */
+contract CheckpointOracle {
+    /*
+        Events
+    */
+
+    // NewCheckpointVote is emitted for every accepted admin signature.
+    event NewCheckpointVote(uint64 indexed index, bytes32 checkpointHash, uint8 v, bytes32 r, bytes32 s);
+
+    /*
+        Public Storage
+    */
+
+    // Latest registered checkpoint
+    uint64 sectionIndex;
+    bytes32 hash;
+    uint height; // Block number the checkpoint was registered at, 0 if none
+
+    mapping(address => bool) admins;
+    uint threshold;
+
+    // Votes per keccak256(sectionIndex, hash) and the admins who cast them
+    mapping(bytes32 => uint) votes;
+    mapping(bytes32 => mapping(address => bool)) voted;
+
+    /*
+        Public Functions
+    */
+    constructor(address[] _adminlist, uint _threshold) public {
+        for (uint i = 0; i < _adminlist.length; i++) {
+            admins[_adminlist[i]] = true;
+        }
+        threshold = _threshold;
+    }
+
+    /**
+     * @dev Get latest stable checkpoint information.
+     * @return section index
+     * @return checkpoint hash
+     * @return block height associated with checkpoint
+     */
+    function GetLatestCheckpoint() view public returns(uint64, bytes32, uint) {
+        return (sectionIndex, hash, height);
+    }
+
+    /**
+     * @dev Vote for a new checkpoint with the signature of an admin over
+     * keccak256(0x19, 0x00, this, _sectionIndex, _hash) (EIP-191).
+     */
+    function SetCheckpoint(uint64 _sectionIndex, bytes32 _hash, uint8 v, bytes32 r, bytes32 s) public {
+        // Only votes for checkpoints newer than the registered one are accepted
+        require(height == 0 || _sectionIndex > sectionIndex);
+
+        bytes32 signedHash = keccak256(abi.encodePacked(byte(0x19), byte(0), this, _sectionIndex, _hash));
+        address signer = ecrecover(signedHash, v, r, s);
+        require(admins[signer]);
+
+        bytes32 id = keccak256(abi.encodePacked(uint(_sectionIndex), _hash));
+        require(!voted[id][signer]);
+        voted[id][signer] = true;
+        emit NewCheckpointVote(_sectionIndex, _hash, v, r, s);
+
+        votes[id]++;
+        if (votes[id] == threshold) {
+            sectionIndex = _sectionIndex;
+            hash = _hash;
+            height = block.number;
+        }
+    }
+}
@@ -0,0 +1,132 @@
+// Package checkpointoracle provides access to the checkpoint oracle contract,
+// the on-chain registrar of the trusted checkpoints light clients sync from.
+package checkpointoracle
+
+//go:generate abigen --sol contract/oracle.sol --pkg contract --out contract/oracle.go
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/pocethereum/pochain/accounts/abi/bind"
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/contracts/checkpointoracle/contract"
+	"github.com/pocethereum/pochain/core/types"
+	"github.com/pocethereum/pochain/crypto"
+)
+
+// errInvalidSignature is returned if a vote signature is not a 65 byte
+// [R || S || V] secp256k1 signature.
+var errInvalidSignature = errors.New("invalid checkpoint signature")
+
+// CheckpointOracle is a Go wrapper around an on-chain checkpoint oracle contract.
+type CheckpointOracle struct {
+	address  common.Address
+	contract *contract.CheckpointOracle
+}
+
+// NewCheckpointOracle binds checkpoint contract and returns a registrar instance.
+func NewCheckpointOracle(contractAddr common.Address, backend bind.ContractBackend) (*CheckpointOracle, error) {
+	c, err := contract.NewCheckpointOracle(contractAddr, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckpointOracle{address: contractAddr, contract: c}, nil
+}
+
+// ContractAddr returns the address of contract.
+func (oracle *CheckpointOracle) ContractAddr() common.Address {
+	return oracle.address
+}
+
+// Contract returns the underlying contract instance.
+func (oracle *CheckpointOracle) Contract() *contract.CheckpointOracle {
+	return oracle.contract
+}
+
+// LatestCheckpoint returns the latest registered checkpoint index and hash,
+// along with the block number it was registered at (0 if none was yet).
+func (oracle *CheckpointOracle) LatestCheckpoint(opts *bind.CallOpts) (uint64, common.Hash, uint64, error) {
+	index, hash, height, err := oracle.contract.GetLatestCheckpoint(opts)
+	if err != nil {
+		return 0, common.Hash{}, 0, err
+	}
+	return index, hash, height.Uint64(), nil
+}
+
+// VoteCheckpoint relays the vote of the admin who created sig (see SignHash)
+// for the checkpoint of the given section. Once enough admins voted for the
+// same checkpoint it is registered by the contract.
+func (oracle *CheckpointOracle) VoteCheckpoint(opts *bind.TransactOpts, index uint64, hash common.Hash, sig []byte) (*types.Transaction, error) {
+	if len(sig) != 65 {
+		return nil, errInvalidSignature
+	}
+	var r, s [32]byte
+	copy(r[:], sig[:32])
+	copy(s[:], sig[32:64])
+	return oracle.contract.SetCheckpoint(opts, index, hash, sig[64]+27, r, s)
+}
+
+// LookupVotes returns the [R || S || V] signatures of all votes cast for the
+// checkpoint hash of the given section within the filtered block range.
+func (oracle *CheckpointOracle) LookupVotes(opts *bind.FilterOpts, index uint64, hash common.Hash) ([][]byte, error) {
+	it, err := oracle.contract.FilterNewCheckpointVote(opts, []uint64{index})
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	var sigs [][]byte
+	for it.Next() {
+		if it.Event.CheckpointHash != hash {
+			continue
+		}
+		sig := make([]byte, 65)
+		copy(sig, it.Event.R[:])
+		copy(sig[32:], it.Event.S[:])
+		sig[64] = it.Event.V - 27
+		sigs = append(sigs, sig)
+	}
+	return sigs, it.Error()
+}
+
+// SignHash returns the hash the admins of the oracle at addr sign to vote for
+// a checkpoint, following EIP-191 with the oracle address as validator:
+//
+//	keccak256(0x19 || 0x00 || addr || index || hash)
+func SignHash(addr common.Address, index uint64, hash common.Hash) []byte {
+	buf := make([]byte, 2+common.AddressLength+8+common.HashLength)
+	buf[0] = 0x19
+	copy(buf[2:], addr.Bytes())
+	binary.BigEndian.PutUint64(buf[2+common.AddressLength:], index)
+	copy(buf[2+common.AddressLength+8:], hash.Bytes())
+	return crypto.Keccak256(buf)
+}
+
+// VerifySigners recovers the signers of a checkpoint and checks that at least
+// threshold distinct ones are among the trusted admins.
+func VerifySigners(addr common.Address, index uint64, hash common.Hash, sigs [][]byte, admins []common.Address, threshold uint64) (bool, []common.Address) {
+	trusted := make(map[common.Address]bool)
+	for _, admin := range admins {
+		trusted[admin] = true
+	}
+	var (
+		sighash = SignHash(addr, index, hash)
+		seen    = make(map[common.Address]bool)
+		signers []common.Address
+	)
+	for _, sig := range sigs {
+		pubkey, err := crypto.Ecrecover(sighash, sig)
+		if err != nil {
+			continue
+		}
+		var signer common.Address
+		copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+		if !trusted[signer] || seen[signer] {
+			continue
+		}
+		seen[signer] = true
+		signers = append(signers, signer)
+	}
+	return uint64(len(signers)) >= threshold && threshold > 0, signers
+}
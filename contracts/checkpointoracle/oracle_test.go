@@ -0,0 +1,116 @@
+package checkpointoracle
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/pocethereum/pochain/accounts/abi/bind"
+	"github.com/pocethereum/pochain/accounts/abi/bind/backends"
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/contracts/checkpointoracle/contract"
+	"github.com/pocethereum/pochain/core"
+	"github.com/pocethereum/pochain/crypto"
+	"github.com/pocethereum/pochain/params"
+)
+
+var (
+	key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	addr   = crypto.PubkeyToAddress(key.PublicKey)
+)
+
+// newAdmins generates n admin keys along with their addresses.
+func newAdmins(n int) ([]*ecdsa.PrivateKey, []common.Address) {
+	keys := make([]*ecdsa.PrivateKey, n)
+	addrs := make([]common.Address, n)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+	}
+	return keys, addrs
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, oracle common.Address, cp *params.TrustedCheckpoint) []byte {
+	sig, err := crypto.Sign(SignHash(oracle, cp.SectionIndex, cp.Hash()), key)
+	if err != nil {
+		t.Fatalf("failed to sign checkpoint: %v", err)
+	}
+	return sig
+}
+
+func TestCheckpointRegister(t *testing.T) {
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		addr: {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))},
+	})
+	transactOpts := bind.NewKeyedTransactor(key)
+
+	keys, admins := newAdmins(3)
+	contractAddr, _, _, err := contract.DeployCheckpointOracle(transactOpts, backend, admins, big.NewInt(2))
+	if err != nil {
+		t.Fatalf("can't deploy checkpoint oracle: %v", err)
+	}
+	backend.Commit()
+
+	oracle, err := NewCheckpointOracle(contractAddr, backend)
+	if err != nil {
+		t.Fatalf("can't bind checkpoint oracle: %v", err)
+	}
+	if _, _, height, err := oracle.LatestCheckpoint(nil); err != nil || height != 0 {
+		t.Fatalf("unexpected checkpoint before any vote: height %d (%v)", height, err)
+	}
+	cp := &params.TrustedCheckpoint{
+		SectionIndex: 1,
+		SectionHead:  common.HexToHash("0x01"),
+		CHTRoot:      common.HexToHash("0x02"),
+		BloomRoot:    common.HexToHash("0x03"),
+	}
+	// Votes of strangers, votes over another index and repeated votes are rejected.
+	stranger, _ := crypto.GenerateKey()
+	if _, err := oracle.VoteCheckpoint(transactOpts, cp.SectionIndex, cp.Hash(), sign(t, stranger, contractAddr, cp)); err == nil {
+		t.Fatalf("accepted vote of a non-admin")
+	}
+	if _, err := oracle.VoteCheckpoint(transactOpts, cp.SectionIndex+1, cp.Hash(), sign(t, keys[0], contractAddr, cp)); err == nil {
+		t.Fatalf("accepted vote for a different section")
+	}
+	if _, err := oracle.VoteCheckpoint(transactOpts, cp.SectionIndex, cp.Hash(), sign(t, keys[0], contractAddr, cp)); err != nil {
+		t.Fatalf("can't vote: %v", err)
+	}
+	backend.Commit()
+	if _, err := oracle.VoteCheckpoint(transactOpts, cp.SectionIndex, cp.Hash(), sign(t, keys[0], contractAddr, cp)); err == nil {
+		t.Fatalf("accepted repeated vote")
+	}
+	if _, _, height, _ := oracle.LatestCheckpoint(nil); height != 0 {
+		t.Fatalf("checkpoint registered below threshold at %d", height)
+	}
+	// The second admin reaches the threshold and registers the checkpoint.
+	if _, err := oracle.VoteCheckpoint(transactOpts, cp.SectionIndex, cp.Hash(), sign(t, keys[2], contractAddr, cp)); err != nil {
+		t.Fatalf("can't vote: %v", err)
+	}
+	backend.Commit()
+
+	index, hash, height, err := oracle.LatestCheckpoint(nil)
+	if err != nil {
+		t.Fatalf("can't retrieve checkpoint: %v", err)
+	}
+	if index != cp.SectionIndex || !cp.HashEqual(hash) || height != 3 {
+		t.Fatalf("checkpoint mismatch: have %d/%x@%d, want %d/%x@3", index, hash, height, cp.SectionIndex, cp.Hash())
+	}
+	sigs, err := oracle.LookupVotes(&bind.FilterOpts{Start: 0}, index, hash)
+	if err != nil {
+		t.Fatalf("can't look up votes: %v", err)
+	}
+	ok, signers := VerifySigners(contractAddr, index, hash, sigs, admins, 2)
+	if !ok || len(signers) != 2 || signers[0] != admins[0] || signers[1] != admins[2] {
+		t.Fatalf("signers mismatch: have %v, want %v", signers, []common.Address{admins[0], admins[2]})
+	}
+	if ok, _ := VerifySigners(contractAddr, index, hash, sigs, admins, 3); ok {
+		t.Fatalf("verified signatures below threshold")
+	}
+	if ok, _ := VerifySigners(common.Address{}, index, hash, sigs, admins, 2); ok {
+		t.Fatalf("verified signatures of another oracle")
+	}
+	// Sections at or below the registered one are stale.
+	if _, err := oracle.VoteCheckpoint(transactOpts, cp.SectionIndex, cp.Hash(), sign(t, keys[1], contractAddr, cp)); err == nil {
+		t.Fatalf("accepted vote for a registered section")
+	}
+}
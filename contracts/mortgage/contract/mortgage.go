@@ -0,0 +1,753 @@
+// Code generated - DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package contract
+
+import (
+	"math/big"
+	"strings"
+
+	ethereum "github.com/pocethereum/pochain"
+	"github.com/pocethereum/pochain/accounts/abi"
+	"github.com/pocethereum/pochain/accounts/abi/bind"
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/core/types"
+	"github.com/pocethereum/pochain/event"
+)
+
+// MortgageSystemABI is the input ABI used to generate the binding from.
+const MortgageSystemABI = "[{\"constant\":true,\"inputs\":[],\"name\":\"totalMortgage\",\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"owner\",\"type\":\"address\"}],\"name\":\"mortgageOf\",\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"owner\",\"type\":\"address\"}],\"name\":\"lockedOf\",\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[{\"name\":\"owner\",\"type\":\"address\"}],\"name\":\"unlockBlockOf\",\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":true,\"inputs\":[],\"name\":\"lockupPeriod\",\"outputs\":[{\"name\":\"\",\"type\":\"uint256\"}],\"payable\":false,\"stateMutability\":\"view\",\"type\":\"function\"},{\"constant\":false,\"inputs\":[{\"name\":\"to\",\"type\":\"address\"},{\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"mortgage\",\"outputs\":[{\"name\":\"\",\"type\":\"bool\"}],\"payable\":true,\"stateMutability\":\"payable\",\"type\":\"function\"},{\"constant\":false,\"inputs\":[{\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"redeem\",\"outputs\":[{\"name\":\"\",\"type\":\"bool\"}],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"constant\":false,\"inputs\":[],\"name\":\"withdraw\",\"outputs\":[{\"name\":\"\",\"type\":\"bool\"}],\"payable\":false,\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"name\":\"to\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"Mortgage\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"name\":\"owner\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"Redeem\",\"type\":\"event\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"name\":\"owner\",\"type\":\"address\"},{\"indexed\":false,\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"Withdraw\",\"type\":\"event\"}]"
+
+// MortgageSystem is an auto generated Go binding around an Ethereum contract.
+type MortgageSystem struct {
+	MortgageSystemCaller     // Read-only binding to the contract
+	MortgageSystemTransactor // Write-only binding to the contract
+	MortgageSystemFilterer   // Log filterer for contract events
+}
+
+// MortgageSystemCaller is an auto generated read-only Go binding around an Ethereum contract.
+type MortgageSystemCaller struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// MortgageSystemTransactor is an auto generated write-only Go binding around an Ethereum contract.
+type MortgageSystemTransactor struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// MortgageSystemFilterer is an auto generated log filtering Go binding around an Ethereum contract events.
+type MortgageSystemFilterer struct {
+	contract *bind.BoundContract // Generic contract wrapper for the low level calls
+}
+
+// MortgageSystemSession is an auto generated Go binding around an Ethereum contract,
+// with pre-set call and transact options.
+type MortgageSystemSession struct {
+	Contract     *MortgageSystem   // Generic contract binding to set the session for
+	CallOpts     bind.CallOpts     // Call options to use throughout this session
+	TransactOpts bind.TransactOpts // Transaction auth options to use throughout this session
+}
+
+// MortgageSystemCallerSession is an auto generated read-only Go binding around an Ethereum contract,
+// with pre-set call options.
+type MortgageSystemCallerSession struct {
+	Contract *MortgageSystemCaller // Generic contract caller binding to set the session for
+	CallOpts bind.CallOpts         // Call options to use throughout this session
+}
+
+// MortgageSystemTransactorSession is an auto generated write-only Go binding around an Ethereum contract,
+// with pre-set transact options.
+type MortgageSystemTransactorSession struct {
+	Contract     *MortgageSystemTransactor // Generic contract transactor binding to set the session for
+	TransactOpts bind.TransactOpts         // Transaction auth options to use throughout this session
+}
+
+// MortgageSystemRaw is an auto generated low-level Go binding around an Ethereum contract.
+type MortgageSystemRaw struct {
+	Contract *MortgageSystem // Generic contract binding to access the raw methods on
+}
+
+// MortgageSystemCallerRaw is an auto generated low-level read-only Go binding around an Ethereum contract.
+type MortgageSystemCallerRaw struct {
+	Contract *MortgageSystemCaller // Generic read-only contract binding to access the raw methods on
+}
+
+// MortgageSystemTransactorRaw is an auto generated low-level write-only Go binding around an Ethereum contract.
+type MortgageSystemTransactorRaw struct {
+	Contract *MortgageSystemTransactor // Generic write-only contract binding to access the raw methods on
+}
+
+// NewMortgageSystem creates a new instance of MortgageSystem, bound to a specific deployed contract.
+func NewMortgageSystem(address common.Address, backend bind.ContractBackend) (*MortgageSystem, error) {
+	contract, err := bindMortgageSystem(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &MortgageSystem{MortgageSystemCaller: MortgageSystemCaller{contract: contract}, MortgageSystemTransactor: MortgageSystemTransactor{contract: contract}, MortgageSystemFilterer: MortgageSystemFilterer{contract: contract}}, nil
+}
+
+// NewMortgageSystemCaller creates a new read-only instance of MortgageSystem, bound to a specific deployed contract.
+func NewMortgageSystemCaller(address common.Address, caller bind.ContractCaller) (*MortgageSystemCaller, error) {
+	contract, err := bindMortgageSystem(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &MortgageSystemCaller{contract: contract}, nil
+}
+
+// NewMortgageSystemTransactor creates a new write-only instance of MortgageSystem, bound to a specific deployed contract.
+func NewMortgageSystemTransactor(address common.Address, transactor bind.ContractTransactor) (*MortgageSystemTransactor, error) {
+	contract, err := bindMortgageSystem(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &MortgageSystemTransactor{contract: contract}, nil
+}
+
+// NewMortgageSystemFilterer creates a new log filterer instance of MortgageSystem, bound to a specific deployed contract.
+func NewMortgageSystemFilterer(address common.Address, filterer bind.ContractFilterer) (*MortgageSystemFilterer, error) {
+	contract, err := bindMortgageSystem(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &MortgageSystemFilterer{contract: contract}, nil
+}
+
+// bindMortgageSystem binds a generic wrapper to an already deployed contract.
+func bindMortgageSystem(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := abi.JSON(strings.NewReader(MortgageSystemABI))
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_MortgageSystem *MortgageSystemRaw) Call(opts *bind.CallOpts, result interface{}, method string, params ...interface{}) error {
+	return _MortgageSystem.Contract.MortgageSystemCaller.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_MortgageSystem *MortgageSystemRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _MortgageSystem.Contract.MortgageSystemTransactor.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_MortgageSystem *MortgageSystemRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _MortgageSystem.Contract.MortgageSystemTransactor.contract.Transact(opts, method, params...)
+}
+
+// Call invokes the (constant) contract method with params as input values and
+// sets the output to result. The result type might be a single field for simple
+// returns, a slice of interfaces for anonymous returns and a struct for named
+// returns.
+func (_MortgageSystem *MortgageSystemCallerRaw) Call(opts *bind.CallOpts, result interface{}, method string, params ...interface{}) error {
+	return _MortgageSystem.Contract.contract.Call(opts, result, method, params...)
+}
+
+// Transfer initiates a plain transaction to move funds to the contract, calling
+// its default method if one is available.
+func (_MortgageSystem *MortgageSystemTransactorRaw) Transfer(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _MortgageSystem.Contract.contract.Transfer(opts)
+}
+
+// Transact invokes the (paid) contract method with params as input values.
+func (_MortgageSystem *MortgageSystemTransactorRaw) Transact(opts *bind.TransactOpts, method string, params ...interface{}) (*types.Transaction, error) {
+	return _MortgageSystem.Contract.contract.Transact(opts, method, params...)
+}
+
+// LockedOf is a free data retrieval call binding the contract method 0xa5f1e282.
+//
+// Solidity: function lockedOf(owner address) constant returns(uint256)
+func (_MortgageSystem *MortgageSystemCaller) LockedOf(opts *bind.CallOpts, owner common.Address) (*big.Int, error) {
+	var (
+		ret0 = new(*big.Int)
+	)
+	out := ret0
+	err := _MortgageSystem.contract.Call(opts, out, "lockedOf", owner)
+	return *ret0, err
+}
+
+// LockedOf is a free data retrieval call binding the contract method 0xa5f1e282.
+//
+// Solidity: function lockedOf(owner address) constant returns(uint256)
+func (_MortgageSystem *MortgageSystemSession) LockedOf(owner common.Address) (*big.Int, error) {
+	return _MortgageSystem.Contract.LockedOf(&_MortgageSystem.CallOpts, owner)
+}
+
+// LockedOf is a free data retrieval call binding the contract method 0xa5f1e282.
+//
+// Solidity: function lockedOf(owner address) constant returns(uint256)
+func (_MortgageSystem *MortgageSystemCallerSession) LockedOf(owner common.Address) (*big.Int, error) {
+	return _MortgageSystem.Contract.LockedOf(&_MortgageSystem.CallOpts, owner)
+}
+
+// LockupPeriod is a free data retrieval call binding the contract method 0xee947a7c.
+//
+// Solidity: function lockupPeriod() constant returns(uint256)
+func (_MortgageSystem *MortgageSystemCaller) LockupPeriod(opts *bind.CallOpts) (*big.Int, error) {
+	var (
+		ret0 = new(*big.Int)
+	)
+	out := ret0
+	err := _MortgageSystem.contract.Call(opts, out, "lockupPeriod")
+	return *ret0, err
+}
+
+// LockupPeriod is a free data retrieval call binding the contract method 0xee947a7c.
+//
+// Solidity: function lockupPeriod() constant returns(uint256)
+func (_MortgageSystem *MortgageSystemSession) LockupPeriod() (*big.Int, error) {
+	return _MortgageSystem.Contract.LockupPeriod(&_MortgageSystem.CallOpts)
+}
+
+// LockupPeriod is a free data retrieval call binding the contract method 0xee947a7c.
+//
+// Solidity: function lockupPeriod() constant returns(uint256)
+func (_MortgageSystem *MortgageSystemCallerSession) LockupPeriod() (*big.Int, error) {
+	return _MortgageSystem.Contract.LockupPeriod(&_MortgageSystem.CallOpts)
+}
+
+// MortgageOf is a free data retrieval call binding the contract method 0x396ffa1b.
+//
+// Solidity: function mortgageOf(owner address) constant returns(uint256)
+func (_MortgageSystem *MortgageSystemCaller) MortgageOf(opts *bind.CallOpts, owner common.Address) (*big.Int, error) {
+	var (
+		ret0 = new(*big.Int)
+	)
+	out := ret0
+	err := _MortgageSystem.contract.Call(opts, out, "mortgageOf", owner)
+	return *ret0, err
+}
+
+// MortgageOf is a free data retrieval call binding the contract method 0x396ffa1b.
+//
+// Solidity: function mortgageOf(owner address) constant returns(uint256)
+func (_MortgageSystem *MortgageSystemSession) MortgageOf(owner common.Address) (*big.Int, error) {
+	return _MortgageSystem.Contract.MortgageOf(&_MortgageSystem.CallOpts, owner)
+}
+
+// MortgageOf is a free data retrieval call binding the contract method 0x396ffa1b.
+//
+// Solidity: function mortgageOf(owner address) constant returns(uint256)
+func (_MortgageSystem *MortgageSystemCallerSession) MortgageOf(owner common.Address) (*big.Int, error) {
+	return _MortgageSystem.Contract.MortgageOf(&_MortgageSystem.CallOpts, owner)
+}
+
+// TotalMortgage is a free data retrieval call binding the contract method 0xd8a830c6.
+//
+// Solidity: function totalMortgage() constant returns(uint256)
+func (_MortgageSystem *MortgageSystemCaller) TotalMortgage(opts *bind.CallOpts) (*big.Int, error) {
+	var (
+		ret0 = new(*big.Int)
+	)
+	out := ret0
+	err := _MortgageSystem.contract.Call(opts, out, "totalMortgage")
+	return *ret0, err
+}
+
+// TotalMortgage is a free data retrieval call binding the contract method 0xd8a830c6.
+//
+// Solidity: function totalMortgage() constant returns(uint256)
+func (_MortgageSystem *MortgageSystemSession) TotalMortgage() (*big.Int, error) {
+	return _MortgageSystem.Contract.TotalMortgage(&_MortgageSystem.CallOpts)
+}
+
+// TotalMortgage is a free data retrieval call binding the contract method 0xd8a830c6.
+//
+// Solidity: function totalMortgage() constant returns(uint256)
+func (_MortgageSystem *MortgageSystemCallerSession) TotalMortgage() (*big.Int, error) {
+	return _MortgageSystem.Contract.TotalMortgage(&_MortgageSystem.CallOpts)
+}
+
+// UnlockBlockOf is a free data retrieval call binding the contract method 0x046380e6.
+//
+// Solidity: function unlockBlockOf(owner address) constant returns(uint256)
+func (_MortgageSystem *MortgageSystemCaller) UnlockBlockOf(opts *bind.CallOpts, owner common.Address) (*big.Int, error) {
+	var (
+		ret0 = new(*big.Int)
+	)
+	out := ret0
+	err := _MortgageSystem.contract.Call(opts, out, "unlockBlockOf", owner)
+	return *ret0, err
+}
+
+// UnlockBlockOf is a free data retrieval call binding the contract method 0x046380e6.
+//
+// Solidity: function unlockBlockOf(owner address) constant returns(uint256)
+func (_MortgageSystem *MortgageSystemSession) UnlockBlockOf(owner common.Address) (*big.Int, error) {
+	return _MortgageSystem.Contract.UnlockBlockOf(&_MortgageSystem.CallOpts, owner)
+}
+
+// UnlockBlockOf is a free data retrieval call binding the contract method 0x046380e6.
+//
+// Solidity: function unlockBlockOf(owner address) constant returns(uint256)
+func (_MortgageSystem *MortgageSystemCallerSession) UnlockBlockOf(owner common.Address) (*big.Int, error) {
+	return _MortgageSystem.Contract.UnlockBlockOf(&_MortgageSystem.CallOpts, owner)
+}
+
+// Mortgage is a paid mutator transaction binding the contract method 0x43794dda.
+//
+// Solidity: function mortgage(to address, value uint256) returns(bool)
+func (_MortgageSystem *MortgageSystemTransactor) Mortgage(opts *bind.TransactOpts, to common.Address, value *big.Int) (*types.Transaction, error) {
+	return _MortgageSystem.contract.Transact(opts, "mortgage", to, value)
+}
+
+// Mortgage is a paid mutator transaction binding the contract method 0x43794dda.
+//
+// Solidity: function mortgage(to address, value uint256) returns(bool)
+func (_MortgageSystem *MortgageSystemSession) Mortgage(to common.Address, value *big.Int) (*types.Transaction, error) {
+	return _MortgageSystem.Contract.Mortgage(&_MortgageSystem.TransactOpts, to, value)
+}
+
+// Mortgage is a paid mutator transaction binding the contract method 0x43794dda.
+//
+// Solidity: function mortgage(to address, value uint256) returns(bool)
+func (_MortgageSystem *MortgageSystemTransactorSession) Mortgage(to common.Address, value *big.Int) (*types.Transaction, error) {
+	return _MortgageSystem.Contract.Mortgage(&_MortgageSystem.TransactOpts, to, value)
+}
+
+// Redeem is a paid mutator transaction binding the contract method 0xdb006a75.
+//
+// Solidity: function redeem(value uint256) returns(bool)
+func (_MortgageSystem *MortgageSystemTransactor) Redeem(opts *bind.TransactOpts, value *big.Int) (*types.Transaction, error) {
+	return _MortgageSystem.contract.Transact(opts, "redeem", value)
+}
+
+// Redeem is a paid mutator transaction binding the contract method 0xdb006a75.
+//
+// Solidity: function redeem(value uint256) returns(bool)
+func (_MortgageSystem *MortgageSystemSession) Redeem(value *big.Int) (*types.Transaction, error) {
+	return _MortgageSystem.Contract.Redeem(&_MortgageSystem.TransactOpts, value)
+}
+
+// Redeem is a paid mutator transaction binding the contract method 0xdb006a75.
+//
+// Solidity: function redeem(value uint256) returns(bool)
+func (_MortgageSystem *MortgageSystemTransactorSession) Redeem(value *big.Int) (*types.Transaction, error) {
+	return _MortgageSystem.Contract.Redeem(&_MortgageSystem.TransactOpts, value)
+}
+
+// Withdraw is a paid mutator transaction binding the contract method 0x3ccfd60b.
+//
+// Solidity: function withdraw() returns(bool)
+func (_MortgageSystem *MortgageSystemTransactor) Withdraw(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return _MortgageSystem.contract.Transact(opts, "withdraw")
+}
+
+// Withdraw is a paid mutator transaction binding the contract method 0x3ccfd60b.
+//
+// Solidity: function withdraw() returns(bool)
+func (_MortgageSystem *MortgageSystemSession) Withdraw() (*types.Transaction, error) {
+	return _MortgageSystem.Contract.Withdraw(&_MortgageSystem.TransactOpts)
+}
+
+// Withdraw is a paid mutator transaction binding the contract method 0x3ccfd60b.
+//
+// Solidity: function withdraw() returns(bool)
+func (_MortgageSystem *MortgageSystemTransactorSession) Withdraw() (*types.Transaction, error) {
+	return _MortgageSystem.Contract.Withdraw(&_MortgageSystem.TransactOpts)
+}
+
+// MortgageSystemMortgageIterator is returned from FilterMortgage and is used to iterate over the raw logs and unpacked data for Mortgage events raised by the MortgageSystem contract.
+type MortgageSystemMortgageIterator struct {
+	Event *MortgageSystemMortgage // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *MortgageSystemMortgageIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(MortgageSystemMortgage)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(MortgageSystemMortgage)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *MortgageSystemMortgageIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *MortgageSystemMortgageIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// MortgageSystemMortgage represents a Mortgage event raised by the MortgageSystem contract.
+type MortgageSystemMortgage struct {
+	To    common.Address
+	Value *big.Int
+	Raw   types.Log // Blockchain specific contextual infos
+}
+
+// FilterMortgage is a free log retrieval operation binding the contract event 0xbddecaad150f4a9f75fb6864ff351a7f06b19c5b9cf533c22b5bc05ecebc0790.
+//
+// Solidity: e Mortgage(to indexed address, value uint256)
+func (_MortgageSystem *MortgageSystemFilterer) FilterMortgage(opts *bind.FilterOpts, to []common.Address) (*MortgageSystemMortgageIterator, error) {
+
+	var toRule []interface{}
+	for _, toItem := range to {
+		toRule = append(toRule, toItem)
+	}
+
+	logs, sub, err := _MortgageSystem.contract.FilterLogs(opts, "Mortgage", toRule)
+	if err != nil {
+		return nil, err
+	}
+	return &MortgageSystemMortgageIterator{contract: _MortgageSystem.contract, event: "Mortgage", logs: logs, sub: sub}, nil
+}
+
+// WatchMortgage is a free log subscription operation binding the contract event 0xbddecaad150f4a9f75fb6864ff351a7f06b19c5b9cf533c22b5bc05ecebc0790.
+//
+// Solidity: e Mortgage(to indexed address, value uint256)
+func (_MortgageSystem *MortgageSystemFilterer) WatchMortgage(opts *bind.WatchOpts, sink chan<- *MortgageSystemMortgage, to []common.Address) (event.Subscription, error) {
+
+	var toRule []interface{}
+	for _, toItem := range to {
+		toRule = append(toRule, toItem)
+	}
+
+	logs, sub, err := _MortgageSystem.contract.WatchLogs(opts, "Mortgage", toRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(MortgageSystemMortgage)
+				if err := _MortgageSystem.contract.UnpackLog(event, "Mortgage", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// MortgageSystemRedeemIterator is returned from FilterRedeem and is used to iterate over the raw logs and unpacked data for Redeem events raised by the MortgageSystem contract.
+type MortgageSystemRedeemIterator struct {
+	Event *MortgageSystemRedeem // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *MortgageSystemRedeemIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(MortgageSystemRedeem)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(MortgageSystemRedeem)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *MortgageSystemRedeemIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *MortgageSystemRedeemIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// MortgageSystemRedeem represents a Redeem event raised by the MortgageSystem contract.
+type MortgageSystemRedeem struct {
+	Owner common.Address
+	Value *big.Int
+	Raw   types.Log // Blockchain specific contextual infos
+}
+
+// FilterRedeem is a free log retrieval operation binding the contract event 0x222838db2794d11532d940e8dec38ae307ed0b63cd97c233322e221f998767a6.
+//
+// Solidity: e Redeem(owner indexed address, value uint256)
+func (_MortgageSystem *MortgageSystemFilterer) FilterRedeem(opts *bind.FilterOpts, owner []common.Address) (*MortgageSystemRedeemIterator, error) {
+
+	var ownerRule []interface{}
+	for _, ownerItem := range owner {
+		ownerRule = append(ownerRule, ownerItem)
+	}
+
+	logs, sub, err := _MortgageSystem.contract.FilterLogs(opts, "Redeem", ownerRule)
+	if err != nil {
+		return nil, err
+	}
+	return &MortgageSystemRedeemIterator{contract: _MortgageSystem.contract, event: "Redeem", logs: logs, sub: sub}, nil
+}
+
+// WatchRedeem is a free log subscription operation binding the contract event 0x222838db2794d11532d940e8dec38ae307ed0b63cd97c233322e221f998767a6.
+//
+// Solidity: e Redeem(owner indexed address, value uint256)
+func (_MortgageSystem *MortgageSystemFilterer) WatchRedeem(opts *bind.WatchOpts, sink chan<- *MortgageSystemRedeem, owner []common.Address) (event.Subscription, error) {
+
+	var ownerRule []interface{}
+	for _, ownerItem := range owner {
+		ownerRule = append(ownerRule, ownerItem)
+	}
+
+	logs, sub, err := _MortgageSystem.contract.WatchLogs(opts, "Redeem", ownerRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(MortgageSystemRedeem)
+				if err := _MortgageSystem.contract.UnpackLog(event, "Redeem", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// MortgageSystemWithdrawIterator is returned from FilterWithdraw and is used to iterate over the raw logs and unpacked data for Withdraw events raised by the MortgageSystem contract.
+type MortgageSystemWithdrawIterator struct {
+	Event *MortgageSystemWithdraw // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *MortgageSystemWithdrawIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(MortgageSystemWithdraw)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(MortgageSystemWithdraw)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *MortgageSystemWithdrawIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *MortgageSystemWithdrawIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// MortgageSystemWithdraw represents a Withdraw event raised by the MortgageSystem contract.
+type MortgageSystemWithdraw struct {
+	Owner common.Address
+	Value *big.Int
+	Raw   types.Log // Blockchain specific contextual infos
+}
+
+// FilterWithdraw is a free log retrieval operation binding the contract event 0x884edad9ce6fa2440d8a54cc123490eb96d2768479d49ff9c7366125a9424364.
+//
+// Solidity: e Withdraw(owner indexed address, value uint256)
+func (_MortgageSystem *MortgageSystemFilterer) FilterWithdraw(opts *bind.FilterOpts, owner []common.Address) (*MortgageSystemWithdrawIterator, error) {
+
+	var ownerRule []interface{}
+	for _, ownerItem := range owner {
+		ownerRule = append(ownerRule, ownerItem)
+	}
+
+	logs, sub, err := _MortgageSystem.contract.FilterLogs(opts, "Withdraw", ownerRule)
+	if err != nil {
+		return nil, err
+	}
+	return &MortgageSystemWithdrawIterator{contract: _MortgageSystem.contract, event: "Withdraw", logs: logs, sub: sub}, nil
+}
+
+// WatchWithdraw is a free log subscription operation binding the contract event 0x884edad9ce6fa2440d8a54cc123490eb96d2768479d49ff9c7366125a9424364.
+//
+// Solidity: e Withdraw(owner indexed address, value uint256)
+func (_MortgageSystem *MortgageSystemFilterer) WatchWithdraw(opts *bind.WatchOpts, sink chan<- *MortgageSystemWithdraw, owner []common.Address) (event.Subscription, error) {
+
+	var ownerRule []interface{}
+	for _, ownerItem := range owner {
+		ownerRule = append(ownerRule, ownerItem)
+	}
+
+	logs, sub, err := _MortgageSystem.contract.WatchLogs(opts, "Withdraw", ownerRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(MortgageSystemWithdraw)
+				if err := _MortgageSystem.contract.UnpackLog(event, "Withdraw", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
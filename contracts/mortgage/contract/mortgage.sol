@@ -0,0 +1,88 @@
+pragma solidity ^0.4.24;
+
+// MortgageSystem is the mortgage system contract installed at address 0x81 on
+// the mortgage upgrade fork block. It replaces the original contract in place,
+// so the first three storage slots must keep their layout.
+//
+// Redeeming a pledge doesn't pay out at once anymore: the value stays locked in
+// the contract for lockupPeriod blocks and can only be withdrawn afterwards.
+// This keeps miners from pledging, mining a high reward block and pulling the
+// pledge out right away, and leaves the node room to slash misbehaving miners:
+// the slashing hooks of the poc engine confiscate locked and pledged value
+// straight from storage when finalizing a block.
+//
+// The lock-up period is written into storage by the node when installing the
+// contract, there is no constructor. The installed code is assembled from
+// mortgage.easm, which has to be kept in sync with this source.
+contract MortgageSystem {
+    event Mortgage(address indexed to, uint256 value);
+    event Redeem(address indexed owner, uint256 value);
+    event Withdraw(address indexed owner, uint256 value);
+
+    mapping (address => uint256) private _mortgages;   // slot 0, kept from v1
+    uint256 private _totalMortgage;                    // slot 1, kept from v1
+    uint256 private _totalRewarded;                    // slot 2, kept from v1, maintained by the node
+    mapping (address => uint256) private _locked;      // slot 3
+    mapping (address => uint256) private _unlockBlock; // slot 4
+    uint256 private _lockupPeriod;                     // slot 5, set by the node
+
+    function totalMortgage() public view returns (uint256) {
+        return _totalMortgage;
+    }
+
+    function mortgageOf(address owner) public view returns (uint256) {
+        return _mortgages[owner];
+    }
+
+    function lockedOf(address owner) public view returns (uint256) {
+        return _locked[owner];
+    }
+
+    function unlockBlockOf(address owner) public view returns (uint256) {
+        return _unlockBlock[owner];
+    }
+
+    function lockupPeriod() public view returns (uint256) {
+        return _lockupPeriod;
+    }
+
+    // mortgage pledges the sent value on behalf of to.
+    function mortgage(address to, uint256 value) payable public returns (bool) {
+        require(to != address(0));
+        require(msg.value == value);
+
+        _mortgages[to] += value;
+        _totalMortgage += value;
+
+        emit Mortgage(to, value);
+        return true;
+    }
+
+    // redeem moves value out of the pledge of the sender into its lock-up,
+    // restarting the lock-up period of everything locked so far.
+    function redeem(uint256 value) public returns (bool) {
+        require(value != 0);
+        require(_mortgages[msg.sender] >= value);
+
+        _mortgages[msg.sender] -= value;
+        _totalMortgage -= value;
+        _locked[msg.sender] += value;
+        _unlockBlock[msg.sender] = block.number + _lockupPeriod;
+
+        emit Redeem(msg.sender, value);
+        return true;
+    }
+
+    // withdraw pays out the locked value of the sender once its lock-up ended.
+    function withdraw() public returns (bool) {
+        uint256 value = _locked[msg.sender];
+        require(value != 0);
+        require(block.number >= _unlockBlock[msg.sender]);
+
+        _locked[msg.sender] = 0;
+        msg.sender.transfer(value);
+
+        emit Withdraw(msg.sender, value);
+        return true;
+    }
+}
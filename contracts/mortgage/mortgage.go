@@ -0,0 +1,65 @@
+// Package mortgage provides access to the mortgage system contract installed
+// by the mortgage upgrade fork.
+package mortgage
+
+//go:generate abigen --abi contract/mortgage.abi --pkg contract --type MortgageSystem --out contract/mortgage.go
+
+import (
+	"math/big"
+
+	"github.com/pocethereum/pochain/accounts/abi/bind"
+	"github.com/pocethereum/pochain/common"
+	pocmortgage "github.com/pocethereum/pochain/consensus/poc/mortgage"
+	"github.com/pocethereum/pochain/contracts/mortgage/contract"
+)
+
+// Mortgage wraps the mortgage system contract with convenience methods.
+type Mortgage struct {
+	*contract.MortgageSystemSession
+	contractBackend bind.ContractBackend
+}
+
+// Status is the state of a single account in the mortgage system.
+type Status struct {
+	Pledged     *big.Int // Value currently counting towards the mining reward
+	Locked      *big.Int // Redeemed value waiting for its lock-up to end
+	UnlockBlock *big.Int // Block from which the locked value can be withdrawn
+}
+
+// NewMortgage creates a struct exposing convenient high-level operations for
+// interacting with the mortgage system contract of the chain.
+func NewMortgage(transactOpts *bind.TransactOpts, contractBackend bind.ContractBackend) (*Mortgage, error) {
+	return NewMortgageAt(transactOpts, pocmortgage.MortgageContractAddr, contractBackend)
+}
+
+// NewMortgageAt is like NewMortgage, but binds to a contract at a custom address.
+func NewMortgageAt(transactOpts *bind.TransactOpts, contractAddr common.Address, contractBackend bind.ContractBackend) (*Mortgage, error) {
+	mortgage, err := contract.NewMortgageSystem(contractAddr, contractBackend)
+	if err != nil {
+		return nil, err
+	}
+	return &Mortgage{
+		&contract.MortgageSystemSession{
+			Contract:     mortgage,
+			TransactOpts: *transactOpts,
+		},
+		contractBackend,
+	}, nil
+}
+
+// Status retrieves the pledged and locked values of owner.
+func (m *Mortgage) Status(owner common.Address) (*Status, error) {
+	pledged, err := m.MortgageOf(owner)
+	if err != nil {
+		return nil, err
+	}
+	locked, err := m.LockedOf(owner)
+	if err != nil {
+		return nil, err
+	}
+	unlock, err := m.UnlockBlockOf(owner)
+	if err != nil {
+		return nil, err
+	}
+	return &Status{Pledged: pledged, Locked: locked, UnlockBlock: unlock}, nil
+}
@@ -0,0 +1,236 @@
+package mortgage
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"testing"
+
+	"github.com/pocethereum/pochain/accounts/abi/bind"
+	"github.com/pocethereum/pochain/accounts/abi/bind/backends"
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/common/hexutil"
+	pocmortgage "github.com/pocethereum/pochain/consensus/poc/mortgage"
+	"github.com/pocethereum/pochain/contracts/mortgage/contract"
+	"github.com/pocethereum/pochain/core"
+	"github.com/pocethereum/pochain/core/asm"
+	"github.com/pocethereum/pochain/crypto"
+	"github.com/pocethereum/pochain/params"
+)
+
+var (
+	key, _       = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	addr         = crypto.PubkeyToAddress(key.PublicKey)
+	contractAddr = common.HexToAddress("0x0100000000000000000000000000000000000001")
+)
+
+func TestMortgageLockup(t *testing.T) {
+	// The simulated chain always installs the original contract at the system
+	// address, so deploy the upgraded one elsewhere with a 3 block lock-up.
+	contractBackend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		addr: {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))},
+		contractAddr: {
+			Balance: new(big.Int),
+			Code:    pocmortgage.MortgageUpgradeCode,
+			Storage: map[common.Hash]common.Hash{common.BytesToHash(pocmortgage.MortgageLockupPos): common.BigToHash(big.NewInt(3))},
+		},
+	})
+	transactOpts := bind.NewKeyedTransactor(key)
+
+	m, err := NewMortgageAt(transactOpts, contractAddr, contractBackend)
+	if err != nil {
+		t.Fatalf("can't bind mortgage contract: %v", err)
+	}
+	if period, err := m.LockupPeriod(); err != nil || period.Uint64() != 3 {
+		t.Fatalf("lockup period mismatch: have %v, want 3 (%v)", period, err)
+	}
+	// Pledge 10 ether, the value sent must match the pledge.
+	value := new(big.Int).Mul(big.NewInt(10), big.NewInt(params.Ether))
+	m.TransactOpts.Value = value
+	if _, err := m.Mortgage(addr, value); err != nil {
+		t.Fatalf("can't mortgage: %v", err)
+	}
+	m.TransactOpts.Value = nil
+	contractBackend.Commit()
+
+	if total, _ := m.TotalMortgage(); total.Cmp(value) != 0 {
+		t.Fatalf("total mortgage mismatch: have %v, want %v", total, value)
+	}
+	// Redeeming more than pledged must fail, a partial redeem must lock up.
+	if _, err := m.Redeem(new(big.Int).Add(value, big.NewInt(1))); err == nil {
+		t.Fatalf("redeemed more than pledged")
+	}
+	if _, err := m.Redeem(big.NewInt(params.Ether)); err != nil {
+		t.Fatalf("can't redeem: %v", err)
+	}
+	contractBackend.Commit()
+
+	status, err := m.Status(addr)
+	if err != nil {
+		t.Fatalf("can't retrieve status: %v", err)
+	}
+	if status.Pledged.Cmp(new(big.Int).Sub(value, big.NewInt(params.Ether))) != 0 || status.Locked.Cmp(big.NewInt(params.Ether)) != 0 {
+		t.Fatalf("status mismatch after redeem: pledged %v, locked %v", status.Pledged, status.Locked)
+	}
+	if status.UnlockBlock.Uint64() != 2+3 {
+		t.Fatalf("unlock block mismatch: have %v, want %d", status.UnlockBlock, 2+3)
+	}
+	// Withdrawing is rejected until the lock-up ends.
+	if _, err := m.Withdraw(); err == nil {
+		t.Fatalf("withdrew before the lock-up ended")
+	}
+	contractBackend.Commit()
+	contractBackend.Commit()
+
+	if _, err := m.Withdraw(); err != nil {
+		t.Fatalf("can't withdraw: %v", err)
+	}
+	contractBackend.Commit()
+
+	if locked, _ := m.LockedOf(addr); locked.Sign() != 0 {
+		t.Fatalf("locked value left after withdraw: %v", locked)
+	}
+	balance, _ := contractBackend.BalanceAt(nil, contractAddr, nil)
+	if want := new(big.Int).Sub(value, big.NewInt(params.Ether)); balance.Cmp(want) != 0 {
+		t.Fatalf("contract balance mismatch: have %v, want %v", balance, want)
+	}
+}
+
+// Tests the installed upgrade code against the rest of the behaviour specified
+// by mortgage.sol through its binding: the require checks, the non-payable
+// methods, the lock-up restart on every redeem and the emitted events.
+func TestMortgageInterface(t *testing.T) {
+	other := common.HexToAddress("0x0200000000000000000000000000000000000002")
+	contractBackend := backends.NewSimulatedBackend(core.GenesisAlloc{
+		addr: {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))},
+		contractAddr: {
+			Balance: new(big.Int),
+			Code:    pocmortgage.MortgageUpgradeCode,
+			Storage: map[common.Hash]common.Hash{common.BytesToHash(pocmortgage.MortgageLockupPos): common.BigToHash(big.NewInt(2))},
+		},
+	})
+	m, err := NewMortgageAt(bind.NewKeyedTransactor(key), contractAddr, contractBackend)
+	if err != nil {
+		t.Fatalf("can't bind mortgage contract: %v", err)
+	}
+	filterer, err := contract.NewMortgageSystemFilterer(contractAddr, contractBackend)
+	if err != nil {
+		t.Fatalf("can't bind mortgage events: %v", err)
+	}
+	// Pledges for the zero address or not matching the sent value are rejected
+	m.TransactOpts.Value = big.NewInt(100)
+	if _, err := m.Mortgage(common.Address{}, big.NewInt(100)); err == nil {
+		t.Fatalf("mortgaged for the zero address")
+	}
+	if _, err := m.Mortgage(other, big.NewInt(99)); err == nil {
+		t.Fatalf("mortgaged a value other than the one sent")
+	}
+	// Pledging on behalf of another account credits that one
+	if _, err := m.Mortgage(other, big.NewInt(100)); err != nil {
+		t.Fatalf("can't mortgage for other account: %v", err)
+	}
+	m.TransactOpts.Value = big.NewInt(400)
+	if _, err := m.Mortgage(addr, big.NewInt(400)); err != nil {
+		t.Fatalf("can't mortgage: %v", err)
+	}
+	contractBackend.Commit()
+
+	if pledged, _ := m.MortgageOf(other); pledged.Int64() != 100 {
+		t.Fatalf("pledge of other account mismatch: have %v, want 100", pledged)
+	}
+	if total, _ := m.TotalMortgage(); total.Int64() != 500 {
+		t.Fatalf("total mortgage mismatch: have %v, want 500", total)
+	}
+	// Redeeming and withdrawing are not payable, zero redeems are rejected
+	m.TransactOpts.Value = big.NewInt(1)
+	if _, err := m.Redeem(big.NewInt(100)); err == nil {
+		t.Fatalf("redeemed with value sent")
+	}
+	m.TransactOpts.Value = nil
+	if _, err := m.Redeem(new(big.Int)); err == nil {
+		t.Fatalf("redeemed zero value")
+	}
+	if _, err := m.Withdraw(); err == nil {
+		t.Fatalf("withdrew without locked value")
+	}
+	// Every redeem restarts the lock-up of everything locked so far
+	if _, err := m.Redeem(big.NewInt(100)); err != nil {
+		t.Fatalf("can't redeem: %v", err)
+	}
+	contractBackend.Commit()
+	if _, err := m.Redeem(big.NewInt(50)); err != nil {
+		t.Fatalf("can't redeem again: %v", err)
+	}
+	contractBackend.Commit()
+
+	status, _ := m.Status(addr)
+	if status.Pledged.Int64() != 250 || status.Locked.Int64() != 150 || status.UnlockBlock.Uint64() != 3+2 {
+		t.Fatalf("status mismatch: pledged %v, locked %v, unlock block %v", status.Pledged, status.Locked, status.UnlockBlock)
+	}
+	if total, _ := m.TotalMortgage(); total.Int64() != 350 {
+		t.Fatalf("total mortgage mismatch after redeem: have %v, want 350", total)
+	}
+	if _, err := m.Withdraw(); err == nil {
+		t.Fatalf("withdrew before the restarted lock-up ended")
+	}
+	contractBackend.Commit()
+	if _, err := m.Withdraw(); err != nil {
+		t.Fatalf("can't withdraw: %v", err)
+	}
+	contractBackend.Commit()
+
+	// All state changes were announced with the events of the contract ABI
+	mortgages, err := filterer.FilterMortgage(&bind.FilterOpts{}, nil)
+	if err != nil {
+		t.Fatalf("can't filter mortgage events: %v", err)
+	}
+	var pledges []int64
+	for mortgages.Next() {
+		if want := []common.Address{other, addr}[len(pledges)%2]; mortgages.Event.To != want {
+			t.Errorf("mortgage event %d: beneficiary mismatch: have %x, want %x", len(pledges), mortgages.Event.To, want)
+		}
+		pledges = append(pledges, mortgages.Event.Value.Int64())
+	}
+	if len(pledges) != 2 || pledges[0] != 100 || pledges[1] != 400 {
+		t.Errorf("mortgage events mismatch: have %v", pledges)
+	}
+	redeems, err := filterer.FilterRedeem(&bind.FilterOpts{}, []common.Address{addr})
+	if err != nil {
+		t.Fatalf("can't filter redeem events: %v", err)
+	}
+	var redeemed []int64
+	for redeems.Next() {
+		redeemed = append(redeemed, redeems.Event.Value.Int64())
+	}
+	if len(redeemed) != 2 || redeemed[0] != 100 || redeemed[1] != 50 {
+		t.Errorf("redeem events mismatch: have %v", redeemed)
+	}
+	withdrawals, err := filterer.FilterWithdraw(&bind.FilterOpts{}, []common.Address{addr})
+	if err != nil {
+		t.Fatalf("can't filter withdraw events: %v", err)
+	}
+	if !withdrawals.Next() || withdrawals.Event.Owner != addr || withdrawals.Event.Value.Int64() != 150 || withdrawals.Next() {
+		t.Errorf("withdraw events mismatch")
+	}
+	if balance, _ := contractBackend.BalanceAt(nil, contractAddr, nil); balance.Int64() != 350 {
+		t.Fatalf("contract balance mismatch: have %v, want 350", balance)
+	}
+}
+
+// Tests that the code installed by the mortgage upgrade is the one assembled
+// from the contract source.
+func TestMortgageUpgradeCode(t *testing.T) {
+	source, err := ioutil.ReadFile("contract/mortgage.easm")
+	if err != nil {
+		t.Fatalf("failed to read contract source: %v", err)
+	}
+	compiler := asm.NewCompiler(false)
+	compiler.Feed(asm.Lex("mortgage.easm", source, false))
+	bin, errs := compiler.Compile()
+	if len(errs) > 0 {
+		t.Fatalf("failed to assemble contract: %v", errs)
+	}
+	if code := hexutil.MustDecode("0x" + bin); !bytes.Equal(code, pocmortgage.MortgageUpgradeCode) {
+		t.Fatalf("upgrade code out of sync with mortgage.easm, run `evm compile contract/mortgage.easm`:\nhave %x\nwant %s", pocmortgage.MortgageUpgradeCode, bin)
+	}
+}
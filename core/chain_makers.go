@@ -23,6 +23,7 @@ import (
 	"github.com/pocethereum/pochain/common"
 	"github.com/pocethereum/pochain/consensus"
 	"github.com/pocethereum/pochain/consensus/misc"
+	"github.com/pocethereum/pochain/consensus/poc/mortgage"
 	"github.com/pocethereum/pochain/core/state"
 	"github.com/pocethereum/pochain/core/types"
 	"github.com/pocethereum/pochain/core/vm"
@@ -196,6 +197,9 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, engine conse
 		if config.DAOForkSupport && config.DAOForkBlock != nil && config.DAOForkBlock.Cmp(b.header.Number) == 0 {
 			misc.ApplyDAOHardFork(statedb)
 		}
+		if config.MortgageUpgradeBlock != nil && config.MortgageUpgradeBlock.Cmp(b.header.Number) == 0 {
+			mortgage.ApplyUpgrade(statedb, config)
+		}
 		// Execute any user modifications to the block and finalize it
 		if gen != nil {
 			gen(i, b)
@@ -0,0 +1,259 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package forkid
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/consensus/poc/mortgage"
+	"github.com/pocethereum/pochain/params"
+	"github.com/pocethereum/pochain/rlp"
+)
+
+var (
+	// legacyGenesisHash and legacyConfig are the genesis and fork schedule of
+	// the Ethereum mainnet up to Constantinople, whose fork IDs are published in
+	// EIP-2124 and serve as independent test vectors.
+	legacyGenesisHash = common.HexToHash("0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3")
+	legacyConfig      = &params.ChainConfig{
+		ChainID:             big.NewInt(1),
+		HomesteadBlock:      big.NewInt(1150000),
+		DAOForkBlock:        big.NewInt(1920000),
+		EIP150Block:         big.NewInt(2463000),
+		EIP155Block:         big.NewInt(2675000),
+		EIP158Block:         big.NewInt(2675000),
+		ByzantiumBlock:      big.NewInt(4370000),
+		ConstantinopleBlock: big.NewInt(7280000),
+	}
+)
+
+// withMortgageUpgrade returns a copy of the legacy config with the mortgage
+// upgrade scheduled at the given block.
+func withMortgageUpgrade(block int64) *params.ChainConfig {
+	config := *legacyConfig
+	config.MortgageUpgradeBlock = big.NewInt(block)
+	return &config
+}
+
+// TestCreation tests that different genesis and fork rule combinations result in
+// the correct fork ID.
+func TestCreation(t *testing.T) {
+	type testcase struct {
+		head uint64
+		want ID
+	}
+	tests := []struct {
+		config  *params.ChainConfig
+		genesis common.Hash
+		cases   []testcase
+	}{
+		// Ethereum mainnet test cases (EIP-2124 vectors)
+		{
+			legacyConfig,
+			legacyGenesisHash,
+			[]testcase{
+				{0, ID{Hash: checksumToBytes(0xfc64ec04), Next: 1150000}},       // Unsynced
+				{1149999, ID{Hash: checksumToBytes(0xfc64ec04), Next: 1150000}}, // Last Frontier block
+				{1150000, ID{Hash: checksumToBytes(0x97c2c34c), Next: 1920000}}, // First Homestead block
+				{1919999, ID{Hash: checksumToBytes(0x97c2c34c), Next: 1920000}}, // Last Homestead block
+				{1920000, ID{Hash: checksumToBytes(0x91d1f948), Next: 2463000}}, // First DAO block
+				{2462999, ID{Hash: checksumToBytes(0x91d1f948), Next: 2463000}}, // Last DAO block
+				{2463000, ID{Hash: checksumToBytes(0x7a64da13), Next: 2675000}}, // First Tangerine block
+				{2674999, ID{Hash: checksumToBytes(0x7a64da13), Next: 2675000}}, // Last Tangerine block
+				{2675000, ID{Hash: checksumToBytes(0x3edd5b10), Next: 4370000}}, // First Spurious block
+				{4369999, ID{Hash: checksumToBytes(0x3edd5b10), Next: 4370000}}, // Last Spurious block
+				{4370000, ID{Hash: checksumToBytes(0xa00bc324), Next: 7280000}}, // First Byzantium block
+				{7279999, ID{Hash: checksumToBytes(0xa00bc324), Next: 7280000}}, // Last Byzantium block
+				{7280000, ID{Hash: checksumToBytes(0x668db0af), Next: 0}},       // First Constantinople block
+				{10000000, ID{Hash: checksumToBytes(0x668db0af), Next: 0}},      // Future Constantinople block
+			},
+		},
+		// PoC specific forks are announced and checksummed like the inherited ones
+		{
+			withMortgageUpgrade(8000000),
+			legacyGenesisHash,
+			[]testcase{
+				{7280000, ID{Hash: checksumToBytes(0x668db0af), Next: 8000000}}, // First Constantinople block
+				{8000000, ID{Hash: checksumToBytes(checksumUpdate(0x668db0af, 8000000)), Next: 0}},
+			},
+		},
+	}
+	for i, tt := range tests {
+		for j, ttt := range tt.cases {
+			if have := newID(tt.config, tt.genesis, ttt.head); have != ttt.want {
+				t.Errorf("test %d, case %d: fork ID mismatch: have %x, want %x", i, j, have, ttt.want)
+			}
+		}
+	}
+}
+
+// TestValidation tests that a local peer correctly validates and accepts a remote
+// fork ID.
+func TestValidation(t *testing.T) {
+	tests := []struct {
+		head uint64
+		id   ID
+		err  error
+	}{
+		// Local is mainnet Constantinople, remote announces the same. No future fork is announced.
+		{7987396, ID{Hash: checksumToBytes(0x668db0af), Next: 0}, nil},
+
+		// Local is mainnet Constantinople, remote announces the same. Remote also announces a next fork
+		// at block 0xffffffff, but that is uncertain.
+		{7987396, ID{Hash: checksumToBytes(0x668db0af), Next: math.MaxUint64}, nil},
+
+		// Local is mainnet currently in Byzantium only (so it's aware of Constantinople), remote announces
+		// also Byzantium, but it's not yet aware of Constantinople (e.g. non updated node before the fork).
+		// In this case we don't know if Constantinople passed yet or not.
+		{7279999, ID{Hash: checksumToBytes(0xa00bc324), Next: 0}, nil},
+
+		// Local is mainnet currently in Byzantium only (so it's aware of Constantinople), remote announces
+		// also Byzantium, and it's also aware of Constantinople (e.g. updated node before the fork). We
+		// don't know if Constantinople passed yet (will pass) or not.
+		{7279999, ID{Hash: checksumToBytes(0xa00bc324), Next: 7280000}, nil},
+
+		// Local is mainnet currently in Byzantium only (so it's aware of Constantinople), remote announces
+		// also Byzantium, and it's also aware of some random fork (e.g. misconfigured Constantinople). As
+		// neither forks passed at neither nodes, they may mismatch, but we still connect for now.
+		{7279999, ID{Hash: checksumToBytes(0xa00bc324), Next: math.MaxUint64}, nil},
+
+		// Local is mainnet Constantinople, remote announces Byzantium + knowledge about Constantinople.
+		// Remote is simply out of sync, accept.
+		{7987396, ID{Hash: checksumToBytes(0xa00bc324), Next: 7280000}, nil},
+
+		// Local is mainnet Constantinople, remote announces Spurious + knowledge about Byzantium. Remote
+		// is definitely out of sync. It may or may not need the Constantinople update, we don't know yet.
+		{7987396, ID{Hash: checksumToBytes(0x3edd5b10), Next: 4370000}, nil},
+
+		// Local is mainnet Byzantium, remote announces Constantinople. Local is out of sync, accept.
+		{7279999, ID{Hash: checksumToBytes(0x668db0af), Next: 0}, nil},
+
+		// Local is mainnet Spurious, remote announces Byzantium, but is not aware of Constantinople. Local
+		// out of sync. Local also knows about a future fork, but that is uncertain yet.
+		{4369999, ID{Hash: checksumToBytes(0xa00bc324), Next: 0}, nil},
+
+		// Local is mainnet Constantinople, remote announces Byzantium but is not aware of further forks.
+		// Remote needs software update.
+		{7987396, ID{Hash: checksumToBytes(0xa00bc324), Next: 0}, ErrRemoteStale},
+
+		// Local is mainnet Constantinople, and isn't aware of more forks. Remote announces Constantinople +
+		// 0xffffffff. Local needs software update, reject.
+		{7987396, ID{Hash: checksumToBytes(0x5cddc0e1), Next: 0}, ErrLocalIncompatibleOrStale},
+
+		// Local is mainnet Constantinople, remote is Rinkeby Constantinople.
+		{7987396, ID{Hash: checksumToBytes(0xafec6b27), Next: 0}, ErrLocalIncompatibleOrStale},
+
+		// Local is mainnet Constantinople, far in the future. Remote announces Gopherium (non existing fork)
+		// at some future block 88888888, for itself, but past block for local. Local is incompatible.
+		//
+		// This case detects non-upgraded nodes with majority hash power (typical Ropsten mess).
+		{88888888, ID{Hash: checksumToBytes(0x668db0af), Next: 88888888}, ErrLocalIncompatibleOrStale},
+	}
+	for i, tt := range tests {
+		filter := newFilter(legacyConfig, legacyGenesisHash, func() uint64 { return tt.head })
+		if err := filter(tt.id); err != tt.err {
+			t.Errorf("test %d: validation error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+// TestValidationPocFork tests that nodes disagreeing on a PoC specific fork are
+// rejected once it passed, while nodes simply unaware of it yet are kept.
+func TestValidationPocFork(t *testing.T) {
+	var (
+		upgraded = withMortgageUpgrade(8000000)
+		local    = newFilter(upgraded, legacyGenesisHash, func() uint64 { return 8100000 })
+	)
+	// A node without the mortgage upgrade that passed the fork block is on a
+	// different chain.
+	if err := local(newID(legacyConfig, legacyGenesisHash, 8100000)); err != ErrRemoteStale {
+		t.Errorf("non upgraded peer: validation error mismatch: have %v, want %v", err, ErrRemoteStale)
+	}
+	// A node scheduling the upgrade at a different block is on a different chain.
+	if err := local(newID(withMortgageUpgrade(8050000), legacyGenesisHash, 8100000)); err != ErrLocalIncompatibleOrStale {
+		t.Errorf("misconfigured peer: validation error mismatch: have %v, want %v", err, ErrLocalIncompatibleOrStale)
+	}
+	// A syncing node which knows about the upgrade is accepted.
+	if err := local(newID(upgraded, legacyGenesisHash, 7500000)); err != nil {
+		t.Errorf("syncing peer: unexpected validation error: %v", err)
+	}
+	// A matching node is accepted.
+	if err := local(newID(upgraded, legacyGenesisHash, 8100000)); err != nil {
+		t.Errorf("matching peer: unexpected validation error: %v", err)
+	}
+}
+
+// TestValidationPocParams tests that nodes agreeing on the mortgage upgrade block
+// but not on the pledge lockup it installs are rejected once the fork passed.
+func TestValidationPocParams(t *testing.T) {
+	withLockup := func(config *params.ChainConfig, lockup uint64) *params.ChainConfig {
+		config.Poc = &params.PocConfig{MortgageLockup: lockup}
+		return config
+	}
+	var (
+		upgraded = withLockup(withMortgageUpgrade(8000000), 100)
+		local    = newFilter(upgraded, legacyGenesisHash, func() uint64 { return 8100000 })
+	)
+	// A node locking up pledges for a different number of blocks is on a
+	// different chain.
+	if err := local(newID(withLockup(withMortgageUpgrade(8000000), 200), legacyGenesisHash, 8100000)); err != ErrLocalIncompatibleOrStale {
+		t.Errorf("misconfigured peer: validation error mismatch: have %v, want %v", err, ErrLocalIncompatibleOrStale)
+	}
+	// A node syncing up to the upgrade is accepted whatever its lockup.
+	if err := local(newID(withLockup(withMortgageUpgrade(8000000), 200), legacyGenesisHash, 7500000)); err != nil {
+		t.Errorf("syncing peer: unexpected validation error: %v", err)
+	}
+	// A matching node is accepted.
+	if err := local(newID(withLockup(withMortgageUpgrade(8000000), 100), legacyGenesisHash, 8100000)); err != nil {
+		t.Errorf("matching peer: unexpected validation error: %v", err)
+	}
+	// The default lockup doesn't change the ID whether spelled out or not.
+	if have, want := newID(withLockup(withMortgageUpgrade(8000000), mortgage.DefaultMortgageLockup), legacyGenesisHash, 8100000), newID(withMortgageUpgrade(8000000), legacyGenesisHash, 8100000); have != want {
+		t.Errorf("default lockup: fork ID mismatch: have %x, want %x", have, want)
+	}
+	// Lockups of networks upgraded at genesis are told apart too.
+	if newID(withLockup(withMortgageUpgrade(0), 100), legacyGenesisHash, 0) == newID(withLockup(withMortgageUpgrade(0), 200), legacyGenesisHash, 0) {
+		t.Errorf("genesis upgrade: fork IDs match despite differing lockups")
+	}
+}
+
+// Tests that IDs are properly RLP encoded (specifically important because we
+// use uint32 to store the hash, but we need to encode it as [4]byte).
+func TestEncoding(t *testing.T) {
+	tests := []struct {
+		id   ID
+		want []byte
+	}{
+		{ID{Hash: checksumToBytes(0), Next: 0}, common.Hex2Bytes("c6840000000080")},
+		{ID{Hash: checksumToBytes(0xdeadbeef), Next: 0xBADDCAFE}, common.Hex2Bytes("ca84deadbeef84baddcafe")},
+		{ID{Hash: checksumToBytes(math.MaxUint32), Next: math.MaxUint64}, common.Hex2Bytes("ce84ffffffff88ffffffffffffffff")},
+	}
+	for i, tt := range tests {
+		have, err := rlp.EncodeToBytes(tt.id)
+		if err != nil {
+			t.Errorf("test %d: failed to encode forkid: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(have, tt.want) {
+			t.Errorf("test %d: RLP mismatch: have %x, want %x", i, have, tt.want)
+		}
+	}
+}
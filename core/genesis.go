@@ -248,6 +248,9 @@ func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
 			statedb.SetState(addr, key, value)
 		}
 	}
+	if config := g.Config; config != nil && config.MortgageUpgradeBlock != nil && config.MortgageUpgradeBlock.Cmp(new(big.Int).SetUint64(g.Number)) == 0 {
+		mortgage.ApplyUpgrade(statedb, config)
+	}
 	////// for MortageSystem contract ///////end////////////////////////
 
 	root := statedb.IntermediateRoot(false)
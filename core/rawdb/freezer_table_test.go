@@ -0,0 +1,166 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// getChunk returns a chunk of data of the given size, filled with b.
+func getChunk(size int, b byte) []byte {
+	return bytes.Repeat([]byte{b}, size)
+}
+
+// Tests that items can be appended and retrieved back, also across data files.
+func TestFreezerBasics(t *testing.T) {
+	for _, noCompression := range []bool{true, false} {
+		dir, err := ioutil.TempDir("", "freezer")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		// Set the max file size to 50 bytes, so every data file holds at most
+		// three 15 byte items
+		f, err := newCustomTable(dir, "test", 50, noCompression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for x := 0; x < 255; x++ {
+			if err := f.Append(uint64(x), getChunk(15, byte(x))); err != nil {
+				t.Fatalf("compress %v: append %d failed: %v", !noCompression, x, err)
+			}
+		}
+		if err := f.Append(0, getChunk(15, 0)); err == nil {
+			t.Fatalf("compress %v: out of order append succeeded", !noCompression)
+		}
+		// Reopen the table and read everything back
+		f.Close()
+		if f, err = newCustomTable(dir, "test", 50, noCompression); err != nil {
+			t.Fatal(err)
+		}
+		for y := 0; y < 255; y++ {
+			exp := getChunk(15, byte(y))
+			got, err := f.Retrieve(uint64(y))
+			if err != nil {
+				t.Fatalf("compress %v: retrieve %d failed: %v", !noCompression, y, err)
+			}
+			if !bytes.Equal(got, exp) {
+				t.Fatalf("compress %v: item %d mismatch: have %x, want %x", !noCompression, y, got, exp)
+			}
+		}
+		if _, err := f.Retrieve(255); err != errOutOfBounds {
+			t.Fatalf("compress %v: retrieve beyond end: have %v, want %v", !noCompression, err, errOutOfBounds)
+		}
+		f.Close()
+	}
+}
+
+// Tests that a table with a data file cut short (e.g. by a crash during append)
+// is repaired to the last complete item on open.
+func TestFreezerRepairDanglingHead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := newCustomTable(dir, "test", 50, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for x := 0; x < 10; x++ {
+		f.Append(uint64(x), getChunk(15, byte(x)))
+	}
+	f.Close()
+
+	// Chop off a few bytes of the head file, damaging its last item
+	head := filepath.Join(dir, fmt.Sprintf("test.%04d.rdat", 3))
+	stat, err := os.Stat(head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(head, stat.Size()-4); err != nil {
+		t.Fatal(err)
+	}
+	if f, err = newCustomTable(dir, "test", 50, true); err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if f.items != 9 {
+		t.Fatalf("repaired item count mismatch: have %d, want %d", f.items, 9)
+	}
+	if _, err := f.Retrieve(9); err != errOutOfBounds {
+		t.Fatalf("damaged item retrievable: %v", err)
+	}
+	// The table must be writable again from the repaired position
+	if err := f.Append(9, getChunk(15, 0x99)); err != nil {
+		t.Fatalf("append after repair failed: %v", err)
+	}
+	if got, _ := f.Retrieve(9); !bytes.Equal(got, getChunk(15, 0x99)) {
+		t.Fatalf("appended item mismatch: have %x", got)
+	}
+}
+
+// Tests that truncating a table drops the discarded items, including whole data
+// files, and that appending continues from the new end.
+func TestFreezerTruncate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f, err := newCustomTable(dir, "test", 50, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	for x := 0; x < 30; x++ {
+		f.Append(uint64(x), getChunk(15, byte(x)))
+	}
+	if err := f.truncate(10); err != nil {
+		t.Fatalf("truncate failed: %v", err)
+	}
+	if f.items != 10 {
+		t.Fatalf("truncated item count mismatch: have %d, want %d", f.items, 10)
+	}
+	if _, err := os.Stat(filepath.Join(dir, fmt.Sprintf("test.%04d.cdat", 9))); !os.IsNotExist(err) {
+		t.Fatalf("discarded data file still exists: %v", err)
+	}
+	for x := 10; x < 20; x++ {
+		if err := f.Append(uint64(x), getChunk(15, byte(0xff-x))); err != nil {
+			t.Fatalf("append %d after truncate failed: %v", x, err)
+		}
+	}
+	for y := 0; y < 20; y++ {
+		exp := getChunk(15, byte(y))
+		if y >= 10 {
+			exp = getChunk(15, byte(0xff-y))
+		}
+		if got, err := f.Retrieve(uint64(y)); err != nil || !bytes.Equal(got, exp) {
+			t.Fatalf("item %d mismatch: have %x, want %x, err %v", y, got, exp, err)
+		}
+	}
+}
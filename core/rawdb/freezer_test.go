@@ -0,0 +1,124 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/core/types"
+	"github.com/pocethereum/pochain/ethdb"
+)
+
+// Tests that an existing chain database gets its ancient blocks migrated into
+// the freezer, and that they stay readable through the usual accessors.
+func TestFreezerMigration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Assemble a canonical chain in a plain key-value store
+	kvdb := ethdb.NewMemDatabase()
+
+	var (
+		blocks []*types.Block
+		parent common.Hash
+	)
+	for i := 0; i < 20; i++ {
+		header := &types.Header{
+			Number:     big.NewInt(int64(i)),
+			ParentHash: parent,
+			Difficulty: big.NewInt(1),
+			Time:       big.NewInt(int64(i)),
+			Extra:      []byte("test block"),
+		}
+		block := types.NewBlockWithHeader(header)
+		receipts := types.Receipts{&types.Receipt{CumulativeGasUsed: uint64(i), Logs: []*types.Log{}}}
+
+		WriteBlock(kvdb, block)
+		WriteReceipts(kvdb, block.Hash(), block.NumberU64(), receipts)
+		WriteTd(kvdb, block.Hash(), block.NumberU64(), big.NewInt(int64(i+1)))
+		WriteCanonicalHash(kvdb, block.Hash(), block.NumberU64())
+
+		blocks, parent = append(blocks, block), block.Hash()
+	}
+	WriteHeadBlockHash(kvdb, parent)
+
+	// Attach a freezer keeping the last 5 blocks and wait for the migration
+	db, err := NewDatabaseWithFreezer(kvdb, dir, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ancients := db.(AncientReader)
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if frozen, _ := ancients.Ancients(); frozen == 15 {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			frozen, _ := ancients.Ancients()
+			t.Fatalf("migration timed out: frozen %d, want %d", frozen, 15)
+		}
+	}
+	for i, block := range blocks {
+		hash, number := block.Hash(), block.NumberU64()
+
+		// Frozen blocks (except the genesis) must be gone from the key-value store
+		if frozen := i > 0 && i < 15; frozen == HasHeader(kvdb, hash, number) {
+			t.Errorf("block %d: key-value presence mismatch: frozen %v", i, frozen)
+		}
+		if have := ReadCanonicalHash(db, number); have != hash {
+			t.Errorf("block %d: canonical hash mismatch: have %x, want %x", i, have, hash)
+		}
+		if have := ReadBlock(db, hash, number); have == nil || have.Hash() != hash {
+			t.Errorf("block %d: block mismatch: have %v", i, have)
+		}
+		if !HasHeader(db, hash, number) || !HasBody(db, hash, number) {
+			t.Errorf("block %d: header or body reported missing", i)
+		}
+		if have := ReadTd(db, hash, number); have == nil || have.Int64() != int64(i+1) {
+			t.Errorf("block %d: total difficulty mismatch: have %v, want %d", i, have, i+1)
+		}
+		if have := ReadReceipts(db, hash, number); len(have) != 1 || have[0].CumulativeGasUsed != uint64(i) {
+			t.Errorf("block %d: receipts mismatch: have %v", i, have)
+		}
+		if number := ReadHeaderNumber(db, hash); number == nil || *number != uint64(i) {
+			t.Errorf("block %d: hash to number mapping mismatch: have %v", i, number)
+		}
+	}
+	// Frozen blocks must not be served for non-canonical hashes
+	if HasHeader(db, common.Hash{0x01}, 3) || ReadHeader(db, common.Hash{0x01}, 3) != nil {
+		t.Errorf("non-canonical header served from the ancient store")
+	}
+	// Truncating the ancient store must drop the blocks above the limit
+	if err := db.(AncientWriter).TruncateAncients(10); err != nil {
+		t.Fatalf("failed to truncate ancients: %v", err)
+	}
+	if have := ReadCanonicalHash(db, 12); have != (common.Hash{}) {
+		t.Errorf("truncated canonical hash still served: %x", have)
+	}
+	if have := ReadCanonicalHash(db, 9); have != blocks[9].Hash() {
+		t.Errorf("retained canonical hash mismatch: have %x, want %x", have, blocks[9].Hash())
+	}
+}
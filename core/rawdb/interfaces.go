@@ -31,3 +31,29 @@ type DatabaseWriter interface {
 type DatabaseDeleter interface {
 	Delete(key []byte) error
 }
+
+// AncientReader wraps the methods to read from an append-only store of ancient
+// canonical chain data.
+type AncientReader interface {
+	// HasAncient returns an indicator whether the specified ancient data exists.
+	HasAncient(kind string, number uint64) (bool, error)
+
+	// Ancient retrieves an ancient binary blob from the append-only store.
+	Ancient(kind string, number uint64) ([]byte, error)
+
+	// Ancients returns the number of blocks in the ancient store.
+	Ancients() (uint64, error)
+}
+
+// AncientWriter wraps the methods to modify an append-only store of ancient
+// canonical chain data.
+type AncientWriter interface {
+	// AppendAncient appends the RLP encoded data of a block to the ancient store.
+	AppendAncient(number uint64, hash, header, body, receipts, td []byte) error
+
+	// TruncateAncients discards all but the first n blocks of the ancient store.
+	TruncateAncients(n uint64) error
+
+	// Sync flushes all in-memory ancient data to disk.
+	Sync() error
+}
@@ -0,0 +1,122 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"os"
+
+	"github.com/pocethereum/pochain/common"
+)
+
+// stateBloomHashes is the number of bit positions set for each key.
+const stateBloomHashes = 4
+
+var errInvalidBloomKey = errors.New("invalid state bloom key")
+
+// stateBloom is a bloom filter used during the state pruning to record all
+// reachable trie nodes and contract codes. As the keys are already uniformly
+// distributed hashes, the bit positions are taken straight from the key bytes
+// instead of rehashing them.
+//
+// False positives only ever keep a few stale entries on disk, they never cause
+// reachable state to be deleted.
+type stateBloom struct {
+	bits []byte
+}
+
+// newStateBloomWithSize creates an empty state bloom filter of the given size
+// in megabytes.
+func newStateBloomWithSize(size uint64) (*stateBloom, error) {
+	if size == 0 {
+		return nil, errors.New("zero sized state bloom")
+	}
+	return &stateBloom{bits: make([]byte, size*1024*1024)}, nil
+}
+
+// newStateBloomFromDisk loads a state bloom filter previously committed into
+// the given file.
+func newStateBloomFromDisk(filename string) (*stateBloom, error) {
+	bits, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(bits) == 0 {
+		return nil, errors.New("empty state bloom")
+	}
+	return &stateBloom{bits: bits}, nil
+}
+
+// positions returns the bit indexes a key is mapped to.
+func (bloom *stateBloom) positions(key []byte) [stateBloomHashes]uint64 {
+	var (
+		size = uint64(len(bloom.bits)) * 8
+		pos  [stateBloomHashes]uint64
+	)
+	for i := range pos {
+		pos[i] = binary.BigEndian.Uint64(key[i*8:]) % size
+	}
+	return pos
+}
+
+// Put marks a trie node or contract code hash as reachable.
+func (bloom *stateBloom) Put(key []byte) error {
+	if len(key) != common.HashLength {
+		return errInvalidBloomKey
+	}
+	for _, pos := range bloom.positions(key) {
+		bloom.bits[pos/8] |= 1 << (pos % 8)
+	}
+	return nil
+}
+
+// Contain reports whether a key was (likely) marked as reachable.
+func (bloom *stateBloom) Contain(key []byte) bool {
+	if len(key) != common.HashLength {
+		return false
+	}
+	for _, pos := range bloom.positions(key) {
+		if bloom.bits[pos/8]&(1<<(pos%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Commit flushes the bloom filter into the given file. The content is written
+// into a temporary file first and moved in place afterwards, so a crash never
+// leaves a partial filter behind.
+func (bloom *stateBloom) Commit(filename, tempname string) error {
+	f, err := os.Create(tempname)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(bloom.bits); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tempname, filename)
+}
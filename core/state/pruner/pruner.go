@@ -0,0 +1,359 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package pruner implements the offline pruning of stale state data.
+package pruner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/core/rawdb"
+	"github.com/pocethereum/pochain/core/state"
+	"github.com/pocethereum/pochain/core/types"
+	"github.com/pocethereum/pochain/crypto"
+	"github.com/pocethereum/pochain/ethdb"
+	"github.com/pocethereum/pochain/log"
+	"github.com/pocethereum/pochain/rlp"
+	"github.com/pocethereum/pochain/trie"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	// stateBloomFilePrefix is the filename prefix of the committed state bloom
+	// filter, followed by the hex encoded target state root.
+	stateBloomFilePrefix = "statebloom"
+
+	// stateBloomFileSuffix is the filename suffix of the committed state bloom.
+	stateBloomFileSuffix = "bf"
+
+	// stateBloomFileTempSuffix is the suffix of the state bloom while it's
+	// being flushed to disk.
+	stateBloomFileTempSuffix = ".tmp"
+
+	// logInterval is the time between two progress reports.
+	logInterval = 8 * time.Second
+)
+
+var (
+	// emptyRoot is the known root hash of an empty trie.
+	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+	// emptyCode is the known hash of the empty EVM bytecode.
+	emptyCode = crypto.Keccak256(nil)
+)
+
+// iteratee is the key-value store functionality needed to sweep the database.
+type iteratee interface {
+	NewIterator() iterator.Iterator
+}
+
+// Pruner is an offline tool to delete the state trie nodes and contract codes
+// that are not reachable from a target state root. Full nodes only ever discard
+// state that was still held in memory, so every trie node that made it to disk
+// accumulates there forever.
+//
+// The pruning is done in two phases. First the state trie at the target root,
+// all storage tries and contract codes it references are walked and recorded in
+// a bloom filter. Then the whole key-value store is iterated and every trie node
+// or code entry not present in the bloom filter is deleted.
+//
+// The bloom filter is flushed to disk between the two phases, so an interrupted
+// sweep is resumed via RecoverPruning instead of leaving a partially deleted
+// database behind. Pruning must never run against a database in use.
+type Pruner struct {
+	db         ethdb.Database // Chain database to prune
+	stateBloom *stateBloom    // Bloom filter recording the reachable state
+	datadir    string         // Directory to commit the state bloom into
+	headHeader *types.Header  // Header of the current chain head
+}
+
+// NewPruner creates the offline pruner for the given chain database, using a
+// state bloom filter of bloomSize megabytes.
+func NewPruner(db ethdb.Database, datadir string, bloomSize uint64) (*Pruner, error) {
+	if _, ok := rawdb.KeyValueStore(db).(iteratee); !ok {
+		return nil, errors.New("database does not support iteration")
+	}
+	headBlock := rawdb.ReadHeadBlockHash(db)
+	if headBlock == (common.Hash{}) {
+		return nil, errors.New("failed to load head block")
+	}
+	number := rawdb.ReadHeaderNumber(db, headBlock)
+	if number == nil {
+		return nil, errors.New("failed to load head block number")
+	}
+	headHeader := rawdb.ReadHeader(db, headBlock, *number)
+	if headHeader == nil {
+		return nil, errors.New("failed to load head block header")
+	}
+	bloom, err := newStateBloomWithSize(bloomSize)
+	if err != nil {
+		return nil, err
+	}
+	return &Pruner{
+		db:         db,
+		stateBloom: bloom,
+		datadir:    datadir,
+		headHeader: headHeader,
+	}, nil
+}
+
+// Prune deletes all state data not reachable from the given state root. If the
+// root is empty, the most recent canonical block with its state on disk is
+// picked as the target.
+func (p *Pruner) Prune(root common.Hash) error {
+	if root == (common.Hash{}) {
+		header, err := p.findTarget()
+		if err != nil {
+			return err
+		}
+		root = header.Root
+		if header.Hash() != p.headHeader.Hash() {
+			log.Warn("Head state missing, pruning to an older state", "head", p.headHeader.Number, "target", header.Number, "root", root)
+		}
+	} else if has, _ := p.db.Has(root[:]); !has {
+		return fmt.Errorf("associated state[%x] is not present", root)
+	} else if root != p.headHeader.Root {
+		log.Warn("Pruning to a custom state root, head state will be dropped", "head", p.headHeader.Number, "root", root)
+	}
+	start := time.Now()
+	if err := p.markState(root); err != nil {
+		return err
+	}
+	// Keep the genesis state around too, it's cheap and is expected to be
+	// present by tooling tracing or dumping the genesis block.
+	if genesis := rawdb.ReadCanonicalHash(p.db, 0); genesis != (common.Hash{}) {
+		if header := rawdb.ReadHeader(p.db, genesis, 0); header != nil && header.Root != root {
+			if has, _ := p.db.Has(header.Root[:]); has {
+				if err := p.markState(header.Root); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	filename := bloomFilterName(p.datadir, root)
+	if err := p.stateBloom.Commit(filename, filename+stateBloomFileTempSuffix); err != nil {
+		return err
+	}
+	log.Info("State bloom filter committed", "name", filename, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	if err := prune(p.db, p.stateBloom, filename, start); err != nil {
+		return err
+	}
+	if root != p.headHeader.Root {
+		log.Warn("Chain will be rewound to the pruned state on next startup", "root", root)
+	}
+	return nil
+}
+
+// findTarget returns the header of the most recent canonical block whose state
+// root is available on disk.
+func (p *Pruner) findTarget() (*types.Header, error) {
+	header := p.headHeader
+	for {
+		if has, _ := p.db.Has(header.Root[:]); has {
+			return header, nil
+		}
+		if header.Number.Sign() == 0 {
+			return nil, errors.New("no state available on disk")
+		}
+		parent := rawdb.ReadHeader(p.db, header.ParentHash, header.Number.Uint64()-1)
+		if parent == nil {
+			return nil, fmt.Errorf("missing header #%d [%x]", header.Number.Uint64()-1, header.ParentHash)
+		}
+		header = parent
+	}
+}
+
+// markState walks the entire state at the given root, recording every trie
+// node, storage trie node and contract code hash in the state bloom. Any node
+// missing from the database aborts the walk, so an incomplete state is never
+// used as the pruning target.
+func (p *Pruner) markState(root common.Hash) error {
+	var (
+		triedb = trie.NewDatabase(p.db)
+		nodes  int
+		codes  int
+		start  = time.Now()
+		logged = time.Now()
+	)
+	accTrie, err := trie.New(root, triedb)
+	if err != nil {
+		return err
+	}
+	accIter := accTrie.NodeIterator(nil)
+	for accIter.Next(true) {
+		if hash := accIter.Hash(); hash != (common.Hash{}) {
+			p.stateBloom.Put(hash[:])
+			nodes++
+		}
+		if accIter.Leaf() {
+			var acc state.Account
+			if err := rlp.Decode(bytes.NewReader(accIter.LeafBlob()), &acc); err != nil {
+				return err
+			}
+			if acc.Root != emptyRoot {
+				storageTrie, err := trie.New(acc.Root, triedb)
+				if err != nil {
+					return err
+				}
+				storageIter := storageTrie.NodeIterator(nil)
+				for storageIter.Next(true) {
+					if hash := storageIter.Hash(); hash != (common.Hash{}) {
+						p.stateBloom.Put(hash[:])
+						nodes++
+					}
+				}
+				if storageIter.Error() != nil {
+					return storageIter.Error()
+				}
+			}
+			if !bytes.Equal(acc.CodeHash, emptyCode) {
+				p.stateBloom.Put(acc.CodeHash)
+				codes++
+			}
+		}
+		if time.Since(logged) > logInterval {
+			log.Info("Marking reachable state", "root", root, "nodes", nodes, "codes", codes, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if accIter.Error() != nil {
+		return accIter.Error()
+	}
+	log.Info("Marked reachable state", "root", root, "nodes", nodes, "codes", codes, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// prune sweeps the key-value store, deleting every trie node and contract code
+// not recorded in the state bloom. Once done, the committed bloom filter is
+// removed and the database compacted to actually release the disk space.
+func prune(db ethdb.Database, bloom *stateBloom, filename string, start time.Time) error {
+	// State data is never frozen, only the key-value store needs sweeping
+	db = rawdb.KeyValueStore(db)
+
+	var (
+		count  int
+		size   common.StorageSize
+		pstart = time.Now()
+		logged = time.Now()
+		batch  = db.NewBatch()
+		iter   = db.(iteratee).NewIterator()
+	)
+	for iter.Next() {
+		// Trie nodes and contract codes are the only entries keyed by a bare
+		// hash, everything else is namespaced by a prefix.
+		key := iter.Key()
+		if len(key) != common.HashLength || bloom.Contain(key) {
+			continue
+		}
+		count++
+		size += common.StorageSize(len(key) + len(iter.Value()))
+		batch.Delete(key)
+
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				iter.Release()
+				return err
+			}
+			batch.Reset()
+		}
+		if time.Since(logged) > logInterval {
+			log.Info("Pruning state data", "nodes", count, "size", size, "elapsed", common.PrettyDuration(time.Since(pstart)))
+			logged = time.Now()
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Pruned state data", "nodes", count, "size", size, "elapsed", common.PrettyDuration(time.Since(pstart)))
+
+	// The sweep is complete, there's nothing to resume anymore
+	if err := os.RemoveAll(filename); err != nil {
+		return err
+	}
+	if ldb, ok := rawdb.KeyValueStore(db).(*ethdb.LDBDatabase); ok {
+		cstart := time.Now()
+		log.Info("Compacting database", "size", size)
+		if err := ldb.LDB().CompactRange(util.Range{}); err != nil {
+			log.Error("Database compaction failed", "err", err)
+			return err
+		}
+		log.Info("Database compaction finished", "elapsed", common.PrettyDuration(time.Since(cstart)))
+	}
+	log.Info("State pruning successful", "pruned", size, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// RecoverPruning resumes a pruning run that was interrupted after its state
+// bloom filter was committed. It must be called before the database is used,
+// as the sweep may already have deleted parts of the states other than the
+// target one, leaving them with a root node but missing children.
+func RecoverPruning(datadir string, db ethdb.Database) error {
+	if datadir == "" {
+		return nil
+	}
+	filename, root, err := findBloomFilter(datadir)
+	if err != nil {
+		return err
+	}
+	if filename == "" {
+		return nil
+	}
+	if _, ok := rawdb.KeyValueStore(db).(iteratee); !ok {
+		return errors.New("database does not support iteration")
+	}
+	bloom, err := newStateBloomFromDisk(filename)
+	if err != nil {
+		return err
+	}
+	log.Info("Resuming interrupted state pruning", "root", root)
+	return prune(db, bloom, filename, time.Now())
+}
+
+// bloomFilterName returns the path of the state bloom committed for the given
+// target state root.
+func bloomFilterName(datadir string, root common.Hash) string {
+	return filepath.Join(datadir, fmt.Sprintf("%s.%s.%s", stateBloomFilePrefix, root.Hex(), stateBloomFileSuffix))
+}
+
+// findBloomFilter looks up a committed state bloom in the given directory and
+// returns its path along with the target state root, or an empty path if there
+// is none.
+func findBloomFilter(datadir string) (string, common.Hash, error) {
+	matches, err := filepath.Glob(filepath.Join(datadir, stateBloomFilePrefix+".*."+stateBloomFileSuffix))
+	if err != nil {
+		return "", common.Hash{}, err
+	}
+	for _, match := range matches {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(match), stateBloomFilePrefix+"."), "."+stateBloomFileSuffix)
+		if len(name) == 2+2*common.HashLength && strings.HasPrefix(name, "0x") {
+			return match, common.HexToHash(name), nil
+		}
+	}
+	return "", common.Hash{}, nil
+}
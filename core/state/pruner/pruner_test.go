@@ -0,0 +1,192 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/core/rawdb"
+	"github.com/pocethereum/pochain/core/state"
+	"github.com/pocethereum/pochain/core/types"
+	"github.com/pocethereum/pochain/ethdb"
+)
+
+// makeTestState writes two consecutive states into the database, the second
+// one overwriting part of the first, and makes a head block point to the second.
+func makeTestState(t *testing.T, db ethdb.Database) (stale, head common.Hash) {
+	sdb := state.NewDatabase(db)
+
+	commit := func(statedb *state.StateDB) common.Hash {
+		root, err := statedb.Commit(false)
+		if err != nil {
+			t.Fatalf("failed to commit state: %v", err)
+		}
+		if err := sdb.TrieDB().Commit(root, false); err != nil {
+			t.Fatalf("failed to flush state: %v", err)
+		}
+		return root
+	}
+	statedb, _ := state.New(common.Hash{}, sdb)
+	for i := byte(0); i < 50; i++ {
+		addr := common.BytesToAddress([]byte{i})
+		statedb.AddBalance(addr, big.NewInt(int64(i)))
+		statedb.SetState(addr, common.Hash{i}, common.Hash{i, i})
+		if i%10 == 0 {
+			statedb.SetCode(addr, []byte{i, 0x60, 0x00})
+		}
+	}
+	stale = commit(statedb)
+
+	statedb, _ = state.New(stale, sdb)
+	for i := byte(0); i < 50; i += 2 {
+		addr := common.BytesToAddress([]byte{i})
+		statedb.AddBalance(addr, big.NewInt(1))
+		statedb.SetState(addr, common.Hash{i}, common.Hash{0xff})
+	}
+	head = commit(statedb)
+
+	header := &types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(1), Root: head}
+	rawdb.WriteHeader(db, header)
+	rawdb.WriteCanonicalHash(db, header.Hash(), 1)
+	rawdb.WriteHeadBlockHash(db, header.Hash())
+	return stale, head
+}
+
+// checkState verifies that the entire state at the given root is present.
+func checkState(t *testing.T, db ethdb.Database, root common.Hash) {
+	statedb, err := state.New(root, state.NewDatabase(db))
+	if err != nil {
+		t.Fatalf("failed to open state %x: %v", root, err)
+	}
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+	}
+	if it.Error != nil {
+		t.Fatalf("state %x incomplete: %v", root, it.Error)
+	}
+}
+
+func newTestDatabase(t *testing.T) (string, *ethdb.LDBDatabase) {
+	dir, err := ioutil.TempDir("", "pruner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := ethdb.NewLDBDatabase(filepath.Join(dir, "chaindata"), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, db
+}
+
+// Tests that pruning keeps the state of the head block intact, but deletes the
+// stale trie nodes of older states.
+func TestPruneState(t *testing.T) {
+	dir, db := newTestDatabase(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	stale, head := makeTestState(t, db)
+
+	pruner, err := NewPruner(db, dir, 1)
+	if err != nil {
+		t.Fatalf("failed to create pruner: %v", err)
+	}
+	if err := pruner.Prune(common.Hash{}); err != nil {
+		t.Fatalf("failed to prune state: %v", err)
+	}
+	checkState(t, db, head)
+	if has, _ := db.Has(stale[:]); has {
+		t.Errorf("stale state root %x not pruned", stale)
+	}
+	if rawdb.ReadHeadBlockHash(db) == (common.Hash{}) {
+		t.Errorf("non-state data pruned")
+	}
+	if filename, _, _ := findBloomFilter(dir); filename != "" {
+		t.Errorf("state bloom %s left behind", filename)
+	}
+}
+
+// Tests that pruning falls back to an older state if the head state was never
+// flushed to disk.
+func TestPruneStateMissingHead(t *testing.T) {
+	dir, db := newTestDatabase(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	_, head := makeTestState(t, db)
+
+	header := &types.Header{Number: big.NewInt(2), ParentHash: rawdb.ReadHeadBlockHash(db), Difficulty: big.NewInt(1), Root: common.Hash{0x01}}
+	rawdb.WriteHeader(db, header)
+	rawdb.WriteHeadBlockHash(db, header.Hash())
+
+	pruner, err := NewPruner(db, dir, 1)
+	if err != nil {
+		t.Fatalf("failed to create pruner: %v", err)
+	}
+	if err := pruner.Prune(common.Hash{}); err != nil {
+		t.Fatalf("failed to prune state: %v", err)
+	}
+	checkState(t, db, head)
+
+	if err := pruner.Prune(common.Hash{0x02}); err == nil {
+		t.Errorf("pruning to missing state succeeded")
+	}
+}
+
+// Tests that an interrupted pruning is resumed from its committed state bloom.
+func TestRecoverPruning(t *testing.T) {
+	dir, db := newTestDatabase(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	stale, head := makeTestState(t, db)
+
+	// Mark the head state and commit the bloom, but crash before the sweep
+	pruner, err := NewPruner(db, dir, 1)
+	if err != nil {
+		t.Fatalf("failed to create pruner: %v", err)
+	}
+	if err := pruner.markState(head); err != nil {
+		t.Fatalf("failed to mark state: %v", err)
+	}
+	filename := bloomFilterName(dir, head)
+	if err := pruner.stateBloom.Commit(filename, filename+stateBloomFileTempSuffix); err != nil {
+		t.Fatalf("failed to commit state bloom: %v", err)
+	}
+	if name, root, _ := findBloomFilter(dir); name != filename || root != head {
+		t.Fatalf("committed state bloom mismatch: have %s/%x, want %s/%x", name, root, filename, head)
+	}
+	if err := RecoverPruning(dir, db); err != nil {
+		t.Fatalf("failed to recover pruning: %v", err)
+	}
+	checkState(t, db, head)
+	if has, _ := db.Has(stale[:]); has {
+		t.Errorf("stale state root %x not pruned", stale)
+	}
+	if filename, _, _ := findBloomFilter(dir); filename != "" {
+		t.Errorf("state bloom %s left behind", filename)
+	}
+	// Without a committed bloom there's nothing to recover
+	if err := RecoverPruning(dir, db); err != nil {
+		t.Fatalf("failed to recover pruning: %v", err)
+	}
+}
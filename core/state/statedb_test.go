@@ -435,3 +435,36 @@ func TestCopyOfCopy(t *testing.T) {
 		t.Fatalf("2nd copy fail, expected 42, got %v", got)
 	}
 }
+
+// Tests that replacing the storage of an account hides all of its stored slots,
+// survives copies and reverts, and is never committed.
+func TestSetStorage(t *testing.T) {
+	state, _ := New(common.Hash{}, NewDatabase(ethdb.NewMemDatabase()))
+
+	addr := common.BytesToAddress([]byte{0x01})
+	state.SetState(addr, common.Hash{0x01}, common.Hash{0x11})
+	state.SetState(addr, common.Hash{0x02}, common.Hash{0x22})
+	root, _ := state.Commit(false)
+
+	state, _ = New(root, state.Database())
+	state.SetStorage(addr, map[common.Hash]common.Hash{{0x02}: {0x33}})
+
+	if value := state.GetState(addr, common.Hash{0x01}); value != (common.Hash{}) {
+		t.Errorf("replaced slot 1 mismatch: have %x, want empty", value)
+	}
+	if value := state.GetState(addr, common.Hash{0x02}); value != (common.Hash{0x33}) {
+		t.Errorf("replaced slot 2 mismatch: have %x, want %x", value, common.Hash{0x33})
+	}
+	snapshot := state.Snapshot()
+	state.SetState(addr, common.Hash{0x02}, common.Hash{0x44})
+	if value := state.Copy().GetState(addr, common.Hash{0x02}); value != (common.Hash{0x44}) {
+		t.Errorf("copied slot mismatch: have %x, want %x", value, common.Hash{0x44})
+	}
+	state.RevertToSnapshot(snapshot)
+	if value := state.GetState(addr, common.Hash{0x02}); value != (common.Hash{0x33}) {
+		t.Errorf("reverted slot mismatch: have %x, want %x", value, common.Hash{0x33})
+	}
+	if have, _ := state.Commit(false); have != root {
+		t.Errorf("replaced storage was committed: root %x, want %x", have, root)
+	}
+}
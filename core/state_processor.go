@@ -20,6 +20,7 @@ import (
 	"github.com/pocethereum/pochain/common"
 	"github.com/pocethereum/pochain/consensus"
 	"github.com/pocethereum/pochain/consensus/misc"
+	"github.com/pocethereum/pochain/consensus/poc/mortgage"
 	"github.com/pocethereum/pochain/core/state"
 	"github.com/pocethereum/pochain/core/types"
 	"github.com/pocethereum/pochain/core/vm"
@@ -65,6 +66,9 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 	if p.config.DAOForkSupport && p.config.DAOForkBlock != nil && p.config.DAOForkBlock.Cmp(block.Number()) == 0 {
 		misc.ApplyDAOHardFork(statedb)
 	}
+	if p.config.MortgageUpgradeBlock != nil && p.config.MortgageUpgradeBlock.Cmp(block.Number()) == 0 {
+		mortgage.ApplyUpgrade(statedb, p.config)
+	}
 	// Iterate over and process the individual transactions
 	for i, tx := range block.Transactions() {
 		statedb.Prepare(tx.Hash(), block.Hash(), i)
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"io"
 	"os"
+	"time"
 
 	"github.com/pocethereum/pochain/common"
 	"github.com/pocethereum/pochain/core/types"
@@ -40,11 +41,26 @@ type devNull struct{}
 func (*devNull) Write(p []byte) (n int, err error) { return len(p), nil }
 func (*devNull) Close() error                      { return nil }
 
+// timedTx is a transaction stored in a timed journal, along with the time it
+// was first journaled at.
+type timedTx struct {
+	Time uint64
+	Tx   *types.Transaction
+}
+
 // txJournal is a rotating log of transactions with the aim of storing locally
 // created transactions to allow non-executed ones to survive node restarts.
+//
+// A timed journal additionally records when each transaction was journaled and
+// discards the ones older than its lifetime, which makes it suitable for remote
+// transactions that should not linger around across restarts forever.
 type txJournal struct {
 	path   string         // Filesystem path to store the transactions at
 	writer io.WriteCloser // Output stream to write new transactions into
+
+	timed    bool                   // Whether transactions are journaled with their timestamps
+	lifetime time.Duration          // Maximum age of a timed transaction to keep journaling
+	times    map[common.Hash]uint64 // Timestamps of the journaled transactions (timed only)
 }
 
 // newTxJournal creates a new transaction journal to
@@ -54,6 +70,32 @@ func newTxJournal(path string) *txJournal {
 	}
 }
 
+// newTimedTxJournal creates a new transaction journal, dropping transactions
+// older than the given lifetime on load and rotation.
+func newTimedTxJournal(path string, lifetime time.Duration) *txJournal {
+	return &txJournal{
+		path:     path,
+		timed:    true,
+		lifetime: lifetime,
+		times:    make(map[common.Hash]uint64),
+	}
+}
+
+// kind returns the type of transactions the journal is expected to store, to
+// be used in log messages.
+func (journal *txJournal) kind() string {
+	if journal.timed {
+		return "remote"
+	}
+	return "local"
+}
+
+// expired checks whether a timed transaction journaled at the given time has
+// outlived the journal's lifetime.
+func (journal *txJournal) expired(stamp uint64, now time.Time) bool {
+	return journal.lifetime > 0 && now.Sub(time.Unix(int64(stamp), 0)) > journal.lifetime
+}
+
 // load parses a transaction journal dump from disk, loading its contents into
 // the specified pool.
 func (journal *txJournal) load(add func([]*types.Transaction) []error) error {
@@ -90,11 +132,26 @@ func (journal *txJournal) load(add func([]*types.Transaction) []error) error {
 	var (
 		failure error
 		batch   types.Transactions
+		expired int
+		now     = time.Now()
 	)
 	for {
 		// Parse the next transaction and terminate on error
 		tx := new(types.Transaction)
-		if err = stream.Decode(tx); err != nil {
+		if journal.timed {
+			entry := new(timedTx)
+			if err = stream.Decode(entry); err == nil {
+				tx = entry.Tx
+				if journal.expired(entry.Time, now) {
+					total, expired = total+1, expired+1
+					continue
+				}
+				journal.times[tx.Hash()] = entry.Time
+			}
+		} else {
+			err = stream.Decode(tx)
+		}
+		if err != nil {
 			if err != io.EOF {
 				failure = err
 			}
@@ -111,7 +168,11 @@ func (journal *txJournal) load(add func([]*types.Transaction) []error) error {
 			batch = batch[:0]
 		}
 	}
-	log.Info("Loaded local transaction journal", "transactions", total, "dropped", dropped)
+	if journal.timed {
+		log.Info("Loaded remote transaction journal", "transactions", total, "dropped", dropped, "expired", expired)
+	} else {
+		log.Info("Loaded local transaction journal", "transactions", total, "dropped", dropped)
+	}
 
 	return failure
 }
@@ -121,6 +182,14 @@ func (journal *txJournal) insert(tx *types.Transaction) error {
 	if journal.writer == nil {
 		return errNoActiveJournal
 	}
+	if journal.timed {
+		stamp, ok := journal.times[tx.Hash()]
+		if !ok {
+			stamp = uint64(time.Now().Unix())
+			journal.times[tx.Hash()] = stamp
+		}
+		return rlp.Encode(journal.writer, &timedTx{Time: stamp, Tx: tx})
+	}
 	if err := rlp.Encode(journal.writer, tx); err != nil {
 		return err
 	}
@@ -142,17 +211,38 @@ func (journal *txJournal) rotate(all map[common.Address]types.Transactions) erro
 	if err != nil {
 		return err
 	}
-	journaled := 0
+	var (
+		journaled = 0
+		times     = make(map[common.Hash]uint64)
+		now       = time.Now()
+	)
 	for _, txs := range all {
 		for _, tx := range txs {
-			if err = rlp.Encode(replacement, tx); err != nil {
+			if journal.timed {
+				// Keep the original timestamps, dropping anything outlived
+				stamp, ok := journal.times[tx.Hash()]
+				if !ok {
+					stamp = uint64(now.Unix())
+				}
+				if journal.expired(stamp, now) {
+					continue
+				}
+				times[tx.Hash()] = stamp
+				err = rlp.Encode(replacement, &timedTx{Time: stamp, Tx: tx})
+			} else {
+				err = rlp.Encode(replacement, tx)
+			}
+			if err != nil {
 				replacement.Close()
 				return err
 			}
+			journaled++
 		}
-		journaled += len(txs)
 	}
 	replacement.Close()
+	if journal.timed {
+		journal.times = times
+	}
 
 	// Replace the live journal with the newly generated one
 	if err = os.Rename(journal.path+".new", journal.path); err != nil {
@@ -163,7 +253,7 @@ func (journal *txJournal) rotate(all map[common.Address]types.Transactions) erro
 		return err
 	}
 	journal.writer = sink
-	log.Info("Regenerated local transaction journal", "transactions", journaled, "accounts", len(all))
+	log.Info("Regenerated "+journal.kind()+" transaction journal", "transactions", journaled, "accounts", len(all))
 
 	return nil
 }
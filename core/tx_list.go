@@ -437,9 +437,10 @@ func (l *txPricedList) Removed() {
 
 // Cap finds all the transactions below the given price threshold, drops them
 // from the priced list and returs them for further removal from the entire pool.
-func (l *txPricedList) Cap(threshold *big.Int, local *accountSet) types.Transactions {
+// Transactions deemed protected are never dropped.
+func (l *txPricedList) Cap(threshold *big.Int, protected func(*types.Transaction) bool) types.Transactions {
 	drop := make(types.Transactions, 0, 128) // Remote underpriced transactions to drop
-	save := make(types.Transactions, 0, 64)  // Local underpriced transactions to keep
+	save := make(types.Transactions, 0, 64)  // Protected underpriced transactions to keep
 
 	for len(*l.items) > 0 {
 		// Discard stale transactions if found during cleanup
@@ -453,8 +454,8 @@ func (l *txPricedList) Cap(threshold *big.Int, local *accountSet) types.Transact
 			save = append(save, tx)
 			break
 		}
-		// Non stale transaction found, discard unless local
-		if local.containsTx(tx) {
+		// Non stale transaction found, discard unless protected
+		if protected(tx) {
 			save = append(save, tx)
 		} else {
 			drop = append(drop, tx)
@@ -468,9 +469,9 @@ func (l *txPricedList) Cap(threshold *big.Int, local *accountSet) types.Transact
 
 // Underpriced checks whether a transaction is cheaper than (or as cheap as) the
 // lowest priced transaction currently being tracked.
-func (l *txPricedList) Underpriced(tx *types.Transaction, local *accountSet) bool {
-	// Local transactions cannot be underpriced
-	if local.containsTx(tx) {
+func (l *txPricedList) Underpriced(tx *types.Transaction, protected func(*types.Transaction) bool) bool {
+	// Protected transactions cannot be underpriced
+	if protected(tx) {
 		return false
 	}
 	// Discard stale price points if found at the heap start
@@ -494,9 +495,10 @@ func (l *txPricedList) Underpriced(tx *types.Transaction, local *accountSet) boo
 
 // Discard finds a number of most underpriced transactions, removes them from the
 // priced list and returns them for further removal from the entire pool.
-func (l *txPricedList) Discard(count int, local *accountSet) types.Transactions {
+// Transactions deemed protected are never discarded.
+func (l *txPricedList) Discard(count int, protected func(*types.Transaction) bool) types.Transactions {
 	drop := make(types.Transactions, 0, count) // Remote underpriced transactions to drop
-	save := make(types.Transactions, 0, 64)    // Local underpriced transactions to keep
+	save := make(types.Transactions, 0, 64)    // Protected underpriced transactions to keep
 
 	for len(*l.items) > 0 && count > 0 {
 		// Discard stale transactions if found during cleanup
@@ -505,8 +507,8 @@ func (l *txPricedList) Discard(count int, local *accountSet) types.Transactions
 			l.stales--
 			continue
 		}
-		// Non stale transaction found, discard unless local
-		if local.containsTx(tx) {
+		// Non stale transaction found, discard unless protected
+		if protected(tx) {
 			save = append(save, tx)
 		} else {
 			drop = append(drop, tx)
@@ -25,7 +25,9 @@ import (
 	"sync"
 	"time"
 
+	"github.com/hashicorp/golang-lru"
 	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/consensus/poc/mortgage"
 	"github.com/pocethereum/pochain/core/state"
 	"github.com/pocethereum/pochain/core/types"
 	"github.com/pocethereum/pochain/event"
@@ -38,6 +40,10 @@ import (
 const (
 	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
 	chainHeadChanSize = 10
+
+	// evictionCacheSize is the number of recently evicted transactions to keep
+	// the eviction reasons of.
+	evictionCacheSize = 4096
 )
 
 var (
@@ -101,6 +107,25 @@ var (
 	underpricedTxCounter = metrics.NewRegisteredCounter("txpool/underpriced", nil)
 )
 
+// Reasons for evicting a transaction from the pool, as reported by Eviction.
+const (
+	EvictUnderpriced = "underpriced"         // Pushed out by better priced transactions, or below the price limit
+	EvictReplaced    = "replaced"            // Replaced by a transaction with the same nonce and a higher price
+	EvictNonceTooLow = "nonce too low"       // Nonce already used by a transaction included in the chain
+	EvictNoFunds     = "insufficient funds"  // Sender can't pay for the transaction, or it exceeds the block gas limit
+	EvictAccountCap  = "account queue limit" // Sender queued more transactions than allowed per account
+	EvictPendingCap  = "pending limit"       // Sender exceeded its fair share of the executable slots
+	EvictGlobalQueue = "global queue limit"  // The queue of all accounts overflowed
+	EvictLifetime    = "lifetime exceeded"   // Queued for longer than the configured lifetime
+	EvictDropped     = "dropped by operator" // Removed explicitly via the admin API
+)
+
+// TxEviction describes why and when a transaction was evicted from the pool.
+type TxEviction struct {
+	Reason string    `json:"reason"`
+	Time   time.Time `json:"time"`
+}
+
 // TxStatus is the current status of a transaction as seen by the pool.
 type TxStatus uint
 
@@ -136,6 +161,11 @@ type TxPoolConfig struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	Priority []common.Address // Accounts whose transactions (from or to) get priority slots exempt from eviction
+
+	RemoteJournal  string        // Journal of remote transactions to survive node restarts (disabled if empty)
+	RemoteLifetime time.Duration // Maximum age of journaled remote transactions to restore
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -153,6 +183,8 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	GlobalQueue:  1024,
 
 	Lifetime: 3 * time.Hour,
+
+	RemoteLifetime: 3 * time.Hour,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -171,6 +203,10 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool price bump", "provided", conf.PriceBump, "updated", DefaultTxPoolConfig.PriceBump)
 		conf.PriceBump = DefaultTxPoolConfig.PriceBump
 	}
+	if conf.RemoteJournal != "" && conf.RemoteLifetime < time.Second {
+		log.Warn("Sanitizing invalid txpool remote lifetime", "provided", conf.RemoteLifetime, "updated", DefaultTxPoolConfig.RemoteLifetime)
+		conf.RemoteLifetime = DefaultTxPoolConfig.RemoteLifetime
+	}
 	return conf
 }
 
@@ -199,6 +235,11 @@ type TxPool struct {
 
 	locals  *accountSet // Set of local transaction to exempt from eviction rules
 	journal *txJournal  // Journal of local transaction to back up to disk
+	remotes *txJournal  // Journal of remote transaction to back up to disk
+
+	priority    *accountSet              // Set of priority accounts to exempt from eviction rules
+	prioritized map[common.Hash]struct{} // Individual transactions exempt from price based eviction
+	evictions   *lru.Cache               // Reasons of the recently evicted transactions
 
 	pending map[common.Address]*txList   // All currently processable transactions
 	queue   map[common.Address]*txList   // Queued but non-processable transactions
@@ -231,6 +272,14 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		gasPrice:    new(big.Int).SetUint64(config.PriceLimit),
 	}
 	pool.locals = newAccountSet(pool.signer)
+	pool.priority = newAccountSet(pool.signer)
+	pool.priority.add(mortgage.MortgageContractAddr)
+	for _, addr := range config.Priority {
+		log.Info("Setting new priority account", "address", addr)
+		pool.priority.add(addr)
+	}
+	pool.prioritized = make(map[common.Hash]struct{})
+	pool.evictions, _ = lru.New(evictionCacheSize)
 	pool.priced = newTxPricedList(pool.all)
 	pool.reset(nil, chain.CurrentBlock().Header())
 
@@ -245,6 +294,17 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	// If remote journaling is enabled, restore the unexpired remotes too
+	if config.RemoteJournal != "" {
+		pool.remotes = newTimedTxJournal(config.RemoteJournal, config.RemoteLifetime)
+
+		if err := pool.remotes.load(pool.AddRemotes); err != nil {
+			log.Warn("Failed to load remote transaction journal", "err", err)
+		}
+		if err := pool.remotes.rotate(pool.remote()); err != nil {
+			log.Warn("Failed to rotate remote transaction journal", "err", err)
+		}
+	}
 	// Subscribe events from blockchain
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
 
@@ -311,14 +371,15 @@ func (pool *TxPool) loop() {
 		case <-evict.C:
 			pool.mu.Lock()
 			for addr := range pool.queue {
-				// Skip local transactions from the eviction mechanism
-				if pool.locals.contains(addr) {
+				// Skip local and priority transactions from the eviction mechanism
+				if pool.exempt(addr) {
 					continue
 				}
 				// Any non-locals old enough should be removed
 				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
 					for _, tx := range pool.queue[addr].Flatten() {
 						pool.removeTx(tx.Hash(), true)
+						pool.evict(tx.Hash(), EvictLifetime)
 					}
 				}
 			}
@@ -333,6 +394,13 @@ func (pool *TxPool) loop() {
 				}
 				pool.mu.Unlock()
 			}
+			if pool.remotes != nil {
+				pool.mu.Lock()
+				if err := pool.remotes.rotate(pool.remote()); err != nil {
+					log.Warn("Failed to rotate remote tx journal", "err", err)
+				}
+				pool.mu.Unlock()
+			}
 		}
 	}
 }
@@ -442,6 +510,9 @@ func (pool *TxPool) Stop() {
 	if pool.journal != nil {
 		pool.journal.close()
 	}
+	if pool.remotes != nil {
+		pool.remotes.close()
+	}
 	log.Info("Transaction pool stopped")
 }
 
@@ -466,8 +537,9 @@ func (pool *TxPool) SetGasPrice(price *big.Int) {
 	defer pool.mu.Unlock()
 
 	pool.gasPrice = price
-	for _, tx := range pool.priced.Cap(price, pool.locals) {
+	for _, tx := range pool.priced.Cap(price, pool.protected) {
 		pool.removeTx(tx.Hash(), false)
+		pool.evict(tx.Hash(), EvictUnderpriced)
 	}
 	log.Info("Transaction pool price threshold updated", "price", price)
 }
@@ -555,6 +627,24 @@ func (pool *TxPool) local() map[common.Address]types.Transactions {
 	return txs
 }
 
+// remote retrieves all currently known remote transactions, groupped by origin
+// account and sorted by nonce. The returned transaction set is a copy and can be
+// freely modified by calling code.
+func (pool *TxPool) remote() map[common.Address]types.Transactions {
+	txs := make(map[common.Address]types.Transactions)
+	for addr, pending := range pool.pending {
+		if !pool.locals.contains(addr) {
+			txs[addr] = append(txs[addr], pending.Flatten()...)
+		}
+	}
+	for addr, queued := range pool.queue {
+		if !pool.locals.contains(addr) {
+			txs[addr] = append(txs[addr], queued.Flatten()...)
+		}
+	}
+	return txs
+}
+
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
@@ -625,17 +715,18 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 	// If the transaction pool is full, discard underpriced transactions
 	if uint64(pool.all.Count()) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
 		// If the new transaction is underpriced, don't accept it
-		if !local && pool.priced.Underpriced(tx, pool.locals) {
+		if !local && pool.priced.Underpriced(tx, pool.protected) {
 			log.Trace("Discarding underpriced transaction", "hash", hash, "price", tx.GasPrice())
 			underpricedTxCounter.Inc(1)
 			return false, ErrUnderpriced
 		}
 		// New transaction is better than our worse ones, make room for it
-		drop := pool.priced.Discard(pool.all.Count()-int(pool.config.GlobalSlots+pool.config.GlobalQueue-1), pool.locals)
+		drop := pool.priced.Discard(pool.all.Count()-int(pool.config.GlobalSlots+pool.config.GlobalQueue-1), pool.protected)
 		for _, tx := range drop {
 			log.Trace("Discarding freshly underpriced transaction", "hash", tx.Hash(), "price", tx.GasPrice())
 			underpricedTxCounter.Inc(1)
 			pool.removeTx(tx.Hash(), false)
+			pool.evict(tx.Hash(), EvictUnderpriced)
 		}
 	}
 	// If the transaction is replacing an already pending one, do directly
@@ -651,6 +742,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		if old != nil {
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed()
+			pool.evict(old.Hash(), EvictReplaced)
 			pendingReplaceCounter.Inc(1)
 		}
 		pool.all.Add(tx)
@@ -698,6 +790,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 	if old != nil {
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed()
+		pool.evict(old.Hash(), EvictReplaced)
 		queuedReplaceCounter.Inc(1)
 	}
 	if pool.all.Get(hash) == nil {
@@ -708,14 +801,24 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 }
 
 // journalTx adds the specified transaction to the local disk journal if it is
-// deemed to have been sent from a local account.
+// deemed to have been sent from a local account, or to the remote disk journal
+// otherwise.
 func (pool *TxPool) journalTx(from common.Address, tx *types.Transaction) {
-	// Only journal if it's enabled and the transaction is local
-	if pool.journal == nil || !pool.locals.contains(from) {
+	// Only journal if it's enabled for the kind of the transaction
+	if pool.locals.contains(from) {
+		if pool.journal == nil {
+			return
+		}
+		if err := pool.journal.insert(tx); err != nil {
+			log.Warn("Failed to journal local transaction", "err", err)
+		}
 		return
 	}
-	if err := pool.journal.insert(tx); err != nil {
-		log.Warn("Failed to journal local transaction", "err", err)
+	if pool.remotes == nil {
+		return
+	}
+	if err := pool.remotes.insert(tx); err != nil {
+		log.Warn("Failed to journal remote transaction", "err", err)
 	}
 }
 
@@ -735,6 +838,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		// An older transaction was better, discard this
 		pool.all.Remove(hash)
 		pool.priced.Removed()
+		pool.evict(hash, EvictReplaced)
 
 		pendingDiscardCounter.Inc(1)
 		return false
@@ -743,6 +847,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	if old != nil {
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed()
+		pool.evict(old.Hash(), EvictReplaced)
 
 		pendingReplaceCounter.Inc(1)
 	}
@@ -866,6 +971,79 @@ func (pool *TxPool) Get(hash common.Hash) *types.Transaction {
 	return pool.all.Get(hash)
 }
 
+// Drop removes a transaction from the pool, moving all subsequent transactions
+// of the same account back to the future queue. It returns whether the
+// transaction was found.
+func (pool *TxPool) Drop(hash common.Hash) bool {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if pool.all.Get(hash) == nil {
+		return false
+	}
+	pool.removeTx(hash, true)
+	pool.evict(hash, EvictDropped)
+
+	log.Info("Dropped transaction from pool", "hash", hash)
+	return true
+}
+
+// SetPriority marks or unmarks a transaction as a priority one, exempting it
+// from being pushed out of the pool by better priced transactions. It returns
+// whether the transaction was found.
+func (pool *TxPool) SetPriority(hash common.Hash, priority bool) bool {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if pool.all.Get(hash) == nil {
+		return false
+	}
+	if priority {
+		pool.prioritized[hash] = struct{}{}
+	} else {
+		delete(pool.prioritized, hash)
+	}
+	return true
+}
+
+// Eviction returns the reason a transaction was recently evicted from the pool
+// for, or nil if the transaction is still pooled or unknown.
+func (pool *TxPool) Eviction(hash common.Hash) *TxEviction {
+	if pool.all.Get(hash) != nil {
+		return nil
+	}
+	if eviction, ok := pool.evictions.Get(hash); ok {
+		return eviction.(*TxEviction)
+	}
+	return nil
+}
+
+// evict records the reason a transaction was removed from the pool for.
+func (pool *TxPool) evict(hash common.Hash, reason string) {
+	delete(pool.prioritized, hash)
+	pool.evictions.Add(hash, &TxEviction{Reason: reason, Time: time.Now()})
+}
+
+// exempt checks whether the transactions of an account are exempt from the
+// account based eviction rules, the account being either local or priority.
+func (pool *TxPool) exempt(addr common.Address) bool {
+	return pool.locals.contains(addr) || pool.priority.contains(addr)
+}
+
+// protected checks whether a transaction is exempt from the price based
+// eviction rules. Besides the transactions of exempt accounts, this covers the
+// ones sent to a priority account and the individually prioritized ones.
+func (pool *TxPool) protected(tx *types.Transaction) bool {
+	if pool.locals.containsTx(tx) || pool.priority.containsTx(tx) {
+		return true
+	}
+	if to := tx.To(); to != nil && pool.priority.contains(*to) {
+		return true
+	}
+	_, ok := pool.prioritized[tx.Hash()]
+	return ok
+}
+
 // removeTx removes a single transaction from the queue, moving all subsequent
 // transactions back to the future queue.
 func (pool *TxPool) removeTx(hash common.Hash, outofbound bool) {
@@ -936,6 +1114,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			log.Trace("Removed old queued transaction", "hash", hash)
 			pool.all.Remove(hash)
 			pool.priced.Removed()
+			pool.evict(hash, EvictNonceTooLow)
 		}
 		// Drop all transactions that are too costly (low balance or out of gas)
 		drops, _ := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
@@ -944,6 +1123,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			log.Trace("Removed unpayable queued transaction", "hash", hash)
 			pool.all.Remove(hash)
 			pool.priced.Removed()
+			pool.evict(hash, EvictNoFunds)
 			queuedNofundsCounter.Inc(1)
 		}
 		// Gather all executable transactions and promote them
@@ -954,11 +1134,12 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			}
 		}
 		// Drop all transactions over the allowed limit
-		if !pool.locals.contains(addr) {
+		if !pool.exempt(addr) {
 			for _, tx := range list.Cap(int(pool.config.AccountQueue)) {
 				hash := tx.Hash()
 				pool.all.Remove(hash)
 				pool.priced.Removed()
+				pool.evict(hash, EvictAccountCap)
 				queuedRateLimitCounter.Inc(1)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 			}
@@ -983,7 +1164,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 		spammers := prque.New()
 		for addr, list := range pool.pending {
 			// Only evict transactions from high rollers
-			if !pool.locals.contains(addr) && uint64(list.Len()) > pool.config.AccountSlots {
+			if !pool.exempt(addr) && uint64(list.Len()) > pool.config.AccountSlots {
 				spammers.Push(addr, float32(list.Len()))
 			}
 		}
@@ -1008,6 +1189,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 							hash := tx.Hash()
 							pool.all.Remove(hash)
 							pool.priced.Removed()
+							pool.evict(hash, EvictPendingCap)
 
 							// Update the account nonce to the dropped transaction
 							if nonce := tx.Nonce(); pool.pendingState.GetNonce(offenders[i]) > nonce {
@@ -1030,6 +1212,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 						hash := tx.Hash()
 						pool.all.Remove(hash)
 						pool.priced.Removed()
+						pool.evict(hash, EvictPendingCap)
 
 						// Update the account nonce to the dropped transaction
 						if nonce := tx.Nonce(); pool.pendingState.GetNonce(addr) > nonce {
@@ -1052,7 +1235,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 		// Sort all accounts with queued transactions by heartbeat
 		addresses := make(addresssByHeartbeat, 0, len(pool.queue))
 		for addr := range pool.queue {
-			if !pool.locals.contains(addr) { // don't drop locals
+			if !pool.exempt(addr) { // don't drop locals or priority accounts
 				addresses = append(addresses, addressByHeartbeat{addr, pool.beats[addr]})
 			}
 		}
@@ -1069,6 +1252,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			if size := uint64(list.Len()); size <= drop {
 				for _, tx := range list.Flatten() {
 					pool.removeTx(tx.Hash(), true)
+					pool.evict(tx.Hash(), EvictGlobalQueue)
 				}
 				drop -= size
 				queuedRateLimitCounter.Inc(int64(size))
@@ -1078,6 +1262,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			txs := list.Flatten()
 			for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 				pool.removeTx(txs[i].Hash(), true)
+				pool.evict(txs[i].Hash(), EvictGlobalQueue)
 				drop--
 				queuedRateLimitCounter.Inc(1)
 			}
@@ -1099,6 +1284,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			log.Trace("Removed old pending transaction", "hash", hash)
 			pool.all.Remove(hash)
 			pool.priced.Removed()
+			delete(pool.prioritized, hash)
 		}
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
 		drops, invalids := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
@@ -1107,6 +1293,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
 			pool.all.Remove(hash)
 			pool.priced.Removed()
+			pool.evict(hash, EvictNoFunds)
 			pendingNofundsCounter.Inc(1)
 		}
 		for _, tx := range invalids {
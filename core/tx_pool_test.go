@@ -23,10 +23,12 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/consensus/poc/mortgage"
 	"github.com/pocethereum/pochain/core/state"
 	"github.com/pocethereum/pochain/core/types"
 	"github.com/pocethereum/pochain/crypto"
@@ -1736,6 +1738,198 @@ func TestTransactionStatusCheck(t *testing.T) {
 	}
 }
 
+// Tests that transactions from or to priority accounts, as well as individually
+// prioritized ones, are not pushed out of a full pool by better priced ones.
+func TestTransactionPoolPriority(t *testing.T) {
+	t.Parallel()
+
+	// Create the pool to test the priority slots with
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	keys := make([]*ecdsa.PrivateKey, 7)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	config := testTxPoolConfig
+	config.GlobalSlots = 2
+	config.GlobalQueue = 2
+	config.Priority = []common.Address{crypto.PubkeyToAddress(keys[0].PublicKey)}
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	for i := 0; i < len(keys); i++ {
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000))
+	}
+	// Fill the pool with cheap transactions: one from a priority account, one to
+	// the mortgage contract, one prioritized explicitly and a plain one
+	mortgageTx, _ := types.SignTx(types.NewTransaction(0, mortgage.MortgageContractAddr, big.NewInt(100), 100000, big.NewInt(1), nil), types.HomesteadSigner{}, keys[1])
+	txs := types.Transactions{
+		pricedTransaction(0, 100000, big.NewInt(1), keys[0]),
+		mortgageTx,
+		pricedTransaction(0, 100000, big.NewInt(1), keys[2]),
+		pricedTransaction(0, 100000, big.NewInt(1), keys[3]),
+	}
+	for i, err := range pool.AddRemotes(txs) {
+		if err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	if !pool.SetPriority(txs[2].Hash(), true) {
+		t.Fatalf("failed to prioritize pooled transaction")
+	}
+	if pool.SetPriority(common.Hash{}, true) {
+		t.Fatalf("prioritized unknown transaction")
+	}
+	// Push better priced transactions in, only the plain one may be dropped
+	for i := 4; i < len(keys); i++ {
+		if err := pool.AddRemote(pricedTransaction(0, 100000, big.NewInt(int64(i)), keys[i])); err != nil {
+			t.Fatalf("failed to add well priced transaction %d: %v", i, err)
+		}
+	}
+	for i, tx := range txs[:3] {
+		if pool.Get(tx.Hash()) == nil {
+			t.Errorf("priority transaction %d evicted: %v", i, pool.Eviction(tx.Hash()))
+		}
+	}
+	if pool.Get(txs[3].Hash()) != nil {
+		t.Errorf("plain transaction not evicted")
+	}
+	if eviction := pool.Eviction(txs[3].Hash()); eviction == nil || eviction.Reason != EvictUnderpriced {
+		t.Errorf("eviction reason mismatch: have %v, want %s", eviction, EvictUnderpriced)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that transactions can be dropped from the pool explicitly, and that the
+// reasons of evictions are tracked.
+func TestTransactionPoolDropAndEviction(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+
+	txs := types.Transactions{
+		pricedTransaction(0, 100000, big.NewInt(1), key),
+		pricedTransaction(1, 100000, big.NewInt(1), key),
+		pricedTransaction(2, 100000, big.NewInt(1), key),
+	}
+	pool.AddRemotes(txs)
+
+	// Replace the last transaction and drop the middle one
+	replacement := pricedTransaction(2, 100000, big.NewInt(2), key)
+	if err := pool.AddRemote(replacement); err != nil {
+		t.Fatalf("failed to replace transaction: %v", err)
+	}
+	if !pool.Drop(txs[1].Hash()) {
+		t.Fatalf("failed to drop pooled transaction")
+	}
+	if pool.Drop(txs[1].Hash()) {
+		t.Fatalf("dropped transaction twice")
+	}
+	if eviction := pool.Eviction(txs[1].Hash()); eviction == nil || eviction.Reason != EvictDropped {
+		t.Errorf("dropped eviction reason mismatch: have %v, want %s", eviction, EvictDropped)
+	}
+	if eviction := pool.Eviction(txs[2].Hash()); eviction == nil || eviction.Reason != EvictReplaced {
+		t.Errorf("replaced eviction reason mismatch: have %v, want %s", eviction, EvictReplaced)
+	}
+	if eviction := pool.Eviction(txs[0].Hash()); eviction != nil {
+		t.Errorf("pooled transaction reported evicted: %v", eviction)
+	}
+	// The transaction after the gap must have been moved back to the queue
+	pending, queued := pool.Stats()
+	if pending != 1 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 1)
+	}
+	if queued != 1 {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 1)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that remote transactions are journaled if enabled, and restored after a
+// restart unless they have outlived the configured lifetime.
+func TestTransactionRemoteJournaling(t *testing.T) {
+	t.Parallel()
+
+	// Create a temporary directory for the journals
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.Journal = filepath.Join(dir, "transactions.rlp")
+	config.RemoteJournal = filepath.Join(dir, "remotes.rlp")
+	config.RemoteLifetime = time.Hour
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	local, _ := crypto.GenerateKey()
+	remote, _ := crypto.GenerateKey()
+
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(remote.PublicKey), big.NewInt(1000000000))
+
+	if err := pool.AddLocal(pricedTransaction(0, 100000, big.NewInt(1), local)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if err := pool.AddRemote(pricedTransaction(0, 100000, big.NewInt(1), remote)); err != nil {
+		t.Fatalf("failed to add remote transaction: %v", err)
+	}
+	if err := pool.AddRemote(pricedTransaction(1, 100000, big.NewInt(1), remote)); err != nil {
+		t.Fatalf("failed to add remote transaction: %v", err)
+	}
+	pool.Stop()
+
+	// Restart the pool and ensure both locals and remotes survived
+	blockchain = &testBlockChain{statedb, 1000000, new(event.Feed)}
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+
+	if pending, _ := pool.Stats(); pending != 3 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 3)
+	}
+	if pool.locals.contains(crypto.PubkeyToAddress(remote.PublicKey)) {
+		t.Fatalf("restored remote account marked local")
+	}
+	remotes := pool.remote()
+	pool.Stop()
+
+	// Rewrite the remote journal with the first transaction being too old
+	journal := newTimedTxJournal(config.RemoteJournal, 0)
+	journal.times[remotes[crypto.PubkeyToAddress(remote.PublicKey)][1].Hash()] = uint64(time.Now().Add(-2 * time.Hour).Unix())
+	if err := journal.rotate(remotes); err != nil {
+		t.Fatalf("failed to rewrite remote journal: %v", err)
+	}
+	journal.close()
+
+	blockchain = &testBlockChain{statedb, 1000000, new(event.Feed)}
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	pending, queued := pool.Stats()
+	if pending != 2 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 2)
+	}
+	if queued != 0 {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 0)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // Benchmarks the speed of validating the contents of the pending queue of the
 // transaction pool.
 func BenchmarkPendingDemotion100(b *testing.B)   { benchmarkPendingDemotion(b, 100) }
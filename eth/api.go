@@ -294,6 +294,37 @@ func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// PrivateTxPoolAPI offers the transaction pool management methods exposed over
+// the private txpool endpoint.
+type PrivateTxPoolAPI struct {
+	eth *Ethereum
+}
+
+// NewPrivateTxPoolAPI creates a new API definition for the private transaction
+// pool methods of the Ethereum service.
+func NewPrivateTxPoolAPI(eth *Ethereum) *PrivateTxPoolAPI {
+	return &PrivateTxPoolAPI{eth: eth}
+}
+
+// Drop removes a transaction from the pool, returning whether it was pooled.
+// Any subsequent transactions of the same sender are moved back to the queue.
+func (api *PrivateTxPoolAPI) Drop(hash common.Hash) bool {
+	return api.eth.TxPool().Drop(hash)
+}
+
+// SetPriority marks or unmarks a pooled transaction as a priority one, which
+// exempts it from being pushed out by better priced transactions. It returns
+// whether the transaction was pooled.
+func (api *PrivateTxPoolAPI) SetPriority(hash common.Hash, priority bool) bool {
+	return api.eth.TxPool().SetPriority(hash, priority)
+}
+
+// Eviction returns why and when a transaction was recently evicted from the
+// pool, or null if it is still pooled or was never seen.
+func (api *PrivateTxPoolAPI) Eviction(hash common.Hash) *core.TxEviction {
+	return api.eth.TxPool().Eviction(hash)
+}
+
 // PublicDebugAPI is the collection of Ethereum full node APIs exposed
 // over the public debugging endpoint.
 type PublicDebugAPI struct {
@@ -375,7 +406,7 @@ func (api *PrivateDebugAPI) GetBadBlocks(ctx context.Context) ([]*BadBlockArgs,
 		} else {
 			results[i].RLP = fmt.Sprintf("0x%x", rlpBytes)
 		}
-		if results[i].Block, err = ethapi.RPCMarshalBlock(block, true, true); err != nil {
+		if results[i].Block, err = ethapi.RPCMarshalBlock(block, true, true, api.eth.Engine()); err != nil {
 			results[i].Block = map[string]interface{}{"error": err.Error()}
 		}
 	}
@@ -23,6 +23,7 @@ import (
 	"github.com/pocethereum/pochain/accounts"
 	"github.com/pocethereum/pochain/common"
 	"github.com/pocethereum/pochain/common/math"
+	"github.com/pocethereum/pochain/consensus"
 	"github.com/pocethereum/pochain/consensus/poc"
 	"github.com/pocethereum/pochain/consensus/poc/mortgage"
 	"github.com/pocethereum/pochain/core"
@@ -50,6 +51,10 @@ func (b *EthAPIBackend) ChainConfig() *params.ChainConfig {
 	return b.eth.chainConfig
 }
 
+func (b *EthAPIBackend) Engine() consensus.Engine {
+	return b.eth.engine
+}
+
 func (b *EthAPIBackend) CurrentBlock() *types.Block {
 	return b.eth.blockchain.CurrentBlock()
 }
@@ -73,15 +78,23 @@ func (b *EthAPIBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNum
 }
 
 func (b *EthAPIBackend) BlockPocByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.BlockPoc, error) {
+	engine, ok := b.eth.engine.(*poc.Poc)
+	if !ok {
+		return nil, nil
+	}
 	if block, _ := b.BlockByNumber(ctx, blockNr); block != nil {
-		return poc.CalcBlockPoc(block.Header()), nil
+		return engine.BlockPoc(block.Header()), nil
 	}
 	return nil, nil
 }
 
 func (b *EthAPIBackend) BlockPocByHash(ctx context.Context, blockHash common.Hash) (*types.BlockPoc, error) {
+	engine, ok := b.eth.engine.(*poc.Poc)
+	if !ok {
+		return nil, nil
+	}
 	if block, _ := b.GetBlock(ctx, blockHash); block != nil {
-		return poc.CalcBlockPoc(block.Header()), nil
+		return engine.BlockPoc(block.Header()), nil
 	}
 	return nil, nil
 }
@@ -101,7 +114,7 @@ func (b *EthAPIBackend) BlockByNumber(ctx context.Context, blockNr rpc.BlockNumb
 
 func (b *EthAPIBackend) GetBlockEarn(ctx context.Context, blockHash common.Hash) (map[string]*big.Int, error) {
 	block, err := b.GetBlock(ctx, blockHash)
-	if err != nil {
+	if block == nil || err != nil {
 		return nil, err
 	}
 
@@ -123,11 +136,29 @@ func (b *EthAPIBackend) GetBlockEarn(ctx context.Context, blockHash common.Hash)
 	}
 
 	res := make(map[string]*big.Int)
-	blockstate, err := state.New(block.Header().Root, state.NewDatabase(b.ChainDb()))
-	reward := mortgage.CalcReward(block.Coinbase(), block.Nonce(), blockstate)
-	res["reward"] = reward
 	res["txfees"] = txfees
 
+	// The reward is only ever credited during finalization, so it is exactly the
+	// growth of the total rewarded between the parent and the block state. Omit
+	// it if either state is no longer available.
+	if block.NumberU64() == 0 {
+		res["reward"] = new(big.Int)
+		return res, nil
+	}
+	parent := b.eth.blockchain.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return res, nil
+	}
+	parentState, err := b.eth.blockchain.StateAt(parent.Root)
+	if err != nil {
+		return res, nil
+	}
+	blockState, err := b.eth.blockchain.StateAt(block.Root())
+	if err != nil {
+		return res, nil
+	}
+	res["reward"] = new(big.Int).Sub(mortgage.GetTotalRewarded(blockState), mortgage.GetTotalRewarded(parentState))
+
 	return res, nil
 }
 
@@ -146,6 +177,15 @@ func (b *EthAPIBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.
 	return stateDb, header, err
 }
 
+func (b *EthAPIBackend) StateAndHeaderByHash(ctx context.Context, blockHash common.Hash) (*state.StateDB, *types.Header, error) {
+	header := b.eth.blockchain.GetHeaderByHash(blockHash)
+	if header == nil {
+		return nil, nil, nil
+	}
+	stateDb, err := b.eth.BlockChain().StateAt(header.Root)
+	return stateDb, header, err
+}
+
 func (b *EthAPIBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
 	return b.eth.blockchain.GetBlockByHash(hash), nil
 }
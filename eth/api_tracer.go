@@ -28,6 +28,7 @@ import (
 
 	"github.com/pocethereum/pochain/common"
 	"github.com/pocethereum/pochain/common/hexutil"
+	"github.com/pocethereum/pochain/common/math"
 	"github.com/pocethereum/pochain/core"
 	"github.com/pocethereum/pochain/core/rawdb"
 	"github.com/pocethereum/pochain/core/state"
@@ -60,6 +61,14 @@ type TraceConfig struct {
 	Reexec  *uint64
 }
 
+// TraceCallConfig is the config for traceCall API. It holds one more field to
+// override the state and the block context the call runs in.
+type TraceCallConfig struct {
+	TraceConfig
+	StateOverrides *ethapi.StateOverride
+	BlockOverrides *ethapi.BlockOverrides
+}
+
 // txTraceResult is the result of a single transaction trace.
 type txTraceResult struct {
 	Result interface{} `json:"result,omitempty"` // Trace results produced by the tracer
@@ -554,6 +563,56 @@ func (api *PrivateDebugAPI) TraceTransaction(ctx context.Context, hash common.Ha
 	return api.traceTx(ctx, msg, vmctx, statedb, config)
 }
 
+// TraceCall lets you trace a given eth_call. It collects the structured logs
+// created during the execution of EVM if the given transaction was added on
+// top of the provided block and returns them as a JSON object.
+func (api *PrivateDebugAPI) TraceCall(ctx context.Context, args ethapi.CallArgs, number rpc.BlockNumber, config *TraceCallConfig) (interface{}, error) {
+	// Retrieve the block and the state to run the call on top of
+	var (
+		block   *types.Block
+		statedb *state.StateDB
+		err     error
+	)
+	if number == rpc.PendingBlockNumber {
+		block, statedb = api.eth.miner.Pending()
+	} else {
+		switch number {
+		case rpc.LatestBlockNumber:
+			block = api.eth.blockchain.CurrentBlock()
+		default:
+			block = api.eth.blockchain.GetBlockByNumber(uint64(number))
+		}
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", number)
+		}
+		reexec := defaultTraceReexec
+		if config != nil && config.Reexec != nil {
+			reexec = *config.Reexec
+		}
+		statedb, err = api.computeStateDB(block, reexec)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if block == nil || statedb == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	// Fund the sender like eth_call does, then apply the requested overrides
+	msg := args.ToMessage()
+	statedb.SetBalance(msg.From(), math.MaxBig256)
+
+	vmctx := core.NewEVMContext(msg, block.Header(), api.eth.blockchain, nil)
+	var traceConfig *TraceConfig
+	if config != nil {
+		if err := config.StateOverrides.Apply(statedb); err != nil {
+			return nil, err
+		}
+		config.BlockOverrides.Apply(&vmctx)
+		traceConfig = &config.TraceConfig
+	}
+	return api.traceTx(ctx, msg, vmctx, statedb, traceConfig)
+}
+
 // traceTx configures a new tracer according to the provided configuration, and
 // executes the given message in the provided environment. The return value will
 // be tracer dependent.
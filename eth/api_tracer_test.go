@@ -0,0 +1,100 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/common/hexutil"
+	"github.com/pocethereum/pochain/consensus/ethash"
+	"github.com/pocethereum/pochain/core"
+	"github.com/pocethereum/pochain/core/vm"
+	"github.com/pocethereum/pochain/ethdb"
+	"github.com/pocethereum/pochain/internal/ethapi"
+	"github.com/pocethereum/pochain/params"
+	"github.com/pocethereum/pochain/rpc"
+)
+
+// Tests that calls are traced on top of the requested block with the state
+// overrides applied, without the overrides leaking into the chain state.
+func TestTraceCallStateOverrides(t *testing.T) {
+	var (
+		db       = ethdb.NewMemDatabase()
+		contract = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		gspec    = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				// Returns the value of storage slot 0
+				contract: {
+					Code:    common.FromHex("60005460005260206000f3"),
+					Storage: map[common.Hash]common.Hash{{}: common.BigToHash(big.NewInt(1))},
+					Balance: new(big.Int),
+				},
+			},
+		}
+		genesis = gspec.MustCommit(db)
+	)
+	blockchain, _ := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer blockchain.Stop()
+
+	chain, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 2, nil)
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	api := NewPrivateDebugAPI(gspec.Config, &Ethereum{blockchain: blockchain, chainDb: db})
+
+	var (
+		diff = map[common.Hash]common.Hash{{}: common.BigToHash(big.NewInt(2))}
+		code = hexutil.Bytes(common.FromHex("602a60005260206000f3")) // Returns 42
+	)
+	tests := []struct {
+		number    rpc.BlockNumber
+		overrides *ethapi.StateOverride
+		want      int64
+	}{
+		{rpc.LatestBlockNumber, &ethapi.StateOverride{contract: {StateDiff: &diff}}, 2},
+		{1, &ethapi.StateOverride{contract: {Code: &code}}, 42},
+		{rpc.LatestBlockNumber, nil, 1},
+		{1, nil, 1},
+	}
+	for i, tt := range tests {
+		args := ethapi.CallArgs{From: common.HexToAddress("0x2000000000000000000000000000000000000002"), To: &contract}
+		res, err := api.TraceCall(context.Background(), args, tt.number, &TraceCallConfig{StateOverrides: tt.overrides})
+		if err != nil {
+			t.Fatalf("test %d: failed to trace call: %v", i, err)
+		}
+		result, ok := res.(*ethapi.ExecutionResult)
+		if !ok {
+			t.Fatalf("test %d: unexpected trace result type %T", i, res)
+		}
+		if result.Failed || len(result.StructLogs) == 0 {
+			t.Errorf("test %d: call failed or not traced: %+v", i, result)
+		}
+		if want := fmt.Sprintf("%x", common.BigToHash(big.NewInt(tt.want))); result.ReturnValue != want {
+			t.Errorf("test %d: return value mismatch: have %s, want %s", i, result.ReturnValue, want)
+		}
+	}
+	// Conflicting overrides are rejected
+	overrides := &ethapi.StateOverride{contract: {State: &diff, StateDiff: &diff}}
+	if _, err := api.TraceCall(context.Background(), ethapi.CallArgs{To: &contract}, rpc.LatestBlockNumber, &TraceCallConfig{StateOverrides: overrides}); err == nil {
+		t.Error("trace with both state and state diff succeeded")
+	}
+}
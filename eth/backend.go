@@ -22,10 +22,12 @@ import (
 	"fmt"
 	"math/big"
 	"runtime"
+	"strings"
 	"sync"
 	"sync/atomic"
 
 	"github.com/pocethereum/pochain/accounts"
+	"github.com/pocethereum/pochain/accounts/abi/bind"
 	"github.com/pocethereum/pochain/common"
 	"github.com/pocethereum/pochain/common/hexutil"
 	"github.com/pocethereum/pochain/consensus"
@@ -36,6 +38,7 @@ import (
 	"github.com/pocethereum/pochain/core"
 	"github.com/pocethereum/pochain/core/bloombits"
 	"github.com/pocethereum/pochain/core/rawdb"
+	"github.com/pocethereum/pochain/core/state/pruner"
 	"github.com/pocethereum/pochain/core/types"
 	"github.com/pocethereum/pochain/core/vm"
 	"github.com/pocethereum/pochain/eth/downloader"
@@ -47,6 +50,7 @@ import (
 	"github.com/pocethereum/pochain/log"
 	"github.com/pocethereum/pochain/minedev"
 	"github.com/pocethereum/pochain/miner"
+	"github.com/pocethereum/pochain/miner/pool"
 	"github.com/pocethereum/pochain/node"
 	"github.com/pocethereum/pochain/p2p"
 	"github.com/pocethereum/pochain/params"
@@ -59,6 +63,8 @@ type LesServer interface {
 	Stop()
 	Protocols() []p2p.Protocol
 	SetBloomBitsIndexer(bbIndexer *core.ChainIndexer)
+	SetContractBackend(bind.ContractBackend)
+	APIs() []rpc.API
 }
 
 // Ethereum implements the Ethereum full node service.
@@ -88,6 +94,7 @@ type Ethereum struct {
 	APIBackend *EthAPIBackend
 
 	miner     *miner.Miner
+	pool      *pool.Pool
 	plotter   *plotter.Plotter
 	gasPrice  *big.Int
 	etherbase common.Address
@@ -103,6 +110,19 @@ func (s *Ethereum) AddLesServer(ls LesServer) {
 	ls.SetBloomBitsIndexer(s.bloomIndexer)
 }
 
+// SetContractBackend sets a contract backend for the LES server, if any, to
+// access the checkpoint oracle contract through.
+func (s *Ethereum) SetContractBackend(backend bind.ContractBackend) {
+	if s.lesServer == nil {
+		return
+	}
+	s.lesServer.SetContractBackend(backend)
+}
+
+// plotterStorage is the plot database of the process wide plotter, shared by
+// all Ethereum services running in the process.
+var plotterStorage = new(minedev.Plot)
+
 // New creates a new Ethereum object (including the
 // initialisation of the common Ethereum object)
 func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
@@ -112,10 +132,14 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if !config.SyncMode.IsValid() {
 		return nil, fmt.Errorf("invalid sync mode %d", config.SyncMode)
 	}
-	chainDb, err := CreateDB(ctx, config, "chaindata")
+	chainDb, err := CreateChainDB(ctx, config, "chaindata")
 	if err != nil {
 		return nil, err
 	}
+	// Finish any interrupted offline state pruning before touching the state
+	if err := pruner.RecoverPruning(ctx.ResolvePath(""), chainDb); err != nil {
+		log.Error("Failed to recover state", "err", err)
+	}
 
 	chainConfig, genesisHash, genesisErr := core.SetupGenesisBlock(chainDb, config.Genesis)
 	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
@@ -166,18 +190,34 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
 	}
+	if config.TxPool.RemoteJournal != "" {
+		config.TxPool.RemoteJournal = ctx.ResolvePath(config.TxPool.RemoteJournal)
+	}
 	eth.txPool = core.NewTxPool(config.TxPool, eth.chainConfig, eth.blockchain)
 
 	if eth.protocolManager, err = NewProtocolManager(eth.chainConfig, config.SyncMode, config.NetworkId, eth.eventMux, eth.txPool, eth.engine, eth.blockchain, chainDb); err != nil {
 		return nil, err
 	}
+	eth.protocolManager.downloader.SetHeaderCheckFrequency(config.SealCheckFrequency)
 	eth.miner = miner.New(eth, eth.chainConfig, eth.EventMux(), eth.engine)
 	eth.miner.SetExtra(makeExtraData(config.ExtraData))
 
+	if config.Pool.Enabled {
+		poolDb, err := CreateDB(ctx, config, "poolledger")
+		if err != nil {
+			return nil, err
+		}
+		if eth.pool, err = pool.New(&config.Pool, eth, poolDb); err != nil {
+			return nil, err
+		}
+	}
+
 	//eth.plotter = plotter.New(eth.etherbase, eth.config.Ethash.PlotdataDir)
-	plotterStorage := &minedev.Plot{}
 	eth.plotter = plotter.GetPlotterInstance(plotterStorage, plotterStorage)
-	eth.plotter.Start()
+	if config.Ethash.PowMode != ethash.ModeTest {
+		// Test nodes mine pre-generated plots and leave the plot database alone
+		eth.plotter.Start()
+	}
 
 	eth.APIBackend = &EthAPIBackend{eth, nil}
 	gpoParams := config.GPO
@@ -218,17 +258,35 @@ func CreateDB(ctx *node.ServiceContext, config *Config, name string) (ethdb.Data
 	return db, nil
 }
 
+// CreateChainDB creates the chain database, attaching the freezer that moves
+// ancient chain segments out of it.
+func CreateChainDB(ctx *node.ServiceContext, config *Config, name string) (ethdb.Database, error) {
+	db, err := ctx.OpenDatabaseWithFreezer(name, config.DatabaseCache, config.DatabaseHandles, config.DatabaseFreezer, config.DatabaseFreezerThreshold)
+	if err != nil {
+		return nil, err
+	}
+	if db, ok := rawdb.KeyValueStore(db).(*ethdb.LDBDatabase); ok {
+		db.Meter("eth/db/chaindata/")
+	}
+	return db, nil
+}
+
 // CreateConsensusEngine creates the required type of consensus engine instance for an Ethereum service
 func CreateConsensusEngine(ctx *node.ServiceContext, config *ethash.Config, chainConfig *params.ChainConfig, db ethdb.Database) consensus.Engine {
 	// If proof-of-capacity is requested, set it up
 	if chainConfig.Poc != nil {
-		plotpaths := minedev.GetSettingPlotdirs()
-		if len(plotpaths) < 1 {
-			plotpaths = config.PlotdataDir
+		plotPaths := config.PlotPaths
+		if len(plotPaths) == 0 {
+			if plotdirs := minedev.GetSettingPlotdirs(); plotdirs != "" {
+				plotPaths = strings.Split(plotdirs, ",")
+			} else {
+				plotPaths = []string{config.PlotdataDir}
+			}
 		}
-		chainConfig.Poc.PlotPaths = plotpaths
-		chainConfig.Poc.PlotpathsUpdater = minedev.PlotpathsUpdater
-		return poc.New(chainConfig.Poc)
+		if config.PowMode == ethash.ModeTest {
+			return poc.NewTester(chainConfig.Poc, plotPaths)
+		}
+		return poc.New(chainConfig.Poc, plotPaths)
 	}
 	// If proof-of-authority is requested, set it up
 	if chainConfig.Clique != nil {
@@ -267,6 +325,15 @@ func (s *Ethereum) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
+	// Append the mining pool APIs if the node runs a pool
+	if s.pool != nil {
+		apis = append(apis, s.pool.APIs()...)
+	}
+	// Append the LES server APIs if light clients are served
+	if s.lesServer != nil {
+		apis = append(apis, s.lesServer.APIs()...)
+	}
+
 	// Append all the local APIs and return
 	return append(apis, []rpc.API{
 		{
@@ -298,6 +365,10 @@ func (s *Ethereum) APIs() []rpc.API {
 			Namespace: "admin",
 			Version:   "1.0",
 			Service:   NewPrivateAdminAPI(s),
+		}, {
+			Namespace: "txpool",
+			Version:   "1.0",
+			Service:   NewPrivateTxPoolAPI(s),
 		}, {
 			Namespace: "debug",
 			Version:   "1.0",
@@ -313,10 +384,13 @@ func (s *Ethereum) APIs() []rpc.API {
 			Service:   s.netRPCService,
 			Public:    true,
 		}, {
-			"minedev",
-			"1.0",
-			minedev.New(s),
-			true,
+			// Served by test nodes as well: they configure their plot paths,
+			// so the device API never repoints the engine away from the
+			// pre-generated plots they mine.
+			Namespace: "minedev",
+			Version:   "1.0",
+			Service:   minedev.New(s, len(s.config.Ethash.PlotPaths) > 0),
+			Public:    true,
 		},
 	}...)
 }
@@ -385,6 +459,7 @@ func (s *Ethereum) StartMining(local bool) error {
 func (s *Ethereum) StopMining()               { s.miner.Stop() }
 func (s *Ethereum) IsMining() bool            { return s.miner.Mining() }
 func (s *Ethereum) Miner() *miner.Miner       { return s.miner }
+func (s *Ethereum) Pool() *pool.Pool          { return s.pool }
 func (s *Ethereum) IsPloting() bool           { return s.plotter.IsPlotting() }
 func (s *Ethereum) Plotter() *plotter.Plotter { return s.plotter }
 
@@ -426,10 +501,15 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 		maxPeers -= s.config.LightPeers
 	}
 	// Start the networking layer and the light server if requested
+	s.protocolManager.reputation = srvr
 	s.protocolManager.Start(maxPeers)
+	s.protocolManager.startENRUpdater(srvr)
 	if s.lesServer != nil {
 		s.lesServer.Start(srvr)
 	}
+	if s.pool != nil {
+		s.pool.Start()
+	}
 	return nil
 }
 
@@ -442,6 +522,9 @@ func (s *Ethereum) Stop() error {
 	if s.lesServer != nil {
 		s.lesServer.Stop()
 	}
+	if s.pool != nil {
+		s.pool.Stop()
+	}
 	s.txPool.Stop()
 	s.miner.Stop()
 	s.plotter.Stop()
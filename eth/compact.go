@@ -0,0 +1,291 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru"
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/core/types"
+	"github.com/pocethereum/pochain/log"
+	"github.com/pocethereum/pochain/p2p"
+)
+
+const (
+	compactTxTimeout  = 500 * time.Millisecond // Time allowance for a peer to deliver the missing transactions
+	maxCompactPending = 16                     // Maximum number of compact blocks awaiting missing transactions
+	maxCompactRelayed = 16                     // Number of relayed blocks kept around to serve transaction requests
+	maxCompactTxFetch = 1024                   // Maximum number of transactions requested or served per compact block
+)
+
+var (
+	errCompactUncles  = errors.New("compact block uncles not matching header")
+	errCompactTxCount = errors.New("wrong number of compact block transactions delivered")
+	errCompactTimeout = errors.New("compact block transactions timed out")
+)
+
+// shortTxID identifies a transaction of a compact block by the leading bytes of
+// its hash. Colliding IDs are caught by the transaction root check of the
+// reconstructed block, which is then fetched in full instead.
+type shortTxID [8]byte
+
+// txShortID returns the short ID of the transaction with the given hash.
+func txShortID(hash common.Hash) (id shortTxID) {
+	copy(id[:], hash[:])
+	return id
+}
+
+// newCompactBlock creates the compact propagation packet of a block.
+func newCompactBlock(block *types.Block, td *big.Int) *compactBlockData {
+	txs := block.Transactions()
+	ids := make([]shortTxID, len(txs))
+	for i, tx := range txs {
+		ids[i] = txShortID(tx.Hash())
+	}
+	return &compactBlockData{
+		Header: block.Header(),
+		Uncles: block.Uncles(),
+		TxIDs:  ids,
+		TD:     td,
+	}
+}
+
+// pendingCompact is a compact block waiting for the transactions which weren't
+// found in the local pool.
+type pendingCompact struct {
+	peer     *peer
+	packet   *compactBlockData
+	txs      []*types.Transaction // Transactions of the block, nil where still missing
+	missing  []uint64             // Positions of the requested transactions
+	received time.Time            // Arrival time of the compact block
+	timer    *time.Timer          // Timer falling back to a full fetch if the peer doesn't deliver
+}
+
+// compactRelay tracks the compact blocks being reconstructed, along with the
+// blocks recently propagated compactly so their transactions can be served.
+type compactRelay struct {
+	lock    sync.Mutex
+	pending map[common.Hash]*pendingCompact
+	relayed *lru.Cache
+
+	index     map[shortTxID]*types.Transaction // Short IDs of the pooled transactions
+	indexHead common.Hash                      // Chain head the index was last rebuilt at
+}
+
+func newCompactRelay() *compactRelay {
+	relayed, _ := lru.New(maxCompactRelayed)
+	return &compactRelay{
+		pending: make(map[common.Hash]*pendingCompact),
+		relayed: relayed,
+	}
+}
+
+// relayedBlock retrieves a block which was propagated compactly, falling back to
+// the chain for blocks already evicted from the relay cache.
+func (pm *ProtocolManager) relayedBlock(hash common.Hash) *types.Block {
+	if block, ok := pm.compact.relayed.Get(hash); ok {
+		return block.(*types.Block)
+	}
+	return pm.blockchain.GetBlockByHash(hash)
+}
+
+// indexTxs adds the transactions newly entering the pool to the short ID index,
+// unless it wasn't built yet.
+func (c *compactRelay) indexTxs(txs []*types.Transaction) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.index == nil {
+		return
+	}
+	for _, tx := range txs {
+		c.index[txShortID(tx.Hash())] = tx
+	}
+}
+
+// poolIndex maps the short IDs of the pending pool transactions to the
+// transactions themselves.
+func (pm *ProtocolManager) poolIndex() map[shortTxID]*types.Transaction {
+	index := make(map[shortTxID]*types.Transaction)
+
+	pending, err := pm.txpool.Pending()
+	if err != nil {
+		log.Error("Failed to retrieve pending transactions", "err", err)
+		return index
+	}
+	for _, batch := range pending {
+		for _, tx := range batch {
+			index[txShortID(tx.Hash())] = tx
+		}
+	}
+	return index
+}
+
+// lookupPoolTxs resolves the short IDs of a compact block against the pool,
+// returning the transactions found and the positions of the missing ones. The
+// index is rebuilt from the pool whenever the chain head changes and extended
+// with the transactions entering the pool in between.
+func (pm *ProtocolManager) lookupPoolTxs(ids []shortTxID) ([]*types.Transaction, []uint64) {
+	head := pm.blockchain.CurrentBlock().Hash()
+
+	pm.compact.lock.Lock()
+	defer pm.compact.lock.Unlock()
+
+	if pm.compact.index == nil || pm.compact.indexHead != head {
+		pm.compact.index, pm.compact.indexHead = pm.poolIndex(), head
+	}
+	var (
+		txs     = make([]*types.Transaction, len(ids))
+		missing []uint64
+	)
+	for i, id := range ids {
+		if tx, ok := pm.compact.index[id]; ok {
+			txs[i] = tx
+		} else {
+			missing = append(missing, uint64(i))
+		}
+	}
+	return txs, missing
+}
+
+// handleCompactBlock tries to reconstruct a compactly propagated block from the
+// local transaction pool, requesting the missing transactions from the peer.
+func (pm *ProtocolManager) handleCompactBlock(p *peer, packet *compactBlockData, received time.Time) {
+	var (
+		hash   = packet.Header.Hash()
+		number = packet.Header.Number.Uint64()
+	)
+	p.MarkBlock(hash)
+	if pm.blockchain.HasBlock(hash, number) {
+		return
+	}
+	if types.CalcUncleHash(packet.Uncles) != packet.Header.UncleHash {
+		pm.penalizePeer(p.id, p2p.PenaltyUseless, errCompactUncles)
+		return
+	}
+	// Fill in the transactions known locally
+	txs, missing := pm.lookupPoolTxs(packet.TxIDs)
+	if len(missing) == 0 {
+		pm.completeCompactBlock(p, packet, txs, received)
+		return
+	}
+	if len(missing) > maxCompactTxFetch {
+		pm.fetchFullBlock(p, packet.Header)
+		return
+	}
+	// Some transactions are unknown, request them unless the block is already
+	// being reconstructed or too many blocks are
+	pm.compact.lock.Lock()
+	if _, ok := pm.compact.pending[hash]; ok {
+		pm.compact.lock.Unlock()
+		return
+	}
+	if len(pm.compact.pending) >= maxCompactPending {
+		pm.compact.lock.Unlock()
+		pm.fetchFullBlock(p, packet.Header)
+		return
+	}
+	pm.compact.pending[hash] = &pendingCompact{
+		peer:     p,
+		packet:   packet,
+		txs:      txs,
+		missing:  missing,
+		received: received,
+		timer: time.AfterFunc(compactTxTimeout, func() {
+			if pm.dropPendingCompact(hash) != nil {
+				p.Log().Debug("Compact block transactions timed out", "number", number, "hash", hash)
+				pm.penalizePeer(p.id, p2p.PenaltyTimeout, errCompactTimeout)
+				pm.fetchFullBlock(p, packet.Header)
+			}
+		}),
+	}
+	pm.compact.lock.Unlock()
+
+	p.Log().Trace("Requesting compact block transactions", "number", number, "hash", hash, "missing", len(missing), "total", len(txs))
+	if err := p.RequestBlockTxs(hash, missing); err != nil {
+		pm.dropPendingCompact(hash)
+	}
+}
+
+// handleBlockTxs completes the reconstruction of a compact block with the
+// missing transactions delivered by the peer.
+func (pm *ProtocolManager) handleBlockTxs(p *peer, packet *blockTxsData) {
+	pm.compact.lock.Lock()
+	pending := pm.compact.pending[packet.Hash]
+	if pending == nil || pending.peer != p {
+		pm.compact.lock.Unlock()
+		return // Late delivery after a timeout, or not requested at all
+	}
+	delete(pm.compact.pending, packet.Hash)
+	pm.compact.lock.Unlock()
+	pending.timer.Stop()
+
+	// A short response means the peer doesn't have the block any more or hit
+	// its response size limit, more transactions than requested are useless
+	if len(packet.Txs) != len(pending.missing) {
+		if len(packet.Txs) > len(pending.missing) {
+			pm.penalizePeer(p.id, p2p.PenaltyUseless, errCompactTxCount)
+		}
+		pm.fetchFullBlock(p, pending.packet.Header)
+		return
+	}
+	for i, tx := range packet.Txs {
+		pending.txs[pending.missing[i]] = tx
+	}
+	pm.completeCompactBlock(p, pending.packet, pending.txs, pending.received)
+}
+
+// dropPendingCompact removes a compact block from the reconstruction set,
+// returning it if it was still pending.
+func (pm *ProtocolManager) dropPendingCompact(hash common.Hash) *pendingCompact {
+	pm.compact.lock.Lock()
+	defer pm.compact.lock.Unlock()
+
+	pending := pm.compact.pending[hash]
+	if pending != nil {
+		pending.timer.Stop()
+		delete(pm.compact.pending, hash)
+	}
+	return pending
+}
+
+// completeCompactBlock assembles a block from its compact form and the full
+// list of its transactions, importing it like a fully propagated block. Blocks
+// not matching their header are fetched in full.
+func (pm *ProtocolManager) completeCompactBlock(p *peer, packet *compactBlockData, txs []*types.Transaction, received time.Time) {
+	if types.DeriveSha(types.Transactions(txs)) != packet.Header.TxHash {
+		p.Log().Debug("Compact block reconstruction failed", "number", packet.Header.Number, "hash", packet.Header.Hash())
+		pm.fetchFullBlock(p, packet.Header)
+		return
+	}
+	for _, tx := range txs {
+		p.MarkTransaction(tx.Hash())
+	}
+	block := types.NewBlockWithHeader(packet.Header).WithBody(txs, packet.Uncles)
+	block.ReceivedAt = received
+	pm.importPropagatedBlock(p, block, packet.TD)
+}
+
+// fetchFullBlock falls back to retrieving a block announced compactly through
+// the regular announcement based fetcher.
+func (pm *ProtocolManager) fetchFullBlock(p *peer, header *types.Header) {
+	pm.fetcher.Notify(p.id, header.Hash(), header.Number.Uint64(), time.Now(), p.RequestOneHeader, p.RequestBodies)
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/pocethereum/pochain/core"
 	"github.com/pocethereum/pochain/eth/downloader"
 	"github.com/pocethereum/pochain/eth/gasprice"
+	"github.com/pocethereum/pochain/miner/pool"
 	"github.com/pocethereum/pochain/params"
 )
 
@@ -43,14 +44,16 @@ var DefaultConfig = Config{
 		DatasetsInMem:  1,
 		DatasetsOnDisk: 2,
 	},
-	NetworkId:     101,
-	LightPeers:    100,
-	DatabaseCache: 768,
-	TrieCache:     256,
-	TrieTimeout:   60 * time.Minute,
-	GasPrice:      big.NewInt(18 * params.Shannon),
+	NetworkId:          101,
+	SealCheckFrequency: 100,
+	LightPeers:         100,
+	DatabaseCache:      768,
+	TrieCache:          256,
+	TrieTimeout:        60 * time.Minute,
+	GasPrice:           big.NewInt(18 * params.Shannon),
 
 	TxPool: core.DefaultTxPoolConfig,
+	Pool:   pool.DefaultConfig,
 	GPO: gasprice.Config{
 		Blocks:     20,
 		Percentile: 60,
@@ -83,17 +86,32 @@ type Config struct {
 	SyncMode  downloader.SyncMode
 	NoPruning bool
 
+	// Verify the PoC seal of one in this many historical headers during fast
+	// and light sync, the headers close to the head are always verified.
+	SealCheckFrequency int `toml:",omitempty"`
+
 	// Light client options
 	LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightPeers int `toml:",omitempty"` // Maximum number of LES client peers
 
+	// Checkpoint is a hardcoded checkpoint which can be nil.
+	Checkpoint *params.TrustedCheckpoint `toml:",omitempty"`
+
+	// CheckpointOracle is the configuration for checkpoint oracle.
+	CheckpointOracle *params.CheckpointOracleConfig `toml:",omitempty"`
+
 	// Database options
 	SkipBcVersionCheck bool `toml:"-"`
 	DatabaseHandles    int  `toml:"-"`
 	DatabaseCache      int
+	DatabaseFreezer    string `toml:",omitempty"` // Directory of the ancient store (default = inside the chain database)
 	TrieCache          int
 	TrieTimeout        time.Duration
 
+	// Number of recent blocks kept in the chain database before older ones are
+	// moved into the ancient store (0 = params.ImmutabilityThreshold)
+	DatabaseFreezerThreshold uint64 `toml:",omitempty"`
+
 	// Mining-related options
 	Etherbase    common.Address `toml:",omitempty"`
 	MinerThreads int            `toml:",omitempty"`
@@ -106,6 +124,9 @@ type Config struct {
 	// Transaction pool options
 	TxPool core.TxPoolConfig
 
+	// Mining pool options
+	Pool pool.Config
+
 	// Gas Price Oracle options
 	GPO gasprice.Config
 
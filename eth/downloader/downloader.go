@@ -33,6 +33,7 @@ import (
 	"github.com/pocethereum/pochain/event"
 	"github.com/pocethereum/pochain/log"
 	"github.com/pocethereum/pochain/metrics"
+	"github.com/pocethereum/pochain/p2p"
 	"github.com/pocethereum/pochain/params"
 )
 
@@ -103,6 +104,8 @@ type Downloader struct {
 	rttEstimate   uint64 // Round trip time to target for download requests
 	rttConfidence uint64 // Confidence in the estimated RTT (unit: millionths to allow atomic ops)
 
+	checkFreq int // Seal verification frequency of historical headers during fast and light sync
+
 	// Statistics
 	syncStatsChainOrigin uint64 // Origin block number where syncing started at
 	syncStatsChainHeight uint64 // Highest block number known when syncing started
@@ -113,7 +116,10 @@ type Downloader struct {
 	blockchain BlockChain
 
 	// Callbacks
-	dropPeer peerDropFn // Drops a peer for misbehaving
+	dropPeer peerDropFn    // Drops a peer for misbehaving
+	penalize peerPenaltyFn // Reports the misbehaviour of dropped peers (optional)
+
+	snapSyncer SnapSyncer // Retrieves the state in ranges during snap sync (optional)
 
 	// Status
 	synchroniseMock func(id string, hash common.Hash) error // Replacement for synchronise during testing
@@ -171,6 +177,14 @@ type LightChain interface {
 	Rollback([]common.Hash)
 }
 
+// SnapSyncer retrieves the state of a block in ranges, leaving the missing parts
+// to be healed by the trie node sync. Subtries present in the database after a
+// range sync must be complete.
+type SnapSyncer interface {
+	// Sync retrieves the state with the given root until done or canceled.
+	Sync(root common.Hash, cancel <-chan struct{}) error
+}
+
 // BlockChain encapsulates functions required to sync a (full or fast) blockchain.
 type BlockChain interface {
 	LightChain
@@ -211,6 +225,7 @@ func New(mode SyncMode, stateDb ethdb.Database, mux *event.TypeMux, chain BlockC
 		peers:          newPeerSet(),
 		rttEstimate:    uint64(rttMaxEstimate),
 		rttConfidence:  uint64(1000000),
+		checkFreq:      fsHeaderCheckFrequency,
 		blockchain:     chain,
 		lightchain:     lightchain,
 		dropPeer:       dropPeer,
@@ -249,7 +264,7 @@ func (d *Downloader) Progress() ethereum.SyncProgress {
 	switch d.mode {
 	case FullSync:
 		current = d.blockchain.CurrentBlock().NumberU64()
-	case FastSync:
+	case FastSync, SnapSync:
 		current = d.blockchain.CurrentFastBlock().NumberU64()
 	case LightSync:
 		current = d.lightchain.CurrentHeader().Number.Uint64()
@@ -263,6 +278,44 @@ func (d *Downloader) Progress() ethereum.SyncProgress {
 	}
 }
 
+// SetHeaderCheckFrequency sets the frequency of the seal verifications done on
+// historical headers during fast and light sync, one in freq random headers
+// being verified. Non-positive values restore the default.
+func (d *Downloader) SetHeaderCheckFrequency(freq int) {
+	if freq <= 0 {
+		freq = fsHeaderCheckFrequency
+	}
+	d.checkFreq = freq
+}
+
+// SetPenaltyHook sets the callback reporting the misbehaviour of dropped peers
+// to the peer reputation system.
+func (d *Downloader) SetPenaltyHook(penalize peerPenaltyFn) {
+	d.penalize = penalize
+}
+
+// SetSnapSyncer sets the range syncer retrieving the bulk of the state in snap
+// sync mode. Without one, snap sync retrieves the state like fast sync.
+func (d *Downloader) SetSnapSyncer(syncer SnapSyncer) {
+	d.snapSyncer = syncer
+}
+
+// reportPeer reports the misbehaviour a peer is dropped for, weighing invalid
+// chains heavier than useless data and timeouts.
+func (d *Downloader) reportPeer(id string, reason error) {
+	if d.penalize == nil {
+		return
+	}
+	points := p2p.PenaltyUseless
+	switch reason {
+	case errTimeout, errStallingPeer:
+		points = p2p.PenaltyTimeout
+	case errInvalidChain:
+		points = p2p.PenaltyInvalid
+	}
+	d.penalize(id, points, reason)
+}
+
 // Synchronising returns whether the downloader is currently retrieving blocks.
 func (d *Downloader) Synchronising() bool {
 	return atomic.LoadInt32(&d.synchronising) > 0
@@ -328,6 +381,7 @@ func (d *Downloader) Synchronise(id string, head common.Hash, td *big.Int, mode
 			// Timeouts can occur if e.g. compaction hits at the wrong time, and can be ignored
 			log.Warn("Downloader wants to drop peer, but peerdrop-function is not set", "peer", id)
 		} else {
+			d.reportPeer(id, err)
 			d.dropPeer(id)
 		}
 	default:
@@ -440,7 +494,7 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 
 	// Ensure our origin point is below any fast sync pivot point
 	pivot := uint64(0)
-	if d.mode == FastSync {
+	if d.mode == FastSync || d.mode == SnapSync {
 		if height <= uint64(fsMinFullBlocks) {
 			origin = 0
 		} else {
@@ -451,7 +505,7 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 		}
 	}
 	d.committed = 1
-	if d.mode == FastSync && pivot != 0 {
+	if (d.mode == FastSync || d.mode == SnapSync) && pivot != 0 {
 		d.committed = 0
 	}
 	// Initiate the sync using a concurrent header and content retrieval algorithm
@@ -466,7 +520,7 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, td *big.I
 		func() error { return d.fetchReceipts(origin + 1) },        // Receipts are retrieved during fast sync
 		func() error { return d.processHeaders(origin+1, pivot, td) },
 	}
-	if d.mode == FastSync {
+	if d.mode == FastSync || d.mode == SnapSync {
 		fetchers = append(fetchers, func() error { return d.processFastSyncContent(latest) })
 	} else if d.mode == FullSync {
 		fetchers = append(fetchers, d.processFullSyncContent)
@@ -595,7 +649,7 @@ func (d *Downloader) findAncestor(p *peerConnection, height uint64) (uint64, err
 
 	if d.mode == FullSync {
 		ceil = d.blockchain.CurrentBlock().NumberU64()
-	} else if d.mode == FastSync {
+	} else if d.mode == FastSync || d.mode == SnapSync {
 		ceil = d.blockchain.CurrentFastBlock().NumberU64()
 	}
 	if ceil >= MaxForkAncestry {
@@ -866,6 +920,7 @@ func (d *Downloader) fetchHeaders(p *peerConnection, from uint64, pivot uint64)
 			// Header retrieval timed out, consider the peer bad and drop
 			p.log.Debug("Header request timed out", "elapsed", ttl)
 			headerTimeoutMeter.Mark(1)
+			d.reportPeer(p.id, errTimeout)
 			d.dropPeer(p.id)
 
 			// Finish the sync gracefully instead of dumping the gathered data though
@@ -1086,6 +1141,7 @@ func (d *Downloader) fetchParts(errCancel error, deliveryCh chan dataPack, deliv
 							// Timeouts can occur if e.g. compaction hits at the wrong time, and can be ignored
 							peer.log.Warn("Downloader wants to drop peer, but peerdrop-function is not set", "peer", pid)
 						} else {
+							d.reportPeer(pid, errStallingPeer)
 							d.dropPeer(pid)
 						}
 					}
@@ -1158,6 +1214,14 @@ func (d *Downloader) fetchParts(errCancel error, deliveryCh chan dataPack, deliv
 // keeps processing and scheduling them into the header chain and downloader's
 // queue until the stream ends or a failure occurs.
 func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) error {
+	// Headers are fully verified from a bit before the pivot in fast sync, or
+	// the remote head in light sync, only sampled seal checks are done below.
+	head := pivot
+	if d.mode == LightSync {
+		d.syncStatsLock.RLock()
+		head = d.syncStatsChainHeight
+		d.syncStatsLock.RUnlock()
+	}
 	// Keep a count of uncertain headers to roll back
 	rollback := []*types.Header{}
 	defer func() {
@@ -1228,7 +1292,7 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 				// This check cannot be executed "as is" for full imports, since blocks may still be
 				// queued for processing when the header download completes. However, as long as the
 				// peer gave us something useful, we're already happy/progressed (above check).
-				if d.mode == FastSync || d.mode == LightSync {
+				if d.mode == FastSync || d.mode == SnapSync || d.mode == LightSync {
 					head := d.lightchain.CurrentHeader()
 					if td.Cmp(d.lightchain.GetTd(head.Hash(), head.Number.Uint64())) > 0 {
 						return errStallingPeer
@@ -1256,7 +1320,7 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 				chunk := headers[:limit]
 
 				// In case of header only syncing, validate the chunk immediately
-				if d.mode == FastSync || d.mode == LightSync {
+				if d.mode == FastSync || d.mode == SnapSync || d.mode == LightSync {
 					// Collect the yet unknown headers to mark them as uncertain
 					unknown := make([]*types.Header, 0, len(headers))
 					for _, header := range chunk {
@@ -1265,8 +1329,8 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 						}
 					}
 					// If we're importing pure headers, verify based on their recentness
-					frequency := fsHeaderCheckFrequency
-					if chunk[len(chunk)-1].Number.Uint64()+uint64(fsHeaderForceVerify) > pivot {
+					frequency := d.checkFreq
+					if chunk[len(chunk)-1].Number.Uint64()+uint64(fsHeaderForceVerify) > head {
 						frequency = 1
 					}
 					if n, err := d.lightchain.InsertHeaderChain(chunk, frequency); err != nil {
@@ -1284,7 +1348,7 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, td *big.Int) er
 					}
 				}
 				// Unless we're doing light chains, schedule the headers for associated content retrieval
-				if d.mode == FullSync || d.mode == FastSync {
+				if d.mode == FullSync || d.mode == FastSync || d.mode == SnapSync {
 					// If we've reached the allowed number of pending headers, stall a bit
 					for d.queue.PendingBlocks() >= maxQueuedHeaders || d.queue.PendingReceipts() >= maxQueuedHeaders {
 						select {
@@ -212,7 +212,8 @@ func (d *Downloader) runStateSync(s *stateSync) *stateSync {
 // stateSync schedules requests for downloading a particular state trie defined
 // by a given state root.
 type stateSync struct {
-	d *Downloader // Downloader instance to access and manage current peerset
+	d    *Downloader // Downloader instance to access and manage current peerset
+	root common.Hash // State root being synced
 
 	sched  *trie.Sync                 // State trie sync scheduler defining the tasks
 	keccak hash.Hash                  // Keccak256 hasher to verify deliveries with
@@ -239,6 +240,7 @@ type stateTask struct {
 func newStateSync(d *Downloader, root common.Hash) *stateSync {
 	return &stateSync{
 		d:       d,
+		root:    root,
 		sched:   state.NewStateSync(root, d.stateDB),
 		keccak:  sha3.NewKeccak256(),
 		tasks:   make(map[common.Hash]*stateTask),
@@ -252,10 +254,44 @@ func newStateSync(d *Downloader, root common.Hash) *stateSync {
 // it finishes, and finally notifying any goroutines waiting for the loop to
 // finish.
 func (s *stateSync) run() {
+	if s.d.mode == SnapSync && s.d.snapSyncer != nil {
+		if err := s.syncRanges(); err != nil {
+			s.err = err
+			close(s.done)
+			return
+		}
+	}
 	s.err = s.loop()
 	close(s.done)
 }
 
+// syncRanges retrieves the bulk of the state through the range syncer, leaving
+// only the missing parts for the trie node sync to heal.
+func (s *stateSync) syncRanges() error {
+	cancel := make(chan struct{})
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-s.cancel:
+		case <-s.d.cancelCh:
+		case <-finished:
+		}
+		close(cancel)
+	}()
+	if err := s.d.snapSyncer.Sync(s.root, cancel); err != nil {
+		select {
+		case <-cancel:
+			return errCancelStateFetch
+		default:
+		}
+		log.Warn("State range sync failed, healing", "err", err)
+	}
+	// Reschedule the trie node sync, skipping the retrieved subtries
+	s.sched = state.NewStateSync(s.root, s.d.stateDB)
+	return nil
+}
+
 // Wait blocks until the sync is done or canceled.
 func (s *stateSync) Wait() error {
 	<-s.done
@@ -310,6 +346,7 @@ func (s *stateSync) loop() (err error) {
 				// 2 items are the minimum requested, if even that times out, we've no use of
 				// this peer at the moment.
 				log.Warn("Stalling state sync, dropping peer", "peer", req.peer.id)
+				s.d.reportPeer(req.peer.id, errStallingPeer)
 				s.d.dropPeer(req.peer.id)
 			}
 			// Process all the received blobs and check for stale delivery
@@ -25,6 +25,10 @@ import (
 // peerDropFn is a callback type for dropping a peer detected as malicious.
 type peerDropFn func(id string)
 
+// peerPenaltyFn is a callback type for reporting the misbehaviour a peer is
+// dropped for to the peer reputation system.
+type peerPenaltyFn func(id string, points int, reason error)
+
 // dataPack is a data message returned by a peer for some query.
 type dataPack interface {
 	PeerId() string
@@ -0,0 +1,85 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"github.com/pocethereum/pochain/core"
+	"github.com/pocethereum/pochain/core/forkid"
+	"github.com/pocethereum/pochain/p2p"
+	"github.com/pocethereum/pochain/p2p/enr"
+	"github.com/pocethereum/pochain/rlp"
+)
+
+// ethEntry is the "eth" ENR entry which advertises eth protocol
+// on the discovery network.
+type ethEntry struct {
+	ForkID forkid.ID // Fork identifier per EIP-2124
+
+	// Ignore additional fields (for forward compatibility).
+	Rest []rlp.RawValue `rlp:"tail"`
+}
+
+// ENRKey implements enr.Entry.
+func (e ethEntry) ENRKey() string {
+	return "eth"
+}
+
+// currentENREntry constructs an eth ENR entry from the current chain head.
+func (pm *ProtocolManager) currentENREntry() *ethEntry {
+	return &ethEntry{ForkID: forkid.NewID(pm.blockchain)}
+}
+
+// nodeFilter reports whether a node record is acceptable as a dial candidate.
+// Nodes not announcing an eth entry are accepted, as the record may simply
+// predate it; nodes announcing the fork ID of another chain are not.
+func (pm *ProtocolManager) nodeFilter(r *enr.Record) bool {
+	var entry ethEntry
+	if err := r.Load(&entry); err != nil {
+		return enr.IsNotFound(err)
+	}
+	return pm.forkFilter(entry.ForkID) == nil
+}
+
+// startENRUpdater adds the eth entry to the local node record of the server
+// and keeps it up to date as the chain passes fork blocks.
+func (pm *ProtocolManager) startENRUpdater(srv *p2p.Server) {
+	current := pm.currentENREntry()
+	srv.SetRecordEntry(current)
+
+	headCh := make(chan core.ChainHeadEvent, 10)
+	sub := pm.blockchain.SubscribeChainHeadEvent(headCh)
+
+	pm.wg.Add(1)
+	go func() {
+		defer pm.wg.Done()
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case <-headCh:
+				if next := pm.currentENREntry(); next.ForkID != current.ForkID {
+					current = next
+					srv.SetRecordEntry(current)
+				}
+			case <-sub.Err():
+				return
+			case <-pm.quitSync:
+				return
+			}
+		}
+	}()
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/pocethereum/pochain/consensus"
 	"github.com/pocethereum/pochain/core/types"
 	"github.com/pocethereum/pochain/log"
+	"github.com/pocethereum/pochain/p2p"
 	"gopkg.in/karalabe/cookiejar.v2/collections/prque"
 )
 
@@ -67,6 +68,10 @@ type chainInsertFn func(types.Blocks) (int, error)
 // peerDropFn is a callback type for dropping a peer detected as malicious.
 type peerDropFn func(id string)
 
+// peerPenaltyFn is a callback type for reporting the misbehaviour a peer is
+// dropped for to the peer reputation system.
+type peerPenaltyFn func(id string, points int, reason error)
+
 // announce is the hash notification of the availability of a new block in the
 // network.
 type announce struct {
@@ -136,6 +141,7 @@ type Fetcher struct {
 	chainHeight    chainHeightFn      // Retrieves the current chain's height
 	insertChain    chainInsertFn      // Injects a batch of blocks into the chain
 	dropPeer       peerDropFn         // Drops a peer for misbehaving
+	penalize       peerPenaltyFn      // Reports the misbehaviour of dropped peers (optional)
 
 	// Testing hooks
 	announceChangeHook func(common.Hash, bool) // Method to call upon adding or deleting a hash from the announce list
@@ -172,6 +178,19 @@ func New(getBlock blockRetrievalFn, verifyHeader headerVerifierFn, broadcastBloc
 	}
 }
 
+// SetPenaltyHook sets the callback reporting the misbehaviour of dropped peers
+// to the peer reputation system. It must be called before Start.
+func (f *Fetcher) SetPenaltyHook(penalize peerPenaltyFn) {
+	f.penalize = penalize
+}
+
+// reportPeer reports the misbehaviour a peer is dropped for.
+func (f *Fetcher) reportPeer(id string, points int, reason error) {
+	if f.penalize != nil {
+		f.penalize(id, points, reason)
+	}
+}
+
 // Start boots up the announcement based synchroniser, accepting and processing
 // hash notifications and block fetches until termination requested.
 func (f *Fetcher) Start() {
@@ -450,6 +469,7 @@ func (f *Fetcher) loop() {
 					// If the delivered header does not match the promised number, drop the announcer
 					if header.Number.Uint64() != announce.number {
 						log.Trace("Invalid block number fetched", "peer", announce.origin, "hash", header.Hash(), "announced", announce.number, "provided", header.Number)
+						f.reportPeer(announce.origin, p2p.PenaltyUseless, consensus.ErrInvalidNumber)
 						f.dropPeer(announce.origin)
 						f.forgetHash(hash)
 						continue
@@ -662,6 +682,7 @@ func (f *Fetcher) insert(peer string, block *types.Block) {
 		default:
 			// Something went very wrong, drop the peer
 			log.Debug("Propagated block verification failed", "peer", peer, "number", block.Number(), "hash", hash, "err", err)
+			f.reportPeer(peer, p2p.PenaltyInvalid, err)
 			f.dropPeer(peer)
 			return
 		}
@@ -17,6 +17,7 @@
 package filters
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"math/big"
@@ -32,8 +33,10 @@ import (
 	"github.com/pocethereum/pochain/core/bloombits"
 	"github.com/pocethereum/pochain/core/rawdb"
 	"github.com/pocethereum/pochain/core/types"
+	"github.com/pocethereum/pochain/crypto"
 	"github.com/pocethereum/pochain/ethdb"
 	"github.com/pocethereum/pochain/event"
+	"github.com/pocethereum/pochain/internal/ethapi"
 	"github.com/pocethereum/pochain/params"
 	"github.com/pocethereum/pochain/rpc"
 )
@@ -265,6 +268,132 @@ func TestPendingTxFilter(t *testing.T) {
 	}
 }
 
+// TestPendingTxSubscription tests whether pending tx subscriptions deliver the
+// full transactions that are posted to the event mux.
+func TestPendingTxSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mux        = new(event.TypeMux)
+		db         = ethdb.NewMemDatabase()
+		txFeed     = new(event.Feed)
+		rmLogsFeed = new(event.Feed)
+		logsFeed   = new(event.Feed)
+		chainFeed  = new(event.Feed)
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		api        = NewPublicFilterAPI(backend, false)
+
+		transactions = []*types.Transaction{
+			types.NewTransaction(0, common.HexToAddress("0xb794f5ea0ba39494ce83a213fffba74279579268"), new(big.Int), 0, new(big.Int), nil),
+			types.NewTransaction(1, common.HexToAddress("0xb794f5ea0ba39494ce83a213fffba74279579268"), new(big.Int), 0, new(big.Int), nil),
+		}
+		txs = make(chan []*types.Transaction)
+		sub = api.events.SubscribePendingTxs(txs)
+	)
+	defer sub.Unsubscribe()
+
+	txFeed.Send(core.NewTxsEvent{Txs: transactions})
+
+	select {
+	case got := <-txs:
+		if len(got) != len(transactions) {
+			t.Fatalf("invalid number of transactions, want %d transactions(s), got %d", len(transactions), len(got))
+		}
+		for i := range got {
+			if got[i] != transactions[i] {
+				t.Errorf("txs[%d] invalid, want %x, got %x", i, transactions[i].Hash(), got[i].Hash())
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatal("pending transactions not delivered")
+	}
+}
+
+// TestNewPendingTransactions tests whether the newPendingTransactions RPC
+// subscription delivers the transaction hashes, or the full transactions if
+// requested.
+func TestNewPendingTransactions(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mux        = new(event.TypeMux)
+		db         = ethdb.NewMemDatabase()
+		txFeed     = new(event.Feed)
+		rmLogsFeed = new(event.Feed)
+		logsFeed   = new(event.Feed)
+		chainFeed  = new(event.Feed)
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		server     = rpc.NewServer()
+
+		key, _ = crypto.GenerateKey()
+		from   = crypto.PubkeyToAddress(key.PublicKey)
+		tx, _  = types.SignTx(types.NewTransaction(7, common.HexToAddress("0xb794f5ea0ba39494ce83a213fffba74279579268"), big.NewInt(1), 21000, big.NewInt(1), []byte{0x01}), types.HomesteadSigner{}, key)
+	)
+	if err := server.RegisterName("eth", NewPublicFilterAPI(backend, false)); err != nil {
+		t.Fatalf("failed to register filter API: %v", err)
+	}
+	defer server.Stop()
+
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	// post keeps posting the transaction until the subscription installed in
+	// the background delivers it
+	post := func() chan struct{} {
+		done := make(chan struct{})
+		go func() {
+			for {
+				txFeed.Send(core.NewTxsEvent{Txs: []*types.Transaction{tx}})
+				select {
+				case <-done:
+					return
+				case <-time.After(10 * time.Millisecond):
+				}
+			}
+		}()
+		return done
+	}
+	hashes := make(chan common.Hash, 16)
+	sub, err := client.EthSubscribe(context.Background(), hashes, "newPendingTransactions")
+	if err != nil {
+		t.Fatalf("failed to subscribe to hashes: %v", err)
+	}
+	done := post()
+	select {
+	case hash := <-hashes:
+		if hash != tx.Hash() {
+			t.Errorf("hash mismatch: have %x, want %x", hash, tx.Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("pending transaction hash not delivered")
+	}
+	close(done)
+	sub.Unsubscribe()
+
+	full := make(chan *ethapi.RPCTransaction, 16)
+	sub, err = client.EthSubscribe(context.Background(), full, "newPendingTransactions", true)
+	if err != nil {
+		t.Fatalf("failed to subscribe to full transactions: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	done = post()
+	defer close(done)
+
+	var have *ethapi.RPCTransaction
+	select {
+	case have = <-full:
+	case <-time.After(time.Second):
+		t.Fatal("pending transaction not delivered")
+	}
+	if have.Hash != tx.Hash() || have.From != from || uint64(have.Nonce) != tx.Nonce() || have.Value.ToInt().Cmp(tx.Value()) != 0 || !bytes.Equal(have.Input, tx.Data()) {
+		t.Errorf("transaction mismatch: have %+v, want %x from %x", have, tx.Hash(), from)
+	}
+	if have.BlockNumber != nil {
+		t.Errorf("pending transaction with block number %v", have.BlockNumber)
+	}
+}
+
 // TestLogFilterCreation test whether a given filter criteria makes sense.
 // If not it must return an error.
 func TestLogFilterCreation(t *testing.T) {
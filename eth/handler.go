@@ -30,9 +30,11 @@ import (
 	"github.com/pocethereum/pochain/consensus"
 	"github.com/pocethereum/pochain/consensus/misc"
 	"github.com/pocethereum/pochain/core"
+	"github.com/pocethereum/pochain/core/forkid"
 	"github.com/pocethereum/pochain/core/types"
 	"github.com/pocethereum/pochain/eth/downloader"
 	"github.com/pocethereum/pochain/eth/fetcher"
+	"github.com/pocethereum/pochain/eth/snap"
 	"github.com/pocethereum/pochain/ethdb"
 	"github.com/pocethereum/pochain/event"
 	"github.com/pocethereum/pochain/log"
@@ -63,10 +65,17 @@ func errResp(code errCode, format string, v ...interface{}) error {
 	return fmt.Errorf("%v - %v", code, fmt.Sprintf(format, v...))
 }
 
+// peerReputation is the reputation system misbehaving peers are reported to.
+type peerReputation interface {
+	Penalize(id discover.NodeID, points int, reason string)
+}
+
 type ProtocolManager struct {
-	networkId uint64
+	networkId  uint64
+	forkFilter forkid.Filter // Fork ID filter, constant across the lifetime of the node
 
 	fastSync  uint32 // Flag whether fast sync is enabled (gets disabled if we already have blocks)
+	snapSync  uint32 // Flag whether fast sync retrieves the state in ranges through the snap protocol
 	acceptTxs uint32 // Flag whether we're considered synchronised (enables transaction processing)
 
 	txpool      txPool
@@ -77,6 +86,9 @@ type ProtocolManager struct {
 	downloader *downloader.Downloader
 	fetcher    *fetcher.Fetcher
 	peers      *peerSet
+	compact    *compactRelay
+	snap       *snap.Handler
+	reputation peerReputation // Reputation system of the p2p server, nil if not running
 
 	SubProtocols []p2p.Protocol
 
@@ -102,29 +114,34 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 	// Create the protocol manager with the base fields
 	manager := &ProtocolManager{
 		networkId:   networkId,
+		forkFilter:  forkid.NewFilter(blockchain),
 		eventMux:    mux,
 		txpool:      txpool,
 		blockchain:  blockchain,
 		chainconfig: config,
 		peers:       newPeerSet(),
+		compact:     newCompactRelay(),
 		newPeerCh:   make(chan *peer),
 		noMorePeers: make(chan struct{}),
 		txsyncCh:    make(chan *txsync),
 		quitSync:    make(chan struct{}),
 	}
 	// Figure out whether to allow fast sync or not
-	if mode == downloader.FastSync && blockchain.CurrentBlock().NumberU64() > 0 {
+	if (mode == downloader.FastSync || mode == downloader.SnapSync) && blockchain.CurrentBlock().NumberU64() > 0 {
 		log.Warn("Blockchain not empty, fast sync disabled")
 		mode = downloader.FullSync
 	}
-	if mode == downloader.FastSync {
+	if mode == downloader.FastSync || mode == downloader.SnapSync {
 		manager.fastSync = uint32(1)
 	}
+	if mode == downloader.SnapSync {
+		manager.snapSync = uint32(1)
+	}
 	// Initiate a sub-protocol for every implemented version we can handle
 	manager.SubProtocols = make([]p2p.Protocol, 0, len(ProtocolVersions))
 	for i, version := range ProtocolVersions {
 		// Skip protocol version if incompatible with the mode of operation
-		if mode == downloader.FastSync && version < eth63 {
+		if (mode == downloader.FastSync || mode == downloader.SnapSync) && version < eth63 {
 			continue
 		}
 		// Compatible; initialise the sub-protocol
@@ -153,13 +170,22 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 				}
 				return nil
 			},
+			NodeFilter: manager.nodeFilter,
 		})
 	}
 	if len(manager.SubProtocols) == 0 {
 		return nil, errIncompatibleConfig
 	}
+	// Serve the local state through the snap protocol, which also retrieves the
+	// state during snap sync
+	manager.snap = snap.NewHandler(chaindb, blockchain.StateCache().TrieDB())
+	manager.snap.Syncer().SetPenaltyHook(manager.penalizePeer)
+	manager.SubProtocols = append(manager.SubProtocols, manager.snap.SubProtocols...)
+
 	// Construct the different synchronisation mechanisms
 	manager.downloader = downloader.New(mode, chaindb, manager.eventMux, blockchain, nil, manager.removePeer)
+	manager.downloader.SetPenaltyHook(manager.penalizePeer)
+	manager.downloader.SetSnapSyncer(manager.snap.Syncer())
 
 	validator := func(header *types.Header) error {
 		return engine.VerifyHeader(blockchain, header, true)
@@ -177,6 +203,7 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		return manager.blockchain.InsertChain(blocks)
 	}
 	manager.fetcher = fetcher.New(blockchain.GetBlockByHash, validator, manager.BroadcastBlock, heighter, inserter, manager.removePeer)
+	manager.fetcher.SetPenaltyHook(manager.penalizePeer)
 
 	return manager, nil
 }
@@ -200,6 +227,17 @@ func (pm *ProtocolManager) removePeer(id string) {
 	}
 }
 
+// penalizePeer reports the misbehaviour of a peer to the reputation system,
+// which bans the node once its penalties pile up.
+func (pm *ProtocolManager) penalizePeer(id string, points int, reason error) {
+	peer := pm.peers.Peer(id)
+	if peer == nil || pm.reputation == nil {
+		return
+	}
+	log.Debug("Penalizing Ethereum peer", "peer", id, "points", points, "reason", reason)
+	pm.reputation.Penalize(peer.ID(), points, reason.Error())
+}
+
 func (pm *ProtocolManager) Start(maxPeers int) {
 	pm.maxPeers = maxPeers
 
@@ -263,7 +301,7 @@ func (pm *ProtocolManager) handle(p *peer) error {
 		number  = head.Number.Uint64()
 		td      = pm.blockchain.GetTd(hash, number)
 	)
-	if err := p.Handshake(pm.networkId, td, hash, genesis.Hash()); err != nil {
+	if err := p.Handshake(pm.networkId, td, hash, genesis.Hash(), forkid.NewID(pm.blockchain), pm.forkFilter); err != nil {
 		p.Log().Debug("Ethereum handshake failed", "err", err)
 		return err
 	}
@@ -638,30 +676,65 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			return errResp(ErrDecode, "%v: %v", msg, err)
 		}
 		request.Block.ReceivedAt = msg.ReceivedAt
-		request.Block.ReceivedFrom = p
+		pm.importPropagatedBlock(p, request.Block, request.TD)
 
-		// Mark the peer as owning the block and schedule it for import
-		p.MarkBlock(request.Block.Hash())
-		pm.fetcher.Enqueue(p.id, request.Block)
+	case p.version >= eth100 && msg.Code == NewCompactBlockMsg:
+		// Retrieve and decode the compactly propagated block, then reconstruct it
+		var request compactBlockData
+		if err := msg.Decode(&request); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		if request.Header == nil || request.TD == nil {
+			return errResp(ErrDecode, "%v: missing header or total difficulty", msg)
+		}
+		pm.handleCompactBlock(p, &request, msg.ReceivedAt)
 
-		// Assuming the block is importable by the peer, but possibly not yet done so,
-		// calculate the head hash and TD that the peer truly must have.
+	case p.version >= eth100 && msg.Code == GetBlockTxsMsg:
+		// Decode the transaction request of a compact block
+		var request getBlockTxsData
+		if err := msg.Decode(&request); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		if len(request.Indexes) > maxCompactTxFetch {
+			return errResp(ErrMsgTooLarge, "%v: %d transactions requested > %d", msg, len(request.Indexes), maxCompactTxFetch)
+		}
+		requested := make(map[uint64]struct{}, len(request.Indexes))
+		for _, index := range request.Indexes {
+			if _, ok := requested[index]; ok {
+				return errResp(ErrDecode, "%v: duplicate transaction index %d", msg, index)
+			}
+			requested[index] = struct{}{}
+		}
+		// Gather the requested transactions until reaching the response size
+		// limit, answering with an empty list if the block is unknown or the
+		// request is out of bounds
 		var (
-			trueHead = request.Block.ParentHash()
-			trueTD   = new(big.Int).Sub(request.TD, request.Block.Difficulty())
+			txs   []*types.Transaction
+			bytes int
 		)
-		// Update the peers total difficulty if better than the previous
-		if _, td := p.Head(); trueTD.Cmp(td) > 0 {
-			p.SetHead(trueHead, trueTD)
-
-			// Schedule a sync if above ours. Note, this will not fire a sync for a gap of
-			// a singe block (as the true TD is below the propagated block), however this
-			// scenario should easily be covered by the fetcher.
-			currentBlock := pm.blockchain.CurrentBlock()
-			if trueTD.Cmp(pm.blockchain.GetTd(currentBlock.Hash(), currentBlock.NumberU64())) > 0 {
-				go pm.synchronise(p)
+		if block := pm.relayedBlock(request.Hash); block != nil {
+			all := block.Transactions()
+			for _, index := range request.Indexes {
+				if index >= uint64(len(all)) {
+					txs = nil
+					break
+				}
+				if bytes >= softResponseLimit {
+					break
+				}
+				txs = append(txs, all[index])
+				bytes += int(all[index].Size())
 			}
 		}
+		return p.SendBlockTxs(request.Hash, txs)
+
+	case p.version >= eth100 && msg.Code == BlockTxsMsg:
+		// Transactions of a compact block arrived, complete the block
+		var request blockTxsData
+		if err := msg.Decode(&request); err != nil {
+			return errResp(ErrDecode, "%v: %v", msg, err)
+		}
+		pm.handleBlockTxs(p, &request)
 
 	case msg.Code == TxMsg:
 		// Transactions arrived, make sure we have a valid and fresh chain to handle them
@@ -688,6 +761,35 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	return nil
 }
 
+// importPropagatedBlock schedules a block propagated by a peer for import and
+// updates the head of the peer, starting a sync if the peer is ahead of us.
+func (pm *ProtocolManager) importPropagatedBlock(p *peer, block *types.Block, td *big.Int) {
+	block.ReceivedFrom = p
+
+	// Mark the peer as owning the block and schedule it for import
+	p.MarkBlock(block.Hash())
+	pm.fetcher.Enqueue(p.id, block)
+
+	// Assuming the block is importable by the peer, but possibly not yet done so,
+	// calculate the head hash and TD that the peer truly must have.
+	var (
+		trueHead = block.ParentHash()
+		trueTD   = new(big.Int).Sub(td, block.Difficulty())
+	)
+	// Update the peers total difficulty if better than the previous
+	if _, td := p.Head(); trueTD.Cmp(td) > 0 {
+		p.SetHead(trueHead, trueTD)
+
+		// Schedule a sync if above ours. Note, this will not fire a sync for a gap of
+		// a singe block (as the true TD is below the propagated block), however this
+		// scenario should easily be covered by the fetcher.
+		currentBlock := pm.blockchain.CurrentBlock()
+		if trueTD.Cmp(pm.blockchain.GetTd(currentBlock.Hash(), currentBlock.NumberU64())) > 0 {
+			go pm.synchronise(p)
+		}
+	}
+}
+
 // BroadcastBlock will either propagate a block to a subset of it's peers, or
 // will only announce it's availability (depending what's requested).
 func (pm *ProtocolManager) BroadcastBlock(block *types.Block, propagate bool) {
@@ -704,12 +806,26 @@ func (pm *ProtocolManager) BroadcastBlock(block *types.Block, propagate bool) {
 			log.Error("Propagating dangling block", "number", block.Number(), "hash", hash)
 			return
 		}
-		// Send the block to a subset of our peers
-		transfer := peers[:int(math.Sqrt(float64(len(peers))))]
+		// Send the block compactly to all peers able to reconstruct it from their
+		// pools, keeping it around to serve their transaction requests, and in full
+		// to a subset of the rest
+		pm.compact.relayed.Add(hash, block)
+
+		var legacy []*peer
+		compact := 0
+		for _, peer := range peers {
+			if peer.version >= eth100 {
+				peer.AsyncSendCompactBlock(block, td)
+				compact++
+			} else {
+				legacy = append(legacy, peer)
+			}
+		}
+		transfer := legacy[:int(math.Sqrt(float64(len(legacy))))]
 		for _, peer := range transfer {
 			peer.AsyncSendNewBlock(block, td)
 		}
-		log.Trace("Propagated block", "hash", hash, "recipients", len(transfer), "duration", common.PrettyDuration(time.Since(block.ReceivedAt)))
+		log.Trace("Propagated block", "hash", hash, "compact", compact, "recipients", len(transfer), "duration", common.PrettyDuration(time.Since(block.ReceivedAt)))
 		return
 	}
 	// Otherwise if the block is indeed in out own chain, announce it
@@ -756,6 +872,7 @@ func (pm *ProtocolManager) txBroadcastLoop() {
 	for {
 		select {
 		case event := <-pm.txsCh:
+			pm.compact.indexTxs(event.Txs)
 			pm.BroadcastTxs(event.Txs)
 
 		// Err() channel will be closed when unsubscribing.
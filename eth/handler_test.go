@@ -17,6 +17,7 @@
 package eth
 
 import (
+	"errors"
 	"math"
 	"math/big"
 	"math/rand"
@@ -26,6 +27,7 @@ import (
 	"github.com/pocethereum/pochain/common"
 	"github.com/pocethereum/pochain/consensus/ethash"
 	"github.com/pocethereum/pochain/core"
+	"github.com/pocethereum/pochain/core/forkid"
 	"github.com/pocethereum/pochain/core/state"
 	"github.com/pocethereum/pochain/core/types"
 	"github.com/pocethereum/pochain/core/vm"
@@ -34,6 +36,8 @@ import (
 	"github.com/pocethereum/pochain/ethdb"
 	"github.com/pocethereum/pochain/event"
 	"github.com/pocethereum/pochain/p2p"
+	"github.com/pocethereum/pochain/p2p/discover"
+	"github.com/pocethereum/pochain/p2p/enr"
 	"github.com/pocethereum/pochain/params"
 )
 
@@ -45,8 +49,8 @@ func TestProtocolCompatibility(t *testing.T) {
 		mode       downloader.SyncMode
 		compatible bool
 	}{
-		{61, downloader.FullSync, true}, {62, downloader.FullSync, true}, {63, downloader.FullSync, true},
-		{61, downloader.FastSync, false}, {62, downloader.FastSync, false}, {63, downloader.FastSync, true},
+		{61, downloader.FullSync, true}, {62, downloader.FullSync, true}, {63, downloader.FullSync, true}, {64, downloader.FullSync, true},
+		{61, downloader.FastSync, false}, {62, downloader.FastSync, false}, {63, downloader.FastSync, true}, {64, downloader.FastSync, true},
 	}
 	// Make sure anything we screw up is restored
 	backup := ProtocolVersions
@@ -69,6 +73,7 @@ func TestProtocolCompatibility(t *testing.T) {
 // Tests that block headers can be retrieved from a remote chain based on user queries.
 func TestGetBlockHeaders62(t *testing.T) { testGetBlockHeaders(t, 62) }
 func TestGetBlockHeaders63(t *testing.T) { testGetBlockHeaders(t, 63) }
+func TestGetBlockHeaders64(t *testing.T) { testGetBlockHeaders(t, 64) }
 
 func testGetBlockHeaders(t *testing.T, protocol int) {
 	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, downloader.MaxHashFetch+15, nil, nil)
@@ -228,6 +233,7 @@ func testGetBlockHeaders(t *testing.T, protocol int) {
 // Tests that block contents can be retrieved from a remote chain based on their hashes.
 func TestGetBlockBodies62(t *testing.T) { testGetBlockBodies(t, 62) }
 func TestGetBlockBodies63(t *testing.T) { testGetBlockBodies(t, 63) }
+func TestGetBlockBodies64(t *testing.T) { testGetBlockBodies(t, 64) }
 
 func testGetBlockBodies(t *testing.T, protocol int) {
 	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, downloader.MaxBlockFetch+15, nil, nil)
@@ -299,6 +305,7 @@ func testGetBlockBodies(t *testing.T, protocol int) {
 
 // Tests that the node state database can be retrieved based on hashes.
 func TestGetNodeData63(t *testing.T) { testGetNodeData(t, 63) }
+func TestGetNodeData64(t *testing.T) { testGetNodeData(t, 64) }
 
 func testGetNodeData(t *testing.T, protocol int) {
 	// Define three accounts to simulate transactions with
@@ -391,6 +398,7 @@ func testGetNodeData(t *testing.T, protocol int) {
 
 // Tests that the transaction receipts can be retrieved based on hashes.
 func TestGetReceipt63(t *testing.T) { testGetReceipt(t, 63) }
+func TestGetReceipt64(t *testing.T) { testGetReceipt(t, 64) }
 
 func testGetReceipt(t *testing.T, protocol int) {
 	// Define three accounts to simulate transactions with
@@ -520,3 +528,261 @@ func testDAOChallenge(t *testing.T, localForked, remoteForked bool, timeout bool
 		}
 	}
 }
+
+// Tests that dial candidates are filtered by the fork ID in their eth entry.
+func TestNodeFilter(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	var none, own, foreign enr.Record
+	own.Set(pm.currentENREntry())
+	foreign.Set(&ethEntry{ForkID: forkid.ID{Hash: [4]byte{0x00, 0x01, 0x02, 0x03}}})
+
+	if !pm.nodeFilter(&none) {
+		t.Error("node without eth entry rejected")
+	}
+	if !pm.nodeFilter(&own) {
+		t.Error("node on the local chain rejected")
+	}
+	if pm.nodeFilter(&foreign) {
+		t.Error("node on another chain accepted")
+	}
+}
+
+// reputationRecorder records the penalties reported to it.
+type reputationRecorder struct {
+	ids    []discover.NodeID
+	points []int
+}
+
+func (r *reputationRecorder) Penalize(id discover.NodeID, points int, reason string) {
+	r.ids = append(r.ids, id)
+	r.points = append(r.points, points)
+}
+
+// Tests that penalties of connected peers are reported to the reputation system.
+func TestPenalizePeer(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	rep := new(reputationRecorder)
+	pm.reputation = rep
+
+	peer, _ := newTestPeer("peer", eth63, pm, true)
+	defer peer.close()
+
+	for i := 0; pm.peers.Peer(peer.peer.id) == nil; i++ {
+		if i == 100 {
+			t.Fatal("peer not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	pm.penalizePeer("unknown", p2p.PenaltyInvalid, errors.New("invalid seal"))
+	pm.penalizePeer(peer.peer.id, p2p.PenaltyInvalid, errors.New("invalid seal"))
+
+	if len(rep.ids) != 1 || rep.ids[0] != peer.peer.ID() || rep.points[0] != p2p.PenaltyInvalid {
+		t.Errorf("wrong penalties reported: %v %v", rep.ids, rep.points)
+	}
+}
+
+// newCompactTestBlock creates a block on top of the genesis block of the protocol
+// manager, containing the given number of transactions.
+func newCompactTestBlock(pm *ProtocolManager, db ethdb.Database, txs int) (*types.Block, *big.Int) {
+	genesis := pm.blockchain.Genesis()
+	blocks, _ := core.GenerateChain(pm.chainconfig, genesis, ethash.NewFaker(), db, 1, func(i int, block *core.BlockGen) {
+		for j := 0; j < txs; j++ {
+			tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(testBank), common.Address{byte(j)}, big.NewInt(1000), params.TxGas, nil, nil), types.HomesteadSigner{}, testBankKey)
+			block.AddTx(tx)
+		}
+	})
+	td := new(big.Int).Add(pm.blockchain.GetTd(genesis.Hash(), 0), blocks[0].Difficulty())
+	return blocks[0], td
+}
+
+// Tests that propagated blocks are sent compactly to eth/100 peers and in full to
+// older ones, and that the transactions of compact blocks are served.
+func TestCompactBlockPropagation(t *testing.T) {
+	pm, db := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	compact, _ := newTestPeer("compact", eth100, pm, true)
+	defer compact.close()
+	legacy, _ := newTestPeer("legacy", eth64, pm, true)
+	defer legacy.close()
+
+	for i := 0; pm.peers.Len() < 2; i++ {
+		if i == 100 {
+			t.Fatal("peers not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	block, td := newCompactTestBlock(pm, db, 3)
+	pm.BroadcastBlock(block, true)
+
+	if err := p2p.ExpectMsg(compact.app, NewCompactBlockMsg, newCompactBlock(block, td)); err != nil {
+		t.Fatalf("compact block mismatch: %v", err)
+	}
+	if err := p2p.ExpectMsg(legacy.app, NewBlockMsg, &newBlockData{Block: block, TD: td}); err != nil {
+		t.Fatalf("full block mismatch: %v", err)
+	}
+	// Request some of the transactions, then an out of bounds one
+	p2p.Send(compact.app, GetBlockTxsMsg, &getBlockTxsData{Hash: block.Hash(), Indexes: []uint64{2, 0}})
+	want := &blockTxsData{Hash: block.Hash(), Txs: []*types.Transaction{block.Transactions()[2], block.Transactions()[0]}}
+	if err := p2p.ExpectMsg(compact.app, BlockTxsMsg, want); err != nil {
+		t.Fatalf("transactions mismatch: %v", err)
+	}
+	p2p.Send(compact.app, GetBlockTxsMsg, &getBlockTxsData{Hash: block.Hash(), Indexes: []uint64{3}})
+	if err := p2p.ExpectMsg(compact.app, BlockTxsMsg, &blockTxsData{Hash: block.Hash()}); err != nil {
+		t.Fatalf("out of bounds request not answered empty: %v", err)
+	}
+}
+
+// Tests that compact blocks are reconstructed from the transaction pool, and
+// that only the missing transactions are requested.
+func TestCompactBlockReconstruction(t *testing.T) {
+	pm, db := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	block, td := newCompactTestBlock(pm, db, 3)
+	txs := block.Transactions()
+	pm.txpool.AddRemotes(types.Transactions{txs[0], txs[2]})
+
+	peer, _ := newTestPeer("peer", eth100, pm, true)
+	defer peer.close()
+
+	// Skip the initial transaction sync, then propagate the block
+	if err := p2p.ExpectMsg(peer.app, TxMsg, types.Transactions{txs[0], txs[2]}); err != nil {
+		t.Fatalf("transaction sync mismatch: %v", err)
+	}
+	p2p.Send(peer.app, NewCompactBlockMsg, newCompactBlock(block, td))
+	if err := p2p.ExpectMsg(peer.app, GetBlockTxsMsg, &getBlockTxsData{Hash: block.Hash(), Indexes: []uint64{1}}); err != nil {
+		t.Fatalf("transaction request mismatch: %v", err)
+	}
+	p2p.Send(peer.app, BlockTxsMsg, &blockTxsData{Hash: block.Hash(), Txs: []*types.Transaction{txs[1]}})
+
+	for i := 0; pm.blockchain.CurrentBlock().Hash() != block.Hash(); i++ {
+		if i == 100 {
+			t.Fatal("reconstructed block not imported")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// Tests that compact blocks which can't be reconstructed are fetched in full.
+func TestCompactBlockFallback(t *testing.T) {
+	pm, db := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	block, td := newCompactTestBlock(pm, db, 2)
+
+	peer, _ := newTestPeer("peer", eth100, pm, true)
+	defer peer.close()
+
+	p2p.Send(peer.app, NewCompactBlockMsg, newCompactBlock(block, td))
+	if err := p2p.ExpectMsg(peer.app, GetBlockTxsMsg, &getBlockTxsData{Hash: block.Hash(), Indexes: []uint64{0, 1}}); err != nil {
+		t.Fatalf("transaction request mismatch: %v", err)
+	}
+	// Deliver the transactions in the wrong order, failing the root check
+	txs := block.Transactions()
+	p2p.Send(peer.app, BlockTxsMsg, &blockTxsData{Hash: block.Hash(), Txs: []*types.Transaction{txs[1], txs[0]}})
+
+	query := &getBlockHeadersData{Origin: hashOrNumber{Hash: block.Hash()}, Amount: 1}
+	if err := p2p.ExpectMsg(peer.app, GetBlockHeadersMsg, query); err != nil {
+		t.Fatalf("full block not fetched: %v", err)
+	}
+}
+
+// Tests that transaction requests of compact blocks with duplicate or too many
+// indexes are rejected, and that responses are capped by the soft size limit.
+func TestCompactBlockTxsLimits(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	// Relay a block with transactions too large to be served all at once
+	var txs []*types.Transaction
+	for i := 0; i < 25; i++ {
+		txs = append(txs, newTestTransaction(testBankKey, uint64(i), 100*1024))
+	}
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1)}, txs, nil, nil)
+	pm.compact.relayed.Add(block.Hash(), block)
+
+	indexes := make([]uint64, len(txs))
+	for i := range indexes {
+		indexes[i] = uint64(i)
+	}
+	var (
+		want  []*types.Transaction
+		bytes int
+	)
+	for _, tx := range txs {
+		if bytes >= softResponseLimit {
+			break
+		}
+		want = append(want, tx)
+		bytes += int(tx.Size())
+	}
+	if len(want) == len(txs) {
+		t.Fatalf("test transactions fit into a single response")
+	}
+	peer, _ := newTestPeer("peer", eth100, pm, true)
+	defer peer.close()
+
+	p2p.Send(peer.app, GetBlockTxsMsg, &getBlockTxsData{Hash: block.Hash(), Indexes: indexes})
+	if err := p2p.ExpectMsg(peer.app, BlockTxsMsg, &blockTxsData{Hash: block.Hash(), Txs: want}); err != nil {
+		t.Fatalf("capped response mismatch: %v", err)
+	}
+	// Make sure malformed requests get the peer dropped
+	tests := []struct {
+		name    string
+		indexes []uint64
+	}{
+		{"duplicate", []uint64{1, 0, 1}},
+		{"oversized", make([]uint64, maxCompactTxFetch+1)},
+	}
+	for _, tt := range tests {
+		peer, errc := newTestPeer(tt.name, eth100, pm, true)
+		p2p.Send(peer.app, GetBlockTxsMsg, &getBlockTxsData{Hash: block.Hash(), Indexes: tt.indexes})
+
+		select {
+		case err := <-errc:
+			if err == nil {
+				t.Errorf("%s: peer dropped without error", tt.name)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("%s: peer not dropped", tt.name)
+		}
+		peer.close()
+	}
+}
+
+// Tests that the short ID index of the pool is kept across compact blocks and
+// extended with the transactions entering the pool.
+func TestCompactPoolIndex(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	pooled := newTestTransaction(testBankKey, 0, 0)
+	pm.txpool.AddRemotes([]*types.Transaction{pooled})
+
+	arrived := newTestTransaction(testBankKey, 1, 0)
+	ids := []shortTxID{txShortID(pooled.Hash()), txShortID(arrived.Hash())}
+	if _, missing := pm.lookupPoolTxs(ids); len(missing) != 1 || missing[0] != 1 {
+		t.Fatalf("missing transactions mismatch: have %v, want [1]", missing)
+	}
+	// Announce a transaction without adding it to the pool, it can only be
+	// found if the index isn't rebuilt
+	pm.txpool.(*testTxPool).txFeed.Send(core.NewTxsEvent{Txs: []*types.Transaction{arrived}})
+	for i := 0; ; i++ {
+		txs, missing := pm.lookupPoolTxs(ids)
+		if len(missing) == 0 {
+			if txs[0] != pooled || txs[1] != arrived {
+				t.Fatalf("transactions mismatch")
+			}
+			break
+		}
+		if i == 100 {
+			t.Fatal("arrived transaction not indexed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/pocethereum/pochain/common"
 	"github.com/pocethereum/pochain/core"
+	"github.com/pocethereum/pochain/core/forkid"
 	"github.com/pocethereum/pochain/core/types"
 	"github.com/pocethereum/pochain/event"
 	"github.com/pocethereum/pochain/rlp"
@@ -32,16 +33,22 @@ import (
 const (
 	eth62 = 62
 	eth63 = 63
+	eth64 = 64
+
+	// eth100 adds the PoC specific compact block relay. It is numbered well past
+	// the upstream eth versions so that it never gets negotiated with a node
+	// speaking an unrelated upstream protocol of the same number.
+	eth100 = 100
 )
 
 // ProtocolName is the official short name of the protocol used during capability negotiation.
 var ProtocolName = "eth"
 
 // ProtocolVersions are the supported versions of the eth protocol (first is primary).
-var ProtocolVersions = []uint{eth63, eth62}
+var ProtocolVersions = []uint{eth100, eth64, eth63, eth62}
 
 // ProtocolLengths are the number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = []uint64{17, 8}
+var ProtocolLengths = []uint64{20, 17, 17, 8}
 
 const ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
 
@@ -62,6 +69,11 @@ const (
 	NodeDataMsg    = 0x0e
 	GetReceiptsMsg = 0x0f
 	ReceiptsMsg    = 0x10
+
+	// Protocol messages belonging to eth/100
+	NewCompactBlockMsg = 0x11
+	GetBlockTxsMsg     = 0x12
+	BlockTxsMsg        = 0x13
 )
 
 type errCode int
@@ -76,6 +88,7 @@ const (
 	ErrNoStatusMsg
 	ErrExtraStatusMsg
 	ErrSuspendedPeer
+	ErrForkIDRejected
 )
 
 func (e errCode) String() string {
@@ -93,6 +106,7 @@ var errorToString = map[int]string{
 	ErrNoStatusMsg:             "No status message",
 	ErrExtraStatusMsg:          "Extra status message",
 	ErrSuspendedPeer:           "Suspended peer",
+	ErrForkIDRejected:          "Fork ID rejected",
 }
 
 type txPool interface {
@@ -108,8 +122,8 @@ type txPool interface {
 	SubscribeNewTxsEvent(chan<- core.NewTxsEvent) event.Subscription
 }
 
-// statusData is the network packet for the status message.
-type statusData struct {
+// statusData63 is the network packet for the status message for eth/63.
+type statusData63 struct {
 	ProtocolVersion uint32
 	NetworkId       uint64
 	TD              *big.Int
@@ -117,6 +131,16 @@ type statusData struct {
 	GenesisBlock    common.Hash
 }
 
+// statusData is the network packet for the status message for eth/64 and later.
+type statusData struct {
+	ProtocolVersion uint32
+	NetworkID       uint64
+	TD              *big.Int
+	Head            common.Hash
+	Genesis         common.Hash
+	ForkID          forkid.ID
+}
+
 // newBlockHashesData is the network packet for the block announcements.
 type newBlockHashesData []struct {
 	Hash   common.Hash // Hash of one particular block being announced
@@ -173,6 +197,29 @@ type newBlockData struct {
 	TD    *big.Int
 }
 
+// compactBlockData is the network packet for the compact block propagation
+// message, identifying the transactions of the block by their short IDs.
+type compactBlockData struct {
+	Header *types.Header
+	Uncles []*types.Header
+	TxIDs  []shortTxID
+	TD     *big.Int
+}
+
+// getBlockTxsData is the network packet requesting the transactions of a
+// compactly propagated block the receiver couldn't find in its pool.
+type getBlockTxsData struct {
+	Hash    common.Hash // Hash of the block containing the transactions
+	Indexes []uint64    // Positions of the requested transactions within the block
+}
+
+// blockTxsData is the network packet answering a transaction request of a
+// compactly propagated block, listing the transactions in the requested order.
+type blockTxsData struct {
+	Hash common.Hash
+	Txs  []*types.Transaction
+}
+
 // blockBody represents the data content of a single block.
 type blockBody struct {
 	Transactions []*types.Transaction // Transactions contained within a block
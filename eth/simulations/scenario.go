@@ -0,0 +1,356 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/core"
+	"github.com/pocethereum/pochain/core/types"
+	"github.com/pocethereum/pochain/event"
+	"github.com/pocethereum/pochain/log"
+	"github.com/pocethereum/pochain/p2p/discover"
+	"github.com/pocethereum/pochain/p2p/simulations"
+	"github.com/pocethereum/pochain/p2p/simulations/adapters"
+)
+
+var (
+	errTooFewNodes  = errors.New("scenario needs at least one node")
+	errTooFewBlocks = errors.New("scenario needs at least two blocks")
+	errNoService    = errors.New("node not running the poc service")
+)
+
+// Topology returns the pairs of nodes (by index) to connect in a network of
+// the given size.
+type Topology func(nodes int) [][2]int
+
+// FullMesh connects every node to every other one.
+func FullMesh(nodes int) [][2]int {
+	var links [][2]int
+	for i := 0; i < nodes; i++ {
+		for j := i + 1; j < nodes; j++ {
+			links = append(links, [2]int{i, j})
+		}
+	}
+	return links
+}
+
+// Line connects the nodes one after the other, so blocks of the first node
+// take the most hops to reach the last one.
+func Line(nodes int) [][2]int {
+	var links [][2]int
+	for i := 1; i < nodes; i++ {
+		links = append(links, [2]int{i - 1, i})
+	}
+	return links
+}
+
+// Scenario describes a network of PoC miners to simulate.
+type Scenario struct {
+	Nodes     int                 // Number of mining nodes
+	Nonces    uint64              // Number of nonces plotted by every node
+	BlockTime time.Duration       // Average block time targeted by the genesis difficulty
+	Blocks    uint64              // Minimum number of blocks to mine before stopping
+	Topology  Topology            // Links between the nodes (default = FullMesh)
+	Link      adapters.LinkConfig // Conditions of every link
+}
+
+// Result is the outcome of a simulated scenario.
+type Result struct {
+	Head        uint64        // Number of the head block all nodes agree on
+	Mined       int           // Number of blocks sealed up to the head
+	Orphans     int           // Number of sealed blocks not in the canonical chain
+	BlockTime   time.Duration // Average time between canonical blocks
+	Propagation time.Duration // Average time for sealed blocks to reach every node
+}
+
+// OrphanRate returns the share of the sealed blocks which ended up off the
+// canonical chain.
+func (r *Result) OrphanRate() float64 {
+	if r.Mined == 0 {
+		return 0
+	}
+	return float64(r.Orphans) / float64(r.Mined)
+}
+
+// String implements fmt.Stringer.
+func (r *Result) String() string {
+	return fmt.Sprintf("head=%d mined=%d orphans=%d (%.1f%%) blocktime=%v propagation=%v",
+		r.Head, r.Mined, r.Orphans, 100*r.OrphanRate(), r.BlockTime, r.Propagation)
+}
+
+// minedBlock tracks a sealed block through the network.
+type minedBlock struct {
+	number uint64
+	mined  time.Time
+}
+
+// tracker collects the blocks sealed and imported by the nodes.
+type tracker struct {
+	blocks map[common.Hash]*minedBlock
+	seen   map[common.Hash]map[discover.NodeID]time.Time // Imports, which may precede the seal event
+	lock   sync.Mutex
+}
+
+func newTracker() *tracker {
+	return &tracker{
+		blocks: make(map[common.Hash]*minedBlock),
+		seen:   make(map[common.Hash]map[discover.NodeID]time.Time),
+	}
+}
+
+// mined records a block sealed at the given time.
+func (t *tracker) mined(hash common.Hash, number uint64, at time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, ok := t.blocks[hash]; !ok {
+		t.blocks[hash] = &minedBlock{number: number, mined: at}
+	}
+}
+
+// imported records a block imported by a node at the given time.
+func (t *tracker) imported(id discover.NodeID, hash common.Hash, at time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.seen[hash] == nil {
+		t.seen[hash] = make(map[discover.NodeID]time.Time)
+	}
+	if _, ok := t.seen[hash][id]; !ok {
+		t.seen[hash][id] = at
+	}
+}
+
+// track feeds the sealing and import events of a node into the tracker until
+// the returned subscription is unsubscribed.
+func (t *tracker) track(id discover.NodeID, service *Service) event.Subscription {
+	var (
+		minedSub = service.EventMux().Subscribe(core.NewMinedBlockEvent{})
+		chainCh  = make(chan core.ChainEvent, 64)
+		sideCh   = make(chan core.ChainSideEvent, 64)
+		chainSub = service.BlockChain().SubscribeChainEvent(chainCh)
+		sideSub  = service.BlockChain().SubscribeChainSideEvent(sideCh)
+	)
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer minedSub.Unsubscribe()
+		defer chainSub.Unsubscribe()
+		defer sideSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-minedSub.Chan():
+				if ev == nil {
+					return nil
+				}
+				block := ev.Data.(core.NewMinedBlockEvent).Block
+				t.mined(block.Hash(), block.NumberU64(), ev.Time)
+			case ev := <-chainCh:
+				t.imported(id, ev.Block.Hash(), time.Now())
+			case ev := <-sideCh:
+				t.imported(id, ev.Block.Hash(), time.Now())
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
+
+// Run simulates the scenario: it starts the nodes, links them up and mines
+// until all nodes agree on a head of at least the requested number.
+func (s *Scenario) Run(ctx context.Context) (*Result, error) {
+	if s.Nodes < 1 {
+		return nil, errTooFewNodes
+	}
+	if s.Blocks < 2 {
+		return nil, errTooFewBlocks
+	}
+	topology := s.Topology
+	if topology == nil {
+		topology = FullMesh
+	}
+	genesis := NewGenesis(s.BlockTime, uint64(s.Nodes)*s.Nonces)
+
+	adapter := adapters.NewSimAdapter(map[string]adapters.ServiceFunc{
+		ServiceName: NewService(genesis, s.Nonces),
+	})
+	if err := adapter.SetLinkConfig(s.Link); err != nil {
+		return nil, err
+	}
+	net := simulations.NewNetwork(adapter, &simulations.NetworkConfig{DefaultService: ServiceName})
+	defer net.Shutdown()
+
+	// Boot all the nodes and link them up as requested
+	var (
+		ids      = make([]discover.NodeID, s.Nodes)
+		services = make([]*Service, s.Nodes)
+	)
+	for i := range ids {
+		node, err := net.NewNode()
+		if err != nil {
+			return nil, err
+		}
+		if err := net.Start(node.ID()); err != nil {
+			return nil, err
+		}
+		ids[i] = node.ID()
+		if services[i] = pocService(node); services[i] == nil {
+			return nil, errNoService
+		}
+	}
+	links := topology(s.Nodes)
+	for _, link := range links {
+		if err := net.Connect(ids[link[0]], ids[link[1]]); err != nil {
+			return nil, err
+		}
+	}
+	if err := waitPeers(ctx, net, ids, links); err != nil {
+		return nil, err
+	}
+	// Track the sealed blocks and mine until the first node's head is high enough
+	tracker := newTracker()
+	for i, service := range services {
+		sub := tracker.track(ids[i], service)
+		defer sub.Unsubscribe()
+	}
+	for _, service := range services {
+		if err := service.StartMining(true); err != nil {
+			return nil, err
+		}
+	}
+	// Mine until the nodes agree on a high enough head. Competing blocks have
+	// the same difficulty, so only a later block can settle a fork.
+	var head *types.Block
+	err := wait(ctx, func() bool {
+		head = services[0].BlockChain().CurrentBlock()
+		if head.NumberU64() < s.Blocks {
+			return false
+		}
+		for _, service := range services[1:] {
+			if service.BlockChain().CurrentBlock().Hash() != head.Hash() {
+				return false
+			}
+		}
+		return true
+	})
+	for _, service := range services {
+		service.StopMining()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tracker.result(services[0].BlockChain(), head, ids), nil
+}
+
+// result evaluates the tracked blocks against the canonical chain of the given
+// head.
+func (t *tracker) result(chain *core.BlockChain, head *types.Block, ids []discover.NodeID) *Result {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	res := &Result{Head: head.NumberU64()}
+
+	canonical := make(map[common.Hash]bool)
+	for block := head; block.NumberU64() > 0; block = chain.GetBlock(block.ParentHash(), block.NumberU64()-1) {
+		canonical[block.Hash()] = true
+		if block.NumberU64() == 1 {
+			span := head.Time().Int64() - block.Time().Int64()
+			res.BlockTime = time.Duration(span) * time.Second / time.Duration(res.Head-1)
+		}
+	}
+	var (
+		propagation time.Duration
+		propagated  int
+	)
+	for hash, block := range t.blocks {
+		if block.number > res.Head {
+			continue
+		}
+		res.Mined++
+		if !canonical[hash] {
+			res.Orphans++
+		}
+		// Blocks not imported by every node don't count towards propagation
+		if len(t.seen[hash]) < len(ids) {
+			continue
+		}
+		last := block.mined
+		for _, seen := range t.seen[hash] {
+			if seen.After(last) {
+				last = seen
+			}
+		}
+		propagation += last.Sub(block.mined)
+		propagated++
+	}
+	if propagated > 0 {
+		res.Propagation = propagation / time.Duration(propagated)
+	}
+	log.Info("Simulated scenario finished", "result", res)
+	return res
+}
+
+// pocService returns the PoC mining service of a simulated node.
+func pocService(node *simulations.Node) *Service {
+	simNode, ok := node.Node.(*adapters.SimNode)
+	if !ok {
+		return nil
+	}
+	for _, service := range simNode.Services() {
+		if service, ok := service.(*Service); ok {
+			return service
+		}
+	}
+	return nil
+}
+
+// waitPeers waits until all nodes are connected to all of their neighbours.
+func waitPeers(ctx context.Context, net *simulations.Network, ids []discover.NodeID, links [][2]int) error {
+	peers := make([]int, len(ids))
+	for _, link := range links {
+		peers[link[0]]++
+		peers[link[1]]++
+	}
+	return wait(ctx, func() bool {
+		for i, id := range ids {
+			if net.GetNode(id).Node.(*adapters.SimNode).Server().PeerCount() < peers[i] {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// wait polls the given condition until it holds or the context is done.
+func wait(ctx context.Context, cond func() bool) error {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for !cond() {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulations
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pocethereum/pochain/p2p/simulations/adapters"
+)
+
+// runScenario simulates the given scenario, failing the test if the network
+// doesn't converge.
+func runScenario(t *testing.T, scenario *Scenario) *Result {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+
+	res, err := scenario.Run(ctx)
+	if err != nil {
+		t.Fatalf("scenario failed: %v", err)
+	}
+	t.Logf("scenario result: %v", res)
+
+	if res.Head < scenario.Blocks {
+		t.Errorf("head mismatch: have %d, want >= %d", res.Head, scenario.Blocks)
+	}
+	// Every canonical block was sealed by someone, the rest got orphaned
+	if res.Mined-res.Orphans != int(res.Head) {
+		t.Errorf("canonical blocks mismatch: mined %d, orphans %d, head %d", res.Mined, res.Orphans, res.Head)
+	}
+	if res.BlockTime < time.Second {
+		t.Errorf("block time too short: have %v, want >= 1s", res.BlockTime)
+	}
+	return res
+}
+
+// Tests that miners competing over perfect links converge on one chain.
+func TestScenario(t *testing.T) {
+	runScenario(t, &Scenario{
+		Nodes:     3,
+		Nonces:    4,
+		BlockTime: 2 * time.Second,
+		Blocks:    6,
+	})
+}
+
+// Tests that miners converge over slow and lossy links, and that the latency
+// shows up in the propagation of the blocks.
+func TestScenarioImpairedLinks(t *testing.T) {
+	link := adapters.LinkConfig{Latency: 300 * time.Millisecond, PacketLoss: 0.1}
+	res := runScenario(t, &Scenario{
+		Nodes:     3,
+		Nonces:    4,
+		BlockTime: 2 * time.Second,
+		Blocks:    6,
+		Topology:  Line,
+		Link:      link,
+	})
+	if res.Propagation < link.Latency {
+		t.Errorf("propagation faster than the link: have %v, want >= %v", res.Propagation, link.Latency)
+	}
+}
+
+// Tests that invalid scenarios are rejected upfront.
+func TestScenarioInvalid(t *testing.T) {
+	tests := []struct {
+		scenario *Scenario
+		err      error
+	}{
+		{&Scenario{Nodes: 0, Blocks: 5}, errTooFewNodes},
+		{&Scenario{Nodes: 1, Blocks: 1}, errTooFewBlocks},
+	}
+	for i, tt := range tests {
+		if _, err := tt.scenario.Run(context.Background()); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
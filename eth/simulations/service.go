@@ -0,0 +1,118 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package simulations runs networks of PoC mining nodes on top of the in-memory
+// p2p simulation framework, measuring how the chain behaves under competing
+// deadlines and impaired links.
+package simulations
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/consensus/ethash"
+	"github.com/pocethereum/pochain/consensus/poc/plotter"
+	"github.com/pocethereum/pochain/core"
+	"github.com/pocethereum/pochain/crypto"
+	"github.com/pocethereum/pochain/eth"
+	"github.com/pocethereum/pochain/eth/downloader"
+	"github.com/pocethereum/pochain/node"
+	"github.com/pocethereum/pochain/p2p/simulations/adapters"
+	"github.com/pocethereum/pochain/params"
+)
+
+// ServiceName is the name the PoC mining service is registered with in the
+// simulation adapter.
+const ServiceName = "poc-eth"
+
+// Service is a simulated PoC mining node, running a full Ethereum service with
+// the test PoC engine, which mines a tiny plot of the node's own coinbase.
+type Service struct {
+	*eth.Ethereum
+
+	coinbase common.Address
+	plotDir  string
+}
+
+// NewGenesis creates the genesis of a simulated network. The PoC test engine
+// keeps its difficulty forever, which is chosen so that a network plotting the
+// given number of nonces in total mines a block every blockTime on average.
+func NewGenesis(blockTime time.Duration, nonces uint64) *core.Genesis {
+	// The deadline of a nonce is its uniform 64 bit hit scaled down by the
+	// base target (2^64 / difficulty), so the best of n nonces is on average
+	// difficulty / (n+1) seconds
+	difficulty := new(big.Int).SetUint64((nonces + 1) * uint64(blockTime/time.Millisecond))
+	difficulty.Div(difficulty, big.NewInt(int64(time.Second/time.Millisecond)))
+	if difficulty.Sign() <= 0 {
+		difficulty.SetUint64(1)
+	}
+	return &core.Genesis{
+		Config:     params.AllPocProtocolChanges,
+		GasLimit:   16777216,
+		Difficulty: difficulty,
+		Timestamp:  uint64(time.Now().Unix()),
+	}
+}
+
+// NewService returns the function creating the PoC mining service of a node
+// within the simulated network of the given genesis, plotting the given number
+// of nonces for the node's coinbase on startup.
+func NewService(genesis *core.Genesis, nonces uint64) adapters.ServiceFunc {
+	return func(ctx *adapters.ServiceContext) (node.Service, error) {
+		coinbase := crypto.PubkeyToAddress(ctx.Config.PrivateKey.PublicKey)
+		seed := strings.ToLower(coinbase.Hex()[2:])
+
+		dir, err := ioutil.TempDir("", "poc-sim-plots")
+		if err != nil {
+			return nil, err
+		}
+		if err := plotter.NewWorker(plotter.NewWork(seed, dir, 0, nonces, nonces)).Run(); err != nil {
+			os.RemoveAll(dir)
+			return nil, fmt.Errorf("failed to plot %d nonces: %v", nonces, err)
+		}
+		config := eth.DefaultConfig
+		config.Genesis = genesis
+		config.SyncMode = downloader.FullSync
+		config.Etherbase = coinbase
+		config.Ethash.PowMode = ethash.ModeTest
+		config.Ethash.PlotPaths = []string{dir}
+
+		ethereum, err := eth.New(ctx.NodeContext, &config)
+		if err != nil {
+			os.RemoveAll(dir)
+			return nil, err
+		}
+		return &Service{Ethereum: ethereum, coinbase: coinbase, plotDir: dir}, nil
+	}
+}
+
+// Coinbase returns the address the node mines and plotted for.
+func (s *Service) Coinbase() common.Address {
+	return s.coinbase
+}
+
+// Stop implements node.Service, terminating the Ethereum service and deleting
+// the plot of the node.
+func (s *Service) Stop() error {
+	err := s.Ethereum.Stop()
+	os.RemoveAll(s.plotDir)
+	return err
+}
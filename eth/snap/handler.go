@@ -0,0 +1,289 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"bytes"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/core/state"
+	"github.com/pocethereum/pochain/ethdb"
+	"github.com/pocethereum/pochain/p2p"
+	"github.com/pocethereum/pochain/rlp"
+	"github.com/pocethereum/pochain/trie"
+)
+
+const (
+	softResponseLimit = 2 * 1024 * 1024 // Target maximum size of returned ranges or codes
+	maxCodeLookups    = 1024            // Maximum number of codes served in one response
+)
+
+// maxHash is the largest possible hash, limiting ranges reaching to the end.
+var maxHash = common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+
+// Handler serves the state tries available in the local trie database through
+// the snap protocol, and dispatches the responses to state requests to the
+// syncer.
+type Handler struct {
+	triedb *trie.Database // Trie database holding the served state
+	syncer *Syncer
+
+	SubProtocols []p2p.Protocol
+}
+
+// NewHandler creates a snap protocol handler serving the state of the given trie
+// database and syncing state into chaindb.
+func NewHandler(chaindb ethdb.Database, triedb *trie.Database) *Handler {
+	h := &Handler{
+		triedb: triedb,
+		syncer: NewSyncer(chaindb),
+	}
+	for i, version := range ProtocolVersions {
+		version := version // Closure for the run
+		h.SubProtocols = append(h.SubProtocols, p2p.Protocol{
+			Name:    ProtocolName,
+			Version: version,
+			Length:  ProtocolLengths[i],
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				return h.handle(newPeer(int(version), p, rw))
+			},
+		})
+	}
+	return h
+}
+
+// Syncer returns the state syncer retrieving state from the connected peers.
+func (h *Handler) Syncer() *Syncer {
+	return h.syncer
+}
+
+// handle is the callback invoked to manage the life cycle of a snap peer. When
+// this function terminates, the peer is disconnected.
+func (h *Handler) handle(p *peer) error {
+	p.Log().Debug("Snap peer connected", "name", p.Name())
+
+	if err := h.syncer.register(p); err != nil {
+		return err
+	}
+	defer h.syncer.unregister(p.id)
+
+	for {
+		if err := h.handleMsg(p); err != nil {
+			p.Log().Debug("Snap message handling failed", "err", err)
+			return err
+		}
+	}
+}
+
+// handleMsg is invoked whenever an inbound message is received from a remote
+// peer. The remote connection is torn down upon returning any error.
+func (h *Handler) handleMsg(p *peer) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	defer msg.Discard()
+
+	switch msg.Code {
+	case GetAccountRangeMsg:
+		var req getAccountRangeData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		accounts, proof := h.serveAccountRange(&req)
+		return p.SendAccountRange(req.ID, accounts, proof)
+
+	case GetStorageRangesMsg:
+		var req getStorageRangesData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		slots, proof := h.serveStorageRanges(&req)
+		return p.SendStorageRanges(req.ID, slots, proof)
+
+	case GetByteCodesMsg:
+		var req getByteCodesData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		return p.SendByteCodes(req.ID, h.serveByteCodes(&req))
+
+	case AccountRangeMsg:
+		res := new(accountRangeData)
+		if err := msg.Decode(res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		h.syncer.deliver(p, res.ID, res)
+
+	case StorageRangesMsg:
+		res := new(storageRangesData)
+		if err := msg.Decode(res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		h.syncer.deliver(p, res.ID, res)
+
+	case ByteCodesMsg:
+		res := new(byteCodesData)
+		if err := msg.Decode(res); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		h.syncer.deliver(p, res.ID, res)
+
+	default:
+		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
+	}
+	return nil
+}
+
+// responseLimit caps the requested response size to the serving limit.
+func responseLimit(requested uint64) int {
+	if requested > softResponseLimit {
+		return softResponseLimit
+	}
+	return int(requested)
+}
+
+// serveAccountRange collects the accounts of the requested range, stopping
+// once the response size is reached, and proves the edges of the range. An
+// empty response without proof is returned if the state is not available.
+func (h *Handler) serveAccountRange(req *getAccountRangeData) ([]*accountData, [][]byte) {
+	tr, err := trie.New(req.Root, h.triedb)
+	if err != nil {
+		return nil, nil
+	}
+	var (
+		accounts []*accountData
+		size     int
+		limit    = responseLimit(req.Bytes)
+	)
+	it := trie.NewIterator(tr.NodeIterator(req.Origin[:]))
+	for size < limit && it.Next() {
+		if bytes.Compare(it.Key, req.Limit[:]) > 0 {
+			break
+		}
+		accounts = append(accounts, &accountData{Hash: common.BytesToHash(it.Key), Body: it.Value})
+		size += common.HashLength + len(it.Value)
+	}
+	if it.Err != nil {
+		return nil, nil
+	}
+	var proof proofList
+	if err := tr.Prove(req.Origin[:], 0, &proof); err != nil {
+		return nil, nil
+	}
+	if len(accounts) > 0 {
+		if err := tr.Prove(accounts[len(accounts)-1].Hash[:], 0, &proof); err != nil {
+			return nil, nil
+		}
+	}
+	return accounts, proof
+}
+
+// serveStorageRanges collects the storage slots of the requested accounts,
+// stopping once the response size is reached. Only the storage of the last
+// account may be incomplete, in which case its edges are proven. Accounts not
+// found in the state end the response.
+func (h *Handler) serveStorageRanges(req *getStorageRangesData) ([][]*slotData, [][]byte) {
+	accTrie, err := trie.New(req.Root, h.triedb)
+	if err != nil {
+		return nil, nil
+	}
+	var (
+		slots [][]*slotData
+		size  int
+		limit = responseLimit(req.Bytes)
+	)
+	for i, hash := range req.Accounts {
+		if size >= limit {
+			break
+		}
+		blob, err := accTrie.TryGet(hash[:])
+		if err != nil || blob == nil {
+			break
+		}
+		var account state.Account
+		if err := rlp.DecodeBytes(blob, &account); err != nil {
+			break
+		}
+		stTrie, err := trie.New(account.Root, h.triedb)
+		if err != nil {
+			break
+		}
+		// Only the first account may be retrieved from an origin on
+		origin, last := common.Hash{}, maxHash
+		if i == 0 {
+			origin, last = req.Origin, req.Limit
+		}
+		var (
+			storage []*slotData
+			aborted bool
+		)
+		it := trie.NewIterator(stTrie.NodeIterator(origin[:]))
+		for it.Next() {
+			if bytes.Compare(it.Key, last[:]) > 0 {
+				break
+			}
+			if size >= limit {
+				aborted = true
+				break
+			}
+			storage = append(storage, &slotData{Hash: common.BytesToHash(it.Key), Body: it.Value})
+			size += common.HashLength + len(it.Value)
+		}
+		if it.Err != nil {
+			break
+		}
+		slots = append(slots, storage)
+
+		// Prove incomplete storage, which ends the response
+		if aborted || origin != (common.Hash{}) || last != maxHash {
+			var proof proofList
+			if err := stTrie.Prove(origin[:], 0, &proof); err != nil {
+				return nil, nil
+			}
+			if len(storage) > 0 {
+				if err := stTrie.Prove(storage[len(storage)-1].Hash[:], 0, &proof); err != nil {
+					return nil, nil
+				}
+			}
+			return slots, proof
+		}
+	}
+	return slots, nil
+}
+
+// serveByteCodes collects the requested contract codes which are known locally.
+func (h *Handler) serveByteCodes(req *getByteCodesData) [][]byte {
+	var (
+		codes [][]byte
+		size  int
+		limit = responseLimit(req.Bytes)
+	)
+	for i, hash := range req.Hashes {
+		if size >= limit || i >= maxCodeLookups {
+			break
+		}
+		if code, err := h.triedb.Node(hash); err == nil && len(code) > 0 {
+			codes = append(codes, code)
+			size += len(code)
+		}
+	}
+	return codes
+}
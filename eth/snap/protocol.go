@@ -0,0 +1,181 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package snap implements the snap protocol, which serves contiguous ranges of
+// the state trie along with Merkle proofs of their edges, and a state syncer
+// retrieving the flat state through it.
+package snap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/crypto"
+	"github.com/pocethereum/pochain/ethdb"
+)
+
+// Constants to match up protocol versions and messages
+const (
+	snap1 = 1
+)
+
+// ProtocolName is the official short name of the protocol used during capability negotiation.
+var ProtocolName = "snap"
+
+// ProtocolVersions are the supported versions of the snap protocol (first is primary).
+var ProtocolVersions = []uint{snap1}
+
+// ProtocolLengths are the number of implemented message corresponding to different protocol versions.
+var ProtocolLengths = []uint64{6}
+
+const ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
+
+// snap protocol message codes
+const (
+	GetAccountRangeMsg  = 0x00
+	AccountRangeMsg     = 0x01
+	GetStorageRangesMsg = 0x02
+	StorageRangesMsg    = 0x03
+	GetByteCodesMsg     = 0x04
+	ByteCodesMsg        = 0x05
+)
+
+type errCode int
+
+const (
+	ErrMsgTooLarge = iota
+	ErrDecode
+	ErrInvalidMsgCode
+)
+
+func (e errCode) String() string {
+	return errorToString[int(e)]
+}
+
+var errorToString = map[int]string{
+	ErrMsgTooLarge:    "Message too long",
+	ErrDecode:         "Invalid message",
+	ErrInvalidMsgCode: "Invalid message code",
+}
+
+func errResp(code errCode, format string, v ...interface{}) error {
+	return fmt.Errorf("%v - %v", code, fmt.Sprintf(format, v...))
+}
+
+var (
+	errBadProof     = errors.New("invalid range proof")
+	errBadStorage   = errors.New("storage ranges not matching request")
+	errBadCode      = errors.New("bytecode not matching request")
+	errUnrequested  = errors.New("unrequested response")
+	errTimeout      = errors.New("request timed out")
+	errNoPeers      = errors.New("no peers serving the state")
+	errCanceled     = errors.New("state range sync canceled")
+	errPeerNotFound = errors.New("peer not registered")
+)
+
+// getAccountRangeData is the network packet requesting the accounts of a state
+// trie between two hashes.
+type getAccountRangeData struct {
+	ID     uint64      // Request ID to match up the response with
+	Root   common.Hash // Root of the account trie to serve
+	Origin common.Hash // Hash of the first account to retrieve
+	Limit  common.Hash // Hash of the last account to retrieve
+	Bytes  uint64      // Soft limit at which to stop returning data
+}
+
+// accountData is an account of a range response, identified by its hash and
+// carrying the RLP encoded account as stored in the trie.
+type accountData struct {
+	Hash common.Hash
+	Body []byte
+}
+
+// accountRangeData is the network packet answering an account range request.
+// An empty response without proof means the peer doesn't have the state.
+type accountRangeData struct {
+	ID       uint64
+	Accounts []*accountData
+	Proof    [][]byte // Trie nodes proving the edges of the range
+}
+
+// getStorageRangesData is the network packet requesting the storage slots of a
+// list of accounts. The origin and limit only apply to the first account, which
+// allows retrieving large storage tries in multiple parts.
+type getStorageRangesData struct {
+	ID       uint64
+	Root     common.Hash   // Root of the account trie the accounts belong to
+	Accounts []common.Hash // Hashes of the accounts to retrieve the storage of
+	Origin   common.Hash   // Hash of the first slot of the first account
+	Limit    common.Hash   // Hash of the last slot of the first account
+	Bytes    uint64        // Soft limit at which to stop returning data
+}
+
+// slotData is a storage slot of a range response, identified by its hash and
+// carrying the RLP encoded value as stored in the trie.
+type slotData struct {
+	Hash common.Hash
+	Body []byte
+}
+
+// storageRangesData is the network packet answering a storage range request,
+// with the slots of the requested accounts in order. Only the storage of the
+// last account may be incomplete, which is then proven.
+type storageRangesData struct {
+	ID    uint64
+	Slots [][]*slotData
+	Proof [][]byte
+}
+
+// getByteCodesData is the network packet requesting contract code by hash.
+type getByteCodesData struct {
+	ID     uint64
+	Hashes []common.Hash
+	Bytes  uint64
+}
+
+// byteCodesData is the network packet answering a bytecode request, returning
+// the known codes in the requested order.
+type byteCodesData struct {
+	ID    uint64
+	Codes [][]byte
+}
+
+// proofList collects the trie nodes of Merkle proofs.
+type proofList [][]byte
+
+// Put implements ethdb.Putter, appending the proof node unless the proof of
+// another key already contains it.
+func (l *proofList) Put(key []byte, value []byte) error {
+	for _, node := range *l {
+		if bytes.Equal(node, value) {
+			return nil
+		}
+	}
+	*l = append(*l, value)
+	return nil
+}
+
+// database converts the proof into a database keyed by the node hashes, as
+// needed for verification.
+func (l proofList) database() *ethdb.MemDatabase {
+	db := ethdb.NewMemDatabase()
+	for _, node := range l {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	return db
+}
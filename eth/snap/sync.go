@@ -0,0 +1,576 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/core/state"
+	"github.com/pocethereum/pochain/crypto"
+	"github.com/pocethereum/pochain/ethdb"
+	"github.com/pocethereum/pochain/log"
+	"github.com/pocethereum/pochain/p2p"
+	"github.com/pocethereum/pochain/rlp"
+	"github.com/pocethereum/pochain/trie"
+)
+
+const (
+	requestBytes       = 512 * 1024       // Response size requested from peers
+	maxStorageAccounts = 128              // Maximum number of accounts to request the storage of at once
+	maxCodeRequest     = 64               // Maximum number of codes to request at once
+	requestTimeout     = 10 * time.Second // Time allowance for a peer to answer a request
+	statsInterval      = 8 * time.Second  // Interval between progress logs
+)
+
+var (
+	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+	emptyCode = crypto.Keccak256Hash(nil)
+)
+
+// syncRequest is a request awaiting its response from a peer.
+type syncRequest struct {
+	peer     string
+	response chan interface{} // Delivered packet, nil if the peer disconnected
+}
+
+// syncAccount is a retrieved account whose storage and code may still need to
+// be retrieved.
+type syncAccount struct {
+	hash    common.Hash
+	body    []byte
+	account state.Account
+}
+
+// Syncer retrieves the state of a block through the snap protocol. Accounts are
+// fetched in contiguous ranges proven against the state root, along with their
+// storage and code, and assembled into the account trie locally.
+//
+// Tries are only ever persisted along with everything below them, so nodes in
+// the database root complete subtries. This allows a trie node sync to heal the
+// state afterwards, skipping all subtries already present. The healing fixes up
+// the differences if the range sync had to switch to a newer state root, or if
+// it failed.
+type Syncer struct {
+	db     ethdb.Database
+	triedb *trie.Database // Trie database assembling the synced tries
+
+	peers    map[string]*peer
+	requests map[uint64]*syncRequest
+	nextID   uint64
+	lock     sync.Mutex // Lock protecting the peers and requests
+
+	penalize func(id string, points int, reason error) // Reports misbehaving peers (optional)
+
+	// Progress retained across sync cycles, allowing to continue on a new root
+	syncLock sync.Mutex
+	accounts *trie.Trie  // Account trie assembled so far
+	next     common.Hash // Hash of the next account to retrieve
+	done     bool        // Whether all accounts were retrieved
+
+	// Statistics
+	accountsSynced uint64
+	slotsSynced    uint64
+	codesSynced    uint64
+	bytesSynced    common.StorageSize
+	logged         time.Time
+}
+
+// NewSyncer creates a state syncer writing the retrieved state into db.
+func NewSyncer(db ethdb.Database) *Syncer {
+	return &Syncer{
+		db:       db,
+		triedb:   trie.NewDatabase(db),
+		peers:    make(map[string]*peer),
+		requests: make(map[uint64]*syncRequest),
+	}
+}
+
+// SetPenaltyHook sets the callback reporting peers serving invalid state or
+// timing out to the peer reputation system.
+func (s *Syncer) SetPenaltyHook(penalize func(id string, points int, reason error)) {
+	s.penalize = penalize
+}
+
+// register adds a peer to the set of peers state is requested from.
+func (s *Syncer) register(p *peer) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.peers[p.id]; ok {
+		return p2p.DiscAlreadyConnected
+	}
+	s.peers[p.id] = p
+	return nil
+}
+
+// unregister removes a peer, failing its pending requests.
+func (s *Syncer) unregister(id string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.peers, id)
+	for reqID, req := range s.requests {
+		if req.peer == id {
+			delete(s.requests, reqID)
+			req.response <- nil
+		}
+	}
+}
+
+// deliver hands a response packet to the request awaiting it.
+func (s *Syncer) deliver(p *peer, id uint64, packet interface{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	req := s.requests[id]
+	if req == nil || req.peer != p.id {
+		p.Log().Debug("Unrequested snap response", "id", id)
+		return
+	}
+	delete(s.requests, id)
+	req.response <- packet
+}
+
+// report penalizes a peer serving invalid data or timing out.
+func (s *Syncer) report(p *peer, points int, reason error) {
+	p.Log().Debug("Snap peer misbehaved", "err", reason)
+	if s.penalize != nil {
+		s.penalize(p.id, points, reason)
+	}
+}
+
+// retrieve sends a request to a peer not yet known to lack the state, retrying
+// with other peers until one delivers a valid response. The verify callback
+// processes the response, reporting whether the peer lacks the state or served
+// invalid data. Peers failing a request are added to the stale set.
+func (s *Syncer) retrieve(stale map[string]struct{}, cancel <-chan struct{}, send func(p *peer, id uint64) error, verify func(p *peer, packet interface{}) (bool, error)) error {
+	for {
+		// Pick a peer which might have the state
+		s.lock.Lock()
+		var p *peer
+		for id, candidate := range s.peers {
+			if _, ok := stale[id]; !ok {
+				p = candidate
+				break
+			}
+		}
+		if p == nil {
+			s.lock.Unlock()
+			return errNoPeers
+		}
+		s.nextID++
+		id, req := s.nextID, &syncRequest{peer: p.id, response: make(chan interface{}, 1)}
+		s.requests[id] = req
+		s.lock.Unlock()
+
+		if err := send(p, id); err != nil {
+			s.cancelRequest(id)
+			stale[p.id] = struct{}{}
+			continue
+		}
+		timeout := time.NewTimer(requestTimeout)
+		select {
+		case packet := <-req.response:
+			timeout.Stop()
+			if packet == nil {
+				stale[p.id] = struct{}{} // Peer dropped
+				continue
+			}
+			missing, err := verify(p, packet)
+			if err != nil {
+				s.report(p, p2p.PenaltyUseless, err)
+				stale[p.id] = struct{}{}
+				continue
+			}
+			if missing {
+				p.Log().Debug("Snap peer lacks requested state")
+				stale[p.id] = struct{}{}
+				continue
+			}
+			return nil
+
+		case <-timeout.C:
+			s.cancelRequest(id)
+			s.report(p, p2p.PenaltyTimeout, errTimeout)
+			stale[p.id] = struct{}{}
+
+		case <-cancel:
+			timeout.Stop()
+			s.cancelRequest(id)
+			return errCanceled
+		}
+	}
+}
+
+// cancelRequest drops a request which won't be waited for any more.
+func (s *Syncer) cancelRequest(id uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.requests, id)
+}
+
+// Sync retrieves the accounts of the state with the given root, continuing
+// where a previous sync cycle stopped. It returns once all accounts are
+// retrieved, or with an error if no peer can serve the state any more. The
+// retrieved state is persisted as it arrives, the missing parts are left to be
+// healed.
+func (s *Syncer) Sync(root common.Hash, cancel <-chan struct{}) error {
+	s.syncLock.Lock()
+	defer s.syncLock.Unlock()
+
+	if s.done {
+		return nil
+	}
+	if s.accounts == nil {
+		s.accounts, _ = trie.New(common.Hash{}, s.triedb)
+	}
+	log.Info("Syncing state ranges", "root", root, "origin", s.next)
+
+	stale := make(map[string]struct{})
+	for {
+		accounts, more, err := s.fetchAccounts(root, s.next, stale, cancel)
+		if err != nil {
+			return err
+		}
+		// Complete the subtries of the accounts before linking them into the trie
+		if err := s.fetchCodes(accounts, stale, cancel); err != nil {
+			return err
+		}
+		if err := s.fetchStorage(root, accounts, stale, cancel); err != nil {
+			return err
+		}
+		for _, acc := range accounts {
+			s.accounts.Update(acc.hash[:], acc.body)
+			s.bytesSynced += common.StorageSize(common.HashLength + len(acc.body))
+		}
+		s.accountsSynced += uint64(len(accounts))
+
+		if err := s.persist(s.accounts); err != nil {
+			return err
+		}
+		if len(accounts) > 0 {
+			s.next = accounts[len(accounts)-1].hash
+		}
+		if !more || s.next == maxHash {
+			break
+		}
+		s.next = incHash(s.next)
+		s.logProgress(false)
+	}
+	s.done = true
+	s.logProgress(true)
+	return nil
+}
+
+// fetchAccounts retrieves the accounts from origin on, as many as a peer serves
+// in one response, and reports whether there are more.
+func (s *Syncer) fetchAccounts(root common.Hash, origin common.Hash, stale map[string]struct{}, cancel <-chan struct{}) ([]*syncAccount, bool, error) {
+	var (
+		accounts []*syncAccount
+		more     bool
+	)
+	send := func(p *peer, id uint64) error {
+		return p.RequestAccountRange(id, root, origin, maxHash, requestBytes)
+	}
+	verify := func(p *peer, packet interface{}) (bool, error) {
+		res, ok := packet.(*accountRangeData)
+		if !ok {
+			return false, errUnrequested
+		}
+		if len(res.Accounts) == 0 && len(res.Proof) == 0 {
+			return true, nil
+		}
+		keys := make([][]byte, len(res.Accounts))
+		values := make([][]byte, len(res.Accounts))
+		for i, acc := range res.Accounts {
+			keys[i], values[i] = acc.Hash[:], acc.Body
+		}
+		last := origin[:]
+		if len(keys) > 0 {
+			last = keys[len(keys)-1]
+		}
+		hasMore, err := trie.VerifyRangeProof(root, origin[:], last, keys, values, proofList(res.Proof).database())
+		if err != nil {
+			return false, errBadProof
+		}
+		accounts = make([]*syncAccount, len(res.Accounts))
+		for i, acc := range res.Accounts {
+			accounts[i] = &syncAccount{hash: acc.Hash, body: acc.Body}
+			if err := rlp.DecodeBytes(acc.Body, &accounts[i].account); err != nil {
+				return false, err
+			}
+		}
+		more = hasMore
+		return false, nil
+	}
+	if err := s.retrieve(stale, cancel, send, verify); err != nil {
+		return nil, false, err
+	}
+	return accounts, more, nil
+}
+
+// fetchCodes retrieves the codes of the accounts not yet known locally.
+func (s *Syncer) fetchCodes(accounts []*syncAccount, stale map[string]struct{}, cancel <-chan struct{}) error {
+	var (
+		missing []common.Hash
+		seen    = make(map[common.Hash]bool)
+	)
+	for _, acc := range accounts {
+		hash := common.BytesToHash(acc.account.CodeHash)
+		if hash == emptyCode || seen[hash] {
+			continue
+		}
+		seen[hash] = true
+		if ok, _ := s.db.Has(hash[:]); !ok {
+			missing = append(missing, hash)
+		}
+	}
+	for len(missing) > 0 {
+		batch := missing
+		if len(batch) > maxCodeRequest {
+			batch = batch[:maxCodeRequest]
+		}
+		var codes [][]byte
+		send := func(p *peer, id uint64) error {
+			return p.RequestByteCodes(id, batch, requestBytes)
+		}
+		verify := func(p *peer, packet interface{}) (bool, error) {
+			res, ok := packet.(*byteCodesData)
+			if !ok {
+				return false, errUnrequested
+			}
+			if len(res.Codes) == 0 {
+				return true, nil
+			}
+			codes = res.Codes
+			return false, nil
+		}
+		if err := s.retrieve(stale, cancel, send, verify); err != nil {
+			return err
+		}
+		// Store the delivered codes, requesting the others again
+		delivered := make(map[common.Hash][]byte)
+		for _, code := range codes {
+			delivered[crypto.Keccak256Hash(code)] = code
+		}
+		var rest []common.Hash
+		for _, hash := range missing {
+			if code, ok := delivered[hash]; ok {
+				if err := s.db.Put(hash[:], code); err != nil {
+					return err
+				}
+				s.codesSynced++
+				s.bytesSynced += common.StorageSize(len(code))
+				delete(delivered, hash)
+			} else {
+				rest = append(rest, hash)
+			}
+		}
+		if len(rest) == len(missing) {
+			return errBadCode // Nothing requested was delivered
+		}
+		missing = rest
+	}
+	return nil
+}
+
+// fetchStorage retrieves the storage tries of the accounts not yet known locally.
+func (s *Syncer) fetchStorage(root common.Hash, accounts []*syncAccount, stale map[string]struct{}, cancel <-chan struct{}) error {
+	var missing []*syncAccount
+	for _, acc := range accounts {
+		if acc.account.Root == emptyRoot {
+			continue
+		}
+		if ok, _ := s.db.Has(acc.account.Root[:]); !ok {
+			missing = append(missing, acc)
+		}
+	}
+	for len(missing) > 0 {
+		batch := missing
+		if len(batch) > maxStorageAccounts {
+			batch = batch[:maxStorageAccounts]
+		}
+		hashes := make([]common.Hash, len(batch))
+		for i, acc := range batch {
+			hashes[i] = acc.hash
+		}
+		var (
+			slotLists [][]*slotData
+			tries     []*trie.Trie
+			partial   bool // Whether the last storage trie is incomplete
+		)
+		send := func(p *peer, id uint64) error {
+			return p.RequestStorageRanges(id, root, hashes, common.Hash{}, maxHash, requestBytes)
+		}
+		verify := func(p *peer, packet interface{}) (bool, error) {
+			res, ok := packet.(*storageRangesData)
+			if !ok {
+				return false, errUnrequested
+			}
+			if len(res.Slots) == 0 {
+				return true, nil
+			}
+			if len(res.Slots) > len(hashes) {
+				return false, errBadStorage
+			}
+			// Assemble the storage tries, complete ones need to match their root,
+			// an incomplete last one its proof
+			tries, partial = make([]*trie.Trie, len(res.Slots)), len(res.Proof) > 0
+			for i, slots := range res.Slots {
+				tries[i], _ = trie.New(common.Hash{}, s.triedb)
+				for _, slot := range slots {
+					tries[i].Update(slot.Hash[:], slot.Body)
+				}
+				if i == len(res.Slots)-1 && partial {
+					if _, err := verifyStorage(batch[i].account.Root, common.Hash{}, slots, res.Proof); err != nil {
+						return false, err
+					}
+				} else if tries[i].Hash() != batch[i].account.Root {
+					return false, errBadStorage
+				}
+			}
+			slotLists = res.Slots
+			return false, nil
+		}
+		if err := s.retrieve(stale, cancel, send, verify); err != nil {
+			return err
+		}
+		for i, tr := range tries {
+			slots := slotLists[i]
+			s.countSlots(slots)
+
+			if i == len(tries)-1 && partial && len(slots) > 0 {
+				// The last storage trie is incomplete, retrieve the rest
+				if err := s.fetchLargeStorage(root, batch[i], tr, slots[len(slots)-1].Hash, stale, cancel); err != nil {
+					return err
+				}
+			}
+			if err := s.persist(tr); err != nil {
+				return err
+			}
+		}
+		missing = missing[len(tries):]
+	}
+	return nil
+}
+
+// fetchLargeStorage retrieves the rest of a storage trie too large to be served
+// in one response, adding the slots after last to the given trie.
+func (s *Syncer) fetchLargeStorage(root common.Hash, acc *syncAccount, tr *trie.Trie, last common.Hash, stale map[string]struct{}, cancel <-chan struct{}) error {
+	for last != maxHash {
+		var (
+			origin = incHash(last)
+			slots  []*slotData
+			more   bool
+		)
+		send := func(p *peer, id uint64) error {
+			return p.RequestStorageRanges(id, root, []common.Hash{acc.hash}, origin, maxHash, requestBytes)
+		}
+		verify := func(p *peer, packet interface{}) (bool, error) {
+			res, ok := packet.(*storageRangesData)
+			if !ok {
+				return false, errUnrequested
+			}
+			if len(res.Slots) == 0 {
+				return true, nil
+			}
+			if len(res.Slots) != 1 {
+				return false, errBadStorage
+			}
+			hasMore, err := verifyStorage(acc.account.Root, origin, res.Slots[0], res.Proof)
+			if err != nil {
+				return false, err
+			}
+			slots, more = res.Slots[0], hasMore
+			return false, nil
+		}
+		if err := s.retrieve(stale, cancel, send, verify); err != nil {
+			return err
+		}
+		for _, slot := range slots {
+			tr.Update(slot.Hash[:], slot.Body)
+		}
+		s.countSlots(slots)
+
+		if err := s.persist(tr); err != nil {
+			return err
+		}
+		if !more || len(slots) == 0 {
+			break
+		}
+		last = slots[len(slots)-1].Hash
+	}
+	if tr.Hash() != acc.account.Root {
+		return errBadStorage
+	}
+	return nil
+}
+
+// verifyStorage checks a proven range of storage slots, reporting whether the
+// storage trie has more slots.
+func verifyStorage(root common.Hash, origin common.Hash, slots []*slotData, proof [][]byte) (bool, error) {
+	keys := make([][]byte, len(slots))
+	values := make([][]byte, len(slots))
+	for i, slot := range slots {
+		keys[i], values[i] = slot.Hash[:], slot.Body
+	}
+	last := origin[:]
+	if len(keys) > 0 {
+		last = keys[len(keys)-1]
+	}
+	more, err := trie.VerifyRangeProof(root, origin[:], last, keys, values, proofList(proof).database())
+	if err != nil {
+		return false, errBadProof
+	}
+	return more, nil
+}
+
+// countSlots adds retrieved storage slots to the statistics.
+func (s *Syncer) countSlots(slots []*slotData) {
+	for _, slot := range slots {
+		s.bytesSynced += common.StorageSize(common.HashLength + len(slot.Body))
+	}
+	s.slotsSynced += uint64(len(slots))
+}
+
+// persist writes a trie assembled so far to the database.
+func (s *Syncer) persist(tr *trie.Trie) error {
+	root, err := tr.Commit(nil)
+	if err != nil {
+		return err
+	}
+	return s.triedb.Commit(root, false)
+}
+
+// logProgress periodically reports the sync progress to the user.
+func (s *Syncer) logProgress(force bool) {
+	if !force && time.Since(s.logged) < statsInterval {
+		return
+	}
+	s.logged = time.Now()
+
+	log.Info("Imported state ranges", "accounts", s.accountsSynced, "slots", s.slotsSynced, "codes", s.codesSynced, "size", s.bytesSynced, "next", s.next, "done", s.done)
+}
+
+// incHash returns the hash following the given one.
+func incHash(h common.Hash) common.Hash {
+	return common.BigToHash(new(big.Int).Add(h.Big(), common.Big1))
+}
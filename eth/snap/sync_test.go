@@ -0,0 +1,136 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/core/state"
+	"github.com/pocethereum/pochain/crypto"
+	"github.com/pocethereum/pochain/ethdb"
+	"github.com/pocethereum/pochain/p2p"
+	"github.com/pocethereum/pochain/p2p/discover"
+)
+
+// makeTestState creates a state with plain accounts, contracts with small
+// storage and code, and one contract whose storage doesn't fit in a response.
+func makeTestState(t *testing.T) (ethdb.Database, common.Hash) {
+	db := ethdb.NewMemDatabase()
+	sdb := state.NewDatabase(db)
+	statedb, _ := state.New(common.Hash{}, sdb)
+
+	for i := 0; i < 5000; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i)))
+		statedb.AddBalance(addr, big.NewInt(int64(i+1)))
+		statedb.SetNonce(addr, uint64(i))
+		if i%50 == 0 {
+			statedb.SetCode(addr, []byte{byte(i), byte(i >> 8), 0x42})
+			for j := 0; j < 20; j++ {
+				statedb.SetState(addr, common.BigToHash(big.NewInt(int64(j))), common.BigToHash(big.NewInt(int64(i+j+1))))
+			}
+		}
+	}
+	large := common.HexToAddress("0x1000000000000000000000000000000000000000")
+	statedb.SetCode(large, []byte("large storage"))
+	for j := 0; j < 20000; j++ {
+		statedb.SetState(large, common.BigToHash(big.NewInt(int64(j))), crypto.Keccak256Hash(big.NewInt(int64(j)).Bytes()))
+	}
+	root, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := sdb.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to persist state: %v", err)
+	}
+	return db, root
+}
+
+// connect links two snap handlers through a message pipe.
+func connect(t *testing.T, local, remote *Handler) {
+	app, net := p2p.MsgPipe()
+	localID, remoteID := discover.NodeID{1}, discover.NodeID{2}
+
+	go local.handle(newPeer(snap1, p2p.NewPeer(remoteID, "remote", nil), app))
+	go remote.handle(newPeer(snap1, p2p.NewPeer(localID, "local", nil), net))
+
+	for i := 0; ; i++ {
+		local.syncer.lock.Lock()
+		n := len(local.syncer.peers)
+		local.syncer.lock.Unlock()
+		if n > 0 {
+			return
+		}
+		if i == 100 {
+			t.Fatalf("peer not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Tests that the whole state can be retrieved in ranges, leaving nothing to
+// be healed.
+func TestSync(t *testing.T) {
+	srcdb, root := makeTestState(t)
+	server := NewHandler(srcdb, state.NewDatabase(srcdb).TrieDB())
+
+	dstdb := ethdb.NewMemDatabase()
+	client := NewHandler(dstdb, state.NewDatabase(dstdb).TrieDB())
+	connect(t, client, server)
+
+	if err := client.Syncer().Sync(root, nil); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if pending := state.NewStateSync(root, dstdb).Pending(); pending != 0 {
+		t.Fatalf("state incomplete: %d items left to heal", pending)
+	}
+	src, _ := state.New(root, state.NewDatabase(srcdb))
+	dst, err := state.New(root, state.NewDatabase(dstdb))
+	if err != nil {
+		t.Fatalf("failed to open synced state: %v", err)
+	}
+	for i := 0; i < 5000; i += 7 {
+		addr := common.BigToAddress(big.NewInt(int64(i)))
+		if have, want := dst.GetBalance(addr), src.GetBalance(addr); have.Cmp(want) != 0 {
+			t.Fatalf("account %d: balance mismatch: have %v, want %v", i, have, want)
+		}
+	}
+	large := common.HexToAddress("0x1000000000000000000000000000000000000000")
+	key := common.BigToHash(big.NewInt(12345))
+	if have, want := dst.GetState(large, key), src.GetState(large, key); have != want {
+		t.Fatalf("large storage mismatch: have %x, want %x", have, want)
+	}
+}
+
+// Tests that a sync without peers serving the state fails, so the state is
+// left to be healed.
+func TestSyncMissingState(t *testing.T) {
+	_, root := makeTestState(t)
+
+	emptydb := ethdb.NewMemDatabase()
+	server := NewHandler(emptydb, state.NewDatabase(emptydb).TrieDB())
+
+	dstdb := ethdb.NewMemDatabase()
+	client := NewHandler(dstdb, state.NewDatabase(dstdb).TrieDB())
+	connect(t, client, server)
+
+	if err := client.Syncer().Sync(root, nil); err != errNoPeers {
+		t.Fatalf("sync error mismatch: have %v, want %v", err, errNoPeers)
+	}
+}
@@ -18,6 +18,7 @@
 package ethclient
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -170,6 +171,70 @@ func (ec *Client) HeaderByNumber(ctx context.Context, number *big.Int) (*types.H
 	return head, err
 }
 
+// PocHeader is a block header along with the proof-of-capacity details and the
+// block reward reported by the node.
+type PocHeader struct {
+	*types.Header
+	GenerationSignature common.Hash
+	ScoopNumber         uint64
+	Deadline            *big.Int // Seconds the nonce had to wait, nil for pending blocks
+	BaseTarget          *big.Int
+	Reward              *big.Int // Mining reward, nil if the node has no state for it
+	TxFees              *big.Int // Transaction fees, nil if unknown
+}
+
+type rpcPocHeader struct {
+	GenerationSignature common.Hash     `json:"generationSignature"`
+	ScoopNumber         *hexutil.Uint64 `json:"scoopNumber"`
+	Deadline            *hexutil.Big    `json:"deadline"`
+	BaseTarget          *hexutil.Big    `json:"baseTarget"`
+	Reward              *hexutil.Big    `json:"reward"`
+	TxFees              *hexutil.Big    `json:"txfees"`
+}
+
+// PocHeaderByHash returns the block header with the given hash, along with its
+// proof-of-capacity details.
+func (ec *Client) PocHeaderByHash(ctx context.Context, hash common.Hash) (*PocHeader, error) {
+	return ec.getPocHeader(ctx, "eth_getBlockByHash", hash, false)
+}
+
+// PocHeaderByNumber returns a block header from the current canonical chain, along
+// with its proof-of-capacity details. If number is nil, the latest known header is
+// returned.
+func (ec *Client) PocHeaderByNumber(ctx context.Context, number *big.Int) (*PocHeader, error) {
+	return ec.getPocHeader(ctx, "eth_getBlockByNumber", toBlockNumArg(number), false)
+}
+
+func (ec *Client) getPocHeader(ctx context.Context, method string, args ...interface{}) (*PocHeader, error) {
+	var raw json.RawMessage
+	err := ec.c.CallContext(ctx, &raw, method, args...)
+	if err != nil {
+		return nil, err
+	} else if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, ethereum.NotFound
+	}
+	var head *types.Header
+	var fields rpcPocHeader
+	if err := json.Unmarshal(raw, &head); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	header := &PocHeader{
+		Header:              head,
+		GenerationSignature: fields.GenerationSignature,
+		Deadline:            (*big.Int)(fields.Deadline),
+		BaseTarget:          (*big.Int)(fields.BaseTarget),
+		Reward:              (*big.Int)(fields.Reward),
+		TxFees:              (*big.Int)(fields.TxFees),
+	}
+	if fields.ScoopNumber != nil {
+		header.ScoopNumber = uint64(*fields.ScoopNumber)
+	}
+	return header, nil
+}
+
 type rpcTransaction struct {
 	tx *types.Transaction
 	txExtraInfo
@@ -0,0 +1,230 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/common/hexutil"
+	"github.com/pocethereum/pochain/consensus/poc"
+	"github.com/pocethereum/pochain/consensus/poc/mortgage"
+	"github.com/pocethereum/pochain/core/state"
+	"github.com/pocethereum/pochain/core/types"
+	"github.com/pocethereum/pochain/crypto"
+	"github.com/pocethereum/pochain/ethdb"
+	"github.com/pocethereum/pochain/internal/ethapi"
+	"github.com/pocethereum/pochain/rpc"
+)
+
+// Tests that the schema matches the resolvers.
+func TestBuildSchema(t *testing.T) {
+	if _, err := newHandler(nil); err != nil {
+		t.Fatalf("could not create new GraphQL handler: %v", err)
+	}
+}
+
+// testBackend is an API backend serving the blocks and states of a chain with
+// a side chain, panicking on any method the queries shouldn't need.
+type testBackend struct {
+	ethapi.Backend
+
+	blocks map[common.Hash]*types.Block
+	canon  map[uint64]common.Hash
+	states map[common.Hash]*state.StateDB
+
+	pocLookups int // Number of proof of capacity lookups served
+}
+
+func (b *testBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return b.blocks[hash], nil
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	return b.blocks[b.canon[uint64(number)]], nil
+}
+
+func (b *testBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	return b.StateAndHeaderByHash(ctx, b.canon[uint64(number)])
+}
+
+func (b *testBackend) StateAndHeaderByHash(ctx context.Context, hash common.Hash) (*state.StateDB, *types.Header, error) {
+	if block := b.blocks[hash]; block != nil {
+		return b.states[hash], block.Header(), nil
+	}
+	return nil, nil, nil
+}
+
+func (b *testBackend) BlockPocByHash(ctx context.Context, hash common.Hash) (*types.BlockPoc, error) {
+	b.pocLookups++
+	if block := b.blocks[hash]; block != nil {
+		return poc.CalcBlockPoc(block.Header()), nil
+	}
+	return nil, nil
+}
+
+// newTestBackend creates a backend with a canonical and a side block at height
+// one, owner having a different mortgage state after each of them.
+func newTestBackend(t *testing.T, owner common.Address) (*testBackend, *types.Block, *types.Block) {
+	backend := &testBackend{
+		blocks: make(map[common.Hash]*types.Block),
+		canon:  make(map[uint64]common.Hash),
+		states: make(map[common.Hash]*state.StateDB),
+	}
+	mappingKey := func(pos []byte) common.Hash {
+		return crypto.Keccak256Hash(owner.Hash().Bytes(), pos)
+	}
+	for i, pledged := range []int64{100, 200} {
+		header := &types.Header{
+			Number:     big.NewInt(1),
+			Coinbase:   common.Address{byte(i + 1)},
+			Nonce:      types.EncodeNonce(uint64(i)),
+			Difficulty: big.NewInt(1000000),
+			Time:       big.NewInt(10),
+		}
+		header.SetGenerationSignature(common.HexToHash("0x01"))
+		block := types.NewBlockWithHeader(header)
+
+		statedb, err := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+		if err != nil {
+			t.Fatalf("failed to create state: %v", err)
+		}
+		statedb.SetState(mortgage.MortgageContractAddr, common.BytesToHash(mortgage.MortgageTotalMortgagePos), common.BigToHash(big.NewInt(pledged)))
+		statedb.SetState(mortgage.MortgageContractAddr, mappingKey(mortgage.MortgageMappingPos), common.BigToHash(big.NewInt(pledged/10)))
+		statedb.SetState(mortgage.MortgageContractAddr, mappingKey(mortgage.MortgageLockedMappingPos), common.BigToHash(big.NewInt(pledged/100)))
+		statedb.SetState(mortgage.MortgageContractAddr, mappingKey(mortgage.MortgageUnlockBlockMappingPos), common.BigToHash(big.NewInt(pledged+1)))
+
+		backend.blocks[block.Hash()] = block
+		backend.states[block.Hash()] = statedb
+	}
+	var canon, side *types.Block
+	for _, block := range backend.blocks {
+		if block.Coinbase() == (common.Address{1}) {
+			canon = block
+		} else {
+			side = block
+		}
+	}
+	backend.canon[1] = canon.Hash()
+	return backend, canon, side
+}
+
+// query runs a GraphQL query against the backend, decoding its result.
+func query(t *testing.T, backend ethapi.Backend, query string, result interface{}) {
+	handler, err := newHandler(backend)
+	if err != nil {
+		t.Fatalf("could not create new GraphQL handler: %v", err)
+	}
+	body, _ := json.Marshal(map[string]string{"query": query})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var response struct {
+		Data   json.RawMessage
+		Errors []interface{}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response %q: %v", rec.Body.String(), err)
+	}
+	if len(response.Errors) > 0 {
+		t.Fatalf("query failed: %v", response.Errors)
+	}
+	if err := json.Unmarshal(response.Data, result); err != nil {
+		t.Fatalf("failed to decode result %s: %v", response.Data, err)
+	}
+}
+
+// Tests that the proof of capacity fields of a block are resolved once and
+// match the seal of the block.
+func TestBlockPoc(t *testing.T) {
+	backend, canon, _ := newTestBackend(t, common.Address{})
+
+	var result struct {
+		Block struct {
+			GenerationSignature common.Hash
+			ScoopNumber         hexutil.Uint64
+			Deadline            hexutil.Big
+			BaseTarget          hexutil.Big
+		}
+	}
+	query(t, backend, `{ block(number: 1) { generationSignature scoopNumber deadline baseTarget } }`, &result)
+
+	want := poc.CalcBlockPoc(canon.Header())
+	if have := result.Block.GenerationSignature; have != canon.GenerationSignature() {
+		t.Errorf("generation signature mismatch: have %x, want %x", have, canon.GenerationSignature())
+	}
+	if have := uint64(result.Block.ScoopNumber); have != want.ScoopNumber {
+		t.Errorf("scoop number mismatch: have %d, want %d", have, want.ScoopNumber)
+	}
+	if have := result.Block.Deadline.ToInt(); have.Cmp(want.Deadline) != 0 {
+		t.Errorf("deadline mismatch: have %v, want %v", have, want.Deadline)
+	}
+	if have := result.Block.BaseTarget.ToInt(); have.Cmp(want.BaseTarget) != 0 {
+		t.Errorf("base target mismatch: have %v, want %v", have, want.BaseTarget)
+	}
+	if backend.pocLookups != 1 {
+		t.Errorf("proof of capacity lookups mismatch: have %d, want 1", backend.pocLookups)
+	}
+}
+
+// Tests that the mortgage state of a block is read from the state after that
+// very block, even if it isn't canonical.
+func TestBlockMortgage(t *testing.T) {
+	owner := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	backend, canon, side := newTestBackend(t, owner)
+
+	for _, block := range []*types.Block{canon, side} {
+		var result struct {
+			Block struct {
+				TotalMortgage hexutil.Big
+				Account       struct {
+					Mortgage struct {
+						Pledged     hexutil.Big
+						Locked      hexutil.Big
+						UnlockBlock hexutil.Uint64
+					}
+				}
+			}
+		}
+		q := `{ block(hash: "%HASH%") { totalMortgage account(address: "%OWNER%") { mortgage { pledged locked unlockBlock } } } }`
+		q = strings.NewReplacer("%HASH%", block.Hash().Hex(), "%OWNER%", owner.Hex()).Replace(q)
+		query(t, backend, q, &result)
+
+		statedb := backend.states[block.Hash()]
+		if have, want := result.Block.TotalMortgage.ToInt(), mortgage.GetTotalMortgage(statedb); have.Cmp(want) != 0 {
+			t.Errorf("block %x: total mortgage mismatch: have %v, want %v", block.Hash(), have, want)
+		}
+		account := result.Block.Account.Mortgage
+		if have, want := account.Pledged.ToInt(), mortgage.MortgageOf(owner, statedb); have.Cmp(want) != 0 {
+			t.Errorf("block %x: pledge mismatch: have %v, want %v", block.Hash(), have, want)
+		}
+		if have, want := account.Locked.ToInt(), mortgage.LockedOf(owner, statedb); have.Cmp(want) != 0 {
+			t.Errorf("block %x: locked value mismatch: have %v, want %v", block.Hash(), have, want)
+		}
+		if have, want := uint64(account.UnlockBlock), mortgage.UnlockBlockOf(owner, statedb).Uint64(); have != want {
+			t.Errorf("block %x: unlock block mismatch: have %d, want %d", block.Hash(), have, want)
+		}
+	}
+}
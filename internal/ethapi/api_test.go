@@ -0,0 +1,273 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/common/hexutil"
+	"github.com/pocethereum/pochain/common/math"
+	"github.com/pocethereum/pochain/consensus"
+	"github.com/pocethereum/pochain/consensus/ethash"
+	"github.com/pocethereum/pochain/consensus/poc"
+	"github.com/pocethereum/pochain/core"
+	"github.com/pocethereum/pochain/core/rawdb"
+	"github.com/pocethereum/pochain/core/state"
+	"github.com/pocethereum/pochain/core/types"
+	"github.com/pocethereum/pochain/core/vm"
+	"github.com/pocethereum/pochain/crypto"
+	"github.com/pocethereum/pochain/ethdb"
+	"github.com/pocethereum/pochain/params"
+	"github.com/pocethereum/pochain/rpc"
+)
+
+// testBackend is an API backend serving the methods under test, panicking on
+// anything else.
+type testBackend struct {
+	Backend
+	db     ethdb.Database
+	engine consensus.Engine
+}
+
+func (b *testBackend) ChainDb() ethdb.Database         { return b.db }
+func (b *testBackend) Engine() consensus.Engine        { return b.engine }
+func (b *testBackend) GetTd(hash common.Hash) *big.Int { return big.NewInt(1) }
+
+// Tests that the leveldb debug methods reach through the ancient freezer of the
+// chain database.
+func TestChaindbFreezer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethapi-chaindb")
+	if err != nil {
+		t.Fatalf("failed to create temporary datadir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	kvdb, err := ethdb.NewLDBDatabase(filepath.Join(dir, "chaindata"), 16, 16)
+	if err != nil {
+		t.Fatalf("failed to create key-value store: %v", err)
+	}
+	db, err := rawdb.NewDatabaseWithFreezer(kvdb, filepath.Join(dir, "ancient"), 0)
+	if err != nil {
+		t.Fatalf("failed to create freezer database: %v", err)
+	}
+	defer db.Close()
+
+	api := NewPrivateDebugAPI(&testBackend{db: db})
+	stats, err := api.ChaindbProperty("")
+	if err != nil {
+		t.Fatalf("failed to retrieve database stats: %v", err)
+	}
+	if !strings.Contains(stats, "Compactions") {
+		t.Errorf("unexpected database stats: %q", stats)
+	}
+	if err := api.ChaindbCompact(); err != nil {
+		t.Errorf("failed to compact database: %v", err)
+	}
+	// Memory databases have no leveldb to report on
+	api = NewPrivateDebugAPI(&testBackend{db: ethdb.NewMemDatabase()})
+	if _, err := api.ChaindbProperty(""); err == nil {
+		t.Errorf("memory database property retrieved")
+	}
+}
+
+// Tests that marshalled blocks carry the proof-of-capacity details of their seal
+// only if the chain is run by a proof-of-capacity engine.
+func TestRPCMarshalBlockPoc(t *testing.T) {
+	header := &types.Header{
+		Number:     big.NewInt(100),
+		Coinbase:   common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314"),
+		Nonce:      types.EncodeNonce(7),
+		Difficulty: big.NewInt(1000000),
+	}
+	header.SetGenerationSignature(common.HexToHash("0xdeadbeef"))
+	block := types.NewBlockWithHeader(header)
+	want := poc.CalcBlockPoc(header)
+
+	api := NewPublicBlockChainAPI(&testBackend{engine: poc.New(&params.PocConfig{}, nil)})
+	fields, err := api.rpcOutputBlock(block, false, false)
+	if err != nil {
+		t.Fatalf("failed to marshal block: %v", err)
+	}
+	if have, ok := fields["scoopNumber"].(hexutil.Uint64); !ok || uint64(have) != want.ScoopNumber {
+		t.Errorf("scoop number mismatch: have %v, want %d", fields["scoopNumber"], want.ScoopNumber)
+	}
+	if have, ok := fields["deadline"].(*hexutil.Big); !ok || have.ToInt().Cmp(want.Deadline) != 0 {
+		t.Errorf("deadline mismatch: have %v, want %v", fields["deadline"], want.Deadline)
+	}
+	if have, ok := fields["baseTarget"].(*hexutil.Big); !ok || have.ToInt().Cmp(want.BaseTarget) != 0 {
+		t.Errorf("base target mismatch: have %v, want %v", fields["baseTarget"], want.BaseTarget)
+	}
+	// Other engines and unsealed headers have no plot to derive the details from
+	for i, engine := range []consensus.Engine{ethash.NewFaker(), poc.New(&params.PocConfig{}, nil)} {
+		marshalled := block
+		if i == 1 {
+			unsealed := types.CopyHeader(header)
+			unsealed.Difficulty = new(big.Int)
+			marshalled = types.NewBlockWithHeader(unsealed)
+		}
+		fields, err := RPCMarshalBlock(marshalled, false, false, engine)
+		if err != nil {
+			t.Fatalf("case %d: failed to marshal block: %v", i, err)
+		}
+		for _, field := range []string{"scoopNumber", "deadline", "baseTarget"} {
+			if _, ok := fields[field]; ok {
+				t.Errorf("case %d: unexpected field %s", i, field)
+			}
+		}
+		if _, ok := fields["generationSignature"]; !ok {
+			t.Errorf("case %d: missing generation signature", i)
+		}
+	}
+}
+
+// callBackend is an API backend executing calls on a single in-memory state.
+type callBackend struct {
+	testBackend
+	config *params.ChainConfig
+	sdb    state.Database
+	root   common.Hash
+	header *types.Header
+}
+
+// newCallBackend creates a backend whose state holds a contract at callTarget
+// returning its first two storage slots, on top of block 1 of a chain
+// switching to byzantium at block 10.
+func newCallBackend(t *testing.T) *callBackend {
+	config := *params.TestChainConfig
+	config.ByzantiumBlock = big.NewInt(10)
+
+	sdb := state.NewDatabase(ethdb.NewMemDatabase())
+	statedb, _ := state.New(common.Hash{}, sdb)
+	statedb.SetCode(callTarget, common.FromHex("60005460005260015460205260406000f3"))
+	statedb.SetState(callTarget, common.Hash{}, common.BigToHash(big.NewInt(1)))
+	statedb.SetState(callTarget, common.BigToHash(big.NewInt(1)), common.BigToHash(big.NewInt(2)))
+	root, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	if err := sdb.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	return &callBackend{
+		testBackend: testBackend{engine: ethash.NewFaker()},
+		config:      &config,
+		sdb:         sdb,
+		root:        root,
+		header:      &types.Header{Number: big.NewInt(1), Time: big.NewInt(100), GasLimit: 8000000, Difficulty: big.NewInt(1)},
+	}
+}
+
+func (b *callBackend) ChainConfig() *params.ChainConfig                        { return b.config }
+func (b *callBackend) GetHeader(hash common.Hash, number uint64) *types.Header { return nil }
+
+func (b *callBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	statedb, err := state.New(b.root, b.sdb)
+	return statedb, b.header, err
+}
+
+func (b *callBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config) (*vm.EVM, func() error, error) {
+	state.SetBalance(msg.From(), math.MaxBig256)
+	context := core.NewEVMContext(msg, header, b, nil)
+	return vm.NewEVM(context, state, b.config, vmCfg), state.Error, nil
+}
+
+var (
+	callSender = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	callTarget = common.HexToAddress("0x2000000000000000000000000000000000000002")
+)
+
+// Tests that eth_call runs with the account overrides applied.
+func TestCallStateOverrides(t *testing.T) {
+	api := NewPublicBlockChainAPI(newCallBackend(t))
+
+	var (
+		nonce    = hexutil.Uint64(5)
+		balance  = (*hexutil.Big)(big.NewInt(12345))
+		slots    = map[common.Hash]common.Hash{common.BigToHash(big.NewInt(1)): common.BigToHash(big.NewInt(5))}
+		selfBal  = hexutil.Bytes(common.FromHex("303160005260206000f3"))           // Returns its own balance
+		creation = hexutil.Bytes(common.FromHex("600060006000f060005260206000f3")) // Returns the address of a new contract
+	)
+	tests := []struct {
+		overrides *StateOverride
+		want      []byte
+	}{
+		{nil, append(common.BigToHash(big.NewInt(1)).Bytes(), common.BigToHash(big.NewInt(2)).Bytes()...)},
+		{&StateOverride{callTarget: {State: &slots}}, append(common.Hash{}.Bytes(), common.BigToHash(big.NewInt(5)).Bytes()...)},
+		{&StateOverride{callTarget: {StateDiff: &slots}}, append(common.BigToHash(big.NewInt(1)).Bytes(), common.BigToHash(big.NewInt(5)).Bytes()...)},
+		{&StateOverride{callTarget: {Code: &selfBal, Balance: &balance}}, common.BigToHash(big.NewInt(12345)).Bytes()},
+		{&StateOverride{callTarget: {Code: &creation, Nonce: &nonce}}, crypto.CreateAddress(callTarget, 5).Hash().Bytes()},
+	}
+	for i, tt := range tests {
+		res, err := api.Call(context.Background(), CallArgs{From: callSender, To: &callTarget}, rpc.LatestBlockNumber, tt.overrides, nil)
+		if err != nil {
+			t.Fatalf("test %d: call failed: %v", i, err)
+		}
+		if !bytes.Equal(res, tt.want) {
+			t.Errorf("test %d: result mismatch: have %x, want %x", i, res, tt.want)
+		}
+	}
+	// State and state diff can't be overridden at the same time
+	overrides := &StateOverride{callTarget: {State: &slots, StateDiff: &slots}}
+	if _, err := api.Call(context.Background(), CallArgs{From: callSender, To: &callTarget}, rpc.LatestBlockNumber, overrides, nil); err == nil {
+		t.Error("call with both state and state diff succeeded")
+	}
+	if _, err := api.EstimateGas(context.Background(), CallArgs{From: callSender, To: &callTarget, Gas: 100000}, overrides, nil); err == nil {
+		t.Error("estimate with both state and state diff succeeded")
+	}
+}
+
+// Tests that eth_call and eth_estimateGas run with the chain rules of an
+// overridden block number.
+func TestCallBlockOverrides(t *testing.T) {
+	api := NewPublicBlockChainAPI(newCallBackend(t))
+
+	// Returns the block number, the coinbase and the size of the last return
+	// data, which is an invalid opcode before byzantium
+	code := hexutil.Bytes(common.FromHex("43600052416020523d60405260606000f3"))
+	overrides := &StateOverride{callTarget: {Code: &code}}
+
+	args := CallArgs{From: callSender, To: &callTarget}
+	if res, _ := api.Call(context.Background(), args, rpc.LatestBlockNumber, overrides, nil); len(res) != 0 {
+		t.Fatalf("pre-byzantium call succeeded: %x", res)
+	}
+	coinbase := common.HexToAddress("0x3000000000000000000000000000000000000003")
+	block := &BlockOverrides{Number: (*hexutil.Big)(big.NewInt(10)), Coinbase: &coinbase}
+
+	res, err := api.Call(context.Background(), args, rpc.LatestBlockNumber, overrides, block)
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	want := append(append(common.BigToHash(big.NewInt(10)).Bytes(), coinbase.Hash().Bytes()...), common.Hash{}.Bytes()...)
+	if !bytes.Equal(res, want) {
+		t.Errorf("result mismatch: have %x, want %x", res, want)
+	}
+	args.Gas = 100000
+	if _, err := api.EstimateGas(context.Background(), args, overrides, nil); err == nil {
+		t.Error("pre-byzantium estimate succeeded")
+	}
+	if gas, err := api.EstimateGas(context.Background(), args, overrides, block); err != nil || uint64(gas) <= params.TxGas {
+		t.Errorf("estimate mismatch: have %d, %v", gas, err)
+	}
+}
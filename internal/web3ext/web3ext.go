@@ -23,8 +23,11 @@ var Modules = map[string]string{
 	"clique":     Clique_JS,
 	"debug":      Debug_JS,
 	"eth":        Eth_JS,
+	"les":        Les_JS,
 	"miner":      Miner_JS,
 	"plotter":    Plotter_JS,
+	"poc":        Poc_JS,
+	"pool":       Pool_JS,
 	"net":        Net_JS,
 	"personal":   Personal_JS,
 	"rpc":        RPC_JS,
@@ -125,6 +128,17 @@ web3._extend({
 			call: 'admin_removePeer',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'banPeer',
+			call: 'admin_banPeer',
+			params: 2,
+			inputFormatter: [null, null]
+		}),
+		new web3._extend.Method({
+			name: 'unbanPeer',
+			call: 'admin_unbanPeer',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'exportChain',
 			call: 'admin_exportChain',
@@ -171,6 +185,10 @@ web3._extend({
 			name: 'peers',
 			getter: 'admin_peers'
 		}),
+		new web3._extend.Property({
+			name: 'bans',
+			getter: 'admin_listBans'
+		}),
 		new web3._extend.Property({
 			name: 'datadir',
 			getter: 'admin_datadir'
@@ -359,6 +377,12 @@ web3._extend({
 			params: 2,
 			inputFormatter: [null, null]
 		}),
+		new web3._extend.Method({
+			name: 'traceCall',
+			call: 'debug_traceCall',
+			params: 3,
+			inputFormatter: [null, null, null]
+		}),
 		new web3._extend.Method({
 			name: 'preimage',
 			call: 'debug_preimage',
@@ -465,6 +489,34 @@ web3._extend({
 	]
 });
 `
+const Les_JS = `
+web3._extend({
+	property: 'les',
+	methods: [
+		new web3._extend.Method({
+			name: 'setClientCapacity',
+			call: 'les_setClientCapacity',
+			params: 2
+		}),
+	],
+	properties:
+	[
+		new web3._extend.Property({
+			name: 'priorityClients',
+			getter: 'les_priorityClients'
+		}),
+		new web3._extend.Property({
+			name: 'clientStats',
+			getter: 'les_clientStats'
+		}),
+		new web3._extend.Property({
+			name: 'capacityInfo',
+			getter: 'les_capacityInfo'
+		}),
+	]
+});
+`
+
 const Miner_JS = `
 web3._extend({
 	property: 'miner',
@@ -569,6 +621,68 @@ web3._extend({
 });
 `
 
+const Poc_JS = `
+web3._extend({
+	property: 'poc',
+	methods: [
+		new web3._extend.Method({
+			name: 'setPlotPaths',
+			call: 'poc_setPlotPaths',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'addPlotPath',
+			call: 'poc_addPlotPath',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'removePlotPath',
+			call: 'poc_removePlotPath',
+			params: 1
+		}),
+	],
+	properties: [
+		new web3._extend.Property({
+			name: 'plotPaths',
+			getter: 'poc_getPlotPaths'
+		}),
+	]
+});
+`
+
+const Pool_JS = `
+web3._extend({
+	property: 'pool',
+	methods: [
+		new web3._extend.Method({
+			name: 'submitNonce',
+			call: 'pool_submitNonce',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.utils.fromDecimal, web3._extend.utils.fromDecimal],
+			outputFormatter: web3._extend.utils.toDecimal
+		}),
+		new web3._extend.Method({
+			name: 'getShares',
+			call: 'pool_getShares',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'getBalance',
+			call: 'pool_getBalance',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+	],
+	properties: [
+		new web3._extend.Property({
+			name: 'round',
+			getter: 'pool_getRound'
+		}),
+	]
+});
+`
+
 const Net_JS = `
 web3._extend({
 	property: 'net',
@@ -688,7 +802,23 @@ web3._extend({
 const TxPool_JS = `
 web3._extend({
 	property: 'txpool',
-	methods: [],
+	methods: [
+		new web3._extend.Method({
+			name: 'drop',
+			call: 'txpool_drop',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'setPriority',
+			call: 'txpool_setPriority',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'eviction',
+			call: 'txpool_eviction',
+			params: 1
+		}),
+	],
 	properties:
 	[
 		new web3._extend.Property({
@@ -0,0 +1,80 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"strings"
+
+	"github.com/pocethereum/pochain/p2p/discover"
+)
+
+// PrivateLightServerAPI provides an API to manage the serving capacity of the
+// LES server.
+type PrivateLightServerAPI struct {
+	server *LesServer
+}
+
+// NewPrivateLightServerAPI creates a new LES server management API.
+func NewPrivateLightServerAPI(server *LesServer) *PrivateLightServerAPI {
+	return &PrivateLightServerAPI{server: server}
+}
+
+// parseClientID parses an enode URL or a hex encoded node ID.
+func parseClientID(id string) (discover.NodeID, error) {
+	if strings.HasPrefix(id, "enode://") {
+		node, err := discover.ParseNode(id)
+		if err != nil {
+			return discover.NodeID{}, err
+		}
+		return node.ID, nil
+	}
+	return discover.HexID(id)
+}
+
+// SetClientCapacity assigns capacity to a client, making it a priority client
+// which is always served. Zero capacity turns it back into a free client. A
+// connected client is disconnected if its capacity changes, picking up the new
+// flow control parameters when it reconnects.
+func (api *PrivateLightServerAPI) SetClientCapacity(id string, capacity uint64) (bool, error) {
+	node, err := parseClientID(id)
+	if err != nil {
+		return false, err
+	}
+	if err := api.server.clientPool.setPriority(node, capacity); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// PriorityClients returns the capacities assigned to priority clients.
+func (api *PrivateLightServerAPI) PriorityClients() map[string]uint64 {
+	clients := make(map[string]uint64)
+	for id, capacity := range api.server.clientPool.priorityClients() {
+		clients[id.String()] = capacity
+	}
+	return clients
+}
+
+// ClientStats returns the usage statistics of the connected clients.
+func (api *PrivateLightServerAPI) ClientStats() []ClientInfo {
+	return api.server.clientPool.clientInfos()
+}
+
+// CapacityInfo returns how the serving capacity is shared between clients.
+func (api *PrivateLightServerAPI) CapacityInfo() CapacityInfo {
+	return api.server.clientPool.capacityInfo()
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/pocethereum/pochain/accounts"
 	"github.com/pocethereum/pochain/common"
 	"github.com/pocethereum/pochain/common/math"
+	"github.com/pocethereum/pochain/consensus"
 	"github.com/pocethereum/pochain/consensus/poc"
 	"github.com/pocethereum/pochain/core"
 	"github.com/pocethereum/pochain/core/bloombits"
@@ -48,6 +49,10 @@ func (b *LesApiBackend) ChainConfig() *params.ChainConfig {
 	return b.eth.chainConfig
 }
 
+func (b *LesApiBackend) Engine() consensus.Engine {
+	return b.eth.engine
+}
+
 func (b *LesApiBackend) CurrentBlock() *types.Block {
 	return types.NewBlockWithHeader(b.eth.BlockChain().CurrentHeader())
 }
@@ -66,15 +71,23 @@ func (b *LesApiBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNum
 }
 
 func (b *LesApiBackend) BlockPocByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.BlockPoc, error) {
+	engine, ok := b.eth.engine.(*poc.Poc)
+	if !ok {
+		return nil, nil
+	}
 	if block, _ := b.BlockByNumber(ctx, blockNr); block != nil {
-		return poc.CalcBlockPoc(block.Header()), nil
+		return engine.BlockPoc(block.Header()), nil
 	}
 	return nil, nil
 }
 
 func (b *LesApiBackend) BlockPocByHash(ctx context.Context, blockHash common.Hash) (*types.BlockPoc, error) {
+	engine, ok := b.eth.engine.(*poc.Poc)
+	if !ok {
+		return nil, nil
+	}
 	if block, _ := b.GetBlock(ctx, blockHash); block != nil {
-		return poc.CalcBlockPoc(block.Header()), nil
+		return engine.BlockPoc(block.Header()), nil
 	}
 	return nil, nil
 }
@@ -95,6 +108,14 @@ func (b *LesApiBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.
 	return light.NewState(ctx, header, b.eth.odr), header, nil
 }
 
+func (b *LesApiBackend) StateAndHeaderByHash(ctx context.Context, blockHash common.Hash) (*state.StateDB, *types.Header, error) {
+	header := b.eth.blockchain.GetHeaderByHash(blockHash)
+	if header == nil {
+		return nil, nil, nil
+	}
+	return light.NewState(ctx, header, b.eth.odr), header, nil
+}
+
 func (b *LesApiBackend) GetBlock(ctx context.Context, blockHash common.Hash) (*types.Block, error) {
 	return b.eth.blockchain.GetBlockByHash(ctx, blockHash)
 }
@@ -0,0 +1,121 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/pocethereum/pochain/accounts/abi/bind"
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/contracts/checkpointoracle"
+	"github.com/pocethereum/pochain/light"
+	"github.com/pocethereum/pochain/log"
+	"github.com/pocethereum/pochain/params"
+)
+
+// checkpointOracle is responsible for offering the latest stable checkpoint
+// registered on-chain by the admins of the checkpoint oracle contract. Servers
+// advertise it along with the admin votes, which the clients verify locally
+// against their configured admins before syncing from it.
+type checkpointOracle struct {
+	config   *params.CheckpointOracleConfig
+	contract *checkpointoracle.CheckpointOracle
+
+	running  int32                                 // Flag whether the contract backend is set or not
+	getLocal func(uint64) params.TrustedCheckpoint // Function used to retrieve local checkpoint
+
+	lock   sync.Mutex
+	cached *params.TrustedCheckpoint // Latest stable checkpoint seen on-chain
+	height uint64                    // Block number the cached checkpoint was registered at
+	sigs   [][]byte                  // Admin votes of the cached checkpoint
+}
+
+// newCheckpointOracle returns a checkpoint registrar handler, or nil if no
+// oracle is configured for the chain.
+func newCheckpointOracle(config *params.CheckpointOracleConfig, getLocal func(uint64) params.TrustedCheckpoint) *checkpointOracle {
+	if config == nil {
+		log.Info("Checkpoint oracle is not enabled")
+		return nil
+	}
+	if config.Address == (common.Address{}) || uint64(len(config.Signers)) < config.Threshold {
+		log.Warn("Invalid checkpoint oracle config")
+		return nil
+	}
+	log.Info("Configured checkpoint oracle", "address", config.Address, "signers", len(config.Signers), "threshold", config.Threshold)
+	return &checkpointOracle{
+		config:   config,
+		getLocal: getLocal,
+	}
+}
+
+// start binds the contract backend, enabling the on-chain lookups.
+func (reg *checkpointOracle) start(backend bind.ContractBackend) {
+	if reg.isRunning() {
+		log.Error("Already bound to checkpoint oracle")
+		return
+	}
+	contract, err := checkpointoracle.NewCheckpointOracle(reg.config.Address, backend)
+	if err != nil {
+		log.Error("Oracle contract binding failed", "err", err)
+		return
+	}
+	reg.contract = contract
+	atomic.StoreInt32(&reg.running, 1)
+}
+
+// isRunning returns an indicator whether the registrar is running.
+func (reg *checkpointOracle) isRunning() bool {
+	return atomic.LoadInt32(&reg.running) == 1
+}
+
+// stableCheckpoint returns the latest checkpoint registered on-chain along with
+// the block number it was registered at and the admin votes for it. Nothing is
+// returned if the local CHT and bloom trie of the section don't match it, as
+// the checkpoint couldn't be served in that case.
+func (reg *checkpointOracle) stableCheckpoint() (*params.TrustedCheckpoint, uint64, [][]byte) {
+	index, hash, height, err := reg.contract.LatestCheckpoint(nil)
+	if err != nil || height == 0 {
+		return nil, 0, nil
+	}
+	reg.lock.Lock()
+	defer reg.lock.Unlock()
+
+	if reg.cached != nil && reg.cached.SectionIndex == index && reg.cached.HashEqual(hash) {
+		return reg.cached, reg.height, reg.sigs
+	}
+	local := reg.getLocal(index)
+	if !local.HashEqual(hash) {
+		log.Debug("Registered checkpoint doesn't match local section", "section", index, "hash", hash)
+		return nil, 0, nil
+	}
+	// Admins only vote once the section is finished, skip the blocks before it
+	start := (index + 1) * light.CHTFrequencyClient
+	sigs, err := reg.contract.LookupVotes(&bind.FilterOpts{Start: start, End: &height}, index, hash)
+	if err != nil {
+		log.Warn("Failed to look up checkpoint votes", "section", index, "err", err)
+		return nil, 0, nil
+	}
+	reg.cached, reg.height, reg.sigs = &local, height, sigs
+	return reg.cached, reg.height, reg.sigs
+}
+
+// verifySigners recovers the signer addresses according to the signature and
+// checks whether there are enough approvals to finalize the checkpoint.
+func (reg *checkpointOracle) verifySigners(index uint64, hash common.Hash, signatures [][]byte) (bool, []common.Address) {
+	return checkpointoracle.VerifySigners(reg.config.Address, index, hash, signatures, reg.config.Signers, reg.config.Threshold)
+}
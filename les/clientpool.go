@@ -0,0 +1,357 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pocethereum/pochain/ethdb"
+	"github.com/pocethereum/pochain/les/flowcontrol"
+	"github.com/pocethereum/pochain/log"
+	"github.com/pocethereum/pochain/p2p"
+	"github.com/pocethereum/pochain/p2p/discover"
+	"github.com/pocethereum/pochain/rlp"
+)
+
+var (
+	errNoCapacity        = errors.New("no free client capacity")
+	errAlreadyConnected  = errors.New("client already connected")
+	errCapacityTooLow    = errors.New("priority capacity below the capacity of free clients")
+	errCapacityExhausted = errors.New("priority capacities exceed the total capacity")
+)
+
+var priorityClientsKey = []byte("_priorityClients")
+
+// ClientInfo describes a client connected to the server and its usage of the
+// serving capacity.
+type ClientInfo struct {
+	ID        discover.NodeID `json:"id"`
+	Priority  bool            `json:"priority"`
+	Capacity  uint64          `json:"capacity"`
+	Connected time.Time       `json:"connected"`
+	Requests  uint64          `json:"requests"` // Number of requests served
+	Cost      uint64          `json:"cost"`     // Sum of the flow control costs of the requests
+}
+
+// CapacityInfo summarizes how the serving capacity is shared between clients.
+type CapacityInfo struct {
+	Total             uint64 `json:"total"`             // Capacity shared between all clients
+	Free              uint64 `json:"free"`              // Capacity of each free client
+	PriorityAssigned  uint64 `json:"priorityAssigned"`  // Capacity assigned to priority clients
+	PriorityConnected uint64 `json:"priorityConnected"` // Capacity used by connected priority clients
+	FreeClients       int    `json:"freeClients"`       // Number of connected free clients
+}
+
+// poolClient is a client connected to the server.
+type poolClient struct {
+	id         discover.NodeID
+	params     *flowcontrol.ServerParams
+	priority   bool
+	connected  time.Time
+	node       *flowcontrol.ClientNode // flow control state, nil until the handshake is done
+	disconnect func(p2p.DiscReason)
+}
+
+// priorityClient is the persisted capacity assignment of a priority client.
+type priorityClient struct {
+	ID       discover.NodeID
+	Capacity uint64
+}
+
+// clientPool shares the serving capacity of the server between clients. The
+// capacity of a client is the recharge rate of its flow control buffer. Priority
+// clients are served with the capacity assigned to them, free clients share the
+// rest, each getting the default flow control parameters. Free clients are
+// disconnected whenever a priority client needs their capacity.
+type clientPool struct {
+	lock       sync.Mutex
+	db         ethdb.Database // Database persisting the priority assignments, nil if not persisted
+	freeParams *flowcontrol.ServerParams
+	totalCap   uint64
+
+	priority          map[discover.NodeID]uint64 // Capacities assigned to priority clients
+	clients           map[discover.NodeID]*poolClient
+	priorityConnected uint64 // Sum of the capacities of the connected priority clients
+	freeConnected     int    // Number of connected free clients
+}
+
+// newClientPool creates a client pool with the given total capacity, serving
+// free clients with the given flow control parameters. Priority assignments are
+// loaded from the database if one is given.
+func newClientPool(db ethdb.Database, freeParams *flowcontrol.ServerParams, totalCap uint64) *clientPool {
+	cp := &clientPool{
+		db:         db,
+		freeParams: freeParams,
+		totalCap:   totalCap,
+		priority:   make(map[discover.NodeID]uint64),
+		clients:    make(map[discover.NodeID]*poolClient),
+	}
+	if db == nil {
+		return cp
+	}
+	if data, err := db.Get(priorityClientsKey); err == nil {
+		var list []priorityClient
+		if err := rlp.DecodeBytes(data, &list); err != nil {
+			log.Error("Failed to decode priority clients", "err", err)
+		}
+		for _, c := range list {
+			cp.priority[c.ID] = c.Capacity
+		}
+	}
+	return cp
+}
+
+// params returns the flow control parameters for the given capacity, keeping
+// the ratio of buffer limit and recharge rate of free clients.
+func (cp *clientPool) params(capacity uint64) *flowcontrol.ServerParams {
+	return &flowcontrol.ServerParams{
+		BufLimit:    cp.freeParams.BufLimit / cp.freeParams.MinRecharge * capacity,
+		MinRecharge: capacity,
+	}
+}
+
+// isPriority reports whether the node has capacity assigned.
+func (cp *clientPool) isPriority(id discover.NodeID) bool {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+
+	_, ok := cp.priority[id]
+	return ok
+}
+
+// connect reserves capacity for a newly connected client. Priority clients are
+// always accepted, disconnecting the free clients with the highest usage if
+// needed. Free clients are only accepted if unused capacity is left.
+func (cp *clientPool) connect(id discover.NodeID, disconnect func(p2p.DiscReason)) (*poolClient, error) {
+	cp.lock.Lock()
+
+	if _, ok := cp.clients[id]; ok {
+		cp.lock.Unlock()
+		return nil, errAlreadyConnected
+	}
+	client := &poolClient{id: id, connected: time.Now(), disconnect: disconnect}
+	capacity, priority := cp.priority[id]
+	if !priority {
+		if cp.available() < cp.freeParams.MinRecharge {
+			cp.lock.Unlock()
+			return nil, errNoCapacity
+		}
+		client.params = cp.freeParams
+		cp.clients[id] = client
+		cp.freeConnected++
+		cp.lock.Unlock()
+		return client, nil
+	}
+	// Make room for the priority client
+	var kicked []*poolClient
+	for cp.available() < capacity {
+		victim := cp.busiestFree()
+		if victim == nil {
+			break // can't happen as long as the assignments fit the total capacity
+		}
+		cp.remove(victim)
+		kicked = append(kicked, victim)
+	}
+	client.params, client.priority = cp.params(capacity), true
+	cp.clients[id] = client
+	cp.priorityConnected += capacity
+	cp.lock.Unlock()
+
+	for _, c := range kicked {
+		log.Debug("Disconnecting free LES client for priority client", "id", c.id, "priority", id)
+		c.disconnect(p2p.DiscTooManyPeers)
+	}
+	return client, nil
+}
+
+// available returns the capacity not used by the connected clients. The lock
+// must be held.
+func (cp *clientPool) available() uint64 {
+	used := cp.priorityConnected + uint64(cp.freeConnected)*cp.freeParams.MinRecharge
+	if used > cp.totalCap {
+		return 0
+	}
+	return cp.totalCap - used
+}
+
+// busiestFree returns the connected free client with the highest request
+// costs. The lock must be held.
+func (cp *clientPool) busiestFree() *poolClient {
+	var (
+		busiest *poolClient
+		maxCost uint64
+	)
+	for _, c := range cp.clients {
+		if c.priority {
+			continue
+		}
+		var cost uint64
+		if c.node != nil {
+			_, cost = c.node.Stats()
+		}
+		if busiest == nil || cost > maxCost {
+			busiest, maxCost = c, cost
+		}
+	}
+	return busiest
+}
+
+// registered stores the flow control state of a client after the handshake.
+func (cp *clientPool) registered(client *poolClient, node *flowcontrol.ClientNode) {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+
+	client.node = node
+}
+
+// disconnect releases the capacity of a client.
+func (cp *clientPool) disconnect(client *poolClient) {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+
+	cp.remove(client)
+}
+
+// remove drops a client from the pool unless it was already removed. The lock
+// must be held.
+func (cp *clientPool) remove(client *poolClient) {
+	if cp.clients[client.id] != client {
+		return
+	}
+	delete(cp.clients, client.id)
+	if client.priority {
+		cp.priorityConnected -= client.params.MinRecharge
+	} else {
+		cp.freeConnected--
+	}
+}
+
+// setPriority assigns capacity to a client, zero turning it into a free client.
+// A connected client whose capacity changes is disconnected, so that it gets
+// the new flow control parameters when it reconnects.
+func (cp *clientPool) setPriority(id discover.NodeID, capacity uint64) error {
+	cp.lock.Lock()
+
+	if capacity != 0 {
+		if capacity < cp.freeParams.MinRecharge {
+			cp.lock.Unlock()
+			return errCapacityTooLow
+		}
+		assigned := capacity
+		for other, c := range cp.priority {
+			if other != id {
+				assigned += c
+			}
+		}
+		if assigned > cp.totalCap {
+			cp.lock.Unlock()
+			return errCapacityExhausted
+		}
+		cp.priority[id] = capacity
+	} else {
+		delete(cp.priority, id)
+	}
+	cp.store()
+
+	client := cp.clients[id]
+	if client != nil && client.params.MinRecharge == capacity && client.priority {
+		client = nil // capacity unchanged
+	}
+	if client != nil {
+		cp.remove(client)
+	}
+	cp.lock.Unlock()
+
+	if client != nil {
+		client.disconnect(p2p.DiscRequested)
+	}
+	return nil
+}
+
+// store persists the priority assignments. The lock must be held.
+func (cp *clientPool) store() {
+	if cp.db == nil {
+		return
+	}
+	list := make([]priorityClient, 0, len(cp.priority))
+	for id, capacity := range cp.priority {
+		list = append(list, priorityClient{id, capacity})
+	}
+	data, err := rlp.EncodeToBytes(list)
+	if err == nil {
+		err = cp.db.Put(priorityClientsKey, data)
+	}
+	if err != nil {
+		log.Error("Failed to store priority clients", "err", err)
+	}
+}
+
+// priorityClients returns the capacities assigned to priority clients.
+func (cp *clientPool) priorityClients() map[discover.NodeID]uint64 {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+
+	clients := make(map[discover.NodeID]uint64, len(cp.priority))
+	for id, capacity := range cp.priority {
+		clients[id] = capacity
+	}
+	return clients
+}
+
+// clientInfos returns the usage statistics of the connected clients, sorted by
+// connection time.
+func (cp *clientPool) clientInfos() []ClientInfo {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+
+	infos := make([]ClientInfo, 0, len(cp.clients))
+	for _, c := range cp.clients {
+		info := ClientInfo{
+			ID:        c.id,
+			Priority:  c.priority,
+			Capacity:  c.params.MinRecharge,
+			Connected: c.connected,
+		}
+		if c.node != nil {
+			info.Requests, info.Cost = c.node.Stats()
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Connected.Before(infos[j].Connected) })
+	return infos
+}
+
+// capacityInfo returns how the capacity is currently shared.
+func (cp *clientPool) capacityInfo() CapacityInfo {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+
+	var assigned uint64
+	for _, capacity := range cp.priority {
+		assigned += capacity
+	}
+	return CapacityInfo{
+		Total:             cp.totalCap,
+		Free:              cp.freeParams.MinRecharge,
+		PriorityAssigned:  assigned,
+		PriorityConnected: cp.priorityConnected,
+		FreeClients:       cp.freeConnected,
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"testing"
+
+	"github.com/pocethereum/pochain/ethdb"
+	"github.com/pocethereum/pochain/les/flowcontrol"
+	"github.com/pocethereum/pochain/p2p"
+	"github.com/pocethereum/pochain/p2p/discover"
+)
+
+// testClient records the disconnection of a pool client.
+type testClient struct {
+	id     discover.NodeID
+	reason *p2p.DiscReason
+}
+
+func newTestClient(i byte) *testClient {
+	return &testClient{id: discover.NodeID{i}}
+}
+
+func (c *testClient) disconnect(reason p2p.DiscReason) {
+	c.reason = &reason
+}
+
+func TestClientPoolCapacity(t *testing.T) {
+	var (
+		db         = ethdb.NewMemDatabase()
+		freeParams = &flowcontrol.ServerParams{BufLimit: 600, MinRecharge: 10}
+		pool       = newClientPool(db, freeParams, 40)
+		fleet      = newTestClient(0xff)
+	)
+	if err := pool.setPriority(fleet.id, 5); err != errCapacityTooLow {
+		t.Fatalf("wrong error for low capacity: %v", err)
+	}
+	if err := pool.setPriority(fleet.id, 50); err != errCapacityExhausted {
+		t.Fatalf("wrong error for excessive capacity: %v", err)
+	}
+	if err := pool.setPriority(fleet.id, 20); err != nil {
+		t.Fatalf("can't assign capacity: %v", err)
+	}
+	// Free clients may use the whole capacity while the priority client is away
+	var free []*testClient
+	for i := 0; i < 4; i++ {
+		c := newTestClient(byte(i))
+		client, err := pool.connect(c.id, c.disconnect)
+		if err != nil {
+			t.Fatalf("free client %d rejected: %v", i, err)
+		}
+		if *client.params != *freeParams {
+			t.Fatalf("wrong free client params: %+v", client.params)
+		}
+		node := flowcontrol.NewClientNode(flowcontrol.NewClientManager(50, 10, 1000000000), client.params)
+		node.RequestProcessed(uint64(i))
+		pool.registered(client, node)
+		free = append(free, c)
+	}
+	if _, err := pool.connect(discover.NodeID{0x10}, nil); err != errNoCapacity {
+		t.Fatalf("wrong error for free client over capacity: %v", err)
+	}
+	// The priority client pushes out the free clients having used the most
+	client, err := pool.connect(fleet.id, fleet.disconnect)
+	if err != nil {
+		t.Fatalf("priority client rejected: %v", err)
+	}
+	if client.params.MinRecharge != 20 || client.params.BufLimit != 1200 {
+		t.Errorf("wrong priority client params: %+v", client.params)
+	}
+	for i, c := range free {
+		if kicked := c.reason != nil; kicked != (i >= 2) {
+			t.Errorf("free client %d: kicked %v", i, kicked)
+		}
+	}
+	info := pool.capacityInfo()
+	if info.PriorityAssigned != 20 || info.PriorityConnected != 20 || info.FreeClients != 2 {
+		t.Errorf("wrong capacity info: %+v", info)
+	}
+	stats := pool.clientInfos()
+	if len(stats) != 3 {
+		t.Fatalf("wrong number of client stats: %+v", stats)
+	}
+	for _, info := range stats {
+		switch {
+		case info.ID == fleet.id && (!info.Priority || info.Capacity != 20):
+			t.Errorf("wrong priority client stats: %+v", info)
+		case info.ID == free[1].id && (info.Priority || info.Requests != 1 || info.Cost != 1):
+			t.Errorf("wrong free client stats: %+v", info)
+		}
+	}
+	// Changing the capacity of a connected client disconnects it
+	if err := pool.setPriority(fleet.id, 0); err != nil {
+		t.Fatalf("can't remove priority: %v", err)
+	}
+	if fleet.reason == nil || *fleet.reason != p2p.DiscRequested {
+		t.Error("priority client not disconnected after capacity change")
+	}
+	pool.disconnect(client) // no-op, already removed
+	if info := pool.capacityInfo(); info.PriorityConnected != 0 || info.FreeClients != 2 {
+		t.Errorf("wrong capacity info after removing priority: %+v", info)
+	}
+}
+
+func TestClientPoolPersistence(t *testing.T) {
+	var (
+		db         = ethdb.NewMemDatabase()
+		freeParams = &flowcontrol.ServerParams{BufLimit: 600, MinRecharge: 10}
+		id         = discover.NodeID{1}
+	)
+	if err := newClientPool(db, freeParams, 40).setPriority(id, 30); err != nil {
+		t.Fatalf("can't assign capacity: %v", err)
+	}
+	pool := newClientPool(db, freeParams, 40)
+	if clients := pool.priorityClients(); len(clients) != 1 || clients[id] != 30 {
+		t.Errorf("priority clients not restored: %v", clients)
+	}
+	if !pool.isPriority(id) {
+		t.Error("restored client not prioritized")
+	}
+}
@@ -37,6 +37,8 @@ type ClientNode struct {
 	lock     sync.Mutex
 	cm       *ClientManager
 	cmNode   *cmNode
+
+	requests, sumCost uint64 // usage statistics: number of requests and sum of their costs
 }
 
 func NewClientNode(cm *ClientManager, params *ServerParams) *ClientNode {
@@ -82,6 +84,8 @@ func (peer *ClientNode) RequestProcessed(cost uint64) (bv, realCost uint64) {
 	time := mclock.Now()
 	peer.recalcBV(time)
 	peer.bufValue -= cost
+	peer.requests++
+	peer.sumCost += cost
 	peer.recalcBV(time)
 	rcValue, rcost := peer.cm.processed(peer.cmNode, time)
 	if rcValue < peer.params.BufLimit {
@@ -93,6 +97,15 @@ func (peer *ClientNode) RequestProcessed(cost uint64) (bv, realCost uint64) {
 	return peer.bufValue, rcost
 }
 
+// Stats returns the number of requests processed for the client and the sum of
+// their costs.
+func (peer *ClientNode) Stats() (requests, sumCost uint64) {
+	peer.lock.Lock()
+	defer peer.lock.Unlock()
+
+	return peer.requests, peer.sumCost
+}
+
 type ServerNode struct {
 	bufEstimate uint64
 	lastTime    mclock.AbsTime
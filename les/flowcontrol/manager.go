@@ -33,6 +33,7 @@ type cmNode struct {
 	rcWeight                     uint64
 	rcValue, rcDelta, startValue int64
 	finishRecharge               mclock.AbsTime
+	served                       float64 // Serving time used relative to the weight, orders waiting requests
 }
 
 func (node *cmNode) update(time mclock.AbsTime) {
@@ -67,23 +68,38 @@ func (node *cmNode) set(serving bool, simReqCnt, sumWeight uint64) {
 	}
 }
 
+// cmRequest is a request waiting for the manager to be able to serve it.
+type cmRequest struct {
+	node   *cmNode
+	resume chan bool // Receives whether the request was accepted
+}
+
+// ClientManager shares the serving time of the server between the clients.
+// Requests not fitting into the limits wait in a queue, from which the client
+// that used the least serving time relative to its weight is served first.
+// The weight of a client is its capacity, the recharge rate of its buffer, so
+// a client of twice the capacity gets twice the serving time under load.
 type ClientManager struct {
 	lock                             sync.Mutex
 	nodes                            map[*cmNode]struct{}
 	simReqCnt, sumWeight, rcSumValue uint64
 	maxSimReq, maxRcSum              uint64
 	rcRecharge                       uint64
-	resumeQueue                      chan chan bool
+	queue                            []*cmRequest
+	served                           float64 // Relative serving time of the last started request
+	wake                             chan struct{}
+	quit                             chan struct{}
 	time                             mclock.AbsTime
 }
 
 func NewClientManager(rcTarget, maxSimReq, maxRcSum uint64) *ClientManager {
 	cm := &ClientManager{
-		nodes:       make(map[*cmNode]struct{}),
-		resumeQueue: make(chan chan bool),
-		rcRecharge:  rcConst * rcConst / (100*rcConst/rcTarget - rcConst),
-		maxSimReq:   maxSimReq,
-		maxRcSum:    maxRcSum,
+		nodes:      make(map[*cmNode]struct{}),
+		wake:       make(chan struct{}, 1),
+		quit:       make(chan struct{}),
+		rcRecharge: rcConst * rcConst / (100*rcConst/rcTarget - rcConst),
+		maxSimReq:  maxSimReq,
+		maxRcSum:   maxRcSum,
 	}
 	go cm.queueProc()
 	return cm
@@ -95,7 +111,11 @@ func (self *ClientManager) Stop() {
 
 	// signal any waiting accept routines to return false
 	self.nodes = make(map[*cmNode]struct{})
-	close(self.resumeQueue)
+	for _, req := range self.queue {
+		req.resume <- false
+	}
+	self.queue = nil
+	close(self.quit)
 }
 
 func (self *ClientManager) addNode(cnode *ClientNode) *cmNode {
@@ -106,6 +126,9 @@ func (self *ClientManager) addNode(cnode *ClientNode) *cmNode {
 		finishRecharge: time,
 		rcWeight:       1,
 	}
+	if cnode.params.MinRecharge > 1 {
+		node.rcWeight = cnode.params.MinRecharge
+	}
 	self.lock.Lock()
 	defer self.lock.Unlock()
 
@@ -122,6 +145,17 @@ func (self *ClientManager) removeNode(node *cmNode) {
 	self.stop(node, time)
 	delete(self.nodes, node)
 	self.update(time)
+
+	// Reject the requests of the node still waiting
+	queue := self.queue[:0]
+	for _, req := range self.queue {
+		if req.node == node {
+			req.resume <- false
+		} else {
+			queue = append(queue, req)
+		}
+	}
+	self.queue = queue
 }
 
 // recalc sumWeight
@@ -168,42 +202,93 @@ func (self *ClientManager) canStartReq() bool {
 	return self.simReqCnt < self.maxSimReq && self.rcSumValue < self.maxRcSum
 }
 
+// queueProc serves the waiting requests whenever the limits allow, retrying
+// periodically while they don't.
 func (self *ClientManager) queueProc() {
-	for rc := range self.resumeQueue {
-		for {
-			time.Sleep(time.Millisecond * 10)
-			self.lock.Lock()
-			self.update(mclock.Now())
-			cs := self.canStartReq()
-			self.lock.Unlock()
-			if cs {
-				break
-			}
+	for {
+		self.lock.Lock()
+		self.update(mclock.Now())
+		for len(self.queue) > 0 && self.canStartReq() {
+			req := self.dequeue()
+			self.start(req.node)
+			req.resume <- true
+		}
+		var retry <-chan time.Time
+		if len(self.queue) > 0 {
+			retry = time.After(time.Millisecond * 10)
+		}
+		self.lock.Unlock()
+
+		select {
+		case <-self.wake:
+		case <-retry:
+		case <-self.quit:
+			return
+		}
+	}
+}
+
+// signal wakes up the queue processing if requests are waiting. The lock is
+// assumed to be held.
+func (self *ClientManager) signal() {
+	if len(self.queue) > 0 {
+		select {
+		case self.wake <- struct{}{}:
+		default:
 		}
-		close(rc)
 	}
 }
 
+// dequeue removes the waiting request of the client with the least relative
+// serving time used, the earliest one on ties. The lock is assumed to be held.
+func (self *ClientManager) dequeue() *cmRequest {
+	best := 0
+	for i, req := range self.queue {
+		if req.node.served < self.queue[best].node.served {
+			best = i
+		}
+	}
+	req := self.queue[best]
+	self.queue = append(self.queue[:best], self.queue[best+1:]...)
+	return req
+}
+
+// accept starts serving a request of the node, waiting until the limits allow
+// it. Requests only go ahead of the waiting ones if none are queued.
 func (self *ClientManager) accept(node *cmNode, time mclock.AbsTime) bool {
 	self.lock.Lock()
 	defer self.lock.Unlock()
 
 	self.update(time)
-	if !self.canStartReq() {
-		resume := make(chan bool)
-		self.lock.Unlock()
-		self.resumeQueue <- resume
-		<-resume
-		self.lock.Lock()
+	if len(self.queue) > 0 || !self.canStartReq() {
 		if _, ok := self.nodes[node]; !ok {
 			return false // reject if node has been removed or manager has been stopped
 		}
+		resume := make(chan bool, 1)
+		self.queue = append(self.queue, &cmRequest{node: node, resume: resume})
+		self.signal()
+		self.lock.Unlock()
+		accepted := <-resume
+		self.lock.Lock()
+		return accepted
+	}
+	self.start(node)
+	return true
+}
+
+// start marks a request of the node as being served. Clients idle for a while
+// don't get to catch up on the serving time they haven't used. The lock is
+// assumed to be held.
+func (self *ClientManager) start(node *cmNode) {
+	if node.served < self.served {
+		node.served = self.served
 	}
+	self.served = node.served
+
 	self.simReqCnt++
 	node.set(true, self.simReqCnt, self.sumWeight)
 	node.startValue = node.rcValue
 	self.update(self.time)
-	return true
 }
 
 func (self *ClientManager) stop(node *cmNode, time mclock.AbsTime) {
@@ -211,7 +296,9 @@ func (self *ClientManager) stop(node *cmNode, time mclock.AbsTime) {
 		self.update(time)
 		self.simReqCnt--
 		node.set(false, self.simReqCnt, self.sumWeight)
+		node.served += float64(node.rcValue-node.startValue) / float64(node.rcWeight)
 		self.update(time)
+		self.signal()
 	}
 }
 
@@ -0,0 +1,106 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package flowcontrol
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Tests that a client with a higher capacity gets a bigger share of the serving
+// time when free clients keep the server busy.
+func TestClientManagerPriority(t *testing.T) {
+	cm := NewClientManager(50, 1, 1000000000)
+	defer cm.Stop()
+
+	const (
+		freeClients = 5
+		freeCap     = 100
+		priorityCap = 10 * freeCap
+	)
+	var (
+		served = make([]uint64, freeClients+1) // The last client is the priority one
+		stop   = make(chan struct{})
+		wg     sync.WaitGroup
+	)
+	for i := range served {
+		capacity := uint64(freeCap)
+		if i == freeClients {
+			capacity = priorityCap
+		}
+		node := NewClientNode(cm, &ServerParams{BufLimit: capacity * 1000, MinRecharge: capacity})
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if _, ok := node.AcceptRequest(); !ok {
+					return
+				}
+				time.Sleep(time.Millisecond) // Serve the request
+				node.RequestProcessed(0)
+				atomic.AddUint64(&served[i], 1)
+			}
+		}(i)
+	}
+	time.Sleep(2 * time.Second)
+	close(stop)
+	wg.Wait()
+
+	var free uint64
+	for _, n := range served[:freeClients] {
+		free += n
+	}
+	if priority := served[freeClients]; priority < 2*free/freeClients {
+		t.Errorf("priority client starved: served %d requests, free clients %v", priority, served[:freeClients])
+	}
+}
+
+// Tests that requests waiting to be served are rejected when the manager is
+// stopped.
+func TestClientManagerStop(t *testing.T) {
+	cm := NewClientManager(50, 1, 1000000000)
+
+	params := &ServerParams{BufLimit: 1000, MinRecharge: 1}
+	busy, waiting := NewClientNode(cm, params), NewClientNode(cm, params)
+	if _, ok := busy.AcceptRequest(); !ok {
+		t.Fatal("first request not accepted")
+	}
+	result := make(chan bool)
+	go func() {
+		_, ok := waiting.AcceptRequest()
+		result <- ok
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cm.Stop()
+
+	select {
+	case ok := <-result:
+		if ok {
+			t.Error("waiting request accepted after stop")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiting request not released")
+	}
+}
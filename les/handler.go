@@ -107,6 +107,7 @@ type ProtocolManager struct {
 	lesTopic    discv5.Topic
 	reqDist     *requestDistributor
 	retriever   *retrieveManager
+	oracle      *checkpointOracle // Verifies the checkpoints advertised by servers, nil if not configured
 
 	downloader *downloader.Downloader
 	fetcher    *lightFetcher
@@ -263,13 +264,26 @@ func (pm *ProtocolManager) newPeer(pv int, nv uint64, p *p2p.Peer, rw p2p.MsgRea
 // handle is the callback invoked to manage the life cycle of a les peer. When
 // this function terminates, the peer is disconnected.
 func (pm *ProtocolManager) handle(p *peer) error {
-	// Ignore maxPeers if this is a trusted peer
-	if pm.peers.Len() >= pm.maxPeers && !p.Peer.Info().Network.Trusted {
+	// Ignore maxPeers if this is a trusted peer or a priority client
+	priority := pm.server != nil && pm.server.clientPool.isPriority(p.ID())
+	if pm.peers.Len() >= pm.maxPeers && !p.Peer.Info().Network.Trusted && !priority {
 		return p2p.DiscTooManyPeers
 	}
 
 	p.Log().Debug("Light Ethereum peer connected", "name", p.Name())
 
+	// Reserve serving capacity for clients
+	var client *poolClient
+	if pm.server != nil {
+		var err error
+		if client, err = pm.server.clientPool.connect(p.ID(), p.Peer.Disconnect); err != nil {
+			p.Log().Debug("Light Ethereum client rejected", "err", err)
+			return p2p.DiscTooManyPeers
+		}
+		defer pm.server.clientPool.disconnect(client)
+		p.fcParams = client.params
+	}
+
 	// Execute the LES handshake
 	var (
 		genesis = pm.blockchain.Genesis()
@@ -285,6 +299,9 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	if rw, ok := p.rw.(*meteredMsgReadWriter); ok {
 		rw.Init(p.version)
 	}
+	if client != nil {
+		pm.server.clientPool.registered(client, p.fcClient)
+	}
 	// Register the peer locally
 	if err := pm.peers.Register(p); err != nil {
 		p.Log().Error("Light Ethereum peer registration failed", "err", err)
@@ -352,11 +369,11 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 		bufValue, _ := p.fcClient.AcceptRequest()
 		cost := costs.baseCost + reqCnt*costs.reqCost
-		if cost > pm.server.defParams.BufLimit {
-			cost = pm.server.defParams.BufLimit
+		if cost > p.fcParams.BufLimit {
+			cost = p.fcParams.BufLimit
 		}
 		if cost > bufValue {
-			recharge := time.Duration((cost - bufValue) * 1000000 / pm.server.defParams.MinRecharge)
+			recharge := time.Duration((cost - bufValue) * 1000000 / p.fcParams.MinRecharge)
 			p.Log().Error("Request came too early", "recharge", common.PrettyDuration(recharge))
 			return true
 		}
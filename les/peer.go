@@ -32,6 +32,7 @@ import (
 	"github.com/pocethereum/pochain/les/flowcontrol"
 	"github.com/pocethereum/pochain/light"
 	"github.com/pocethereum/pochain/p2p"
+	"github.com/pocethereum/pochain/params"
 	"github.com/pocethereum/pochain/rlp"
 )
 
@@ -72,10 +73,17 @@ type peer struct {
 	hasBlock       func(common.Hash, uint64) bool
 	responseErrors int
 
-	fcClient       *flowcontrol.ClientNode // nil if the peer is server only
-	fcServer       *flowcontrol.ServerNode // nil if the peer is client only
+	fcClient       *flowcontrol.ClientNode   // nil if the peer is server only
+	fcParams       *flowcontrol.ServerParams // flow control parameters assigned to a client peer
+	fcServer       *flowcontrol.ServerNode   // nil if the peer is client only
 	fcServerParams *flowcontrol.ServerParams
 	fcCosts        requestCostTable
+
+	// Checkpoint advertised by the server, along with its registration block
+	// and the admin votes for it. Only valid if verified against the oracle.
+	checkpoint       params.TrustedCheckpoint
+	checkpointNumber uint64
+	checkpointSigs   [][]byte
 }
 
 func newPeer(version int, network uint64, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
@@ -409,11 +417,20 @@ func (p *peer) Handshake(td *big.Int, head common.Hash, headNum uint64, genesis
 		send = send.add("serveChainSince", uint64(0))
 		send = send.add("serveStateSince", uint64(0))
 		send = send.add("txRelay", nil)
-		send = send.add("flowControl/BL", server.defParams.BufLimit)
-		send = send.add("flowControl/MRR", server.defParams.MinRecharge)
+		send = send.add("flowControl/BL", p.fcParams.BufLimit)
+		send = send.add("flowControl/MRR", p.fcParams.MinRecharge)
 		list := server.fcCostStats.getCurrentList()
 		send = send.add("flowControl/MRC", list)
 		p.fcCosts = list.decode()
+
+		// Advertise the latest checkpoint registered in the oracle, if any
+		if server.oracle != nil && server.oracle.isRunning() {
+			if cp, height, sigs := server.oracle.stableCheckpoint(); cp != nil {
+				send = send.add("checkpoint/value", cp)
+				send = send.add("checkpoint/registerHeight", height)
+				send = send.add("checkpoint/signatures", sigs)
+			}
+		}
 	} else {
 		p.requestAnnounceType = announceTypeSimple // set to default until "very light" client mode is implemented
 		send = send.add("announceType", p.requestAnnounceType)
@@ -464,7 +481,7 @@ func (p *peer) Handshake(td *big.Int, head common.Hash, headNum uint64, genesis
 		if recv.get("announceType", &p.announceType) != nil {
 			p.announceType = announceTypeSimple
 		}
-		p.fcClient = flowcontrol.NewClientNode(server.fcManager, server.defParams)
+		p.fcClient = flowcontrol.NewClientNode(server.fcManager, p.fcParams)
 	} else {
 		if recv.get("serveChainSince", nil) != nil {
 			return errResp(ErrUselessPeer, "peer cannot serve chain")
@@ -490,6 +507,14 @@ func (p *peer) Handshake(td *big.Int, head common.Hash, headNum uint64, genesis
 		p.fcServer = flowcontrol.NewServerNode(params)
 		p.fcCosts = MRC.decode()
 	}
+	if server == nil {
+		// Checkpoints are optional, the client verifies them before use
+		if recv.get("checkpoint/value", &p.checkpoint) == nil {
+			if recv.get("checkpoint/registerHeight", &p.checkpointNumber) != nil || recv.get("checkpoint/signatures", &p.checkpointSigs) != nil {
+				p.checkpoint = params.TrustedCheckpoint{}
+			}
+		}
+	}
 
 	p.headInfo = &announceData{Td: rTd, Hash: rHash, Number: rNum}
 	return nil
@@ -23,6 +23,7 @@ import (
 	"math"
 	"sync"
 
+	"github.com/pocethereum/pochain/accounts/abi/bind"
 	"github.com/pocethereum/pochain/common"
 	"github.com/pocethereum/pochain/core"
 	"github.com/pocethereum/pochain/core/rawdb"
@@ -34,7 +35,9 @@ import (
 	"github.com/pocethereum/pochain/log"
 	"github.com/pocethereum/pochain/p2p"
 	"github.com/pocethereum/pochain/p2p/discv5"
+	"github.com/pocethereum/pochain/params"
 	"github.com/pocethereum/pochain/rlp"
+	"github.com/pocethereum/pochain/rpc"
 )
 
 type LesServer struct {
@@ -42,12 +45,14 @@ type LesServer struct {
 	protocolManager *ProtocolManager
 	fcManager       *flowcontrol.ClientManager // nil if our node is client only
 	fcCostStats     *requestCostStats
-	defParams       *flowcontrol.ServerParams
+	defParams       *flowcontrol.ServerParams // flow control parameters of free clients
+	clientPool      *clientPool
 	lesTopics       []discv5.Topic
 	privateKey      *ecdsa.PrivateKey
 	quitSync        chan struct{}
 
 	chtIndexer, bloomTrieIndexer *core.ChainIndexer
+	oracle                       *checkpointOracle // nil if no checkpoint oracle is configured
 }
 
 func NewLesServer(eth *eth.Ethereum, config *eth.Config) (*LesServer, error) {
@@ -94,12 +99,22 @@ func NewLesServer(eth *eth.Ethereum, config *eth.Config) (*LesServer, error) {
 	srv.chtIndexer.Start(eth.BlockChain())
 	pm.server = srv
 
+	oracle := config.CheckpointOracle
+	if oracle == nil {
+		oracle = params.CheckpointOracles[eth.BlockChain().Genesis().Hash()]
+	}
+	srv.oracle = newCheckpointOracle(oracle, srv.localCheckpoint)
+
 	srv.defParams = &flowcontrol.ServerParams{
 		BufLimit:    300000000,
 		MinRecharge: 50000,
 	}
 	srv.fcManager = flowcontrol.NewClientManager(uint64(config.LightServ), 10, 1000000000)
 	srv.fcCostStats = newCostStats(eth.ChainDb())
+
+	// The total capacity allows serving as many free clients as light peers are
+	// allowed, priority clients take their share from it
+	srv.clientPool = newClientPool(eth.ChainDb(), srv.defParams, srv.defParams.MinRecharge*uint64(config.LightPeers))
 	return srv, nil
 }
 
@@ -107,6 +122,18 @@ func (s *LesServer) Protocols() []p2p.Protocol {
 	return s.protocolManager.SubProtocols
 }
 
+// APIs returns the APIs of the LES server.
+func (s *LesServer) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "les",
+			Version:   "1.0",
+			Service:   NewPrivateLightServerAPI(s),
+			Public:    false,
+		},
+	}
+}
+
 // Start starts the LES server
 func (s *LesServer) Start(srvr *p2p.Server) {
 	s.protocolManager.Start(s.config.LightPeers)
@@ -130,6 +157,27 @@ func (s *LesServer) SetBloomBitsIndexer(bloomIndexer *core.ChainIndexer) {
 	bloomIndexer.AddChildIndexer(s.bloomTrieIndexer)
 }
 
+// SetContractBackend sets the backend the checkpoint oracle contract is
+// accessed through, enabling the advertisement of registered checkpoints.
+func (s *LesServer) SetContractBackend(backend bind.ContractBackend) {
+	if s.oracle != nil {
+		s.oracle.start(backend)
+	}
+}
+
+// localCheckpoint returns the checkpoint of the given LES/2 section built from
+// the local CHT and bloom trie.
+func (s *LesServer) localCheckpoint(index uint64) params.TrustedCheckpoint {
+	// convert the LES/2 section index to the last LES/1 one for chtIndexer.SectionHead
+	sectionHead := s.chtIndexer.SectionHead((index+1)*(light.CHTFrequencyClient/light.CHTFrequencyServer) - 1)
+	return params.TrustedCheckpoint{
+		SectionIndex: index,
+		SectionHead:  sectionHead,
+		CHTRoot:      light.GetChtV2Root(s.protocolManager.chainDb, index, sectionHead),
+		BloomRoot:    light.GetBloomTrieRoot(s.protocolManager.chainDb, index, sectionHead),
+	}
+}
+
 // Stop stops the LES service
 func (s *LesServer) Stop() {
 	s.chtIndexer.Close()
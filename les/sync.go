@@ -23,6 +23,7 @@ import (
 	"github.com/pocethereum/pochain/core/rawdb"
 	"github.com/pocethereum/pochain/eth/downloader"
 	"github.com/pocethereum/pochain/light"
+	"github.com/pocethereum/pochain/params"
 )
 
 // syncer is responsible for periodically synchronising with the network, both
@@ -74,6 +75,34 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
-	pm.blockchain.(*light.LightChain).SyncCht(ctx)
+	lc := pm.blockchain.(*light.LightChain)
+	if cp := pm.validCheckpoint(peer); cp != nil {
+		lc.AddTrustedCheckpoint(cp)
+	}
+	lc.SyncCht(ctx)
 	pm.downloader.Synchronise(peer.id, peer.Head(), peer.Td(), downloader.LightSync)
 }
+
+// validCheckpoint returns the checkpoint advertised by the peer if it is signed
+// by enough admins of the configured checkpoint oracle and is newer than the
+// latest section known locally, nil otherwise.
+func (pm *ProtocolManager) validCheckpoint(peer *peer) *params.TrustedCheckpoint {
+	if pm.oracle == nil || pm.odr == nil || pm.odr.ChtIndexer() == nil {
+		return nil
+	}
+	peer.lock.RLock()
+	cp, sigs := peer.checkpoint, peer.checkpointSigs
+	peer.lock.RUnlock()
+
+	if cp.Empty() {
+		return nil
+	}
+	if sections, _, _ := pm.odr.ChtIndexer().Sections(); cp.SectionIndex < sections {
+		return nil
+	}
+	if ok, signers := pm.oracle.verifySigners(cp.SectionIndex, cp.Hash(), sigs); !ok {
+		peer.Log().Debug("Rejected unapproved checkpoint", "section", cp.SectionIndex, "signers", len(signers))
+		return nil
+	}
+	return &cp
+}
@@ -10,11 +10,13 @@ import (
 	"github.com/pocethereum/pochain/miner"
 	"encoding/json"
 	"os"
+	"strings"
 	"time"
 )
 
 type MineDevice struct {
 	ieth              IEthereum
+	fixedPlotPaths    bool // Plot paths set by flag or config, taking precedence over the settings
 }
 
 type IEthereum interface {
@@ -38,15 +40,37 @@ const (
 	DEV_STATUS_WAITING  = "waiting"
 )
 
-var PlotpathsUpdater = make(chan string, 100)
 var gDev *MineDevice
 
-func New(ieth IEthereum) *MineDevice {
-	gDev = &MineDevice{ieth: ieth}
-	PlotpathsUpdater<-GetSettingPlotdirs()
+// New creates the mining device API. The engine picks up the plot directories
+// of the device settings on startup, so creating the API doesn't touch it. If
+// fixedPlotPaths is set, the plot paths were configured explicitly and changes
+// of the settings are not applied to the engine either.
+func New(ieth IEthereum, fixedPlotPaths bool) *MineDevice {
+	gDev = &MineDevice{ieth: ieth, fixedPlotPaths: fixedPlotPaths}
 	return gDev
 }
 
+// updatePlotPaths points the poc engine at the plot directories selected in
+// the device settings, if any and not overridden by the configuration.
+func (dev *MineDevice) updatePlotPaths() {
+	if dev.fixedPlotPaths {
+		log.Info("Plot paths configured explicitly, ignoring device settings")
+		return
+	}
+	plotpaths := GetSettingPlotdirs()
+	if plotpaths == "" {
+		return
+	}
+	pocengine, ok := dev.ieth.Engine().(*poc.Poc)
+	if !ok {
+		return
+	}
+	if err := pocengine.SetPlotPaths(strings.Split(plotpaths, ",")); err != nil {
+		log.Warn("Update plot paths failed", "plotpaths", plotpaths, "error", err)
+	}
+}
+
 func (dev *MineDevice) status() string {
 	// Case 1.
 	uo := User{}
@@ -340,7 +364,7 @@ func (dev *MineDevice) SettingPlotdirs(value string) (r Result) {
 		}
 	}
 
-	PlotpathsUpdater<-GetSettingPlotdirs()
+	dev.updatePlotPaths()
 	return r
 }
 
@@ -0,0 +1,82 @@
+package pool
+
+import (
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/common/hexutil"
+)
+
+// PublicPoolAPI offers the mining pool services to plot owners.
+type PublicPoolAPI struct {
+	pool *Pool
+}
+
+// NewPublicPoolAPI creates a new RPC service for the mining pool.
+func NewPublicPoolAPI(pool *Pool) *PublicPoolAPI {
+	return &PublicPoolAPI{pool}
+}
+
+// RPCRound is the round information returned to pool members.
+type RPCRound struct {
+	Number              hexutil.Uint64 `json:"number"`
+	GenerationSignature common.Hash    `json:"generationSignature"`
+	ScoopNumber         hexutil.Uint64 `json:"scoopNumber"`
+	BaseTarget          *hexutil.Big   `json:"baseTarget"`
+	PoolAddress         common.Address `json:"poolAddress"`
+}
+
+// GetRound returns the round members should scan their plots for.
+func (api *PublicPoolAPI) GetRound() (*RPCRound, error) {
+	round, err := api.pool.Round()
+	if err != nil {
+		return nil, err
+	}
+	return &RPCRound{
+		Number:              hexutil.Uint64(round.Number),
+		GenerationSignature: round.GenerationSignature,
+		ScoopNumber:         hexutil.Uint64(round.ScoopNumber),
+		BaseTarget:          (*hexutil.Big)(round.BaseTarget),
+		PoolAddress:         round.Coinbase,
+	}, nil
+}
+
+// SubmitNonce submits a nonce found by owner for the round at number and
+// returns its deadline in seconds.
+func (api *PublicPoolAPI) SubmitNonce(owner common.Address, number hexutil.Uint64, nonce hexutil.Uint64) (hexutil.Uint64, error) {
+	deadline, err := api.pool.SubmitNonce(owner, uint64(number), uint64(nonce))
+	return hexutil.Uint64(deadline), err
+}
+
+// RPCShare is a single recorded share.
+type RPCShare struct {
+	Owner    common.Address `json:"owner"`
+	Nonce    hexutil.Uint64 `json:"nonce"`
+	Deadline hexutil.Uint64 `json:"deadline"`
+}
+
+// GetShares returns the shares recorded for the round at number.
+func (api *PublicPoolAPI) GetShares(number hexutil.Uint64) []RPCShare {
+	shares := []RPCShare{}
+	for _, share := range api.pool.Shares(uint64(number)) {
+		shares = append(shares, RPCShare{
+			Owner:    share.Owner,
+			Nonce:    hexutil.Uint64(share.Nonce),
+			Deadline: hexutil.Uint64(share.Deadline),
+		})
+	}
+	return shares
+}
+
+// RPCAccount is the ledger entry of a pool member.
+type RPCAccount struct {
+	Pending *hexutil.Big `json:"pending"`
+	Paid    *hexutil.Big `json:"paid"`
+}
+
+// GetBalance returns the pending and paid out balance of a pool member.
+func (api *PublicPoolAPI) GetBalance(owner common.Address) *RPCAccount {
+	acc := api.pool.Account(owner)
+	return &RPCAccount{
+		Pending: (*hexutil.Big)(acc.Pending),
+		Paid:    (*hexutil.Big)(acc.Paid),
+	}
+}
@@ -0,0 +1,52 @@
+package pool
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/pocethereum/pochain/params"
+)
+
+// Config are the configuration parameters of the mining pool service.
+type Config struct {
+	Enabled bool // Whether the node runs as a mining pool
+
+	Fee            uint64        // Pool fee kept from each won block, in basis points
+	MinPayout      *big.Int      // Minimum pending balance of a member before it is paid out
+	PayoutInterval time.Duration // Time interval between two payout rounds
+	ShareWindow    uint64        // Number of most recent rounds whose shares split a won block
+	Confirmations  uint64        // Number of blocks on top of a won block before it is credited
+	MaxDeadline    uint64        // Largest deadline (in seconds) accepted as a share
+}
+
+// DefaultConfig contains the default settings for the mining pool.
+var DefaultConfig = Config{
+	Fee:            200,
+	MinPayout:      new(big.Int).Mul(big.NewInt(10), big.NewInt(params.Ether)),
+	PayoutInterval: time.Hour,
+	ShareWindow:    10,
+	Confirmations:  7,
+	MaxDeadline:    86400,
+}
+
+// sanitize checks the provided user configurations and changes anything that's
+// unreasonable or unworkable.
+func (config *Config) sanitize() Config {
+	conf := *config
+	if conf.Fee > 10000 {
+		conf.Fee = 10000
+	}
+	if conf.MinPayout == nil || conf.MinPayout.Sign() <= 0 {
+		conf.MinPayout = DefaultConfig.MinPayout
+	}
+	if conf.PayoutInterval < time.Minute {
+		conf.PayoutInterval = DefaultConfig.PayoutInterval
+	}
+	if conf.ShareWindow < 1 {
+		conf.ShareWindow = DefaultConfig.ShareWindow
+	}
+	if conf.MaxDeadline < 1 {
+		conf.MaxDeadline = DefaultConfig.MaxDeadline
+	}
+	return conf
+}
@@ -0,0 +1,289 @@
+package pool
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/ethdb"
+	"github.com/pocethereum/pochain/rlp"
+)
+
+var (
+	errNonceClaimed = errors.New("nonce already claimed by another member")
+	errShareTooHigh = errors.New("share deadline not better than current share")
+)
+
+var (
+	roundPrefix   = []byte("s") // roundPrefix + num (uint64 big endian) -> rlp(shares of the round)
+	accountPrefix = []byte("a") // accountPrefix + owner -> rlp(member account)
+	noncePrefix   = []byte("n") // noncePrefix + nonce (uint64 big endian) -> owner
+	payoutPrefix  = []byte("p") // payoutPrefix + tx hash -> rlp(payout)
+
+	membersKey      = []byte("PoolMembers")
+	lastCreditedKey = []byte("PoolLastCredited")
+	lastPrunedKey   = []byte("PoolLastPruned")
+)
+
+// shareScale is the weight of a share with a zero deadline. A share with
+// deadline d weighs shareScale/(d+1), so better deadlines earn more.
+const shareScale = 1000000
+
+// Share is the best deadline a member submitted for a single round.
+type Share struct {
+	Owner    common.Address
+	Nonce    uint64
+	Deadline uint64
+}
+
+func (s *Share) weight() uint64 {
+	return shareScale / (s.Deadline + 1)
+}
+
+// Account is the payout ledger entry of a single pool member.
+type Account struct {
+	Pending *big.Int // Credited but not yet paid out
+	Paid    *big.Int // Total amount paid out so far
+}
+
+// Payout is a single payout transaction sent to a pool member.
+type Payout struct {
+	Owner  common.Address
+	Amount *big.Int
+	Time   uint64
+}
+
+// ledger keeps the shares and the payout balances of the pool members in a
+// local database.
+type ledger struct {
+	db   ethdb.Database
+	lock sync.Mutex
+}
+
+func newLedger(db ethdb.Database) *ledger {
+	return &ledger{db: db}
+}
+
+func encodeNumber(number uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, number)
+	return enc
+}
+
+func (l *ledger) readRLP(key []byte, val interface{}) bool {
+	data, _ := l.db.Get(key)
+	if len(data) == 0 {
+		return false
+	}
+	return rlp.DecodeBytes(data, val) == nil
+}
+
+func (l *ledger) writeRLP(key []byte, val interface{}) error {
+	data, err := rlp.EncodeToBytes(val)
+	if err != nil {
+		return err
+	}
+	return l.db.Put(key, data)
+}
+
+// recordShare stores a submitted nonce as the share of its owner for the
+// given round, if it beats the owner's previous share of that round.
+func (l *ledger) recordShare(number uint64, share *Share) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	nonceKey := append(noncePrefix, encodeNumber(share.Nonce)...)
+	if owner, _ := l.db.Get(nonceKey); len(owner) > 0 && common.BytesToAddress(owner) != share.Owner {
+		return errNonceClaimed
+	}
+	shares := l.roundShares(number)
+	for i, s := range shares {
+		if s.Owner == share.Owner {
+			if share.Deadline >= s.Deadline {
+				return errShareTooHigh
+			}
+			shares[i] = *share
+			return l.writeShares(number, shares, nonceKey, share.Owner)
+		}
+	}
+	if err := l.addMember(share.Owner); err != nil {
+		return err
+	}
+	return l.writeShares(number, append(shares, *share), nonceKey, share.Owner)
+}
+
+func (l *ledger) writeShares(number uint64, shares []Share, nonceKey []byte, owner common.Address) error {
+	if err := l.db.Put(nonceKey, owner.Bytes()); err != nil {
+		return err
+	}
+	return l.writeRLP(append(roundPrefix, encodeNumber(number)...), shares)
+}
+
+func (l *ledger) roundShares(number uint64) []Share {
+	var shares []Share
+	l.readRLP(append(roundPrefix, encodeNumber(number)...), &shares)
+	return shares
+}
+
+// Shares returns the shares submitted for the given round.
+func (l *ledger) Shares(number uint64) []Share {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.roundShares(number)
+}
+
+func (l *ledger) addMember(owner common.Address) error {
+	members := l.members()
+	for _, m := range members {
+		if m == owner {
+			return nil
+		}
+	}
+	return l.writeRLP(membersKey, append(members, owner))
+}
+
+func (l *ledger) members() []common.Address {
+	var members []common.Address
+	l.readRLP(membersKey, &members)
+	return members
+}
+
+// Members returns all the addresses that ever submitted a share.
+func (l *ledger) Members() []common.Address {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.members()
+}
+
+func (l *ledger) account(owner common.Address) *Account {
+	acc := &Account{Pending: new(big.Int), Paid: new(big.Int)}
+	l.readRLP(append(accountPrefix, owner.Bytes()...), acc)
+	return acc
+}
+
+// Account returns the ledger entry of a pool member.
+func (l *ledger) Account(owner common.Address) *Account {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.account(owner)
+}
+
+// credit splits amount between the members holding shares in the window of
+// rounds ending at number, proportionally to the weight of their shares.
+// It returns the credited amount per member; any rounding dust stays with
+// the pool.
+func (l *ledger) credit(number uint64, window uint64, amount *big.Int) (map[common.Address]*big.Int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var (
+		weights = make(map[common.Address]uint64)
+		total   uint64
+	)
+	for i := uint64(0); i < window && i <= number; i++ {
+		for _, share := range l.roundShares(number - i) {
+			weights[share.Owner] += share.weight()
+			total += share.weight()
+		}
+	}
+	credits := make(map[common.Address]*big.Int)
+	if total == 0 {
+		return credits, nil
+	}
+	for owner, weight := range weights {
+		credit := new(big.Int).Mul(amount, new(big.Int).SetUint64(weight))
+		credit.Div(credit, new(big.Int).SetUint64(total))
+		if credit.Sign() == 0 {
+			continue
+		}
+		acc := l.account(owner)
+		acc.Pending.Add(acc.Pending, credit)
+		if err := l.writeRLP(append(accountPrefix, owner.Bytes()...), acc); err != nil {
+			return credits, err
+		}
+		credits[owner] = credit
+	}
+	return credits, nil
+}
+
+// debit moves amount from the pending to the paid balance of a member and
+// records the payout transaction.
+func (l *ledger) debit(owner common.Address, amount *big.Int, tx common.Hash) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	acc := l.account(owner)
+	acc.Pending.Sub(acc.Pending, amount)
+	if acc.Pending.Sign() < 0 {
+		acc.Pending.SetUint64(0)
+	}
+	acc.Paid.Add(acc.Paid, amount)
+	if err := l.writeRLP(append(accountPrefix, owner.Bytes()...), acc); err != nil {
+		return err
+	}
+	return l.writeRLP(append(payoutPrefix, tx.Bytes()...), &Payout{
+		Owner:  owner,
+		Amount: amount,
+		Time:   uint64(time.Now().Unix()),
+	})
+}
+
+// Payout returns the payout recorded for the given transaction hash.
+func (l *ledger) Payout(tx common.Hash) *Payout {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	payout := new(Payout)
+	if !l.readRLP(append(payoutPrefix, tx.Bytes()...), payout) {
+		return nil
+	}
+	return payout
+}
+
+// lastCredited returns the number of the last block processed for crediting,
+// and whether any block was processed at all.
+func (l *ledger) lastCredited() (uint64, bool) {
+	data, _ := l.db.Get(lastCreditedKey)
+	if len(data) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(data), true
+}
+
+// setLastCredited marks all blocks up to number as processed and drops the
+// rounds that can no longer fall into the share window of a future block.
+func (l *ledger) setLastCredited(number uint64, window uint64) error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if err := l.db.Put(lastCreditedKey, encodeNumber(number)); err != nil {
+		return err
+	}
+	if number < window {
+		return nil
+	}
+	// Rounds without shares have no entry, so prune from where the last call
+	// stopped instead of until the first missing round.
+	cutoff, from := number-window+1, uint64(1)
+	if data, _ := l.db.Get(lastPrunedKey); len(data) == 8 {
+		from = binary.BigEndian.Uint64(data) + 1
+	}
+	for i := from; i <= cutoff; i++ {
+		key := append(roundPrefix, encodeNumber(i)...)
+		if ok, _ := l.db.Has(key); ok {
+			if err := l.db.Delete(key); err != nil {
+				return err
+			}
+		}
+	}
+	if from > cutoff {
+		return nil
+	}
+	return l.db.Put(lastPrunedKey, encodeNumber(cutoff))
+}
@@ -0,0 +1,123 @@
+package pool
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/ethdb"
+)
+
+var (
+	testOwner1 = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	testOwner2 = common.HexToAddress("0x2000000000000000000000000000000000000002")
+)
+
+func TestLedgerShares(t *testing.T) {
+	l := newLedger(ethdb.NewMemDatabase())
+
+	if err := l.recordShare(1, &Share{Owner: testOwner1, Nonce: 10, Deadline: 100}); err != nil {
+		t.Fatalf("failed to record share: %v", err)
+	}
+	// A worse deadline of the same owner must be rejected, a better one kept
+	if err := l.recordShare(1, &Share{Owner: testOwner1, Nonce: 11, Deadline: 200}); err != errShareTooHigh {
+		t.Fatalf("worse share error mismatch: have %v, want %v", err, errShareTooHigh)
+	}
+	if err := l.recordShare(1, &Share{Owner: testOwner1, Nonce: 12, Deadline: 50}); err != nil {
+		t.Fatalf("failed to record better share: %v", err)
+	}
+	// Nonces claimed by one member cannot be submitted by another
+	if err := l.recordShare(1, &Share{Owner: testOwner2, Nonce: 12, Deadline: 50}); err != errNonceClaimed {
+		t.Fatalf("claimed nonce error mismatch: have %v, want %v", err, errNonceClaimed)
+	}
+	shares := l.Shares(1)
+	if len(shares) != 1 || shares[0].Nonce != 12 || shares[0].Deadline != 50 {
+		t.Fatalf("shares mismatch: have %v", shares)
+	}
+	if members := l.Members(); len(members) != 1 || members[0] != testOwner1 {
+		t.Fatalf("members mismatch: have %v", members)
+	}
+}
+
+func TestLedgerCredit(t *testing.T) {
+	l := newLedger(ethdb.NewMemDatabase())
+
+	// Owner 1 holds a share in round 1 only, owner 2 in round 2 with the same
+	// deadline. A window of 2 rounds ending at round 2 must split evenly.
+	l.recordShare(1, &Share{Owner: testOwner1, Nonce: 1, Deadline: 9})
+	l.recordShare(2, &Share{Owner: testOwner2, Nonce: 2, Deadline: 9})
+
+	credits, err := l.credit(2, 2, big.NewInt(1000))
+	if err != nil {
+		t.Fatalf("failed to credit: %v", err)
+	}
+	if credits[testOwner1].Cmp(big.NewInt(500)) != 0 || credits[testOwner2].Cmp(big.NewInt(500)) != 0 {
+		t.Fatalf("credits mismatch: have %v", credits)
+	}
+	// A window of a single round only rewards the shares of that round
+	if credits, _ = l.credit(2, 1, big.NewInt(1000)); len(credits) != 1 || credits[testOwner2].Cmp(big.NewInt(1000)) != 0 {
+		t.Fatalf("single round credits mismatch: have %v", credits)
+	}
+	if pending := l.Account(testOwner2).Pending; pending.Cmp(big.NewInt(1500)) != 0 {
+		t.Fatalf("pending balance mismatch: have %v, want %v", pending, 1500)
+	}
+	// Paying out moves the pending balance into the paid one
+	tx := common.HexToHash("0x01")
+	if err := l.debit(testOwner2, big.NewInt(1500), tx); err != nil {
+		t.Fatalf("failed to debit: %v", err)
+	}
+	acc := l.Account(testOwner2)
+	if acc.Pending.Sign() != 0 || acc.Paid.Cmp(big.NewInt(1500)) != 0 {
+		t.Fatalf("account mismatch after payout: pending %v, paid %v", acc.Pending, acc.Paid)
+	}
+	if payout := l.Payout(tx); payout == nil || payout.Owner != testOwner2 || payout.Amount.Cmp(big.NewInt(1500)) != 0 {
+		t.Fatalf("payout mismatch: have %v", payout)
+	}
+}
+
+func TestLedgerPruneRounds(t *testing.T) {
+	l := newLedger(ethdb.NewMemDatabase())
+
+	for i := uint64(1); i <= 5; i++ {
+		l.recordShare(i, &Share{Owner: testOwner1, Nonce: i, Deadline: i})
+	}
+	if err := l.setLastCredited(5, 2); err != nil {
+		t.Fatalf("failed to set last credited: %v", err)
+	}
+	if last, ok := l.lastCredited(); !ok || last != 5 {
+		t.Fatalf("last credited mismatch: have %d/%v, want 5", last, ok)
+	}
+	// Only round 5 may still end up in the share window of block 6
+	for i := uint64(1); i <= 5; i++ {
+		if have := len(l.Shares(i)); (i >= 5) != (have == 1) {
+			t.Errorf("round %d: share count %d", i, have)
+		}
+	}
+}
+
+// Tests that rounds without shares don't stop the pruning of older rounds.
+func TestLedgerPruneRoundGaps(t *testing.T) {
+	l := newLedger(ethdb.NewMemDatabase())
+
+	rounds := []uint64{1, 2, 4, 5, 7, 9}
+	for _, i := range rounds {
+		l.recordShare(i, &Share{Owner: testOwner1, Nonce: i, Deadline: i})
+	}
+	// Prune through the gap at round 3, then through the gaps at 6 and 8
+	if err := l.setLastCredited(6, 2); err != nil {
+		t.Fatalf("failed to set last credited: %v", err)
+	}
+	for _, i := range rounds {
+		if have := len(l.Shares(i)); (i > 5) != (have == 1) {
+			t.Errorf("round %d: share count %d after first prune", i, have)
+		}
+	}
+	if err := l.setLastCredited(9, 2); err != nil {
+		t.Fatalf("failed to set last credited: %v", err)
+	}
+	for _, i := range rounds {
+		if have := len(l.Shares(i)); (i > 8) != (have == 1) {
+			t.Errorf("round %d: share count %d after second prune", i, have)
+		}
+	}
+}
@@ -0,0 +1,351 @@
+// Package pool implements a proof-of-capacity mining pool, letting many plot
+// owners mine to a single shared coinbase and splitting the rewards.
+package pool
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/pocethereum/pochain/accounts"
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/consensus"
+	"github.com/pocethereum/pochain/consensus/poc"
+	"github.com/pocethereum/pochain/consensus/poc/mortgage"
+	"github.com/pocethereum/pochain/core"
+	"github.com/pocethereum/pochain/core/types"
+	"github.com/pocethereum/pochain/ethdb"
+	"github.com/pocethereum/pochain/log"
+	"github.com/pocethereum/pochain/miner"
+	"github.com/pocethereum/pochain/params"
+	plotparams "github.com/pocethereum/pochain/params/plot"
+	"github.com/pocethereum/pochain/rpc"
+)
+
+const (
+	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+	chainHeadChanSize = 10
+)
+
+var (
+	errNotPocEngine    = errors.New("mining pool requires the poc consensus engine")
+	errNoRound         = errors.New("pool is not mining")
+	errStaleRound      = errors.New("submission for stale round")
+	errDeadlineTooBig  = errors.New("deadline exceeds pool limit")
+	errMissingReceipts = errors.New("missing block receipts")
+)
+
+// Backend wraps all methods required by the mining pool.
+type Backend interface {
+	AccountManager() *accounts.Manager
+	BlockChain() *core.BlockChain
+	TxPool() *core.TxPool
+	Miner() *miner.Miner
+	Engine() consensus.Engine
+	Etherbase() (common.Address, error)
+}
+
+// Round describes the block the pool is currently mining on.
+type Round struct {
+	Number              uint64
+	GenerationSignature common.Hash
+	ScoopNumber         uint64
+	BaseTarget          *big.Int
+	Coinbase            common.Address
+}
+
+// Pool accepts nonce submissions from plot owners mining to the shared pool
+// address, records their deadline based shares and pays out the rewards of
+// the blocks the pool wins.
+type Pool struct {
+	config  Config
+	backend Backend
+	engine  *poc.Poc
+	ledger  *ledger
+
+	bestLock sync.Mutex
+	best     map[uint64]uint64 // Best deadline forwarded to the sealer per round
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a mining pool on top of the given backend, keeping its ledger
+// in db.
+func New(config *Config, backend Backend, db ethdb.Database) (*Pool, error) {
+	engine, ok := backend.Engine().(*poc.Poc)
+	if !ok {
+		return nil, errNotPocEngine
+	}
+	engine.EnableRemoteNonces()
+
+	return &Pool{
+		config:  config.sanitize(),
+		backend: backend,
+		engine:  engine,
+		ledger:  newLedger(db),
+		best:    make(map[uint64]uint64),
+		quit:    make(chan struct{}),
+	}, nil
+}
+
+// Start launches the crediting and payout loop of the pool.
+func (p *Pool) Start() {
+	p.wg.Add(1)
+	go p.loop()
+
+	log.Info("Mining pool started", "fee", p.config.Fee, "window", p.config.ShareWindow)
+}
+
+// Stop terminates the pool loop.
+func (p *Pool) Stop() {
+	close(p.quit)
+	p.wg.Wait()
+	p.ledger.db.Close()
+
+	log.Info("Mining pool stopped")
+}
+
+// APIs returns the RPC services offered by the mining pool.
+func (p *Pool) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "pool",
+			Version:   "1.0",
+			Service:   NewPublicPoolAPI(p),
+			Public:    true,
+		},
+	}
+}
+
+// Round returns the block the pool is currently mining on.
+func (p *Pool) Round() (*Round, error) {
+	block := p.backend.Miner().PendingBlock()
+	if block == nil {
+		return nil, errNoRound
+	}
+	address, err := p.backend.Etherbase()
+	if err != nil || block.Coinbase() != address || !p.backend.Miner().Mining() {
+		return nil, errNoRound
+	}
+	genSig := block.GetGenerationSignature()
+	return &Round{
+		Number:              block.NumberU64(),
+		GenerationSignature: genSig,
+		ScoopNumber:         poc.CalcScoop(genSig.Bytes(), block.NumberU64()),
+		BaseTarget:          plotparams.DifficultyToBaseTarget(block.Difficulty()),
+		Coinbase:            block.Coinbase(),
+	}, nil
+}
+
+// SubmitNonce verifies a nonce scanned by a pool member from a plot seeded
+// with the pool address, records it as a share and hands it to the sealer if
+// it is the best deadline of the round so far.
+func (p *Pool) SubmitNonce(owner common.Address, number uint64, nonce uint64) (uint64, error) {
+	round, err := p.Round()
+	if err != nil {
+		return 0, err
+	}
+	if round.Number != number {
+		return 0, errStaleRound
+	}
+	_, hit := poc.CalcNonceHit(round.Coinbase, nonce, round.GenerationSignature.Bytes(), round.Number)
+	deadline := hit.Div(hit, round.BaseTarget).Uint64()
+	if deadline > p.config.MaxDeadline {
+		return deadline, errDeadlineTooBig
+	}
+	if err := p.ledger.recordShare(number, &Share{Owner: owner, Nonce: nonce, Deadline: deadline}); err != nil {
+		return deadline, err
+	}
+	log.Debug("Recorded pool share", "owner", owner, "number", number, "nonce", nonce, "deadline", deadline)
+
+	if p.improvesRound(number, deadline) {
+		err = p.engine.SubmitNonce(&poc.NonceSubmission{
+			Number:              round.Number,
+			GenerationSignature: round.GenerationSignature,
+			Nonce:               nonce,
+		})
+	}
+	return deadline, err
+}
+
+// improvesRound reports whether deadline is the best one seen for the round,
+// forgetting about older rounds along the way.
+func (p *Pool) improvesRound(number uint64, deadline uint64) bool {
+	p.bestLock.Lock()
+	defer p.bestLock.Unlock()
+
+	for n := range p.best {
+		if n < number {
+			delete(p.best, n)
+		}
+	}
+	if best, ok := p.best[number]; ok && best <= deadline {
+		return false
+	}
+	p.best[number] = deadline
+	return true
+}
+
+// Shares returns the shares recorded for the given round.
+func (p *Pool) Shares(number uint64) []Share {
+	return p.ledger.Shares(number)
+}
+
+// Account returns the ledger entry of the given pool member.
+func (p *Pool) Account(owner common.Address) *Account {
+	return p.ledger.Account(owner)
+}
+
+// Payout returns the payout recorded for the given transaction hash.
+func (p *Pool) Payout(tx common.Hash) *Payout {
+	return p.ledger.Payout(tx)
+}
+
+func (p *Pool) loop() {
+	defer p.wg.Done()
+
+	headCh := make(chan core.ChainHeadEvent, chainHeadChanSize)
+	headSub := p.backend.BlockChain().SubscribeChainHeadEvent(headCh)
+	defer headSub.Unsubscribe()
+
+	payouts := time.NewTicker(p.config.PayoutInterval)
+	defer payouts.Stop()
+
+	for {
+		select {
+		case ev := <-headCh:
+			p.creditBlocks(ev.Block.NumberU64())
+		case <-payouts.C:
+			p.payout()
+		case <-headSub.Err():
+			return
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// creditBlocks splits the rewards of every confirmed block won by the pool
+// since the last processed one among the shareholders.
+func (p *Pool) creditBlocks(head uint64) {
+	if head < p.config.Confirmations {
+		return
+	}
+	target := head - p.config.Confirmations
+
+	address, err := p.backend.Etherbase()
+	if err != nil {
+		return
+	}
+	from := target
+	if last, ok := p.ledger.lastCredited(); ok {
+		if last >= target {
+			return
+		}
+		from = last + 1
+	}
+	for number := from; number <= target; number++ {
+		block := p.backend.BlockChain().GetBlockByNumber(number)
+		if block == nil {
+			return
+		}
+		if block.Coinbase() == address {
+			reward, err := p.blockReward(block)
+			if err != nil {
+				log.Warn("Failed to derive pool block reward", "number", number, "hash", block.Hash(), "err", err)
+				return
+			}
+			if fee := new(big.Int).Mul(reward, new(big.Int).SetUint64(p.config.Fee)); fee.Sign() > 0 {
+				reward.Sub(reward, fee.Div(fee, big.NewInt(10000)))
+			}
+			credits, err := p.ledger.credit(number, p.config.ShareWindow, reward)
+			if err != nil {
+				log.Error("Failed to credit pool block", "number", number, "err", err)
+				return
+			}
+			log.Info("Credited pool block", "number", number, "hash", block.Hash(), "reward", reward, "members", len(credits))
+		}
+		if err := p.ledger.setLastCredited(number, p.config.ShareWindow); err != nil {
+			log.Error("Failed to update pool ledger", "number", number, "err", err)
+			return
+		}
+	}
+}
+
+// blockReward returns the mining reward plus the transaction fees the coinbase
+// of the block earned. The reward is only ever credited during finalization,
+// so it is the growth of the total rewarded between the parent and the block
+// state, which have to be available.
+func (p *Pool) blockReward(block *types.Block) (*big.Int, error) {
+	chain := p.backend.BlockChain()
+
+	parent := chain.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	parentState, err := chain.StateAt(parent.Root)
+	if err != nil {
+		return nil, err
+	}
+	blockState, err := chain.StateAt(block.Root())
+	if err != nil {
+		return nil, err
+	}
+	reward := new(big.Int).Sub(mortgage.GetTotalRewarded(blockState), mortgage.GetTotalRewarded(parentState))
+
+	receipts := chain.GetReceiptsByHash(block.Hash())
+	if len(receipts) != len(block.Transactions()) {
+		return nil, errMissingReceipts
+	}
+	for i, tx := range block.Transactions() {
+		fee := new(big.Int).Mul(new(big.Int).SetUint64(receipts[i].GasUsed), tx.GasPrice())
+		reward.Add(reward, fee)
+	}
+	return reward, nil
+}
+
+// payout sends a transaction to every member whose pending balance reached
+// the configured minimum.
+func (p *Pool) payout() {
+	address, err := p.backend.Etherbase()
+	if err != nil {
+		return
+	}
+	account := accounts.Account{Address: address}
+	wallet, err := p.backend.AccountManager().Find(account)
+	if err != nil {
+		log.Warn("Pool account unavailable for payouts", "address", address, "err", err)
+		return
+	}
+	var (
+		txpool   = p.backend.TxPool()
+		gasPrice = txpool.GasPrice()
+		gasCost  = new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(params.TxGas))
+		chainID  = p.backend.BlockChain().Config().ChainID
+	)
+	for _, owner := range p.ledger.Members() {
+		pending := p.ledger.Account(owner).Pending
+		if pending.Cmp(p.config.MinPayout) < 0 || pending.Cmp(gasCost) <= 0 {
+			continue
+		}
+		value := new(big.Int).Sub(pending, gasCost)
+		nonce := txpool.State().GetNonce(address)
+
+		tx, err := wallet.SignTx(account, types.NewTransaction(nonce, owner, value, params.TxGas, gasPrice, nil), chainID)
+		if err != nil {
+			log.Warn("Failed to sign pool payout", "owner", owner, "err", err)
+			return
+		}
+		if err := txpool.AddLocal(tx); err != nil {
+			log.Warn("Failed to submit pool payout", "owner", owner, "err", err)
+			return
+		}
+		if err := p.ledger.debit(owner, pending, tx.Hash()); err != nil {
+			log.Error("Failed to record pool payout", "owner", owner, "tx", tx.Hash(), "err", err)
+			return
+		}
+		log.Info("Sent pool payout", "owner", owner, "value", value, "tx", tx.Hash())
+	}
+}
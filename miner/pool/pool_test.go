@@ -0,0 +1,87 @@
+package pool
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/pocethereum/pochain/common"
+	"github.com/pocethereum/pochain/consensus"
+	"github.com/pocethereum/pochain/consensus/ethash"
+	"github.com/pocethereum/pochain/consensus/poc/mortgage"
+	"github.com/pocethereum/pochain/core"
+	"github.com/pocethereum/pochain/core/state"
+	"github.com/pocethereum/pochain/core/types"
+	"github.com/pocethereum/pochain/core/vm"
+	"github.com/pocethereum/pochain/crypto"
+	"github.com/pocethereum/pochain/ethdb"
+	"github.com/pocethereum/pochain/params"
+)
+
+// testReward is the mining reward credited by rewardEngine.
+var testReward = big.NewInt(1000000)
+
+// rewardEngine is a fake engine crediting a fixed mining reward the way the
+// poc engine does, without requiring sealed blocks.
+type rewardEngine struct {
+	consensus.Engine
+}
+
+func (e rewardEngine) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
+	state.AddBalance(header.Coinbase, testReward)
+	mortgage.AddTotalRewarded(state, testReward)
+	header.Root = state.IntermediateRoot(true)
+	return types.NewBlock(header, txs, uncles, receipts), nil
+}
+
+// testBackend is a pool backend serving a block chain only.
+type testBackend struct {
+	Backend
+	chain *core.BlockChain
+}
+
+func (b *testBackend) BlockChain() *core.BlockChain { return b.chain }
+
+// Tests that the reward of a block is the amount credited to its coinbase
+// during finalization plus the fees, and that it isn't derived without the
+// block and parent states.
+func TestBlockReward(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		db      = ethdb.NewMemDatabase()
+		engine  = rewardEngine{ethash.NewFaker()}
+		gspec   = &core.Genesis{Config: params.TestChainConfig, Alloc: core.GenesisAlloc{sender: {Balance: big.NewInt(1000000000)}}}
+		genesis = gspec.MustCommit(db)
+	)
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, engine, db, 2, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(testOwner1)
+		if i == 1 {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), testOwner2, big.NewInt(1), params.TxGas, big.NewInt(2), nil), types.HomesteadSigner{}, key)
+			b.AddTx(tx)
+		}
+	})
+	chain, _ := core.NewBlockChain(db, nil, gspec.Config, engine, vm.Config{})
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	p := &Pool{backend: &testBackend{chain: chain}}
+
+	fees := new(big.Int).SetUint64(2 * params.TxGas)
+	for i, want := range []*big.Int{testReward, new(big.Int).Add(testReward, fees)} {
+		reward, err := p.blockReward(blocks[i])
+		if err != nil {
+			t.Fatalf("block %d: failed to derive reward: %v", i+1, err)
+		}
+		if reward.Cmp(want) != 0 {
+			t.Errorf("block %d: reward mismatch: have %v, want %v", i+1, reward, want)
+		}
+	}
+	// A block whose state is unavailable must not be credited with a zero reward
+	header := types.CopyHeader(blocks[1].Header())
+	header.Root = common.HexToHash("0xdeadbeef")
+	if reward, err := p.blockReward(types.NewBlockWithHeader(header)); err == nil {
+		t.Errorf("reward derived without block state: %v", reward)
+	}
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/pocethereum/pochain/common"
 	"github.com/pocethereum/pochain/consensus"
+	"github.com/pocethereum/pochain/consensus/poc/mortgage"
 	"github.com/pocethereum/pochain/core"
 	"github.com/pocethereum/pochain/core/state"
 	"github.com/pocethereum/pochain/core/types"
@@ -431,7 +432,11 @@ func (self *worker) commitNewWork() {
 		return
 	}
 
+	// Create the current work task and check any fork transitions needed
 	work := self.current
+	if self.config.MortgageUpgradeBlock != nil && self.config.MortgageUpgradeBlock.Cmp(header.Number) == 0 {
+		mortgage.ApplyUpgrade(work.state, self.config)
+	}
 	pending, err := self.eth.TxPool().Pending()
 	if err != nil {
 		log.Error("Failed to fetch pending transactions", "err", err)
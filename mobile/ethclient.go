@@ -70,6 +70,24 @@ func (ec *EthereumClient) GetHeaderByNumber(ctx *Context, number int64) (header
 	return &Header{rawHeader}, err
 }
 
+// GetPocHeaderByHash returns the block header with the given hash, along with its
+// proof-of-capacity details.
+func (ec *EthereumClient) GetPocHeaderByHash(ctx *Context, hash *Hash) (header *PocHeader, _ error) {
+	rawHeader, err := ec.client.PocHeaderByHash(ctx.context, hash.hash)
+	return &PocHeader{rawHeader}, err
+}
+
+// GetPocHeaderByNumber returns a block header from the current canonical chain, along
+// with its proof-of-capacity details. If number is <0, the latest known header is returned.
+func (ec *EthereumClient) GetPocHeaderByNumber(ctx *Context, number int64) (header *PocHeader, _ error) {
+	if number < 0 {
+		rawHeader, err := ec.client.PocHeaderByNumber(ctx.context, nil)
+		return &PocHeader{rawHeader}, err
+	}
+	rawHeader, err := ec.client.PocHeaderByNumber(ctx.context, big.NewInt(number))
+	return &PocHeader{rawHeader}, err
+}
+
 // GetTransactionByHash returns the transaction with the given hash.
 func (ec *EthereumClient) GetTransactionByHash(ctx *Context, hash *Hash) (tx *Transaction, _ error) {
 	// TODO(karalabe): handle isPending
@@ -22,9 +22,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 
 	"github.com/pocethereum/pochain/common"
 	"github.com/pocethereum/pochain/core/types"
+	"github.com/pocethereum/pochain/ethclient"
 	"github.com/pocethereum/pochain/rlp"
 )
 
@@ -114,6 +116,45 @@ func (h *Header) GetMixDigest() *Hash    { return &Hash{h.header.MixDigest} }
 func (h *Header) GetNonce() *Nonce       { return &Nonce{h.header.Nonce} }
 func (h *Header) GetHash() *Hash         { return &Hash{h.header.Hash()} }
 
+// GetGenerationSignature returns the proof-of-capacity generation signature the
+// header was sealed against.
+func (h *Header) GetGenerationSignature() *Hash { return &Hash{h.header.GetGenerationSignature()} }
+
+// PocHeader represents a block header along with the proof-of-capacity details
+// and the block reward reported by the node.
+type PocHeader struct {
+	header *ethclient.PocHeader
+}
+
+// GetHeader returns the plain block header.
+func (h *PocHeader) GetHeader() *Header { return &Header{h.header.Header} }
+
+// GetGenerationSignature returns the generation signature the block was sealed against.
+func (h *PocHeader) GetGenerationSignature() *Hash { return &Hash{h.header.GenerationSignature} }
+
+// GetScoopNumber returns the plot scoop the block had to be mined from.
+func (h *PocHeader) GetScoopNumber() int64 { return int64(h.header.ScoopNumber) }
+
+// GetDeadline returns the deadline of the block in seconds, or nil if it is unknown.
+func (h *PocHeader) GetDeadline() *BigInt { return newBigIntOrNil(h.header.Deadline) }
+
+// GetBaseTarget returns the network base target of the block.
+func (h *PocHeader) GetBaseTarget() *BigInt { return newBigIntOrNil(h.header.BaseTarget) }
+
+// GetReward returns the mining reward of the block, or nil if it is unknown.
+func (h *PocHeader) GetReward() *BigInt { return newBigIntOrNil(h.header.Reward) }
+
+// GetTxFees returns the transaction fees paid in the block, or nil if they are unknown.
+func (h *PocHeader) GetTxFees() *BigInt { return newBigIntOrNil(h.header.TxFees) }
+
+// newBigIntOrNil wraps a big integer, keeping nil values nil.
+func newBigIntOrNil(x *big.Int) *BigInt {
+	if x == nil {
+		return nil
+	}
+	return &BigInt{x}
+}
+
 // Headers represents a slice of headers.
 type Headers struct{ headers []*types.Header }
 
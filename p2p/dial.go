@@ -72,6 +72,8 @@ type dialstate struct {
 	maxDynDials int
 	ntab        discoverTable
 	netrestrict *netutil.Netlist
+	nodeFilter  func(*discover.Node) bool // optional filter of dynamic dial candidates
+	bans        *reputation               // optional bans of nodes and networks
 
 	lookupRunning bool
 	dialing       map[discover.NodeID]connFlag
@@ -159,6 +161,21 @@ func (s *dialstate) removeStatic(n *discover.Node) {
 	s.hist.remove(n.ID)
 }
 
+// addCandidates queues nodes found outside of the discovery table, e.g. via DNS
+// discovery, for dynamic dialing. Nodes already queued are skipped.
+func (s *dialstate) addCandidates(nodes []*discover.Node) {
+	queued := make(map[discover.NodeID]bool, len(s.lookupBuf))
+	for _, n := range s.lookupBuf {
+		queued[n.ID] = true
+	}
+	for _, n := range nodes {
+		if !queued[n.ID] {
+			queued[n.ID] = true
+			s.lookupBuf = append(s.lookupBuf, n)
+		}
+	}
+}
+
 func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now time.Time) []task {
 	if s.start.IsZero() {
 		s.start = now
@@ -166,7 +183,11 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 
 	var newtasks []task
 	addDial := func(flag connFlag, n *discover.Node) bool {
-		if err := s.checkDial(n, peers); err != nil {
+		err := s.checkDial(n, peers)
+		if err == nil && flag == dynDialedConn && s.nodeFilter != nil && !s.nodeFilter(n) {
+			err = errFiltered
+		}
+		if err != nil {
 			log.Trace("Skipping dial candidate", "id", n.ID, "addr", &net.TCPAddr{IP: n.IP, Port: int(n.TCP)}, "err", err)
 			return false
 		}
@@ -258,6 +279,7 @@ var (
 	errAlreadyConnected = errors.New("already connected")
 	errRecentlyDialed   = errors.New("recently dialed")
 	errNotWhitelisted   = errors.New("not contained in netrestrict whitelist")
+	errFiltered         = errors.New("rejected by protocol node filter")
 )
 
 func (s *dialstate) checkDial(n *discover.Node, peers map[discover.NodeID]*Peer) error {
@@ -271,6 +293,8 @@ func (s *dialstate) checkDial(n *discover.Node, peers map[discover.NodeID]*Peer)
 		return errSelf
 	case s.netrestrict != nil && !s.netrestrict.Contains(n.IP):
 		return errNotWhitelisted
+	case s.bans != nil && s.bans.isBanned(n.ID, n.IP):
+		return errBanned
 	case s.hist.contains(n.ID):
 		return errRecentlyDialed
 	}
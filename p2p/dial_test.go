@@ -432,6 +432,33 @@ func TestDialStateNetRestrict(t *testing.T) {
 	})
 }
 
+// This test checks that candidates rejected by the node filter are not dialed.
+func TestDialStateNodeFilter(t *testing.T) {
+	table := fakeTable{
+		{ID: uintID(1), IP: net.ParseIP("127.0.0.1"), TCP: 30303},
+		{ID: uintID(2), IP: net.ParseIP("127.0.0.2"), TCP: 30303},
+		{ID: uintID(3), IP: net.ParseIP("127.0.0.3"), TCP: 30303},
+		{ID: uintID(4), IP: net.ParseIP("127.0.0.4"), TCP: 30303},
+		{ID: uintID(5), IP: net.ParseIP("127.0.0.5"), TCP: 30303},
+	}
+	state := newDialState(nil, nil, table, 10, nil)
+	state.nodeFilter = func(n *discover.Node) bool { return n.IP[15]%2 == 1 }
+
+	runDialTest(t, dialtest{
+		init: state,
+		rounds: []round{
+			{
+				new: []task{
+					&dialTask{flags: dynDialedConn, dest: table[0]},
+					&dialTask{flags: dynDialedConn, dest: table[2]},
+					&dialTask{flags: dynDialedConn, dest: table[4]},
+					&discoverTask{},
+				},
+			},
+		},
+	})
+}
+
 // This test checks that static dials are launched.
 func TestDialStateStaticDial(t *testing.T) {
 	wantStatic := []*discover.Node{
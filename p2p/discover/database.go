@@ -56,6 +56,7 @@ type nodeDB struct {
 var (
 	nodeDBVersionKey = []byte("version") // Version of the database to flush if changes
 	nodeDBItemPrefix = []byte("n:")      // Identifier to prefix node entries with
+	nodeDBBanPrefix  = []byte("ban:")    // Identifier to prefix ban entries with
 
 	nodeDBDiscoverRoot      = ":discover"
 	nodeDBDiscoverPing      = nodeDBDiscoverRoot + ":lastping"
@@ -363,8 +364,77 @@ func nextNode(it iterator.Iterator) *Node {
 	return nil
 }
 
+// storeBan persists a ban of the given target until the expiry time, zero
+// meaning the ban never expires.
+func (db *nodeDB) storeBan(target string, expires time.Time) error {
+	var exp int64
+	if !expires.IsZero() {
+		exp = expires.Unix()
+	}
+	return db.storeInt64(append(nodeDBBanPrefix, target...), exp)
+}
+
+// deleteBan removes the ban of the given target.
+func (db *nodeDB) deleteBan(target string) error {
+	return db.lvl.Delete(append(nodeDBBanPrefix, target...), nil)
+}
+
+// bans retrieves all stored bans along with their expiry times.
+func (db *nodeDB) bans() map[string]time.Time {
+	it := db.lvl.NewIterator(util.BytesPrefix(nodeDBBanPrefix), nil)
+	defer it.Release()
+
+	bans := make(map[string]time.Time)
+	for it.Next() {
+		target := string(it.Key()[len(nodeDBBanPrefix):])
+		if exp, read := binary.Varint(it.Value()); read > 0 && exp != 0 {
+			bans[target] = time.Unix(exp, 0)
+		} else {
+			bans[target] = time.Time{}
+		}
+	}
+	return bans
+}
+
 // close flushes and closes the database files.
 func (db *nodeDB) close() {
 	close(db.quit)
 	db.lvl.Close()
 }
+
+// BanDB gives access to the bans of a node database without running the
+// discovery table, which otherwise owns the database.
+type BanDB struct {
+	db *nodeDB
+}
+
+// OpenBanDB opens the node database at the given path for storing bans. If no
+// path is given, an in-memory, temporary database is constructed.
+func OpenBanDB(path string, self NodeID) (*BanDB, error) {
+	db, err := newNodeDB(path, Version, self)
+	if err != nil {
+		return nil, err
+	}
+	return &BanDB{db: db}, nil
+}
+
+// StoreBan persists a ban of the given target. A zero expiry time denotes a
+// permanent ban.
+func (db *BanDB) StoreBan(target string, expires time.Time) error {
+	return db.db.storeBan(target, expires)
+}
+
+// DeleteBan removes a ban from the node database.
+func (db *BanDB) DeleteBan(target string) error {
+	return db.db.deleteBan(target)
+}
+
+// Bans returns the stored bans, keyed by target.
+func (db *BanDB) Bans() map[string]time.Time {
+	return db.db.bans()
+}
+
+// Close flushes and closes the database files.
+func (db *BanDB) Close() {
+	db.db.close()
+}
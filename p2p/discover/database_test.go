@@ -378,3 +378,30 @@ func TestNodeDBSelfExpiration(t *testing.T) {
 		t.Errorf("self not evacuated")
 	}
 }
+
+func TestNodeDBBans(t *testing.T) {
+	db, _ := newNodeDB("", Version, NodeID{})
+	defer db.close()
+
+	expires := time.Unix(time.Now().Add(time.Hour).Unix(), 0)
+	if err := db.storeBan("10.0.0.0/8", expires); err != nil {
+		t.Fatalf("failed to store ban: %v", err)
+	}
+	if err := db.storeBan("forever", time.Time{}); err != nil {
+		t.Fatalf("failed to store ban: %v", err)
+	}
+	// Bans must survive node expiration.
+	if err := db.expireNodes(); err != nil {
+		t.Fatalf("failed to expire nodes: %v", err)
+	}
+	want := map[string]time.Time{"10.0.0.0/8": expires, "forever": {}}
+	if bans := db.bans(); !reflect.DeepEqual(bans, want) {
+		t.Errorf("ban mismatch: have %v, want %v", bans, want)
+	}
+	if err := db.deleteBan("forever"); err != nil {
+		t.Fatalf("failed to delete ban: %v", err)
+	}
+	if bans := db.bans(); len(bans) != 1 {
+		t.Errorf("ban not deleted: %v", bans)
+	}
+}
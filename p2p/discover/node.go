@@ -34,6 +34,7 @@ import (
 	"github.com/pocethereum/pochain/common"
 	"github.com/pocethereum/pochain/crypto"
 	"github.com/pocethereum/pochain/crypto/secp256k1"
+	"github.com/pocethereum/pochain/p2p/enr"
 	"io/ioutil"
 	"net/http"
 )
@@ -58,6 +59,9 @@ type Node struct {
 	// with ID.
 	sha common.Hash
 
+	// Signed node record of the node, if known.
+	record *enr.Record
+
 	// Time when the node was added to the table.
 	addedAt time.Time
 }
@@ -77,6 +81,45 @@ func NewNode(id NodeID, ip net.IP, udpPort, tcpPort uint16) *Node {
 	}
 }
 
+// NodeFromRecord creates a node from a signed node record of the "v4" identity
+// scheme. The returned node is incomplete if the record has no IP address or
+// TCP port.
+func NodeFromRecord(r *enr.Record) (*Node, error) {
+	var (
+		pubkey enr.Secp256k1
+		ip     enr.IP
+		udp    enr.UDP
+		tcp    enr.TCP
+	)
+	if !r.Signed() {
+		return nil, errors.New("unsigned node record")
+	}
+	if err := r.Load(&pubkey); err != nil {
+		return nil, err
+	}
+	// The endpoint is optional, missing entries are left empty.
+	r.Load(&ip)
+	r.Load(&udp)
+	r.Load(&tcp)
+
+	n := NewNode(PubkeyID((*ecdsa.PublicKey)(&pubkey)), net.IP(ip), uint16(udp), uint16(tcp))
+	n.record = r
+	return n, nil
+}
+
+// Record returns the signed node record of the node, or nil if it isn't known.
+func (n *Node) Record() *enr.Record {
+	return n.record
+}
+
+// Seq returns the sequence number of the known node record, zero if none.
+func (n *Node) Seq() uint64 {
+	if n.record == nil {
+		return 0
+	}
+	return n.record.Seq()
+}
+
 func (n *Node) addr() *net.UDPAddr {
 	return &net.UDPAddr{IP: n.IP, Port: int(n.UDP)}
 }
@@ -36,6 +36,7 @@ import (
 	"github.com/pocethereum/pochain/common"
 	"github.com/pocethereum/pochain/crypto"
 	"github.com/pocethereum/pochain/log"
+	"github.com/pocethereum/pochain/p2p/enr"
 	"github.com/pocethereum/pochain/p2p/netutil"
 )
 
@@ -98,9 +99,10 @@ type bondproc struct {
 // it is an interface so we can test without opening lots of UDP
 // sockets and without generating a private key.
 type transport interface {
-	ping(NodeID, *net.UDPAddr) error
+	ping(NodeID, *net.UDPAddr) (seq uint64, err error)
 	waitping(NodeID) error
 	findnode(toid NodeID, addr *net.UDPAddr, target NodeID) ([]*Node, error)
+	requestENR(*Node) (*enr.Record, error)
 	close()
 }
 
@@ -208,6 +210,22 @@ func (tab *Table) ReadRandomNodes(buf []*Node) (n int) {
 	return i + 1
 }
 
+// StoreBan persists a ban of the given target in the node database. A zero
+// expiry time denotes a permanent ban.
+func (tab *Table) StoreBan(target string, expires time.Time) error {
+	return tab.db.storeBan(target, expires)
+}
+
+// DeleteBan removes a ban from the node database.
+func (tab *Table) DeleteBan(target string) error {
+	return tab.db.deleteBan(target)
+}
+
+// Bans returns the bans stored in the node database, keyed by target.
+func (tab *Table) Bans() map[string]time.Time {
+	return tab.db.bans()
+}
+
 // Close terminates the network listener and flushes the node database.
 func (tab *Table) Close() {
 	select {
@@ -473,14 +491,24 @@ func (tab *Table) doRevalidate(done chan<- struct{}) {
 	}
 
 	// Ping the selected node and wait for a pong.
-	err := tab.ping(last.ID, last.addr())
-
+	seq, err := tab.ping(last.ID, last.addr())
+
+	// Also fetch the node record if the node announced a newer one.
+	if err == nil && seq > last.Seq() {
+		if record, rerr := tab.net.requestENR(last); rerr == nil {
+			updated := *last
+			updated.record = record
+			last = &updated
+		} else {
+			log.Debug("ENR request failed", "id", last.ID, "err", rerr)
+		}
+	}
 	tab.mutex.Lock()
 	defer tab.mutex.Unlock()
 	b := tab.buckets[bi]
 	if err == nil {
 		// The node responded, move it to the front.
-		log.Debug("Revalidated node", "b", bi, "id", last.ID)
+		log.Debug("Revalidated node", "b", bi, "id", last.ID, "seq", last.Seq())
 		b.bump(last)
 		return
 	}
@@ -641,7 +669,8 @@ func (tab *Table) pingpong(w *bondproc, pinged bool, id NodeID, addr *net.UDPAdd
 	defer func() { tab.bondslots <- struct{}{} }()
 
 	// Ping the remote side and wait for a pong.
-	if w.err = tab.ping(id, addr); w.err != nil {
+	seq, err := tab.ping(id, addr)
+	if w.err = err; w.err != nil {
 		close(w.done)
 		return
 	}
@@ -653,18 +682,28 @@ func (tab *Table) pingpong(w *bondproc, pinged bool, id NodeID, addr *net.UDPAdd
 	}
 	// Bonding succeeded, update the node database.
 	w.n = NewNode(id, addr.IP, uint16(addr.Port), tcpPort)
+
+	// Fetch the node record if the remote node has one.
+	if seq > 0 {
+		if record, err := tab.net.requestENR(w.n); err == nil {
+			w.n.record = record
+		} else {
+			log.Trace("ENR request failed", "id", id, "err", err)
+		}
+	}
 	close(w.done)
 }
 
 // ping a remote endpoint and wait for a reply, also updating the node
 // database accordingly.
-func (tab *Table) ping(id NodeID, addr *net.UDPAddr) error {
+func (tab *Table) ping(id NodeID, addr *net.UDPAddr) (uint64, error) {
 	tab.db.updateLastPing(id, time.Now())
-	if err := tab.net.ping(id, addr); err != nil {
-		return err
+	seq, err := tab.net.ping(id, addr)
+	if err != nil {
+		return 0, err
 	}
 	tab.db.updateBondTime(id, time.Now())
-	return nil
+	return seq, nil
 }
 
 // bucket returns the bucket for the given node ID hash.
@@ -27,6 +27,7 @@ import (
 
 	"github.com/pocethereum/pochain/crypto"
 	"github.com/pocethereum/pochain/log"
+	"github.com/pocethereum/pochain/p2p/enr"
 	"github.com/pocethereum/pochain/p2p/nat"
 	"github.com/pocethereum/pochain/p2p/netutil"
 	"github.com/pocethereum/pochain/rlp"
@@ -44,6 +45,7 @@ var (
 	errTimeout          = errors.New("RPC timeout")
 	errClockWarp        = errors.New("reply deadline too far in the future")
 	errClosed           = errors.New("socket closed")
+	errNoRecord         = errors.New("no local node record")
 )
 
 // Timeouts
@@ -62,6 +64,8 @@ const (
 	pongPacket
 	findnodePacket
 	neighborsPacket
+	enrRequestPacket
+	enrResponsePacket
 )
 
 // RPC request structures
@@ -103,6 +107,21 @@ type (
 		Rest []rlp.RawValue `rlp:"tail"`
 	}
 
+	// enrRequest queries for the remote node's record (EIP-868).
+	enrRequest struct {
+		Expiration uint64
+		// Ignore additional fields (for forward compatibility).
+		Rest []rlp.RawValue `rlp:"tail"`
+	}
+
+	// enrResponse is the reply to enrRequest.
+	enrResponse struct {
+		ReplyTok []byte // Hash of the enrRequest packet.
+		Record   enr.Record
+		// Ignore additional fields (for forward compatibility).
+		Rest []rlp.RawValue `rlp:"tail"`
+	}
+
 	rpcNode struct {
 		IP  net.IP // len 4 for IPv4 or 16 for IPv6
 		UDP uint16 // for discovery protocol
@@ -140,6 +159,23 @@ func (t *udp) nodeFromRPC(sender *net.UDPAddr, rn rpcNode) (*Node, error) {
 	return n, err
 }
 
+// seqTail encodes a node record sequence number as the trailing element of a
+// ping or pong packet.
+func seqTail(seq uint64) []rlp.RawValue {
+	enc, _ := rlp.EncodeToBytes(seq)
+	return []rlp.RawValue{enc}
+}
+
+// seqFromTail decodes the node record sequence number trailing a ping or pong
+// packet, zero if the sender doesn't support node records.
+func seqFromTail(tail []rlp.RawValue) uint64 {
+	var seq uint64
+	if len(tail) > 0 {
+		rlp.DecodeBytes(tail[0], &seq)
+	}
+	return seq
+}
+
 func nodeToRPC(n *Node) rpcNode {
 	return rpcNode{ID: n.ID, IP: n.IP, UDP: n.UDP, TCP: n.TCP}
 }
@@ -169,6 +205,8 @@ type udp struct {
 	closing chan struct{}
 	nat     nat.Interface
 
+	localRecord func() *enr.Record // Retrieves the local node record, nil if not available
+
 	*Table
 }
 
@@ -226,6 +264,8 @@ type Config struct {
 	NetRestrict  *netutil.Netlist  // network whitelist
 	Bootnodes    []*Node           // list of bootstrap nodes
 	Unhandled    chan<- ReadPacket // unhandled packets are sent on this channel
+
+	LocalRecord func() *enr.Record // if set, the local node record is served to other nodes
 }
 
 // ListenUDP returns a new table that listens for UDP packets on laddr.
@@ -246,6 +286,7 @@ func newUDP(c conn, cfg Config) (*Table, *udp, error) {
 		closing:     make(chan struct{}),
 		gotreply:    make(chan reply),
 		addpending:  make(chan *pending),
+		localRecord: cfg.LocalRecord,
 	}
 	realaddr := c.LocalAddr().(*net.UDPAddr)
 	if cfg.AnnounceAddr != nil {
@@ -270,23 +311,75 @@ func (t *udp) close() {
 	// TODO: wait for the loops to end.
 }
 
-// ping sends a ping message to the given node and waits for a reply.
-func (t *udp) ping(toid NodeID, toaddr *net.UDPAddr) error {
+// localSeq returns the sequence number of the local node record, zero if
+// there is none.
+func (t *udp) localSeq() uint64 {
+	if t.localRecord == nil {
+		return 0
+	}
+	if r := t.localRecord(); r != nil {
+		return r.Seq()
+	}
+	return 0
+}
+
+// ping sends a ping message to the given node and waits for a reply, returning
+// the sequence number of the remote node record it announced.
+func (t *udp) ping(toid NodeID, toaddr *net.UDPAddr) (uint64, error) {
 	req := &ping{
 		Version:    Version,
 		From:       t.ourEndpoint,
 		To:         makeEndpoint(toaddr, 0), // TODO: maybe use known TCP port from DB
 		Expiration: uint64(time.Now().Add(expiration).Unix()),
+		Rest:       seqTail(t.localSeq()),
 	}
 	packet, hash, err := encodePacket(t.priv, pingPacket, req)
 	if err != nil {
-		return err
+		return 0, err
 	}
+	var seq uint64
 	errc := t.pending(toid, pongPacket, func(p interface{}) bool {
-		return bytes.Equal(p.(*pong).ReplyTok, hash)
+		reply := p.(*pong)
+		if !bytes.Equal(reply.ReplyTok, hash) {
+			return false
+		}
+		seq = seqFromTail(reply.Rest)
+		return true
 	})
 	t.write(toaddr, req.name(), packet)
-	return <-errc
+	err = <-errc
+	return seq, err
+}
+
+// requestENR sends an ENR request to the given node and waits for its record,
+// which must be signed by the node.
+func (t *udp) requestENR(n *Node) (*enr.Record, error) {
+	req := &enrRequest{Expiration: uint64(time.Now().Add(expiration).Unix())}
+	packet, hash, err := encodePacket(t.priv, enrRequestPacket, req)
+	if err != nil {
+		return nil, err
+	}
+	var record *enr.Record
+	errc := t.pending(n.ID, enrResponsePacket, func(r interface{}) bool {
+		reply := r.(*enrResponse)
+		if !bytes.Equal(reply.ReplyTok, hash) {
+			return false
+		}
+		record = &reply.Record
+		return true
+	})
+	t.write(n.addr(), req.name(), packet)
+	if err := <-errc; err != nil {
+		return nil, err
+	}
+	rn, err := NodeFromRecord(record)
+	if err != nil {
+		return nil, err
+	}
+	if rn.ID != n.ID {
+		return nil, errors.New("record of another node")
+	}
+	return record, nil
 }
 
 func (t *udp) waitping(from NodeID) error {
@@ -570,6 +663,10 @@ func decodePacket(buf []byte) (packet, NodeID, []byte, error) {
 		req = new(findnode)
 	case neighborsPacket:
 		req = new(neighbors)
+	case enrRequestPacket:
+		req = new(enrRequest)
+	case enrResponsePacket:
+		req = new(enrResponse)
 	default:
 		return nil, fromID, hash, fmt.Errorf("unknown type: %d", ptype)
 	}
@@ -586,6 +683,7 @@ func (req *ping) handle(t *udp, from *net.UDPAddr, fromID NodeID, mac []byte) er
 		To:         makeEndpoint(from, req.From.TCP),
 		ReplyTok:   mac,
 		Expiration: uint64(time.Now().Add(expiration).Unix()),
+		Rest:       seqTail(t.localSeq()),
 	})
 	if !t.handleReply(fromID, pingPacket, req) {
 		// Note: we're ignoring the provided IP address right now
@@ -661,6 +759,40 @@ func (req *neighbors) handle(t *udp, from *net.UDPAddr, fromID NodeID, mac []byt
 
 func (req *neighbors) name() string { return "NEIGHBORS/v4" }
 
+func (req *enrRequest) handle(t *udp, from *net.UDPAddr, fromID NodeID, mac []byte) error {
+	if expired(req.Expiration) {
+		return errExpired
+	}
+	if !t.db.hasBond(fromID) {
+		// Records are only served to bonded nodes, for the same reason as
+		// neighbors: the response is much bigger than the request.
+		return errUnknownNode
+	}
+	var record *enr.Record
+	if t.localRecord != nil {
+		record = t.localRecord()
+	}
+	if record == nil {
+		return errNoRecord
+	}
+	t.send(from, enrResponsePacket, &enrResponse{
+		ReplyTok: mac,
+		Record:   *record,
+	})
+	return nil
+}
+
+func (req *enrRequest) name() string { return "ENRREQUEST/v4" }
+
+func (req *enrResponse) handle(t *udp, from *net.UDPAddr, fromID NodeID, mac []byte) error {
+	if !t.handleReply(fromID, enrResponsePacket, req) {
+		return errUnsolicitedReply
+	}
+	return nil
+}
+
+func (req *enrResponse) name() string { return "ENRRESPONSE/v4" }
+
 func expired(ts uint64) bool {
 	return time.Unix(int64(ts), 0).Before(time.Now())
 }
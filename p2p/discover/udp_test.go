@@ -36,6 +36,7 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	"github.com/pocethereum/pochain/common"
 	"github.com/pocethereum/pochain/crypto"
+	"github.com/pocethereum/pochain/p2p/enr"
 	"github.com/pocethereum/pochain/rlp"
 )
 
@@ -137,7 +138,7 @@ func TestUDP_pingTimeout(t *testing.T) {
 
 	toaddr := &net.UDPAddr{IP: net.ParseIP("1.2.3.4"), Port: 2222}
 	toid := NodeID{1, 2, 3, 4}
-	if err := test.udp.ping(toid, toaddr); err != errTimeout {
+	if _, err := test.udp.ping(toid, toaddr); err != errTimeout {
 		t.Error("expected timeout error, got", err)
 	}
 }
@@ -386,6 +387,86 @@ func TestUDP_successfulPing(t *testing.T) {
 	}
 }
 
+func TestUDP_ENRRequest(t *testing.T) {
+	test := newUDPTest(t)
+	defer test.table.Close()
+
+	// Records are only served to bonded nodes.
+	test.packetIn(errUnknownNode, enrRequestPacket, &enrRequest{Expiration: futureExp})
+	test.table.db.updateBondTime(PubkeyID(&test.remotekey.PublicKey), time.Now())
+	test.packetIn(errNoRecord, enrRequestPacket, &enrRequest{Expiration: futureExp})
+
+	var record enr.Record
+	record.Set(enr.IP(testLocal.IP))
+	record.Set(enr.UDP(testLocal.UDP))
+	record.SetSeq(7)
+	if err := enr.SignV4(&record, test.localkey); err != nil {
+		t.Fatal(err)
+	}
+	test.udp.localRecord = func() *enr.Record { return &record }
+
+	test.packetIn(nil, enrRequestPacket, &enrRequest{Expiration: futureExp})
+	test.waitPacketOut(func(p *enrResponse) {
+		reqhash := test.sent[len(test.sent)-1][:macSize]
+		if !bytes.Equal(p.ReplyTok, reqhash) {
+			t.Errorf("got enrResponse.ReplyTok %x, want %x", p.ReplyTok, reqhash)
+		}
+		if !reflect.DeepEqual(&p.Record, &record) {
+			t.Errorf("wrong record in response: %v", spew.Sdump(p.Record))
+		}
+	})
+
+	// Pongs announce the sequence number of the record.
+	test.packetIn(nil, pingPacket, &ping{From: testRemote, To: testLocalAnnounced, Version: Version, Expiration: futureExp})
+	test.waitPacketOut(func(p *pong) {
+		if seq := seqFromTail(p.Rest); seq != record.Seq() {
+			t.Errorf("got pong seq %d, want %d", seq, record.Seq())
+		}
+	})
+}
+
+func TestUDP_requestENR(t *testing.T) {
+	test := newUDPTest(t)
+	defer test.table.Close()
+
+	var record enr.Record
+	record.Set(enr.IP(test.remoteaddr.IP))
+	record.Set(enr.UDP(test.remoteaddr.Port))
+	if err := enr.SignV4(&record, test.remotekey); err != nil {
+		t.Fatal(err)
+	}
+	remote := NewNode(PubkeyID(&test.remotekey.PublicKey), test.remoteaddr.IP, uint16(test.remoteaddr.Port), 0)
+
+	done := make(chan error, 1)
+	go func() {
+		r, err := test.udp.requestENR(remote)
+		if err == nil && !reflect.DeepEqual(r, &record) {
+			err = fmt.Errorf("wrong record returned: %v", spew.Sdump(r))
+		}
+		done <- err
+	}()
+	hash, _ := test.waitPacketOut(func(p *enrRequest) {})
+	test.packetIn(nil, enrResponsePacket, &enrResponse{ReplyTok: hash, Record: record})
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// Records signed by other nodes are rejected.
+	go func() {
+		_, err := test.udp.requestENR(remote)
+		done <- err
+	}()
+	var foreign enr.Record
+	if err := enr.SignV4(&foreign, newkey()); err != nil {
+		t.Fatal(err)
+	}
+	hash, _ = test.waitPacketOut(func(p *enrRequest) {})
+	test.packetIn(nil, enrResponsePacket, &enrResponse{ReplyTok: hash, Record: foreign})
+	if err := <-done; err == nil {
+		t.Fatal("accepted record of another node")
+	}
+}
+
 var testPackets = []struct {
 	input      string
 	wantPacket interface{}
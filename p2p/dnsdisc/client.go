@@ -0,0 +1,253 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package dnsdisc
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/pocethereum/pochain/crypto"
+	"github.com/pocethereum/pochain/log"
+	"github.com/pocethereum/pochain/p2p/enr"
+)
+
+// Client discovers nodes by querying DNS servers.
+type Client struct {
+	cfg     Config
+	entries *lru.Cache
+}
+
+// Config holds configuration options for the client.
+type Config struct {
+	Timeout      time.Duration   // timeout used for DNS lookups (default 5s)
+	CacheLimit   int             // maximum number of cached records (default 1000)
+	ValidSchemes map[string]bool // acceptable ENR identity schemes (default "v4")
+	Resolver     Resolver        // the DNS resolver to use (defaults to system DNS)
+	Logger       log.Logger      // destination of client log messages (defaults to root logger)
+}
+
+// Resolver is a DNS resolver that can query TXT records.
+type Resolver interface {
+	LookupTXT(ctx context.Context, domain string) ([]string, error)
+}
+
+func (cfg Config) withDefaults() Config {
+	const (
+		defaultTimeout = 5 * time.Second
+		defaultCache   = 1000
+	)
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.CacheLimit == 0 {
+		cfg.CacheLimit = defaultCache
+	}
+	if cfg.ValidSchemes == nil {
+		cfg.ValidSchemes = map[string]bool{"v4": true}
+	}
+	if cfg.Resolver == nil {
+		cfg.Resolver = new(net.Resolver)
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Root()
+	}
+	return cfg
+}
+
+// NewClient creates a client.
+func NewClient(cfg Config) *Client {
+	cfg = cfg.withDefaults()
+	cache, err := lru.New(cfg.CacheLimit)
+	if err != nil {
+		panic(err)
+	}
+	return &Client{cfg: cfg, entries: cache}
+}
+
+// SyncTree downloads the entire node tree at the given URL. This doesn't add the
+// tree for later use, but any previously-synced entries are reused from the cache.
+func (c *Client) SyncTree(url string) (*Tree, error) {
+	le, err := parseLink(url)
+	if err != nil {
+		return nil, err
+	}
+	return c.syncTree(context.Background(), le)
+}
+
+// SyncNodes downloads the trees at the given URLs along with all trees linked
+// from them, returning the node records found. Trees failing to sync are
+// skipped, the first failure is returned alongside the records of the others.
+func (c *Client) SyncNodes(ctx context.Context, urls []string) ([]*enr.Record, error) {
+	var (
+		queue   = append([]string{}, urls...)
+		visited = make(map[string]bool)
+		seen    = make(map[string]bool)
+		nodes   []*enr.Record
+		failure error
+	)
+	for len(queue) > 0 && ctx.Err() == nil {
+		url := queue[0]
+		queue = queue[1:]
+
+		le, err := parseLink(url)
+		if err != nil {
+			if failure == nil {
+				failure = err
+			}
+			continue
+		}
+		// Links are canonicalized to deduplicate them regardless of their form.
+		if url = le.String(); visited[url] {
+			continue
+		}
+		visited[url] = true
+
+		t, err := c.syncTree(ctx, le)
+		if err != nil {
+			c.cfg.Logger.Debug("Failed to sync DNS discovery tree", "url", url, "err", err)
+			if failure == nil {
+				failure = err
+			}
+			continue
+		}
+		for _, n := range t.Nodes() {
+			if id := string(n.NodeAddr()); !seen[id] {
+				seen[id] = true
+				nodes = append(nodes, n)
+			}
+		}
+		queue = append(queue, t.Links()...)
+	}
+	return nodes, failure
+}
+
+// syncTree resolves the root of the tree at the given link and all entries
+// below it.
+func (c *Client) syncTree(ctx context.Context, le *linkEntry) (*Tree, error) {
+	root, err := c.resolveRoot(ctx, le)
+	if err != nil {
+		return nil, err
+	}
+	t := &Tree{root: &root, entries: make(map[string]entry)}
+	if err := c.syncSubtree(ctx, le.domain, root.eroot, false, t); err != nil {
+		return nil, err
+	}
+	if err := c.syncSubtree(ctx, le.domain, root.lroot, true, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// syncSubtree resolves the entry with the given hash and, if it's a branch,
+// all entries below it into t.
+func (c *Client) syncSubtree(ctx context.Context, domain, hash string, link bool, t *Tree) error {
+	if _, ok := t.entries[hash]; ok {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	e, err := c.resolveEntry(ctx, domain, hash)
+	if err != nil {
+		return err
+	}
+	switch e := e.(type) {
+	case *branchEntry:
+		for _, child := range e.children {
+			if err := c.syncSubtree(ctx, domain, child, link, t); err != nil {
+				return err
+			}
+		}
+	case *enrEntry:
+		if link {
+			return errENRInLinkTree
+		}
+	case *linkEntry:
+		if !link {
+			return errLinkInENRTree
+		}
+	}
+	t.entries[hash] = e
+	return nil
+}
+
+// resolveRoot retrieves a root entry via DNS and verifies its signature.
+func (c *Client) resolveRoot(ctx context.Context, loc *linkEntry) (rootEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	txts, err := c.cfg.Resolver.LookupTXT(ctx, loc.domain)
+	c.cfg.Logger.Trace("Updating DNS discovery root", "tree", loc.domain, "err", err)
+	if err != nil {
+		return rootEntry{}, err
+	}
+	for _, txt := range txts {
+		if strings.HasPrefix(txt, rootPrefix) {
+			e, err := parseRoot(txt)
+			if err != nil {
+				return e, nameError{loc.domain, err}
+			}
+			if !e.verifySignature(loc.pubkey) {
+				return e, nameError{loc.domain, entryError{typ: "root", err: errInvalidSig}}
+			}
+			return e, nil
+		}
+	}
+	return rootEntry{}, nameError{loc.domain, errNoRoot}
+}
+
+// resolveEntry retrieves an entry from the cache or fetches it from the network
+// if it isn't cached.
+func (c *Client) resolveEntry(ctx context.Context, domain, hash string) (entry, error) {
+	if e, ok := c.entries.Get(hash); ok {
+		return e.(entry), nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	wantHash, err := b32format.DecodeString(hash)
+	if err != nil {
+		return nil, entryError{"branch", errInvalidChild}
+	}
+	name := hash + "." + domain
+	txts, err := c.cfg.Resolver.LookupTXT(ctx, name)
+	c.cfg.Logger.Trace("DNS discovery lookup", "name", name, "err", err)
+	if err != nil {
+		return nil, err
+	}
+	for _, txt := range txts {
+		e, err := parseEntry(txt, c.cfg.ValidSchemes)
+		if err == errUnknownEntry {
+			continue
+		}
+		if !bytes.HasPrefix(crypto.Keccak256([]byte(txt)), wantHash) {
+			err = nameError{name, errHashMismatch}
+		} else if err != nil {
+			err = nameError{name, err}
+		}
+		if err != nil {
+			return nil, err
+		}
+		c.entries.Add(hash, e)
+		return e, nil
+	}
+	return nil, nameError{name, errNoEntry}
+}
@@ -0,0 +1,189 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package dnsdisc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/pocethereum/pochain/crypto"
+	"github.com/pocethereum/pochain/p2p/enr"
+)
+
+// mapResolver is an in-process resolver serving TXT records from a map.
+type mapResolver map[string]string
+
+func (mr mapResolver) add(m map[string]string) {
+	for k, v := range m {
+		mr[k] = v
+	}
+}
+
+func (mr mapResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if record, ok := mr[name]; ok {
+		return []string{record}, nil
+	}
+	return nil, fmt.Errorf("no such name %q", name)
+}
+
+func testKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// testNodes creates n signed node records.
+func testNodes(t *testing.T, n int) []*enr.Record {
+	nodes := make([]*enr.Record, n)
+	for i := range nodes {
+		var r enr.Record
+		r.Set(enr.IP(net.IP{127, 0, 0, byte(i + 1)}))
+		r.Set(enr.TCP(30303))
+		r.Set(enr.UDP(30303))
+		if err := enr.SignV4(&r, testKey(t)); err != nil {
+			t.Fatal(err)
+		}
+		nodes[i] = &r
+	}
+	return nodes
+}
+
+// makeTestTree creates, signs and publishes a tree into the resolver.
+func makeTestTree(t *testing.T, r mapResolver, domain string, nodes []*enr.Record, links []string) (*Tree, string) {
+	tree, err := MakeTree(1, nodes, links)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, err := tree.Sign(testKey(t), domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.add(tree.ToTXT(domain))
+	return tree, url
+}
+
+func TestClientSyncTree(t *testing.T) {
+	r := make(mapResolver)
+	nodes := testNodes(t, 40)
+	tree, url := makeTestTree(t, r, "n", nodes, []string{"enrtree://AM5FCQLWIZX2QFPNJAP7VUERCCRNGRHWZG3YYHIUV7BVDQ5FDPRT2@morenodes.example.org"})
+
+	c := NewClient(Config{Resolver: r})
+	synced, err := c.SyncTree(url)
+	if err != nil {
+		t.Fatal("sync error:", err)
+	}
+	if !reflect.DeepEqual(synced.Nodes(), sortByID(nodes)) {
+		t.Errorf("wrong nodes in synced tree")
+	}
+	if !reflect.DeepEqual(synced.Links(), tree.Links()) {
+		t.Errorf("wrong links in synced tree: %v", synced.Links())
+	}
+	if !reflect.DeepEqual(synced.ToTXT("n"), tree.ToTXT("n")) {
+		t.Errorf("synced tree records don't match the published ones")
+	}
+}
+
+// Tests that SyncNodes follows links into other trees, tolerating broken ones.
+func TestClientSyncNodes(t *testing.T) {
+	var (
+		r      = make(mapResolver)
+		nodes  = testNodes(t, 20)
+		broken = "enrtree://AM5FCQLWIZX2QFPNJAP7VUERCCRNGRHWZG3YYHIUV7BVDQ5FDPRT2@broken.example.org"
+	)
+	_, link := makeTestTree(t, r, "b", nodes[10:], []string{broken})
+	_, url := makeTestTree(t, r, "a", nodes[:12], []string{link})
+
+	c := NewClient(Config{Resolver: r})
+	synced, err := c.SyncNodes(context.Background(), []string{url})
+	if err == nil {
+		t.Error("expected error for the broken link")
+	}
+	if !reflect.DeepEqual(sortByID(synced), sortByID(nodes)) {
+		t.Errorf("wrong nodes synced: have %d, want %d", len(synced), len(nodes))
+	}
+}
+
+func TestClientSyncTreeBadSignature(t *testing.T) {
+	r := make(mapResolver)
+	tree, _ := makeTestTree(t, r, "n", testNodes(t, 3), nil)
+
+	// Serve the tree under the URL of another key.
+	url := (&linkEntry{"n", &testKey(t).PublicKey}).String()
+	c := NewClient(Config{Resolver: r})
+	if _, err := c.SyncTree(url); err == nil {
+		t.Fatal("synced tree with invalid signature")
+	}
+	if err := tree.SetSignature(&testKey(t).PublicKey, tree.Signature()); err != errInvalidSig {
+		t.Fatalf("wrong error setting foreign signature: %v", err)
+	}
+}
+
+func TestClientSyncTreeBadEntries(t *testing.T) {
+	nodes := testNodes(t, 3)
+	link := "enrtree://AM5FCQLWIZX2QFPNJAP7VUERCCRNGRHWZG3YYHIUV7BVDQ5FDPRT2@morenodes.example.org"
+
+	// Tamper with a leaf of the node tree.
+	r := make(mapResolver)
+	tree, url := makeTestTree(t, r, "n", nodes, nil)
+	for name, txt := range tree.ToTXT("n") {
+		if txt == RecordString(nodes[0]) {
+			r[name] = RecordString(nodes[1])
+		}
+	}
+	if _, err := NewClient(Config{Resolver: r}).SyncTree(url); err == nil || err.(nameError).err != errHashMismatch {
+		t.Errorf("expected hash mismatch, got %v", err)
+	}
+	// Swap the node and link subtrees.
+	r = make(mapResolver)
+	tree, err := MakeTree(1, nodes, []string{link})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.root.eroot, tree.root.lroot = tree.root.lroot, tree.root.eroot
+	key := testKey(t)
+	if url, err = tree.Sign(key, "n"); err != nil {
+		t.Fatal(err)
+	}
+	r.add(tree.ToTXT("n"))
+	if _, err := NewClient(Config{Resolver: r}).SyncTree(url); err != errLinkInENRTree {
+		t.Errorf("expected link in ENR tree error, got %v", err)
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	key := testKey(t)
+	url := (&linkEntry{"nodes.example.org", &key.PublicKey}).String()
+
+	domain, pubkey, err := ParseURL(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if domain != "nodes.example.org" || !reflect.DeepEqual(pubkey, &key.PublicKey) {
+		t.Errorf("wrong URL components: %s %v", domain, pubkey)
+	}
+	for _, bad := range []string{"enode://nodes.example.org", "enrtree://nodes.example.org", "enrtree://AAAA@nodes.example.org"} {
+		if _, _, err := ParseURL(bad); err == nil {
+			t.Errorf("parsed invalid URL %q", bad)
+		}
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"github.com/pocethereum/pochain/p2p/discover"
+	"github.com/pocethereum/pochain/p2p/enr"
 )
 
 // Protocol represents a P2P subprotocol implementation.
@@ -52,6 +53,11 @@ type Protocol struct {
 	// about a certain peer in the network. If an info retrieval function is set,
 	// but returns nil, it is assumed that the protocol handshake is still running.
 	PeerInfo func(id discover.NodeID) interface{}
+
+	// NodeFilter is an optional check of the node records of dial candidates,
+	// e.g. for protocol entries announcing which network a node belongs to.
+	// Nodes with a record rejected by the filter aren't dialed dynamically.
+	NodeFilter func(*enr.Record) bool
 }
 
 func (p Protocol) cap() Cap {
@@ -0,0 +1,394 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"errors"
+	"math"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pocethereum/pochain/p2p/discover"
+)
+
+// Penalties reported by protocols for misbehaving peers. Penalties accumulate
+// into the score of a node, which decays over time. Nodes reaching banScore
+// are banned temporarily.
+const (
+	PenaltyTimeout = 10  // Request timeouts and stalled deliveries
+	PenaltyUseless = 25  // Data not matching what was announced or requested
+	PenaltyInvalid = 100 // Invalid blocks, e.g. failing PoC seal verification
+)
+
+const (
+	banScore        = 100              // Score at which a node is banned
+	scoreHalfLife   = 30 * time.Minute // Time after which a score is halved
+	scoreBanTime    = time.Hour        // Duration of bans caused by the score
+	scoreForgetTime = 6 * time.Hour    // Time after which unchanged scores are dropped
+)
+
+var (
+	errBanned        = errors.New("banned")
+	errNotBanned     = errors.New("not banned")
+	errInvalidTarget = errors.New("invalid ban target, want enode URL, node ID, IP or CIDR network")
+)
+
+// Ban describes a ban of a node or an IP network.
+type Ban struct {
+	Target  string     `json:"target"`            // Node ID or IP network in CIDR notation
+	Expires *time.Time `json:"expires,omitempty"` // Expiry time, nil for permanent bans
+}
+
+// banStore is implemented by discovery tables persisting bans in the node
+// database.
+type banStore interface {
+	StoreBan(target string, expires time.Time) error
+	DeleteBan(target string) error
+	Bans() map[string]time.Time
+}
+
+// score is the decaying sum of the penalties of a node.
+type score struct {
+	value   float64
+	updated time.Time
+}
+
+// bannedNet is an IP network ban.
+type bannedNet struct {
+	net     *net.IPNet
+	expires time.Time
+}
+
+// reputation tracks the scores of misbehaving nodes as well as the bans of
+// nodes and IP networks. Bans are persisted if a store is available.
+type reputation struct {
+	lock   sync.Mutex
+	store  banStore                      // Ban persistence, nil if bans are kept in memory only
+	scores map[discover.NodeID]*score    // Current scores of penalized nodes
+	nodes  map[discover.NodeID]time.Time // Banned nodes, zero expiry for permanent bans
+	nets   map[string]bannedNet          // Banned IP networks, keyed by CIDR notation
+	now    func() time.Time              // Clock, replaceable for testing
+}
+
+// newReputation creates a reputation tracker, loading the unexpired bans of
+// the store if one is given.
+func newReputation(store banStore) *reputation {
+	r := &reputation{
+		store:  store,
+		scores: make(map[discover.NodeID]*score),
+		nodes:  make(map[discover.NodeID]time.Time),
+		nets:   make(map[string]bannedNet),
+		now:    time.Now,
+	}
+	if store == nil {
+		return r
+	}
+	now := r.now()
+	for target, expires := range store.Bans() {
+		if !expires.IsZero() && !expires.After(now) {
+			store.DeleteBan(target)
+			continue
+		}
+		if id, ipnet, err := parseBanTarget(target); err == nil {
+			r.add(id, ipnet, expires)
+		}
+	}
+	return r
+}
+
+// parseBanTarget parses an enode URL, node ID, IP address or CIDR network.
+func parseBanTarget(target string) (*discover.NodeID, *net.IPNet, error) {
+	switch {
+	case strings.HasPrefix(target, "enode://"):
+		n, err := discover.ParseNode(target)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &n.ID, nil, nil
+	case strings.Contains(target, "/"):
+		_, ipnet, err := net.ParseCIDR(target)
+		if err != nil {
+			return nil, nil, errInvalidTarget
+		}
+		return nil, ipnet, nil
+	}
+	if ip := net.ParseIP(target); ip != nil {
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		return nil, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	id, err := discover.HexID(target)
+	if err != nil {
+		return nil, nil, errInvalidTarget
+	}
+	return &id, nil, nil
+}
+
+// add inserts a ban without persisting it. The lock must be held or the
+// tracker not yet in use.
+func (r *reputation) add(id *discover.NodeID, ipnet *net.IPNet, expires time.Time) string {
+	if id != nil {
+		r.nodes[*id] = expires
+		return id.String()
+	}
+	key := ipnet.String()
+	r.nets[key] = bannedNet{ipnet, expires}
+	return key
+}
+
+// ban bans the target for the given duration, zero meaning permanently.
+func (r *reputation) ban(target string, d time.Duration) error {
+	id, ipnet, err := parseBanTarget(target)
+	if err != nil {
+		return err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var expires time.Time
+	if d > 0 {
+		expires = r.now().Add(d)
+	}
+	key := r.add(id, ipnet, expires)
+	if r.store != nil {
+		return r.store.StoreBan(key, expires)
+	}
+	return nil
+}
+
+// unban lifts the ban of the target.
+func (r *reputation) unban(target string) error {
+	id, ipnet, err := parseBanTarget(target)
+	if err != nil {
+		return err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var key string
+	if id != nil {
+		if _, ok := r.nodes[*id]; !ok {
+			return errNotBanned
+		}
+		delete(r.nodes, *id)
+		delete(r.scores, *id)
+		key = id.String()
+	} else {
+		key = ipnet.String()
+		if _, ok := r.nets[key]; !ok {
+			return errNotBanned
+		}
+		delete(r.nets, key)
+	}
+	if r.store != nil {
+		return r.store.DeleteBan(key)
+	}
+	return nil
+}
+
+// penalize adds a penalty to the score of a node, banning the node if the
+// score reaches the ban threshold. It returns the new score and whether the
+// node was banned.
+func (r *reputation) penalize(id discover.NodeID, points int) (float64, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.now()
+	r.expireScores(now)
+
+	s := r.scores[id]
+	if s == nil {
+		s = new(score)
+		r.scores[id] = s
+	}
+	s.value = s.value*math.Exp2(-float64(now.Sub(s.updated))/float64(scoreHalfLife)) + float64(points)
+	s.updated = now
+	if s.value < banScore {
+		return s.value, false
+	}
+	delete(r.scores, id)
+	if _, ok := r.nodes[id]; ok {
+		return banScore, true // already banned, don't shorten a longer ban
+	}
+	expires := now.Add(scoreBanTime)
+	r.nodes[id] = expires
+	if r.store != nil {
+		r.store.StoreBan(id.String(), expires)
+	}
+	return banScore, true
+}
+
+// expireScores drops the scores that haven't changed for a long time. The
+// lock must be held.
+func (r *reputation) expireScores(now time.Time) {
+	for id, s := range r.scores {
+		if now.Sub(s.updated) > scoreForgetTime {
+			delete(r.scores, id)
+		}
+	}
+}
+
+// isBanned reports whether the node or its IP address is banned.
+func (r *reputation) isBanned(id discover.NodeID, ip net.IP) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.now()
+	return r.nodeBanned(id, now) || r.ipBanned(ip, now)
+}
+
+// isBannedIP reports whether the IP address is contained in a banned network.
+func (r *reputation) isBannedIP(ip net.IP) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.ipBanned(ip, r.now())
+}
+
+// nodeBanned checks the ban of a node, removing it if expired. The lock must
+// be held.
+func (r *reputation) nodeBanned(id discover.NodeID, now time.Time) bool {
+	expires, ok := r.nodes[id]
+	if !ok {
+		return false
+	}
+	if expires.IsZero() || expires.After(now) {
+		return true
+	}
+	r.expire(id.String())
+	return false
+}
+
+// ipBanned checks the network bans containing an IP address, removing the
+// expired ones. The lock must be held.
+func (r *reputation) ipBanned(ip net.IP, now time.Time) bool {
+	if ip == nil {
+		return false
+	}
+	for key, b := range r.nets {
+		if !b.net.Contains(ip) {
+			continue
+		}
+		if b.expires.IsZero() || b.expires.After(now) {
+			return true
+		}
+		r.expire(key)
+	}
+	return false
+}
+
+// expire removes a ban which has expired. The lock must be held.
+func (r *reputation) expire(key string) {
+	if id, err := discover.HexID(key); err == nil {
+		delete(r.nodes, id)
+	} else {
+		delete(r.nets, key)
+	}
+	if r.store != nil {
+		r.store.DeleteBan(key)
+	}
+}
+
+// bans returns all active bans, sorted by target.
+func (r *reputation) bans() []Ban {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var (
+		now  = r.now()
+		bans []Ban
+	)
+	add := func(key string, expires time.Time) {
+		switch {
+		case expires.IsZero():
+			bans = append(bans, Ban{Target: key})
+		case expires.After(now):
+			exp := expires
+			bans = append(bans, Ban{Target: key, Expires: &exp})
+		default:
+			r.expire(key)
+		}
+	}
+	for id, expires := range r.nodes {
+		add(id.String(), expires)
+	}
+	for key, b := range r.nets {
+		add(key, b.expires)
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].Target < bans[j].Target })
+	return bans
+}
+
+// Penalize reports misbehaviour of a node, adding the penalty points to its
+// score. Nodes reaching the ban threshold are banned temporarily and
+// disconnected.
+func (srv *Server) Penalize(id discover.NodeID, points int, reason string) {
+	if srv.rep == nil {
+		return
+	}
+	score, banned := srv.rep.penalize(id, points)
+	srv.log.Debug("Penalized peer", "id", id, "points", points, "score", score, "reason", reason)
+	if banned {
+		srv.log.Info("Banned misbehaving peer", "id", id, "duration", scoreBanTime, "reason", reason)
+		srv.disconnectBanned()
+	}
+}
+
+// Ban bans a node or an IP network for the given duration, zero meaning
+// permanently. The target is an enode URL, node ID, IP address or network in
+// CIDR notation. Connected peers matching the ban are disconnected.
+func (srv *Server) Ban(target string, d time.Duration) error {
+	if srv.rep == nil {
+		return errServerStopped
+	}
+	if err := srv.rep.ban(target, d); err != nil {
+		return err
+	}
+	srv.log.Info("Banned peer", "target", target, "duration", d)
+	srv.disconnectBanned()
+	return nil
+}
+
+// Unban lifts a ban created by Ban or caused by misbehaviour.
+func (srv *Server) Unban(target string) error {
+	if srv.rep == nil {
+		return errServerStopped
+	}
+	return srv.rep.unban(target)
+}
+
+// Bans returns the active bans of nodes and IP networks.
+func (srv *Server) Bans() []Ban {
+	if srv.rep == nil {
+		return nil
+	}
+	return srv.rep.bans()
+}
+
+// disconnectBanned disconnects all peers which are banned.
+func (srv *Server) disconnectBanned() {
+	for _, p := range srv.Peers() {
+		if srv.rep.isBanned(p.ID(), remoteIP(p.rw.fd)) {
+			p.Disconnect(DiscUselessPeer)
+		}
+	}
+}
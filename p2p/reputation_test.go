@@ -0,0 +1,218 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pocethereum/pochain/p2p/discover"
+)
+
+// memBanStore is an in-memory ban store.
+type memBanStore map[string]time.Time
+
+func (s memBanStore) StoreBan(target string, expires time.Time) error {
+	s[target] = expires
+	return nil
+}
+
+func (s memBanStore) DeleteBan(target string) error {
+	delete(s, target)
+	return nil
+}
+
+func (s memBanStore) Bans() map[string]time.Time {
+	bans := make(map[string]time.Time, len(s))
+	for k, v := range s {
+		bans[k] = v
+	}
+	return bans
+}
+
+// testClock is a manually advanced clock.
+type testClock struct{ t time.Time }
+
+func (c *testClock) now() time.Time      { return c.t }
+func (c *testClock) run(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestReputation(store banStore) (*reputation, *testClock) {
+	clock := &testClock{time.Unix(1500000000, 0)}
+	r := newReputation(store)
+	r.now = clock.now
+	return r, clock
+}
+
+func TestReputationPenalize(t *testing.T) {
+	r, clock := newTestReputation(nil)
+	id := randomID()
+
+	// Timeouts alone need to pile up before causing a ban.
+	for i := 0; i < banScore/PenaltyTimeout-1; i++ {
+		if _, banned := r.penalize(id, PenaltyTimeout); banned {
+			t.Fatalf("banned after %d timeouts", i+1)
+		}
+	}
+	// Scores decay, so the last penalty is not enough after a while.
+	clock.run(scoreHalfLife)
+	if score, banned := r.penalize(id, PenaltyTimeout); banned || score >= banScore {
+		t.Fatalf("banned despite decayed score %f", score)
+	}
+	// Invalid blocks cause a ban right away, which expires after a while.
+	other := randomID()
+	if _, banned := r.penalize(other, PenaltyInvalid); !banned {
+		t.Fatal("not banned after invalid block")
+	}
+	if !r.isBanned(other, nil) {
+		t.Fatal("node not reported as banned")
+	}
+	clock.run(scoreBanTime)
+	if r.isBanned(other, nil) {
+		t.Fatal("ban did not expire")
+	}
+}
+
+func TestReputationBanTargets(t *testing.T) {
+	store := make(memBanStore)
+	r, clock := newTestReputation(store)
+
+	id := randomID()
+	node := discover.NewNode(id, net.IP{10, 1, 2, 3}, 30303, 30303)
+	if err := r.ban(node.String(), 0); err != nil {
+		t.Fatal("can't ban enode URL:", err)
+	}
+	if err := r.ban("192.168.0.0/16", time.Hour); err != nil {
+		t.Fatal("can't ban network:", err)
+	}
+	if err := r.ban("172.16.0.1", 0); err != nil {
+		t.Fatal("can't ban IP:", err)
+	}
+	if err := r.ban("nonsense", 0); err != errInvalidTarget {
+		t.Fatalf("wrong error for invalid target: %v", err)
+	}
+
+	switch {
+	case !r.isBanned(id, net.IP{10, 1, 2, 3}):
+		t.Error("banned node not rejected")
+	case !r.isBannedIP(net.IP{192, 168, 5, 5}):
+		t.Error("IP in banned network not rejected")
+	case !r.isBannedIP(net.IP{172, 16, 0, 1}):
+		t.Error("banned IP not rejected")
+	case r.isBannedIP(net.IP{172, 16, 0, 2}):
+		t.Error("IP next to banned IP rejected")
+	}
+	if bans := r.bans(); len(bans) != 3 {
+		t.Errorf("wrong number of bans listed: %v", bans)
+	}
+
+	// Bans are restored from the store, except for expired ones.
+	clock.run(2 * time.Hour)
+	restored := newReputation(store)
+	restored.now = clock.now
+	if !restored.isBanned(id, nil) {
+		t.Error("permanent node ban not restored")
+	}
+	if restored.isBannedIP(net.IP{192, 168, 5, 5}) {
+		t.Error("expired network ban restored")
+	}
+	if len(store) != 2 {
+		t.Errorf("expired ban not removed from the store: %v", store)
+	}
+
+	// Lifting a ban removes it from the store too.
+	if err := restored.unban(id.String()); err != nil {
+		t.Fatal("can't unban node:", err)
+	}
+	if err := restored.unban(id.String()); err != errNotBanned {
+		t.Fatalf("wrong error unbanning twice: %v", err)
+	}
+	if restored.isBanned(id, nil) || len(store) != 1 {
+		t.Errorf("node still banned after unban, store %v", store)
+	}
+}
+
+func TestServerBanDisconnects(t *testing.T) {
+	connected := make(chan *Peer, 1)
+	remid := randomID()
+	srv := startTestServer(t, remid, func(p *Peer) { connected <- p })
+	defer srv.Stop()
+
+	conn, err := net.DialTimeout("tcp", srv.ListenAddr, 5*time.Second)
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	defer conn.Close()
+
+	select {
+	case peer := <-connected:
+		if err := srv.Ban(remid.String(), time.Hour); err != nil {
+			t.Fatal("ban failed:", err)
+		}
+		select {
+		case <-peer.closed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("banned peer not disconnected")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not accept connection")
+	}
+	if bans := srv.Bans(); len(bans) != 1 || bans[0].Target != remid.String() || bans[0].Expires == nil {
+		t.Errorf("wrong bans listed: %v", bans)
+	}
+}
+
+// Tests that bans are persisted in the node database even if the discovery
+// table, which usually owns it, isn't running.
+func TestServerBansPersistNoDiscovery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "p2p-bans")
+	if err != nil {
+		t.Fatalf("failed to create temporary node database dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := Config{
+		Name:         "test",
+		MaxPeers:     10,
+		PrivateKey:   newkey(),
+		NoDiscovery:  true,
+		NoDial:       true,
+		NodeDatabase: filepath.Join(dir, "nodes"),
+	}
+	srv := &Server{Config: config}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start server: %v", err)
+	}
+	remid := randomID()
+	if err := srv.Ban(remid.String(), 0); err != nil {
+		t.Fatal("ban failed:", err)
+	}
+	srv.Stop()
+
+	srv = &Server{Config: config}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not restart server: %v", err)
+	}
+	defer srv.Stop()
+
+	if bans := srv.Bans(); len(bans) != 1 || bans[0].Target != remid.String() || bans[0].Expires != nil {
+		t.Errorf("wrong bans restored: %v", bans)
+	}
+}
@@ -18,6 +18,7 @@
 package p2p
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"errors"
 	"fmt"
@@ -31,6 +32,8 @@ import (
 	"github.com/pocethereum/pochain/log"
 	"github.com/pocethereum/pochain/p2p/discover"
 	"github.com/pocethereum/pochain/p2p/discv5"
+	"github.com/pocethereum/pochain/p2p/dnsdisc"
+	"github.com/pocethereum/pochain/p2p/enr"
 	"github.com/pocethereum/pochain/p2p/nat"
 	"github.com/pocethereum/pochain/p2p/netutil"
 )
@@ -49,6 +52,12 @@ const (
 
 	// Maximum amount of time allowed for writing a complete message.
 	frameWriteTimeout = 20 * time.Second
+
+	// Interval at which the DNS discovery node lists are re-resolved.
+	dnsRecheckInterval = 30 * time.Minute
+
+	// Interval at which the external IP of the NAT is checked for changes.
+	natRecheckInterval = 10 * time.Minute
 )
 
 var errServerStopped = errors.New("server stopped")
@@ -93,6 +102,10 @@ type Config struct {
 	// protocol.
 	BootstrapNodesV5 []*discv5.Node `toml:",omitempty"`
 
+	// DNSDiscovery contains the enrtree:// URLs of EIP-1459 node lists, which
+	// are resolved via DNS to seed the dialer with candidates.
+	DNSDiscovery []string `toml:",omitempty"`
+
 	// Static nodes are used as pre-configured connections which are always
 	// maintained and re-connected on disconnects.
 	StaticNodes []*discover.Node
@@ -152,12 +165,21 @@ type Server struct {
 	// the whole protocol stack.
 	newTransport func(net.Conn) transport
 	newPeerHook  func(*Peer)
+	dnsResolver  dnsdisc.Resolver
 
 	lock    sync.Mutex // protects running
 	running bool
 
 	ntab         discoverTable
+	bandb        *discover.BanDB // node database storing the bans if discovery is off
+	rep          *reputation
 	listener     net.Listener
+	recordLock   sync.Mutex           // protects the fields below
+	localRecord  *enr.Record          // signed record of the local node
+	recordIP     net.IP               // IP address announced in the record
+	recordTCP    int                  // TCP port announced in the record
+	recordUDP    int                  // UDP port announced in the record
+	recordExtra  map[string]enr.Entry // protocol entries of the record
 	ourHandshake *protoHandshake
 	lastLookup   time.Time
 	DiscV5       *discv5.Network
@@ -169,6 +191,7 @@ type Server struct {
 	quit          chan struct{}
 	addstatic     chan *discover.Node
 	removestatic  chan *discover.Node
+	dnsnodes      chan []*discover.Node
 	posthandshake chan *conn
 	addpeer       chan *conn
 	delpeer       chan peerDrop
@@ -410,6 +433,7 @@ func (srv *Server) Start() (err error) {
 	srv.posthandshake = make(chan *conn)
 	srv.addstatic = make(chan *discover.Node)
 	srv.removestatic = make(chan *discover.Node)
+	srv.dnsnodes = make(chan []*discover.Node)
 	srv.peerOp = make(chan peerOpFunc)
 	srv.peerOpDone = make(chan struct{})
 
@@ -434,7 +458,6 @@ func (srv *Server) Start() (err error) {
 			if !realaddr.IP.IsLoopback() {
 				go nat.Map(srv.NAT, srv.quit, "udp", realaddr.Port, realaddr.Port, "ethereum discovery")
 			}
-			// TODO: react to external IP changes over time.
 			if ext, err := srv.NAT.ExternalIP(); err == nil {
 				realaddr = &net.UDPAddr{IP: ext, Port: realaddr.Port}
 			}
@@ -455,6 +478,7 @@ func (srv *Server) Start() (err error) {
 			NetRestrict:  srv.NetRestrict,
 			Bootnodes:    srv.BootstrapNodes,
 			Unhandled:    unhandled,
+			LocalRecord:  srv.LocalRecord,
 		}
 		ntab, err := discover.ListenUDP(conn, cfg)
 		if err != nil {
@@ -482,8 +506,24 @@ func (srv *Server) Start() (err error) {
 		srv.DiscV5 = ntab
 	}
 
+	// Bans are persisted in the node database, which is owned by the discovery
+	// table if one is running.
+	var store banStore
+	if tab, ok := srv.ntab.(banStore); ok {
+		store = tab
+	} else if srv.NodeDatabase != "" {
+		db, err := discover.OpenBanDB(srv.NodeDatabase, discover.PubkeyID(&srv.PrivateKey.PublicKey))
+		if err != nil {
+			return err
+		}
+		srv.bandb, store = db, db
+	}
+	srv.rep = newReputation(store)
+
 	dynPeers := srv.maxDialedConns()
 	dialer := newDialState(srv.StaticNodes, srv.BootstrapNodes, srv.ntab, dynPeers, srv.NetRestrict)
+	dialer.nodeFilter = srv.filterNode
+	dialer.bans = srv.rep
 
 	// handshake
 	srv.ourHandshake = &protoHandshake{Version: baseProtocolVersion, Name: srv.Name, ID: discover.PubkeyID(&srv.PrivateKey.PublicKey)}
@@ -499,13 +539,187 @@ func (srv *Server) Start() (err error) {
 	if srv.NoDial && srv.ListenAddr == "" {
 		srv.log.Warn("P2P server will be useless, neither dialing nor listening")
 	}
+	srv.setupLocalRecord(realaddr)
+	if srv.NAT != nil && realaddr != nil {
+		srv.loopWG.Add(1)
+		go srv.natRecordLoop()
+	}
 
+	if len(srv.DNSDiscovery) > 0 && dynPeers > 0 {
+		srv.loopWG.Add(1)
+		go srv.dnsDiscoveryLoop()
+	}
 	srv.loopWG.Add(1)
 	go srv.run(dialer)
 	srv.running = true
 	return nil
 }
 
+// dnsDiscoveryLoop periodically resolves the configured DNS discovery node
+// lists, handing the nodes found to the dialer as dynamic dial candidates.
+func (srv *Server) dnsDiscoveryLoop() {
+	defer srv.loopWG.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-srv.quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	client := dnsdisc.NewClient(dnsdisc.Config{Resolver: srv.dnsResolver, Logger: srv.log})
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+		case <-srv.quit:
+			return
+		}
+		records, err := client.SyncNodes(ctx, srv.DNSDiscovery)
+		if err != nil {
+			srv.log.Warn("Failed to resolve DNS discovery list", "err", err)
+		}
+		nodes := make([]*discover.Node, 0, len(records))
+		for _, r := range records {
+			if n, err := discover.NodeFromRecord(r); err == nil && !n.Incomplete() {
+				nodes = append(nodes, n)
+			}
+		}
+		srv.log.Debug("Resolved DNS discovery nodes", "count", len(nodes))
+		if len(nodes) > 0 {
+			select {
+			case srv.dnsnodes <- nodes:
+			case <-srv.quit:
+				return
+			}
+		}
+		timer.Reset(dnsRecheckInterval)
+	}
+}
+
+// LocalRecord returns the signed node record of the local node, or nil if the
+// server isn't running. The returned record must not be modified.
+func (srv *Server) LocalRecord() *enr.Record {
+	srv.recordLock.Lock()
+	defer srv.recordLock.Unlock()
+	return srv.localRecord
+}
+
+// SetRecordEntry adds or replaces a protocol entry of the local node record,
+// which is re-signed with a higher sequence number.
+func (srv *Server) SetRecordEntry(e enr.Entry) {
+	srv.recordLock.Lock()
+	defer srv.recordLock.Unlock()
+	if srv.recordExtra == nil {
+		srv.recordExtra = make(map[string]enr.Entry)
+	}
+	srv.recordExtra[e.ENRKey()] = e
+	if srv.localRecord != nil {
+		srv.signLocalRecord()
+	}
+}
+
+// setupLocalRecord creates the initial local node record from the discovery
+// and listening addresses.
+func (srv *Server) setupLocalRecord(udpaddr *net.UDPAddr) {
+	srv.recordLock.Lock()
+	defer srv.recordLock.Unlock()
+
+	var ip net.IP
+	if udpaddr != nil {
+		ip, srv.recordUDP = udpaddr.IP, udpaddr.Port
+	}
+	if srv.listener != nil {
+		laddr := srv.listener.Addr().(*net.TCPAddr)
+		if ip == nil {
+			ip = laddr.IP
+		}
+		srv.recordTCP = laddr.Port
+	}
+	if ip != nil && !ip.IsUnspecified() {
+		srv.recordIP = ip
+	}
+	srv.signLocalRecord()
+}
+
+// signLocalRecord replaces the local node record with a newly signed one. The
+// initial sequence number is taken from the clock, so records created after a
+// restart supersede the ones other nodes cached before. The record lock must
+// be held.
+func (srv *Server) signLocalRecord() {
+	var r enr.Record
+	if srv.recordIP != nil {
+		r.Set(enr.IP(srv.recordIP))
+	}
+	if srv.recordTCP != 0 {
+		r.Set(enr.TCP(srv.recordTCP))
+	}
+	if srv.recordUDP != 0 {
+		r.Set(enr.UDP(srv.recordUDP))
+	}
+	for _, e := range srv.recordExtra {
+		r.Set(e)
+	}
+	if srv.localRecord != nil {
+		r.SetSeq(srv.localRecord.Seq() + 1)
+	} else {
+		r.SetSeq(uint64(time.Now().Unix()))
+	}
+	if err := enr.SignV4(&r, srv.PrivateKey); err != nil {
+		srv.log.Error("Failed to sign local node record", "err", err)
+		return
+	}
+	srv.localRecord = &r
+	srv.log.Debug("Updated local node record", "seq", r.Seq())
+}
+
+// natRecordLoop periodically queries the external IP of the NAT, updating the
+// local node record when it changes.
+func (srv *Server) natRecordLoop() {
+	defer srv.loopWG.Done()
+
+	ticker := time.NewTicker(natRecheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-srv.quit:
+			return
+		}
+		ext, err := srv.NAT.ExternalIP()
+		if err != nil {
+			srv.log.Debug("Failed to query external IP", "err", err)
+			continue
+		}
+		srv.recordLock.Lock()
+		if !ext.Equal(srv.recordIP) {
+			srv.log.Info("External IP changed", "old", srv.recordIP, "new", ext)
+			srv.recordIP = ext
+			srv.signLocalRecord()
+		}
+		srv.recordLock.Unlock()
+	}
+}
+
+// filterNode reports whether a dynamic dial candidate is acceptable to all
+// protocols. Nodes without a known record are always accepted.
+func (srv *Server) filterNode(n *discover.Node) bool {
+	r := n.Record()
+	if r == nil {
+		return true
+	}
+	for _, p := range srv.Protocols {
+		if p.NodeFilter != nil && !p.NodeFilter(r) {
+			return false
+		}
+	}
+	return true
+}
+
 func (srv *Server) startListening() error {
 	// Launch the TCP listener.
 	listener, err := net.Listen("tcp", srv.ListenAddr)
@@ -533,6 +747,7 @@ type dialer interface {
 	taskDone(task, time.Time)
 	addStatic(*discover.Node)
 	removeStatic(*discover.Node)
+	addCandidates([]*discover.Node)
 }
 
 func (srv *Server) run(dialstate dialer) {
@@ -605,6 +820,11 @@ running:
 			if p, ok := peers[n.ID]; ok {
 				p.Disconnect(DiscRequested)
 			}
+		case nodes := <-srv.dnsnodes:
+			// This channel is used by the DNS discovery loop to
+			// hand over the nodes of the resolved node lists.
+			srv.log.Debug("Adding DNS discovery candidates", "count", len(nodes))
+			dialstate.addCandidates(nodes)
 		case op := <-srv.peerOp:
 			// This channel is used by Peers and PeerCount.
 			op(peers)
@@ -677,6 +897,9 @@ running:
 	if srv.DiscV5 != nil {
 		srv.DiscV5.Close()
 	}
+	if srv.bandb != nil {
+		srv.bandb.Close()
+	}
 	// Disconnect all peers.
 	for _, p := range peers {
 		p.Disconnect(DiscQuitting)
@@ -711,6 +934,8 @@ func (srv *Server) encHandshakeChecks(peers map[discover.NodeID]*Peer, inboundCo
 		return DiscAlreadyConnected
 	case c.id == srv.Self().ID:
 		return DiscSelf
+	case srv.rep.isBanned(c.id, remoteIP(c.fd)):
+		return DiscUselessPeer
 	default:
 		return nil
 	}
@@ -780,6 +1005,14 @@ func (srv *Server) listenLoop() {
 			}
 		}
 
+		// Reject connections from banned networks.
+		if ip := remoteIP(fd); srv.rep.isBannedIP(ip) {
+			srv.log.Debug("Rejected conn (banned)", "addr", fd.RemoteAddr())
+			fd.Close()
+			slots <- struct{}{}
+			continue
+		}
+
 		fd = newMeteredConn(fd, true)
 		srv.log.Trace("Accepted connection", "addr", fd.RemoteAddr())
 		go func() {
@@ -905,11 +1138,21 @@ func (srv *Server) runPeer(p *Peer) {
 	srv.delpeer <- peerDrop{p, err, remoteRequested}
 }
 
+// remoteIP returns the IP address of the remote end of a connection, nil if
+// it isn't a TCP connection.
+func remoteIP(fd net.Conn) net.IP {
+	if tcp, ok := fd.RemoteAddr().(*net.TCPAddr); ok {
+		return tcp.IP
+	}
+	return nil
+}
+
 // NodeInfo represents a short summary of the information known about the host.
 type NodeInfo struct {
 	ID    string `json:"id"`    // Unique node identifier (also the encryption key)
 	Name  string `json:"name"`  // Name of the node, including client type, version, OS, custom data
 	Enode string `json:"enode"` // Enode URL for adding this peer from remote peers
+	ENR   string `json:"enr"`   // Signed node record, empty if not available
 	IP    string `json:"ip"`    // IP address of the node
 	Ports struct {
 		Discovery int `json:"discovery"` // UDP listening port for discovery protocol
@@ -934,6 +1177,9 @@ func (srv *Server) NodeInfo() *NodeInfo {
 	}
 	info.Ports.Discovery = int(node.UDP)
 	info.Ports.Listener = int(node.TCP)
+	if r := srv.LocalRecord(); r != nil {
+		info.ENR = dnsdisc.RecordString(r)
+	}
 
 	// Gather all the running protocol infos (only once per protocol type)
 	for _, proto := range srv.Protocols {
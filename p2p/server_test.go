@@ -17,6 +17,7 @@
 package p2p
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"errors"
 	"math/rand"
@@ -29,6 +30,8 @@ import (
 	"github.com/pocethereum/pochain/crypto/sha3"
 	"github.com/pocethereum/pochain/log"
 	"github.com/pocethereum/pochain/p2p/discover"
+	"github.com/pocethereum/pochain/p2p/dnsdisc"
+	"github.com/pocethereum/pochain/p2p/enr"
 )
 
 func init() {
@@ -179,8 +182,111 @@ func TestServerDial(t *testing.T) {
 	}
 }
 
+// mapResolver is an in-process DNS resolver serving TXT records from a map.
+type mapResolver map[string]string
+
+func (mr mapResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if record, ok := mr[name]; ok {
+		return []string{record}, nil
+	}
+	return nil, errors.New("no such name")
+}
+
+// recordingDialer reports the nodes dialed instead of connecting to them.
+type recordingDialer chan *discover.Node
+
+func (d recordingDialer) Dial(dest *discover.Node) (net.Conn, error) {
+	d <- dest
+	return nil, errors.New("not connecting")
+}
+
+// This test checks that the nodes of the DNS discovery lists are dialed.
+func TestServerDNSDiscovery(t *testing.T) {
+	remkey := newkey()
+	var record enr.Record
+	record.Set(enr.IP(net.IP{127, 0, 0, 1}))
+	record.Set(enr.TCP(30303))
+	if err := enr.SignV4(&record, remkey); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := dnsdisc.MakeTree(1, []*enr.Record{&record}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, err := tree.Sign(newkey(), "nodes.example.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dialed := make(recordingDialer, 1)
+	srv := &Server{
+		Config: Config{
+			Name:         "test",
+			MaxPeers:     10,
+			ListenAddr:   "127.0.0.1:0",
+			PrivateKey:   newkey(),
+			DNSDiscovery: []string{url},
+			Dialer:       dialed,
+		},
+		dnsResolver: mapResolver(tree.ToTXT("nodes.example.org")),
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start server: %v", err)
+	}
+	defer srv.Stop()
+
+	remid := discover.PubkeyID(&remkey.PublicKey)
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case n := <-dialed:
+			if n.ID == remid && n.TCP == 30303 {
+				return
+			}
+		case <-timeout:
+			t.Fatal("server did not dial the DNS discovery node")
+		}
+	}
+}
+
 // This test checks that tasks generated by dialstate are
 // actually executed and taskdone is called for them.
+func TestServerLocalRecord(t *testing.T) {
+	srv := startTestServer(t, randomID(), func(*Peer) {})
+	defer srv.Stop()
+
+	r := srv.LocalRecord()
+	if r == nil {
+		t.Fatal("no local record after start")
+	}
+	n, err := discover.NodeFromRecord(r)
+	if err != nil {
+		t.Fatal("invalid local record:", err)
+	}
+	if n.ID != discover.PubkeyID(&srv.PrivateKey.PublicKey) {
+		t.Errorf("record has wrong node ID %v", n.ID)
+	}
+	if int(n.TCP) != srv.listener.Addr().(*net.TCPAddr).Port {
+		t.Errorf("record has wrong TCP port %d", n.TCP)
+	}
+
+	// Setting an entry re-signs the record with a higher sequence number.
+	srv.SetRecordEntry(enr.UDP(30303))
+	updated := srv.LocalRecord()
+	if updated.Seq() <= r.Seq() {
+		t.Errorf("seq not increased by update: got %d, previous %d", updated.Seq(), r.Seq())
+	}
+	var udp enr.UDP
+	if err := updated.Load(&udp); err != nil || udp != 30303 {
+		t.Errorf("entry not set in record: %v", err)
+	}
+	if _, err := discover.NodeFromRecord(updated); err != nil {
+		t.Error("invalid updated record:", err)
+	}
+	if info := srv.NodeInfo(); info.ENR != dnsdisc.RecordString(updated) {
+		t.Errorf("wrong ENR in node info: %s", info.ENR)
+	}
+}
+
 func TestServerTaskScheduling(t *testing.T) {
 	var (
 		done           = make(chan *testTask)
@@ -309,6 +415,8 @@ func (tg taskgen) addStatic(*discover.Node) {
 }
 func (tg taskgen) removeStatic(*discover.Node) {
 }
+func (tg taskgen) addCandidates([]*discover.Node) {
+}
 
 type testTask struct {
 	index  int
@@ -32,11 +32,13 @@ import (
 )
 
 // SimAdapter is a NodeAdapter which creates in-memory simulation nodes and
-// connects them using in-memory net.Pipe connections
+// connects them using in-memory net.Pipe connections, optionally impaired by
+// latency and packet loss (see SetLinkConfig)
 type SimAdapter struct {
 	mtx      sync.RWMutex
 	nodes    map[discover.NodeID]*SimNode
 	services map[string]ServiceFunc
+	link     LinkConfig
 }
 
 // NewSimAdapter creates a SimAdapter which is capable of running in-memory
@@ -101,8 +103,30 @@ func (s *SimAdapter) NewNode(config *NodeConfig) (Node, error) {
 	return simNode, nil
 }
 
+// SetLinkConfig sets the conditions of the connections dialed from now on,
+// existing connections are not affected
+func (s *SimAdapter) SetLinkConfig(config LinkConfig) error {
+	if config.PacketLoss < 0 || config.PacketLoss >= 1 {
+		return errInvalidPacketLoss
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.link = config
+	return nil
+}
+
+// LinkConfig returns the conditions of newly dialed connections
+func (s *SimAdapter) LinkConfig() LinkConfig {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return s.link
+}
+
 // Dial implements the p2p.NodeDialer interface by connecting to the node using
-// an in-memory net.Pipe connection
+// an in-memory net.Pipe connection, with the link conditions applied to the
+// data sent in both directions
 func (s *SimAdapter) Dial(dest *discover.Node) (conn net.Conn, err error) {
 	node, ok := s.GetNode(dest.ID)
 	if !ok {
@@ -112,9 +136,10 @@ func (s *SimAdapter) Dial(dest *discover.Node) (conn net.Conn, err error) {
 	if srv == nil {
 		return nil, fmt.Errorf("node not running: %s", dest.ID)
 	}
+	link := s.LinkConfig()
 	pipe1, pipe2 := net.Pipe()
-	go srv.SetupConn(pipe1, 0, nil)
-	return pipe2, nil
+	go srv.SetupConn(newLinkConn(pipe1, link), 0, nil)
+	return newLinkConn(pipe2, link), nil
 }
 
 // DialRPC implements the RPCDialer interface by creating an in-memory RPC
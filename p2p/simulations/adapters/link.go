@@ -0,0 +1,161 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package adapters
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+// minRetransmitTimeout is the shortest time a lost write waits before being
+// retransmitted, mirroring the minimum RTO of common TCP stacks.
+const minRetransmitTimeout = 200 * time.Millisecond
+
+var errInvalidPacketLoss = errors.New("packet loss must be in [0, 1)")
+
+// LinkConfig describes the conditions of the simulated connections between
+// nodes. The zero value is a perfect link.
+type LinkConfig struct {
+	// Latency is the one-way delay of the data written to a connection
+	Latency time.Duration
+
+	// PacketLoss is the probability (0 <= p < 1) of a write getting lost. As
+	// the connections are reliable streams, lost writes are retransmitted
+	// after a timeout, delaying them and everything written afterwards.
+	PacketLoss float64
+}
+
+// perfect returns whether the link doesn't impair the connections at all.
+func (c LinkConfig) perfect() bool {
+	return c.Latency <= 0 && c.PacketLoss <= 0
+}
+
+// delay returns how long a write takes to arrive, including the timeouts of
+// the retransmissions of lost attempts.
+func (c LinkConfig) delay() time.Duration {
+	delay := c.Latency
+	if c.PacketLoss <= 0 {
+		return delay
+	}
+	rto := 2 * c.Latency
+	if rto < minRetransmitTimeout {
+		rto = minRetransmitTimeout
+	}
+	for rand.Float64() < c.PacketLoss {
+		delay += rto
+	}
+	return delay
+}
+
+// linkWrite is a write in transit on a conditioned connection.
+type linkWrite struct {
+	data    []byte
+	arrival time.Time
+}
+
+// linkConn is a net.Conn applying the conditions of a link to the data
+// written to it. Writes return immediately and are delivered in order once
+// their delay elapsed, so a delayed write holds up everything behind it.
+type linkConn struct {
+	net.Conn
+	config LinkConfig
+
+	queue   []*linkWrite
+	last    time.Time // Arrival time of the latest write, keeping them ordered
+	err     error     // Error of a failed delivery, returned by later writes
+	closed  bool
+	lock    sync.Mutex
+	pending *sync.Cond
+}
+
+// newLinkConn wraps the given connection into one applying the conditions of
+// the link to everything written, or returns it as is for a perfect link.
+func newLinkConn(conn net.Conn, config LinkConfig) net.Conn {
+	if config.perfect() {
+		return conn
+	}
+	c := &linkConn{
+		Conn:   conn,
+		config: config,
+	}
+	c.pending = sync.NewCond(&c.lock)
+	go c.deliver()
+	return c
+}
+
+// Write implements net.Conn, queueing the data for delayed delivery.
+func (c *linkConn) Write(b []byte) (int, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if c.err != nil {
+		return 0, c.err
+	}
+	arrival := time.Now().Add(c.config.delay())
+	if arrival.Before(c.last) {
+		arrival = c.last
+	}
+	c.last = arrival
+	c.queue = append(c.queue, &linkWrite{data: append([]byte{}, b...), arrival: arrival})
+	c.pending.Signal()
+
+	return len(b), nil
+}
+
+// Close implements net.Conn, dropping the data still in transit.
+func (c *linkConn) Close() error {
+	c.lock.Lock()
+	c.closed = true
+	c.queue = nil
+	c.pending.Signal()
+	c.lock.Unlock()
+
+	return c.Conn.Close()
+}
+
+// deliver writes the queued data to the underlying connection once it has
+// arrived, until the connection is closed.
+func (c *linkConn) deliver() {
+	for {
+		c.lock.Lock()
+		for len(c.queue) == 0 && !c.closed {
+			c.pending.Wait()
+		}
+		if c.closed {
+			c.lock.Unlock()
+			return
+		}
+		write := c.queue[0]
+		c.queue = c.queue[1:]
+		c.lock.Unlock()
+
+		time.Sleep(time.Until(write.arrival))
+		if _, err := c.Conn.Write(write.data); err != nil {
+			c.lock.Lock()
+			c.err = err
+			c.lock.Unlock()
+			return
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package adapters
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// Tests that writes on a conditioned link return immediately, but arrive in
+// order after the link latency.
+func TestLinkLatency(t *testing.T) {
+	latency := 100 * time.Millisecond
+
+	pipe1, pipe2 := net.Pipe()
+	conn := newLinkConn(pipe1, LinkConfig{Latency: latency})
+	defer conn.Close()
+
+	start := time.Now()
+	for _, data := range []string{"foo", "bar", "baz"} {
+		if _, err := conn.Write([]byte(data)); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed >= latency {
+		t.Fatalf("writes blocked: took %v", elapsed)
+	}
+	buf := make([]byte, 9)
+	if _, err := io.ReadFull(pipe2, buf); err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < latency {
+		t.Errorf("data arrived too early: took %v, want >= %v", elapsed, latency)
+	}
+	if !bytes.Equal(buf, []byte("foobarbaz")) {
+		t.Errorf("data mismatch: have %q, want %q", buf, "foobarbaz")
+	}
+}
+
+// Tests that lost writes are delayed by at least one retransmission timeout.
+func TestLinkPacketLoss(t *testing.T) {
+	config := LinkConfig{PacketLoss: 0.5}
+
+	var lost int
+	for i := 0; i < 1000; i++ {
+		switch delay := config.delay(); {
+		case delay == 0:
+		case delay >= minRetransmitTimeout && delay%minRetransmitTimeout == 0:
+			lost++
+		default:
+			t.Fatalf("unexpected delay %v", delay)
+		}
+	}
+	if lost < 400 || lost > 600 {
+		t.Errorf("lost writes out of range: have %d, want ~500", lost)
+	}
+}
+
+// Tests that links losing every packet are rejected.
+func TestSetLinkConfig(t *testing.T) {
+	adapter := NewSimAdapter(nil)
+	for _, loss := range []float64{-0.1, 1, 2} {
+		if err := adapter.SetLinkConfig(LinkConfig{PacketLoss: loss}); err != errInvalidPacketLoss {
+			t.Errorf("loss %v: error mismatch: have %v, want %v", loss, err, errInvalidPacketLoss)
+		}
+	}
+	config := LinkConfig{Latency: time.Second, PacketLoss: 0.2}
+	if err := adapter.SetLinkConfig(config); err != nil {
+		t.Fatalf("failed to set link config: %v", err)
+	}
+	if have := adapter.LinkConfig(); have != config {
+		t.Errorf("link config mismatch: have %+v, want %+v", have, config)
+	}
+}
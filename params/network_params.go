@@ -23,4 +23,9 @@ const (
 	// BloomBitsBlocks is the number of blocks a single bloom bit section vector
 	// contains.
 	BloomBitsBlocks uint64 = 4096
+
+	// ImmutabilityThreshold is the default number of recent blocks kept in the
+	// active database. Canonical blocks older than this are considered final and
+	// moved into the append-only ancient store.
+	ImmutabilityThreshold uint64 = 90000
 )
@@ -0,0 +1,279 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/pocethereum/pochain/log"
+)
+
+const (
+	// apiKeyHeader is the HTTP header clients pass their API key in.
+	apiKeyHeader = "X-API-Key"
+
+	// apiKeyQuery is the URL query parameter clients may pass their API key in,
+	// for websocket clients which cannot set custom headers.
+	apiKeyQuery = "apikey"
+
+	// maxRateBuckets is the number of client rate buckets kept before the full
+	// (i.e. idle) ones are dropped.
+	maxRateBuckets = 4096
+)
+
+// DefaultAuditMethods are the methods whose calls are logged if an access
+// policy doesn't specify its own list.
+var DefaultAuditMethods = []string{"minedev_restart", "personal_*"}
+
+// AccessKey is an API key granting the clients presenting it their own method
+// permissions and rate limit instead of the anonymous ones. Rate limits of keyed
+// clients are tracked per key instead of per IP address.
+type AccessKey struct {
+	Name string // Name used to identify the key in the logs
+	Key  string // Secret passed by the clients
+
+	Allow     []string `toml:",omitempty"`
+	Deny      []string `toml:",omitempty"`
+	RateLimit float64  `toml:",omitempty"`
+	RateBurst int      `toml:",omitempty"`
+}
+
+// AccessPolicy is the method-level access control and rate limiting applied by
+// a server to its clients.
+//
+// Methods are matched against path.Match patterns of their full name such as
+// "eth_getBalance", "personal_*" or "*". Subscriptions are matched by their
+// name within the namespace, e.g. "eth_newHeads". Deny takes precedence over
+// Allow, and an empty Allow list allows every method not denied. The Allow,
+// Deny and rate limit settings of the policy itself apply to clients without
+// an API key.
+type AccessPolicy struct {
+	Allow     []string `toml:",omitempty"`
+	Deny      []string `toml:",omitempty"`
+	RateLimit float64  `toml:",omitempty"` // Requests per second, zero disables rate limiting
+	RateBurst int      `toml:",omitempty"` // Requests allowed in a burst, defaults to the rate limit
+
+	Keys  []AccessKey `toml:",omitempty"`
+	Audit []string    `toml:",omitempty"` // Method patterns whose calls are logged, nil means DefaultAuditMethods
+}
+
+// accessRule is the set of permissions and the rate limit applied to a client.
+type accessRule struct {
+	allow []string
+	deny  []string
+	rate  float64
+	burst int
+}
+
+// validate checks that all method patterns of the policy are well formed.
+func (p *AccessPolicy) validate() error {
+	patterns := append(append([]string{}, p.Allow...), p.Deny...)
+	patterns = append(patterns, p.Audit...)
+
+	keys := make(map[string]bool)
+	for _, key := range p.Keys {
+		if key.Key == "" {
+			return fmt.Errorf("empty API key %q", key.Name)
+		}
+		if keys[key.Key] {
+			return fmt.Errorf("duplicate API key %q", key.Name)
+		}
+		keys[key.Key] = true
+		patterns = append(append(patterns, key.Allow...), key.Deny...)
+	}
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid method pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchMethod reports whether the method matches any of the patterns.
+func matchMethod(patterns []string, method string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, method); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed reports whether the rule permits calling the method.
+func (r *accessRule) allowed(method string) bool {
+	if matchMethod(r.deny, method) {
+		return false
+	}
+	return len(r.allow) == 0 || matchMethod(r.allow, method)
+}
+
+// tokenBucket tracks the requests available to a single client.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// accessControl enforces an access policy on the requests of a server.
+type accessControl struct {
+	anonymous *accessRule
+	keys      map[string]*accessRule
+	names     map[string]string
+	audit     []string
+
+	lock    sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+func newAccessControl(policy *AccessPolicy) (*accessControl, error) {
+	if err := policy.validate(); err != nil {
+		return nil, err
+	}
+	ac := &accessControl{
+		anonymous: &accessRule{policy.Allow, policy.Deny, policy.RateLimit, policy.RateBurst},
+		keys:      make(map[string]*accessRule),
+		names:     make(map[string]string),
+		audit:     policy.Audit,
+		buckets:   make(map[string]*tokenBucket),
+	}
+	if ac.audit == nil {
+		ac.audit = DefaultAuditMethods
+	}
+	for _, key := range policy.Keys {
+		ac.keys[key.Key] = &accessRule{key.Allow, key.Deny, key.RateLimit, key.RateBurst}
+		ac.names[key.Key] = key.Name
+	}
+	return ac, nil
+}
+
+// check verifies that the client of the given context may call the method
+// right now. Unsubscribe requests pass an empty method and are only subject to
+// rate limiting.
+func (ac *accessControl) check(ctx context.Context, method string) Error {
+	var (
+		remote, _ = ctx.Value("remote").(string)
+		apikey, _ = ctx.Value("apikey").(string)
+
+		rule   = ac.anonymous
+		client = remoteHost(remote)
+		name   string
+	)
+	if apikey != "" {
+		keyed, ok := ac.keys[apikey]
+		if !ok {
+			log.Warn("RPC request with unknown API key", "remote", remote, "method", method)
+			return &accessDeniedError{"unknown API key"}
+		}
+		rule, name = keyed, ac.names[apikey]
+		client = "key:" + name
+	}
+	if method != "" {
+		allowed := rule.allowed(method)
+		if matchMethod(ac.audit, method) {
+			log.Info("Audited RPC call", "method", method, "remote", remote, "key", name, "allowed", allowed)
+		}
+		if !allowed {
+			return &accessDeniedError{fmt.Sprintf("method %s not allowed", method)}
+		}
+	}
+	if rule.rate > 0 && !ac.take(client, rule.rate, rule.burst) {
+		return &rateLimitError{}
+	}
+	return nil
+}
+
+// take consumes a token from the bucket of the client, reporting whether one
+// was available.
+func (ac *accessControl) take(client string, rate float64, burst int) bool {
+	capacity := float64(burst)
+	if capacity < rate {
+		capacity = rate
+	}
+	if capacity < 1 {
+		capacity = 1
+	}
+	ac.lock.Lock()
+	defer ac.lock.Unlock()
+
+	now := time.Now()
+	bucket := ac.buckets[client]
+	if bucket == nil {
+		if len(ac.buckets) >= maxRateBuckets {
+			ac.prune(now, rate, capacity)
+		}
+		bucket = &tokenBucket{tokens: capacity, last: now}
+		ac.buckets[client] = bucket
+	}
+	bucket.tokens += now.Sub(bucket.last).Seconds() * rate
+	if bucket.tokens > capacity {
+		bucket.tokens = capacity
+	}
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// prune drops the buckets which refilled since their last use, as they are
+// indistinguishable from new ones.
+func (ac *accessControl) prune(now time.Time, rate float64, capacity float64) {
+	for client, bucket := range ac.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*rate >= capacity {
+			delete(ac.buckets, client)
+		}
+	}
+}
+
+// remoteHost strips the port from a remote address, so all connections of a
+// client share the same rate limit.
+func remoteHost(remote string) string {
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		return host
+	}
+	return remote
+}
+
+// requestAPIKey returns the API key passed in an HTTP request, if any.
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		return key
+	}
+	return r.URL.Query().Get(apiKeyQuery)
+}
+
+// SetAccessPolicy makes the server enforce the given access policy on all of
+// its requests. It must be called before the server starts serving.
+func (s *Server) SetAccessPolicy(policy *AccessPolicy) error {
+	if policy == nil {
+		s.access = nil
+		return nil
+	}
+	ac, err := newAccessControl(policy)
+	if err != nil {
+		return err
+	}
+	s.access = ac
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// accessCall posts a single request for the method to the server, returning
+// the error code of the response or zero on success.
+func accessCall(t *testing.T, srv *Server, method, apikey string) int {
+	body := `{"jsonrpc":"2.0","id":1,"method":"` + method + `","params":[]}`
+	req := httptest.NewRequest(http.MethodPost, "http://url.com", strings.NewReader(body))
+	req.Header.Set("content-type", contentType)
+	req.RemoteAddr = "10.0.0.1:30303"
+	if apikey != "" {
+		req.Header.Set(apiKeyHeader, apikey)
+	}
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+
+	var resp jsonErrResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+	}
+	return resp.Error.Code
+}
+
+func newAccessTestServer(t *testing.T, policy *AccessPolicy) *Server {
+	srv := NewServer()
+	if err := srv.RegisterName("test", new(Service)); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	if err := srv.SetAccessPolicy(policy); err != nil {
+		t.Fatalf("failed to set access policy: %v", err)
+	}
+	return srv
+}
+
+func TestAccessPolicyMethods(t *testing.T) {
+	srv := newAccessTestServer(t, &AccessPolicy{
+		Allow: []string{"test_*"},
+		Deny:  []string{"test_rets"},
+		Keys: []AccessKey{
+			{Name: "admin", Key: "secret"},
+		},
+	})
+	defer srv.Stop()
+
+	tests := []struct {
+		method string
+		apikey string
+		code   int
+	}{
+		{"test_noArgsRets", "", 0},
+		{"test_rets", "", -32004},
+		{"rpc_modules", "", -32004},
+		{"test_rets", "secret", 0},
+		{"rpc_modules", "secret", 0},
+		{"test_noArgsRets", "wrong", -32004},
+	}
+	for i, tt := range tests {
+		if code := accessCall(t, srv, tt.method, tt.apikey); code != tt.code {
+			t.Errorf("test %d: %s with key %q: error code mismatch: have %d, want %d", i, tt.method, tt.apikey, code, tt.code)
+		}
+	}
+}
+
+func TestAccessPolicyRateLimit(t *testing.T) {
+	srv := newAccessTestServer(t, &AccessPolicy{
+		RateLimit: 0.001,
+		RateBurst: 3,
+		Keys: []AccessKey{
+			{Name: "miner", Key: "secret", RateLimit: 0.001, RateBurst: 1},
+		},
+	})
+	defer srv.Stop()
+
+	for i := 0; i < 3; i++ {
+		if code := accessCall(t, srv, "test_noArgsRets", ""); code != 0 {
+			t.Fatalf("request %d within burst failed with code %d", i, code)
+		}
+	}
+	if code := accessCall(t, srv, "test_noArgsRets", ""); code != -32005 {
+		t.Fatalf("request beyond burst: error code mismatch: have %d, want %d", code, -32005)
+	}
+	// Keyed clients are limited separately from their IP address
+	if code := accessCall(t, srv, "test_noArgsRets", "secret"); code != 0 {
+		t.Fatalf("keyed request failed with code %d", code)
+	}
+	if code := accessCall(t, srv, "test_noArgsRets", "secret"); code != -32005 {
+		t.Fatalf("keyed request beyond burst: error code mismatch: have %d, want %d", code, -32005)
+	}
+}
+
+func TestAccessPolicyValidation(t *testing.T) {
+	policies := []*AccessPolicy{
+		{Allow: []string{"eth_["}},
+		{Keys: []AccessKey{{Name: "empty"}}},
+		{Keys: []AccessKey{{Name: "a", Key: "x"}, {Name: "b", Key: "x"}}},
+	}
+	for i, policy := range policies {
+		if err := NewServer().SetAccessPolicy(policy); err == nil {
+			t.Errorf("policy %d: invalid policy accepted", i)
+		}
+	}
+}
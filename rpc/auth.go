@@ -0,0 +1,109 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// jwtIssuedAtWindow is the maximum allowed deviation of the issued-at claim of
+// a token from the local clock.
+const jwtIssuedAtWindow = 60 * time.Second
+
+var (
+	errMissingToken = errors.New("missing token")
+	errMissingIat   = errors.New("missing issued-at")
+	errStaleToken   = errors.New("stale token")
+	errFutureToken  = errors.New("future token")
+	errExpiredToken = errors.New("token is expired")
+)
+
+// jwtHandler is a handler which only lets through requests carrying an HS256
+// signed, fresh JSON Web Token in their Authorization header.
+type jwtHandler struct {
+	keyFunc func(token *jwt.Token) (interface{}, error)
+	next    http.Handler
+}
+
+// newJWTHandler wraps the given handler with a JWT authentication layer using
+// the given shared secret.
+func newJWTHandler(secret []byte, next http.Handler) http.Handler {
+	return &jwtHandler{
+		keyFunc: func(token *jwt.Token) (interface{}, error) {
+			return secret, nil
+		},
+		next: next,
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *jwtHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h.verify(r); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+// verify checks the token carried by the request. Besides the signature, the
+// token has to be issued within jwtIssuedAtWindow of now, so intercepted tokens
+// can't be replayed for long.
+func (h *jwtHandler) verify(r *http.Request) error {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return errMissingToken
+	}
+	var (
+		claims jwt.StandardClaims
+		parser = &jwt.Parser{ValidMethods: []string{"HS256"}, SkipClaimsValidation: true}
+	)
+	if _, err := parser.ParseWithClaims(strings.TrimPrefix(auth, "Bearer "), &claims, h.keyFunc); err != nil {
+		return err
+	}
+	now := time.Now()
+	switch {
+	case claims.IssuedAt == 0:
+		return errMissingIat
+	case time.Unix(claims.IssuedAt, 0).Add(jwtIssuedAtWindow).Before(now):
+		return errStaleToken
+	case time.Unix(claims.IssuedAt, 0).Add(-jwtIssuedAtWindow).After(now):
+		return errFutureToken
+	case claims.ExpiresAt != 0 && time.Unix(claims.ExpiresAt, 0).Before(now):
+		return errExpiredToken
+	}
+	return nil
+}
+
+// AuthHandler returns a handler that serves JSON-RPC over both HTTP and
+// websocket connections to clients authenticated with a JWT signed with the
+// given secret.
+func (srv *Server) AuthHandler(vhosts []string, secret []byte) http.Handler {
+	ws := srv.WebsocketHandler([]string{"*"})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			ws.ServeHTTP(w, r)
+			return
+		}
+		srv.ServeHTTP(w, r)
+	})
+	return newVHostHandler(vhosts, newJWTHandler(secret, handler))
+}
@@ -0,0 +1,128 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"golang.org/x/net/websocket"
+)
+
+func TestJWTAuthentication(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	srv := NewServer()
+	if err := srv.RegisterName("test", new(Service)); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	defer srv.Stop()
+	handler := srv.AuthHandler([]string{"localhost"}, secret)
+
+	sign := func(method jwt.SigningMethod, key []byte, claims jwt.Claims) string {
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatalf("failed to sign token: %v", err)
+		}
+		return "Bearer " + token
+	}
+	now := time.Now()
+
+	tests := []struct {
+		auth string
+		code int
+	}{
+		{sign(jwt.SigningMethodHS256, secret, jwt.StandardClaims{IssuedAt: now.Unix()}), http.StatusOK},
+		{sign(jwt.SigningMethodHS256, secret, jwt.StandardClaims{IssuedAt: now.Add(-30 * time.Second).Unix()}), http.StatusOK},
+		{"", http.StatusUnauthorized},
+		{sign(jwt.SigningMethodHS256, secret, jwt.StandardClaims{}), http.StatusUnauthorized},
+		{sign(jwt.SigningMethodHS256, secret, jwt.StandardClaims{IssuedAt: now.Add(-2 * jwtIssuedAtWindow).Unix()}), http.StatusUnauthorized},
+		{sign(jwt.SigningMethodHS256, secret, jwt.StandardClaims{IssuedAt: now.Add(2 * jwtIssuedAtWindow).Unix()}), http.StatusUnauthorized},
+		{sign(jwt.SigningMethodHS256, secret, jwt.StandardClaims{IssuedAt: now.Unix(), ExpiresAt: now.Add(-time.Second).Unix()}), http.StatusUnauthorized},
+		{sign(jwt.SigningMethodHS256, []byte("wrong secret"), jwt.StandardClaims{IssuedAt: now.Unix()}), http.StatusUnauthorized},
+		{sign(jwt.SigningMethodHS512, secret, jwt.StandardClaims{IssuedAt: now.Unix()}), http.StatusUnauthorized},
+	}
+	for i, tt := range tests {
+		body := `{"jsonrpc":"2.0","id":1,"method":"test_noArgsRets","params":[]}`
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:8551", strings.NewReader(body))
+		req.Header.Set("content-type", contentType)
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.code {
+			t.Errorf("test %d: status code mismatch: have %d, want %d (%s)", i, rec.Code, tt.code, rec.Body.String())
+		}
+	}
+}
+
+// Tests that websocket connections to the authenticated endpoint outlive the
+// timeouts of its HTTP server.
+func TestJWTWebsocketTimeouts(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	srv := NewServer()
+	if err := srv.RegisterName("test", new(Service)); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	defer srv.Stop()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	httpsrv := newHTTPServer(srv.AuthHandler([]string{"*"}, secret))
+	httpsrv.ReadTimeout, httpsrv.WriteTimeout = 100*time.Millisecond, 100*time.Millisecond
+	go httpsrv.Serve(listener)
+	defer httpsrv.Close()
+
+	config, err := websocket.NewConfig("ws://"+listener.Addr().String(), "http://localhost")
+	if err != nil {
+		t.Fatalf("failed to create websocket config: %v", err)
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{IssuedAt: time.Now().Unix()}).SignedString(secret)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	config.Header.Set("Authorization", "Bearer "+token)
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		t.Fatalf("failed to dial websocket: %v", err)
+	}
+	defer conn.Close()
+
+	for i := 0; i < 2; i++ {
+		time.Sleep(200 * time.Millisecond)
+		if err := websocket.Message.Send(conn, `{"jsonrpc":"2.0","id":1,"method":"test_noArgsRets","params":[]}`); err != nil {
+			t.Fatalf("request %d: failed to send: %v", i, err)
+		}
+		var response string
+		if err := websocket.Message.Receive(conn, &response); err != nil {
+			t.Fatalf("request %d: failed to receive: %v", i, err)
+		}
+		if !strings.Contains(response, `"result"`) {
+			t.Fatalf("request %d: unexpected response: %s", i, response)
+		}
+	}
+}
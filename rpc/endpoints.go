@@ -23,7 +23,8 @@ import (
 )
 
 // StartHTTPEndpoint starts the HTTP RPC endpoint, configured with cors/vhosts/modules
-func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string) (net.Listener, *Server, error) {
+// and an optional access policy.
+func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string, policy *AccessPolicy) (net.Listener, *Server, error) {
 	// Generate the whitelist based on the allowed modules
 	whitelist := make(map[string]bool)
 	for _, module := range modules {
@@ -39,6 +40,9 @@ func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []str
 			log.Debug("HTTP registered", "namespace", api.Namespace)
 		}
 	}
+	if err := handler.SetAccessPolicy(policy); err != nil {
+		return nil, nil, err
+	}
 	// All APIs registered, start the HTTP listener
 	var (
 		listener net.Listener
@@ -51,8 +55,8 @@ func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []str
 	return listener, handler, err
 }
 
-// StartWSEndpoint starts a websocket endpoint
-func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool) (net.Listener, *Server, error) {
+// StartWSEndpoint starts a websocket endpoint with an optional access policy.
+func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool, policy *AccessPolicy) (net.Listener, *Server, error) {
 
 	// Generate the whitelist based on the allowed modules
 	whitelist := make(map[string]bool)
@@ -69,6 +73,9 @@ func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []
 			log.Debug("WebSocket registered", "service", api.Service, "namespace", api.Namespace)
 		}
 	}
+	if err := handler.SetAccessPolicy(policy); err != nil {
+		return nil, nil, err
+	}
 	// All APIs registered, start the HTTP listener
 	var (
 		listener net.Listener
@@ -82,6 +89,40 @@ func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []
 
 }
 
+// StartAuthEndpoint starts an endpoint serving both HTTP and websocket RPC
+// requests to clients authenticated by a JWT signed with the given secret. If
+// no modules are given, all APIs are exposed.
+func StartAuthEndpoint(endpoint string, apis []API, modules []string, vhosts []string, secret []byte, policy *AccessPolicy) (net.Listener, *Server, error) {
+	// Generate the whitelist based on the allowed modules
+	whitelist := make(map[string]bool)
+	for _, module := range modules {
+		whitelist[module] = true
+	}
+	// Register all the APIs exposed by the services
+	handler := NewServer()
+	for _, api := range apis {
+		if whitelist[api.Namespace] || len(whitelist) == 0 {
+			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
+				return nil, nil, err
+			}
+			log.Debug("Authenticated RPC registered", "namespace", api.Namespace)
+		}
+	}
+	if err := handler.SetAccessPolicy(policy); err != nil {
+		return nil, nil, err
+	}
+	// All APIs registered, start the authenticated listener
+	var (
+		listener net.Listener
+		err      error
+	)
+	if listener, err = net.Listen("tcp", endpoint); err != nil {
+		return nil, nil, err
+	}
+	go newHTTPServer(handler.AuthHandler(vhosts, secret)).Serve(listener)
+	return listener, handler, err
+}
+
 // StartIPCEndpoint starts an IPC endpoint.
 func StartIPCEndpoint(ipcEndpoint string, apis []API) (net.Listener, *Server, error) {
 	// Register all the APIs exposed by the services.
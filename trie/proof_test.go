@@ -20,6 +20,7 @@ import (
 	"bytes"
 	crand "crypto/rand"
 	mrand "math/rand"
+	"sort"
 	"testing"
 	"time"
 
@@ -143,6 +144,156 @@ func TestMissingKeyProof(t *testing.T) {
 	}
 }
 
+// sortedEntries returns the leaves of a random trie in key order.
+func sortedEntries(n int) (*Trie, []*kv) {
+	trie, vals := randomTrie(n)
+	var entries []*kv
+	for _, kv := range vals {
+		entries = append(entries, kv)
+	}
+	sort.Slice(entries, func(i, j int) bool { return bytes.Compare(entries[i].k, entries[j].k) < 0 })
+	return trie, entries
+}
+
+// rangeProof proves the edge keys of a range into a single proof database.
+func rangeProof(trie *Trie, first, last []byte) *ethdb.MemDatabase {
+	proof := ethdb.NewMemDatabase()
+	trie.Prove(first, 0, proof)
+	trie.Prove(last, 0, proof)
+	return proof
+}
+
+// rangeData splits the given entries into keys and values.
+func rangeData(entries []*kv) ([][]byte, [][]byte) {
+	var keys, vals [][]byte
+	for _, kv := range entries {
+		keys = append(keys, kv.k)
+		vals = append(vals, kv.v)
+	}
+	return keys, vals
+}
+
+// Tests that random ranges of a trie can be proven by the proofs of their
+// first and last leaves.
+func TestRangeProof(t *testing.T) {
+	trie, entries := sortedEntries(4096)
+	for i := 0; i < 500; i++ {
+		start := mrand.Intn(len(entries))
+		end := mrand.Intn(len(entries)-start) + start + 1
+
+		keys, vals := rangeData(entries[start:end])
+		proof := rangeProof(trie, keys[0], keys[len(keys)-1])
+		more, err := VerifyRangeProof(trie.Hash(), keys[0], keys[len(keys)-1], keys, vals, proof)
+		if err != nil {
+			t.Fatalf("case %d(%d->%d): %v", i, start, end-1, err)
+		}
+		if more != (end < len(entries)) {
+			t.Fatalf("case %d(%d->%d): more mismatch: have %v", i, start, end-1, more)
+		}
+	}
+}
+
+// Tests that ranges can be proven with edge keys not contained in the trie.
+func TestRangeProofWithNonExistentEdges(t *testing.T) {
+	trie, entries := sortedEntries(4096)
+	for i := 0; i < 500; i++ {
+		start := mrand.Intn(len(entries)-1) + 1
+		end := mrand.Intn(len(entries)-start) + start + 1
+
+		first := common.CopyBytes(entries[start].k)
+		last := common.CopyBytes(entries[end-1].k)
+		if first[len(first)-1] == 0 || last[len(last)-1] == 0xff {
+			continue
+		}
+		first[len(first)-1]--
+		last[len(last)-1]++
+		if bytes.Equal(first, entries[start-1].k) || (end < len(entries) && bytes.Equal(last, entries[end].k)) {
+			continue
+		}
+		keys, vals := rangeData(entries[start:end])
+		if _, err := VerifyRangeProof(trie.Hash(), first, last, keys, vals, rangeProof(trie, first, last)); err != nil {
+			t.Fatalf("case %d(%d->%d): %v", i, start, end-1, err)
+		}
+	}
+}
+
+// Tests that a range without edge proofs is only accepted if it is the whole
+// trie, and that an empty range proves the absence of further leaves.
+func TestRangeProofEdgeCases(t *testing.T) {
+	trie, entries := sortedEntries(128)
+	keys, vals := rangeData(entries)
+
+	if _, err := VerifyRangeProof(trie.Hash(), nil, nil, keys, vals, nil); err != nil {
+		t.Fatalf("whole trie without proof: %v", err)
+	}
+	if _, err := VerifyRangeProof(trie.Hash(), nil, nil, keys[1:], vals[1:], nil); err == nil {
+		t.Fatalf("partial trie without proof accepted")
+	}
+	if _, err := VerifyRangeProof(trie.Hash(), keys[0], keys[len(keys)-1], keys, vals, rangeProof(trie, keys[0], keys[len(keys)-1])); err != nil {
+		t.Fatalf("whole trie with proof: %v", err)
+	}
+	// Nothing follows a key beyond the last leaf, something follows all others
+	last := bytes.Repeat([]byte{0xff}, 32)
+	proof := ethdb.NewMemDatabase()
+	trie.Prove(last, 0, proof)
+	if _, err := VerifyRangeProof(trie.Hash(), last, last, nil, nil, proof); err != nil {
+		t.Fatalf("empty range at the end: %v", err)
+	}
+	proof = ethdb.NewMemDatabase()
+	trie.Prove(keys[0], 0, proof)
+	if _, err := VerifyRangeProof(trie.Hash(), keys[0], keys[0], nil, nil, proof); err == nil {
+		t.Fatalf("empty range with leaves accepted")
+	}
+	// A single leaf proven by its own key
+	proof = ethdb.NewMemDatabase()
+	trie.Prove(keys[5], 0, proof)
+	more, err := VerifyRangeProof(trie.Hash(), keys[5], keys[5], keys[5:6], vals[5:6], proof)
+	if err != nil {
+		t.Fatalf("single leaf: %v", err)
+	}
+	if !more {
+		t.Fatalf("single leaf: more leaves not reported")
+	}
+}
+
+// Tests that ranges with missing, added or modified leaves are rejected.
+func TestBadRangeProof(t *testing.T) {
+	trie, entries := sortedEntries(4096)
+	for i := 0; i < 500; i++ {
+		start := mrand.Intn(len(entries))
+		end := mrand.Intn(len(entries)-start) + start + 1
+		if end-start < 3 {
+			continue
+		}
+		keys, vals := rangeData(entries[start:end])
+		first, last := keys[0], keys[len(keys)-1]
+		proof := rangeProof(trie, first, last)
+
+		index := mrand.Intn(len(keys)-2) + 1
+		switch mrand.Intn(3) {
+		case 0: // Drop an inner leaf
+			keys = append(keys[:index:index], keys[index+1:]...)
+			vals = append(vals[:index:index], vals[index+1:]...)
+		case 1: // Modify a value
+			vals[index] = randBytes(20)
+		case 2: // Add a leaf
+			key := common.CopyBytes(keys[index])
+			if key[len(key)-1] == 0xff {
+				continue
+			}
+			key[len(key)-1]++
+			if bytes.Compare(key, keys[index+1]) >= 0 {
+				continue
+			}
+			keys = append(keys[:index+1:index+1], append([][]byte{key}, keys[index+1:]...)...)
+			vals = append(vals[:index+1:index+1], append([][]byte{randBytes(20)}, vals[index+1:]...)...)
+		}
+		if _, err := VerifyRangeProof(trie.Hash(), first, last, keys, vals, proof); err == nil {
+			t.Fatalf("case %d(%d->%d): bad range accepted", i, start, end-1)
+		}
+	}
+}
+
 // mutateByte changes one byte in b.
 func mutateByte(b []byte) {
 	for r := mrand.Intn(len(b)); ; {